@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/spf13/pflag"
+)
+
+// defaultAutoCopyThreshold is the estimated row count from which standard CSV
+// exports suggest, or with --auto-copy switch to, COPY mode.
+const defaultAutoCopyThreshold = 1_000_000
+
+// copyIncompatible lists the options COPY mode cannot honor, since COPY output is
+// produced by the server and never goes through the exporters. --auto-copy keeps
+// the standard mode while one of them is in use, and --with-copy rejects those
+// with a reason; the others are left to the server formatting.
+var copyIncompatible = []struct {
+	option string
+	reason string
+	inUse  func(fs *pflag.FlagSet, options exporters.ExportOptions) bool
+}{
+	{"--time-format", "", flagChanged("time-format")},
+	{"--time-zone", "", flagChanged("time-zone")},
+	{"--max-row-bytes", "COPY streams rows without inspecting them", func(_ *pflag.FlagSet, options exporters.ExportOptions) bool {
+		return options.MaxRowBytes > 0
+	}},
+	{"--time-budget", "COPY cannot be stopped between rows; use --by-chunk", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return timeBudgetLimit > 0 && byChunk == ""
+	}},
+	{"--csv-strict-rfc4180", "COPY ends lines with LF and does not check values", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return csvStrict
+	}},
+	{"--param-file", "COPY cannot take query parameters", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return len(paramFiles) > 0
+	}},
+	{"--dual-write", "COPY output bypasses the exporters", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return dualWrite != ""
+	}},
+	{"transforms", "COPY output bypasses the exporters", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return len(transformSteps) > 0
+	}},
+	{"--cast", "COPY output bypasses the exporters", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return len(castColumns) > 0
+	}},
+	{"--encrypt-column", "COPY output bypasses the exporters", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return len(encryptColumns) > 0
+	}},
+	{"--tokenize-column", "COPY output bypasses the exporters", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return len(tokenizeColumns) > 0
+	}},
+	{"--preview-sidecar", "COPY output bypasses the exporters", func(*pflag.FlagSet, exporters.ExportOptions) bool {
+		return previewSidecarRows > 0
+	}},
+}
+
+func flagChanged(name string) func(*pflag.FlagSet, exporters.ExportOptions) bool {
+	return func(fs *pflag.FlagSet, _ exporters.ExportOptions) bool {
+		return fs.Changed(name)
+	}
+}
+
+// validateWithCopy rejects the options in use that --with-copy cannot honor.
+func validateWithCopy(fs *pflag.FlagSet, options exporters.ExportOptions) error {
+	for _, o := range copyIncompatible {
+		if o.reason != "" && o.inUse(fs, options) {
+			return fmt.Errorf("error: %s cannot be used with --with-copy (%s)", o.option, o.reason)
+		}
+	}
+	return nil
+}
+
+// copyBlockers returns the options in use that keep --auto-copy from switching a
+// standard CSV export to COPY mode.
+func copyBlockers(fs *pflag.FlagSet, flavor db.Flavor, options exporters.ExportOptions) []string {
+	var blockers []string
+	for _, o := range copyIncompatible {
+		if o.inUse(fs, options) {
+			blockers = append(blockers, o.option)
+		}
+	}
+	// COPY quotes fields itself and never checks delimiter collisions; the other
+	// CSV options are handled by the exporter
+	for _, name := range slices.Sorted(maps.Keys(options.FormatOptions)) {
+		if name == "quote-all" || (name == "delimiter-collision" && options.FormatOptions[name] == exporters.CollisionIgnore) {
+			continue
+		}
+		blockers = append(blockers, "--opt "+exporters.FormatCSV+"."+name)
+	}
+	if _, set := options.FormatOptions["delimiter-collision"]; !set && options.Delimiter != ',' {
+		blockers = append(blockers, "--delimiter (delimiter collision check)")
+	}
+	if !flavor.CopyTo {
+		blockers = append(blockers, "server flavor "+flavor.Name)
+	}
+	return blockers
+}
+
+// chooseCopyMode tells whether a standard CSV export should run in COPY mode.
+// Exports the planner expects to return at least --auto-copy-threshold rows switch
+// to COPY with --auto-copy. Without it, COPY is only suggested when the query was
+// already explained, as plan, so that exports do not pay for an extra EXPLAIN.
+func chooseCopyMode(ctx context.Context, store db.Store, query string, plan json.RawMessage, blockers []string) bool {
+	if len(blockers) > 0 {
+		if autoCopy {
+			logger.Info("Not switching to COPY mode: it cannot be used with %s", strings.Join(blockers, ", "))
+		}
+		return false
+	}
+	if plan == nil && !autoCopy {
+		return false
+	}
+
+	if plan == nil {
+		var err error
+		if plan, err = db.Explain(ctx, store, query, false); err != nil {
+			logger.Debug("Unable to estimate the number of rows: %v", err)
+			return false
+		}
+	}
+	estimate, err := db.EstimatedRows(plan)
+	if err != nil {
+		logger.Debug("Unable to estimate the number of rows: %v", err)
+		return false
+	}
+	if estimate < float64(autoCopyThreshold) {
+		return false
+	}
+
+	if autoCopy {
+		logger.Info("About %.0f rows expected: switching to COPY mode (--auto-copy)", estimate)
+		return true
+	}
+	logger.Info("About %.0f rows expected: --with-copy would export them several times faster (or let --auto-copy decide)", estimate)
+	return false
+}
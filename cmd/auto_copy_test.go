@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/transforms"
+	"github.com/spf13/pflag"
+)
+
+func TestCopyBlockers(t *testing.T) {
+	savedSteps, savedParams, savedDualWrite, savedBudget := transformSteps, paramFiles, dualWrite, timeBudgetLimit
+	savedCasts, savedEncrypt, savedTokenize, savedPreview, savedStrict := castColumns, encryptColumns, tokenizeColumns, previewSidecarRows, csvStrict
+	defer func() {
+		transformSteps, paramFiles, dualWrite, timeBudgetLimit = savedSteps, savedParams, savedDualWrite, savedBudget
+		castColumns, encryptColumns, tokenizeColumns, previewSidecarRows, csvStrict = savedCasts, savedEncrypt, savedTokenize, savedPreview, savedStrict
+	}()
+
+	postgres, _ := db.ParseFlavor(db.FlavorPostgres)
+	csvOptions := func(delimiter rune, opts map[string]string) exporters.ExportOptions {
+		return exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: delimiter, FormatOptions: opts}
+	}
+
+	tests := []struct {
+		name    string
+		flag    string // flag set on the command line, with value
+		value   string
+		steps   []transforms.Step
+		noCopy  bool
+		options exporters.ExportOptions
+		want    []string
+	}{
+		{name: "defaults", options: csvOptions(',', nil)},
+		{name: "quote all", options: csvOptions(',', map[string]string{"quote-all": "true"})},
+		{name: "time format", flag: "time-format", value: "yyyy", options: csvOptions(',', nil), want: []string{"--time-format"}},
+		{name: "time zone", flag: "time-zone", value: "UTC", options: csvOptions(',', nil), want: []string{"--time-zone"}},
+		{name: "max row bytes", options: exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', MaxRowBytes: 1 << 20}, want: []string{"--max-row-bytes"}},
+		{name: "param file", flag: "param-file", value: "ids=ids.txt", options: csvOptions(',', nil), want: []string{"--param-file"}},
+		{name: "dual write", flag: "dual-write", value: "json:out.json", options: csvOptions(',', nil), want: []string{"--dual-write"}},
+		{name: "time budget", flag: "time-budget", value: "1h", options: csvOptions(',', nil), want: []string{"--time-budget"}},
+		{name: "cast", flag: "cast", value: "id=int", options: csvOptions(',', nil), want: []string{"--cast"}},
+		{name: "encrypt column", flag: "encrypt-column", value: "ssn=aes256:KEY", options: csvOptions(',', nil), want: []string{"--encrypt-column"}},
+		{name: "tokenize column", flag: "tokenize-column", value: "card", options: csvOptions(',', nil), want: []string{"--tokenize-column"}},
+		{name: "preview sidecar", flag: "preview-sidecar", value: "10", options: csvOptions(',', nil), want: []string{"--preview-sidecar"}},
+		{name: "strict RFC 4180", flag: "csv-strict-rfc4180", value: "true", options: csvOptions(',', nil), want: []string{"--csv-strict-rfc4180"}},
+		{
+			name:    "delimiter collision option",
+			options: csvOptions(';', map[string]string{"delimiter-collision": "quote-all", "quote-all": "false"}),
+			want:    []string{"--opt csv.delimiter-collision"},
+		},
+		{name: "delimiter collision check", options: csvOptions(';', nil), want: []string{"--delimiter (delimiter collision check)"}},
+		{name: "delimiter collision ignored", options: csvOptions(';', map[string]string{"delimiter-collision": "ignore"})},
+		{name: "transforms", steps: []transforms.Step{{Rename: map[string]string{"id": "order_id"}}}, options: csvOptions(',', nil), want: []string{"transforms"}},
+		{name: "server flavor", noCopy: true, options: csvOptions(',', nil), want: []string{"server flavor " + db.FlavorPostgres}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The flags set the variables of the export command
+			fs := pflag.NewFlagSet("export", pflag.ContinueOnError)
+			fs.String("time-format", "", "")
+			fs.String("time-zone", "", "")
+			fs.StringVar(&dualWrite, "dual-write", "", "")
+			fs.IntVar(&previewSidecarRows, "preview-sidecar", 0, "")
+			fs.StringArrayVar(&paramFiles, "param-file", nil, "")
+			fs.StringArrayVar(&castColumns, "cast", nil, "")
+			fs.StringArrayVar(&encryptColumns, "encrypt-column", nil, "")
+			fs.StringArrayVar(&tokenizeColumns, "tokenize-column", nil, "")
+			fs.DurationVar(&timeBudgetLimit, "time-budget", 0, "")
+			fs.BoolVar(&csvStrict, "csv-strict-rfc4180", false, "")
+			if tt.flag != "" {
+				if err := fs.Set(tt.flag, tt.value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			transformSteps = tt.steps
+			flavor := postgres
+			flavor.CopyTo = !tt.noCopy
+
+			if got := copyBlockers(fs, flavor, tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("copyBlockers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChooseCopyMode(t *testing.T) {
+	savedAuto, savedThreshold := autoCopy, autoCopyThreshold
+	defer func() { autoCopy, autoCopyThreshold = savedAuto, savedThreshold }()
+	autoCopyThreshold = 1000
+
+	plan := func(rows int) json.RawMessage {
+		data, _ := json.Marshal([]map[string]any{{"Plan": map[string]any{"Node Type": "Seq Scan", "Plan Rows": rows}}})
+		return data
+	}
+
+	tests := []struct {
+		name     string
+		auto     bool
+		rows     int
+		blockers []string
+		want     bool
+	}{
+		{"large with auto copy", true, 5000, nil, true},
+		{"at threshold", true, 1000, nil, true},
+		{"small with auto copy", true, 999, nil, false},
+		{"large without auto copy", false, 5000, nil, false},
+		{"large with blockers", true, 5000, []string{"--time-zone"}, false},
+		// Without a plan nor --auto-copy, no EXPLAIN is run: the nil store is never used
+		{"no plan without auto copy", false, -1, nil, false},
+	}
+	for _, tt := range tests {
+		autoCopy = tt.auto
+		p := plan(tt.rows)
+		if tt.rows < 0 {
+			p = nil
+		}
+		if got := chooseCopyMode(t.Context(), nil, "SELECT * FROM events", p, tt.blockers); got != tt.want {
+			t.Errorf("%s: chooseCopyMode() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/transforms"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/state"
+	"github.com/jackc/pgx/v5"
+)
+
+// chunkOutputPath returns the file a chunk is exported to: events.csv -> events_<chunk>.csv.
+func chunkOutputPath(output, chunk string) string {
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + "_" + chunk + ext
+}
+
+// chunkProgress records the chunks already exported so an interrupted run can resume,
+// and the chunks that failed so --retry-failed-chunks can export only them. Both
+// are kept in the state store, under the output path, and removed once every chunk
+// is done.
+type chunkProgress struct {
+	mu        sync.Mutex
+	key       string
+	failedKey string
+	digest    string
+	done      map[string]bool
+	exported  []exportedChunk // in completion order
+	failed    map[string]failedChunk
+}
+
+// chunkState is the state entry of the chunks already exported, with the digest
+// of the settings they were exported with.
+type chunkState struct {
+	Digest string          `json:"digest"`
+	Chunks []exportedChunk `json:"chunks"`
+}
+
+// exportedChunk is a chunk already exported: its file, row count and partition
+// range, kept so that the manifest of a resumed export lists every file.
+type exportedChunk struct {
+	Name         string          `json:"name"`
+	File         string          `json:"file"` // with the extension of its compression
+	Rows         int             `json:"rows"`
+	PartitionKey string          `json:"partition_key,omitempty"`
+	Min          json.RawMessage `json:"min,omitempty"`
+	Max          json.RawMessage `json:"max,omitempty"`
+}
+
+// failedChunksState is the state entry of the chunks whose export failed, with
+// the digest of the settings of that export.
+type failedChunksState struct {
+	Digest string                 `json:"digest"`
+	Chunks map[string]failedChunk `json:"chunks"`
+}
+
+// failedChunk is the state of a chunk whose export failed.
+type failedChunk struct {
+	Range string `json:"range"`
+	Error string `json:"error"`
+}
+
+// chunkDigest identifies what the content of the chunk files depends on: the
+// query and the options shaping the output. Resuming with other settings would
+// mix files of two different exports.
+func chunkDigest(query string, options exporters.ExportOptions) string {
+	// Tuning and catalog details that leave the exported rows unchanged
+	options.CopyBuffer, options.CopySpillLimit, options.CopySpillDir = 0, 0, ""
+	options.KeepAlive, options.ColumnWidths, options.ColumnComments = 0, nil, nil
+	options.Log = nil
+
+	data, _ := json.Marshal(struct {
+		Query      string
+		Options    exporters.ExportOptions
+		WithCopy   bool
+		Transforms []transforms.Step
+		Cast       []string
+		Encrypt    []string
+		Tokenize   []string
+	}{query, options, withCopy, transformSteps, castColumns, encryptColumns, tokenizeColumns})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadChunkProgress reads the progress of the export to output. It fails when the
+// recorded chunks were exported with settings other than those of digest.
+func loadChunkProgress(output, digest string) (*chunkProgress, error) {
+	p := &chunkProgress{
+		key:       state.Key(state.PrefixChunks, output),
+		failedKey: state.Key(state.PrefixFailedChunks, output),
+		digest:    digest,
+		done:      map[string]bool{},
+		failed:    map[string]failedChunk{},
+	}
+
+	var done chunkState
+	if _, err := state.Get(p.key, &done); err != nil {
+		return nil, fmt.Errorf("error reading chunk progress: %w", err)
+	}
+	var failed failedChunksState
+	if _, err := state.Get(p.failedKey, &failed); err != nil {
+		return nil, fmt.Errorf("error reading chunk progress: %w", err)
+	}
+	if (len(done.Chunks) > 0 && done.Digest != digest) || (len(failed.Chunks) > 0 && failed.Digest != digest) {
+		return nil, fmt.Errorf("chunks of %s were exported or attempted with another query or options; "+
+			"run 'pgxport state reset %s %s' to start over", output, p.key, p.failedKey)
+	}
+	for _, c := range done.Chunks {
+		p.done[c.Name] = true
+	}
+	p.exported = done.Chunks
+	if failed.Chunks != nil {
+		p.failed = failed.Chunks
+	}
+	return p, nil
+}
+
+func (p *chunkProgress) markDone(chunk exportedChunk) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var done chunkState
+	err := state.Update(p.key, &done, func() error {
+		done.Digest = p.digest
+		done.Chunks = append(done.Chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error recording chunk progress: %w", err)
+	}
+	p.done[chunk.Name] = true
+	p.exported = append(p.exported, chunk)
+	return p.forget(chunk.Name)
+}
+
+func (p *chunkProgress) markFailed(c db.Chunk, cause error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var failed failedChunksState
+	err := state.Update(p.failedKey, &failed, func() error {
+		if failed.Chunks == nil {
+			failed.Chunks = map[string]failedChunk{}
+		}
+		failed.Digest = p.digest
+		failed.Chunks[c.Name] = failedChunk{Range: c.Filter(), Error: cause.Error()}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error recording chunk progress: %w", err)
+	}
+	p.failed[c.Name] = failed.Chunks[c.Name]
+	return nil
+}
+
+// forget removes the recorded failure of chunk, if any. p.mu must be held.
+func (p *chunkProgress) forget(chunk string) error {
+	if _, ok := p.failed[chunk]; !ok {
+		return nil
+	}
+	var failed failedChunksState
+	err := state.Update(p.failedKey, &failed, func() error {
+		delete(failed.Chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error recording chunk progress: %w", err)
+	}
+	delete(p.failed, chunk)
+	return nil
+}
+
+// remaining returns the number of chunks of chunks not done yet.
+func (p *chunkProgress) remaining(chunks []db.Chunk) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, c := range chunks {
+		if !p.done[c.Name] {
+			n++
+		}
+	}
+	return n
+}
+
+// files returns the chunks exported by this run and the previous ones, in the order
+// of chunks; chunks no longer in the hypertable come last.
+func (p *chunkProgress) files(chunks []db.Chunk) []exportedChunk {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	position := map[string]int{}
+	for i, c := range chunks {
+		position[c.Name] = i
+	}
+	at := func(c exportedChunk) int {
+		if i, ok := position[c.Name]; ok {
+			return i
+		}
+		return len(chunks)
+	}
+	files := slices.Clone(p.exported)
+	slices.SortStableFunc(files, func(a, b exportedChunk) int { return cmp.Compare(at(a), at(b)) })
+	return files
+}
+
+func (p *chunkProgress) remove() {
+	if err := state.Delete(p.key, p.failedKey); err != nil {
+		logger.Warn("Unable to remove chunk progress %s: %v", p.key, err)
+	}
+}
+
+// pendingChunks returns the chunks to export: those not done yet or, with
+// retryFailed, only those whose export failed. Recorded failures of chunks that
+// no longer exist, e.g. dropped by a retention policy, are forgotten.
+func pendingChunks(chunks []db.Chunk, progress *chunkProgress, retryFailed bool) ([]db.Chunk, error) {
+	if !retryFailed {
+		var pending []db.Chunk
+		for _, c := range chunks {
+			if !progress.done[c.Name] {
+				pending = append(pending, c)
+			}
+		}
+		if skipped := len(chunks) - len(pending); skipped > 0 {
+			logger.Info("Resuming: %d of %d chunks already exported (state %s)", skipped, len(chunks), progress.key)
+		}
+		return pending, nil
+	}
+
+	if len(progress.failed) == 0 {
+		return nil, fmt.Errorf("--retry-failed-chunks: no failed chunks recorded for %s", outputPath)
+	}
+	var pending []db.Chunk
+	exists := map[string]bool{}
+	for _, c := range chunks {
+		exists[c.Name] = true
+		if _, ok := progress.failed[c.Name]; ok {
+			pending = append(pending, c)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(progress.failed)) {
+		if exists[name] {
+			continue
+		}
+		logger.Warn("Chunk %s (%s) no longer exists, forgetting its failure", name, progress.failed[name].Range)
+		progress.mu.Lock()
+		err := progress.forget(name)
+		progress.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+	logger.Info("Retrying %d failed chunks of %d (state %s)", len(pending), len(chunks), progress.failedKey)
+	return pending, nil
+}
+
+// runChunkedExport exports each chunk of the --by-chunk hypertable to its own file,
+// in time order, using --chunk-workers connections. It returns the total row count.
+// Once budget runs out no new chunk is started; the chunks in progress are completed.
+// A chunk that fails is recorded for --retry-failed-chunks and the others go on.
+func runChunkedExport(parent context.Context, store db.Store, dbUrl string, flavor db.Flavor, query string, options exporters.ExportOptions, budget *timeBudget) (int, error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	ht, chunks, err := db.ListChunks(ctx, store.GetConnection(), byChunk)
+	if err != nil {
+		return 0, err
+	}
+	logger.Debug("Hypertable %s.%s has %d chunks", ht.Schema, ht.Name, len(chunks))
+
+	// The workers have their own connections; keep this one from looking abandoned
+	defer db.StartHeartbeat(store.GetConnection(), options.KeepAlive).Stop()
+
+	chunkFiles = nil
+	progress, err := loadChunkProgress(outputPath, chunkDigest(query, options))
+	if err != nil {
+		return 0, err
+	}
+	// The manifest lists the files of the previous runs too
+	defer func() { chunkFiles = progress.files(chunks) }()
+
+	pending, err := pendingChunks(chunks, progress, retryFailedChunks)
+	if err != nil {
+		return 0, err
+	}
+
+	workers := min(chunkWorkers, len(pending))
+	jobs := make(chan db.Chunk)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		total    int
+		failed   []string
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			worker := db.NewStoreForFlavor(flavor)
+			if err := worker.Open(dbUrl); err != nil {
+				fail(fmt.Errorf("failed to connect to database: %w", err))
+				return
+			}
+			defer worker.Close()
+
+			for c := range jobs {
+				exported, err := exportChunk(ctx, worker, c, query, options)
+				if err != nil && ctx.Err() != nil {
+					fail(fmt.Errorf("chunk %s: %w", c.Name, err))
+					return
+				}
+				if err != nil {
+					// The other chunks go on; the failed one is retried with --retry-failed-chunks
+					logger.Warn("Chunk %s failed: %v", c.Name, err)
+					if err := progress.markFailed(c, err); err != nil {
+						fail(err)
+						return
+					}
+					mu.Lock()
+					failed = append(failed, c.Name)
+					mu.Unlock()
+					continue
+				}
+				if err := progress.markDone(exported); err != nil {
+					fail(err)
+					return
+				}
+				mu.Lock()
+				total += exported.Rows
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var unstarted []string
+	deadline := budget.timer()
+dispatch:
+	for i, c := range pending {
+		select {
+		case jobs <- c:
+		case <-ctx.Done():
+			break dispatch
+		case <-deadline:
+			budget.expired()
+			for _, c := range pending[i:] {
+				unstarted = append(unstarted, c.Name)
+			}
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return total, firstErr
+	}
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		return total, fmt.Errorf("%d of %d chunks failed: %s; run again with --retry-failed-chunks once fixed (state %s)",
+			len(failed), len(pending), strings.Join(failed, ", "), progress.failedKey)
+	}
+	if len(unstarted) > 0 {
+		if !budget.stop {
+			return total, budget.err()
+		}
+		// The recorded progress is the checkpoint: the next run exports the remaining chunks
+		logger.Warn("Time budget of %s exceeded: %d of %d chunks not exported (state %s)", budget.limit, len(unstarted), len(chunks), progress.key)
+		budget.setPending(unstarted)
+		return total, nil
+	}
+	if remaining := progress.remaining(chunks); remaining > 0 {
+		logger.Info("Failed chunks exported; run again without --retry-failed-chunks to export the %d chunks not started yet", remaining)
+		return total, nil
+	}
+	progress.remove()
+	return total, nil
+}
+
+func exportChunk(ctx context.Context, store db.Store, c db.Chunk, query string, options exporters.ExportOptions) (exportedChunk, error) {
+	exporter, err := exporters.GetExporter(format)
+	if err != nil {
+		return exportedChunk{}, err
+	}
+	if exporter, err = withTransforms(exporter); err != nil {
+		return exportedChunk{}, err
+	}
+
+	path := chunkOutputPath(outputPath, c.Name)
+	chunkQuery := c.Query(query)
+	// The chunk gets its own log to know its file; its counts still reach the run's
+	options.Log = options.Log.Child()
+	logger.Debug("Exporting chunk %s (%s) to %s", c.Name, c.Filter(), path)
+
+	var (
+		n        int
+		observed *partitionObserver
+	)
+	if copyExp, ok := exporter.(exporters.CopyCapable); ok && withCopy {
+		n, err = copyExp.ExportCopy(store.GetConnection(), chunkQuery, path, options)
+	} else {
+		rows, qerr := store.ExecuteQuery(ctx, chunkQuery)
+		if qerr != nil {
+			return exportedChunk{}, qerr
+		}
+		defer rows.Close()
+
+		rows, stopKeepAlive := db.KeepAliveAfterRows(rows, store.GetConnection(), options.KeepAlive)
+		defer stopKeepAlive()
+		observed = observePartitionKey(rows, c.TimeColumn)
+		n, err = exporter.Export(observed, path, options)
+	}
+	if err != nil {
+		return exportedChunk{}, err
+	}
+
+	logger.Info("Chunk %s: %d rows -> %s", c.Name, n, path)
+	return describeChunk(c, path, n, observed, options.Log.Files()), nil
+}
+
+// describeChunk returns the progress entry of chunk c, exported to path with rows
+// rows; written are the files of the chunk export. observed, when not nil, gives
+// the partition range of the file.
+func describeChunk(c db.Chunk, path string, rows int, observed *partitionObserver, written []exporters.WrittenFile) exportedChunk {
+	exported := exportedChunk{Name: c.Name, File: path, Rows: rows}
+	for _, w := range written {
+		if w.Requested == path {
+			exported.File = w.Path
+		}
+	}
+	// Like the state keys, so that a run from another directory finds the file
+	if abs, err := filepath.Abs(exported.File); err == nil {
+		exported.File = abs
+	}
+	if observed != nil && observed.min != nil {
+		exported.PartitionKey = c.TimeColumn
+		exported.Min, _ = json.Marshal(observed.min)
+		exported.Max, _ = json.Marshal(observed.max)
+	}
+	return exported
+}
+
+// chunkFiles are the chunks exported by the last --by-chunk export, including
+// those of the runs it resumed, for the manifest.
+var chunkFiles []exportedChunk
+
+// partitionObserver tracks the smallest and largest value of the partition key
+// column among the rows read. Time and integer keys are supported.
+type partitionObserver struct {
+	pgx.Rows
+	col      int
+	min, max any
+}
+
+func observePartitionKey(rows pgx.Rows, column string) *partitionObserver {
+	o := &partitionObserver{Rows: rows, col: -1}
+	for i, fd := range rows.FieldDescriptions() {
+		if fd.Name == column {
+			o.col = i
+			break
+		}
+	}
+	return o
+}
+
+func (o *partitionObserver) Values() ([]any, error) {
+	values, err := o.Rows.Values()
+	if err == nil && o.col >= 0 && o.col < len(values) {
+		o.observe(values[o.col])
+	}
+	return values, err
+}
+
+func (o *partitionObserver) observe(v any) {
+	switch v := v.(type) {
+	case time.Time:
+		if o.min == nil || v.Before(o.min.(time.Time)) {
+			o.min = v
+		}
+		if o.max == nil || v.After(o.max.(time.Time)) {
+			o.max = v
+		}
+	case int64:
+		if o.min == nil || v < o.min.(int64) {
+			o.min = v
+		}
+		if o.max == nil || v > o.max.(int64) {
+			o.max = v
+		}
+	case int32:
+		o.observe(int64(v))
+	case int16:
+		o.observe(int64(v))
+	}
+}
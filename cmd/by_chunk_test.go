@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/transforms"
+	"github.com/fbz-tec/pgxport/internal/state"
+)
+
+func TestChunkOutputPath(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{output: "metrics.csv", expected: "metrics__hyper_1_2_chunk.csv"},
+		{output: "/data/out/metrics.json", expected: "/data/out/metrics__hyper_1_2_chunk.json"},
+		{output: "metrics", expected: "metrics__hyper_1_2_chunk"},
+	}
+	for _, tt := range tests {
+		if got := chunkOutputPath(tt.output, "_hyper_1_2_chunk"); got != tt.expected {
+			t.Errorf("chunkOutputPath(%q) = %q, want %q", tt.output, got, tt.expected)
+		}
+	}
+}
+
+func TestChunkProgress(t *testing.T) {
+	t.Setenv(state.EnvFile, filepath.Join(t.TempDir(), "state.json"))
+	output := filepath.Join(t.TempDir(), "metrics.csv")
+
+	p, err := loadChunkProgress(output, "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.done) != 0 {
+		t.Fatalf("new progress should be empty, got %v", p.done)
+	}
+	for _, c := range []string{"_hyper_1_1_chunk", "_hyper_1_2_chunk"} {
+		if err := p.markDone(exportedChunk{Name: c}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resumed, err := loadChunkProgress(output, "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.done["_hyper_1_1_chunk"] || !resumed.done["_hyper_1_2_chunk"] || len(resumed.done) != 2 {
+		t.Errorf("resumed progress = %v", resumed.done)
+	}
+
+	// Chunks exported with other settings are not resumed
+	if _, err := loadChunkProgress(output, "other"); err == nil || !strings.Contains(err.Error(), "state reset chunks:") {
+		t.Errorf("loadChunkProgress() with another digest error = %v", err)
+	}
+
+	resumed.remove()
+	if entries, err := state.List(state.PrefixChunks); err != nil || len(entries) != 0 {
+		t.Errorf("progress should be removed, got %v (err=%v)", entries, err)
+	}
+}
+
+func TestChunkDigest(t *testing.T) {
+	savedSteps, savedCast := transformSteps, castColumns
+	defer func() { transformSteps, castColumns = savedSteps, savedCast }()
+
+	query := "SELECT * FROM metrics"
+	options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ','}
+	digest := chunkDigest(query, options)
+
+	tuned := options
+	tuned.KeepAlive, tuned.CopyBuffer = time.Minute, 1<<20
+	if chunkDigest(query, tuned) != digest {
+		t.Error("tuning options should not change the digest")
+	}
+
+	changes := map[string]func() string{
+		"query":     func() string { return chunkDigest(query+" WHERE value > 0", options) },
+		"delimiter": func() string { o := options; o.Delimiter = ';'; return chunkDigest(query, o) },
+		"cast": func() string {
+			castColumns = []string{"value:int"}
+			defer func() { castColumns = savedCast }()
+			return chunkDigest(query, options)
+		},
+		"transforms": func() string {
+			transformSteps = []transforms.Step{{Rename: map[string]string{"value": "v"}}}
+			defer func() { transformSteps = savedSteps }()
+			return chunkDigest(query, options)
+		},
+	}
+	for name, digestWith := range changes {
+		if digestWith() == digest {
+			t.Errorf("changing the %s should change the digest", name)
+		}
+	}
+}
+
+func TestChunkProgressFailures(t *testing.T) {
+	t.Setenv(state.EnvFile, filepath.Join(t.TempDir(), "state.json"))
+	output := filepath.Join(t.TempDir(), "metrics.csv")
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	chunk := func(name string, i int) db.Chunk {
+		return db.Chunk{Name: name, TimeColumn: "time", Start: day.AddDate(0, 0, i), End: day.AddDate(0, 0, i+1)}
+	}
+	chunks := []db.Chunk{chunk("_hyper_1_1_chunk", 0), chunk("_hyper_1_2_chunk", 1), chunk("_hyper_1_3_chunk", 2)}
+
+	p, err := loadChunkProgress(output, "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pendingChunks(chunks, p, true); err == nil || !strings.Contains(err.Error(), "no failed chunks recorded") {
+		t.Errorf("pendingChunks() without failures error = %v", err)
+	}
+	if err := p.markFailed(chunks[1], errors.New("connection reset")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.markFailed(chunk("_hyper_1_0_chunk", -1), errors.New("disk full")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Failures of an export with other settings are not retried
+	if _, err := loadChunkProgress(output, "other"); err == nil || !strings.Contains(err.Error(), "failed-chunks:") {
+		t.Errorf("loadChunkProgress() with another digest error = %v", err)
+	}
+
+	if err := p.markDone(exportedChunk{Name: "_hyper_1_1_chunk"}); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := loadChunkProgress(output, "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := failedChunk{Range: `"time" >= '2024-01-02 00:00:00+00' AND "time" < '2024-01-03 00:00:00+00'`, Error: "connection reset"}
+	if got := resumed.failed["_hyper_1_2_chunk"]; got != want || len(resumed.failed) != 2 {
+		t.Errorf("recorded failures = %v", resumed.failed)
+	}
+
+	// Without the flag, failed and unstarted chunks are exported
+	pending, err := pendingChunks(chunks, resumed, false)
+	if err != nil || chunkNames(pending) != "_hyper_1_2_chunk,_hyper_1_3_chunk" {
+		t.Errorf("pendingChunks() = %s, %v", chunkNames(pending), err)
+	}
+
+	// With it, only failed chunks that still exist
+	pending, err = pendingChunks(chunks, resumed, true)
+	if err != nil || chunkNames(pending) != "_hyper_1_2_chunk" {
+		t.Errorf("pendingChunks(retry) = %s, %v", chunkNames(pending), err)
+	}
+	if _, ok := resumed.failed["_hyper_1_0_chunk"]; ok {
+		t.Error("failure of a dropped chunk should be forgotten")
+	}
+
+	if err := resumed.markDone(exportedChunk{Name: "_hyper_1_2_chunk"}); err != nil {
+		t.Fatal(err)
+	}
+	var failed failedChunksState
+	if _, err := state.Get(resumed.failedKey, &failed); err != nil || len(failed.Chunks) != 0 {
+		t.Errorf("failures after retry = %v (err=%v)", failed, err)
+	}
+	if n := resumed.remaining(chunks); n != 1 {
+		t.Errorf("remaining() = %d, want 1", n)
+	}
+
+	resumed.remove()
+	if entries, err := state.List(""); err != nil || len(entries) != 0 {
+		t.Errorf("progress should be removed, got %v (err=%v)", entries, err)
+	}
+}
+
+func chunkNames(chunks []db.Chunk) string {
+	names := make([]string, len(chunks))
+	for i, c := range chunks {
+		names[i] = c.Name
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/version"
+)
+
+// catalogMetadata is the document written by --catalog-metadata next to the export.
+// Its layout is meant to be mapped easily onto DataHub or Amundsen ingestion recipes.
+type catalogMetadata struct {
+	GeneratedAt    time.Time       `json:"generated_at"`
+	PgxportVersion string          `json:"pgxport_version"`
+	File           string          `json:"file"`
+	Format         string          `json:"format"`
+	Rows           int             `json:"rows"`
+	Query          string          `json:"query"`
+	Owners         []string        `json:"owners"`
+	Sources        []catalogTable  `json:"sources"`
+	Columns        []catalogColumn `json:"columns"`
+}
+
+type catalogTable struct {
+	Schema      string `json:"schema"`
+	Name        string `json:"name"`
+	Owner       string `json:"owner"`
+	Description string `json:"description,omitempty"`
+}
+
+type catalogColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Source      string `json:"source,omitempty"` // schema.table.column
+}
+
+// catalogMetadataPath returns the sidecar file for an export: orders.csv -> orders.csv.metadata.json.
+func catalogMetadataPath(output string) string {
+	return output + ".metadata.json"
+}
+
+func newCatalogMetadata(query string, meta *db.QueryMetadata) *catalogMetadata {
+	doc := &catalogMetadata{
+		GeneratedAt:    clock.Now().UTC(),
+		PgxportVersion: version.AppVersion,
+		File:           filepath.Base(outputPath),
+		Format:         format,
+		Query:          query,
+		Owners:         []string{},
+		Sources:        []catalogTable{},
+		Columns:        make([]catalogColumn, 0, len(meta.Columns)),
+	}
+
+	seen := map[string]bool{}
+	for _, t := range meta.Tables {
+		doc.Sources = append(doc.Sources, catalogTable{Schema: t.Schema, Name: t.Name, Owner: t.Owner, Description: t.Description})
+		if !seen[t.Owner] {
+			seen[t.Owner] = true
+			doc.Owners = append(doc.Owners, t.Owner)
+		}
+	}
+	for _, c := range meta.Columns {
+		col := catalogColumn{Name: c.Name, Type: c.Type, Description: c.Description}
+		if c.Table != nil {
+			col.Source = c.Table.Schema + "." + c.Table.Name + "." + c.SourceColumn
+		}
+		doc.Columns = append(doc.Columns, col)
+	}
+	return doc
+}
+
+// columnComments returns the comment of each result column of query for --include-comments.
+func columnComments(store db.Store, query string) ([]exporters.ColumnComment, error) {
+	meta, err := db.DescribeCatalog(context.Background(), store, query)
+	if err != nil {
+		return nil, err
+	}
+	comments := make([]exporters.ColumnComment, len(meta.Columns))
+	for i, c := range meta.Columns {
+		comments[i] = exporters.ColumnComment{Name: c.Name, Text: c.Description}
+	}
+	return comments, nil
+}
+
+// write records the row count and stores the sidecar at path.
+func (m *catalogMetadata) write(path string, rows int) error {
+	m.Rows = rows
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding catalog metadata: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing catalog metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/db"
+)
+
+func TestCatalogMetadataWrite(t *testing.T) {
+	saved := outputPath
+	defer func() { outputPath = saved }()
+	outputPath = filepath.Join(t.TempDir(), "orders.csv")
+
+	path := catalogMetadataPath(outputPath)
+	if filepath.Base(path) != "orders.csv.metadata.json" {
+		t.Fatalf("catalogMetadataPath() = %s", path)
+	}
+
+	orders := db.Table{Schema: "sales", Name: "orders"}
+	customers := db.Table{Schema: "sales", Name: "customers"}
+	meta := &db.QueryMetadata{
+		Tables: []db.TableMetadata{
+			{Table: orders, Owner: "sales_team", Description: "One row per order"},
+			{Table: customers, Owner: "sales_team"},
+		},
+		Columns: []db.ColumnMetadata{
+			{Name: "id", Type: "bigint", Table: &orders, SourceColumn: "order_id", Description: "Order number"},
+			{Name: "customer", Type: "text", Table: &customers, SourceColumn: "name"},
+			{Name: "total", Type: "numeric"},
+		},
+	}
+	if err := newCatalogMetadata("SELECT ...", meta).write(path, 7); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got catalogMetadata
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("metadata is not valid JSON: %v\n%s", err, data)
+	}
+
+	if got.File != "orders.csv" || got.Rows != 7 {
+		t.Errorf("unexpected file info: %+v", got)
+	}
+	if len(got.Owners) != 1 || got.Owners[0] != "sales_team" {
+		t.Errorf("Owners = %v, want [sales_team]", got.Owners)
+	}
+	if len(got.Sources) != 2 || got.Sources[0].Description != "One row per order" {
+		t.Errorf("Sources = %+v", got.Sources)
+	}
+	want := []catalogColumn{
+		{Name: "id", Type: "bigint", Description: "Order number", Source: "sales.orders.order_id"},
+		{Name: "customer", Type: "text", Source: "sales.customers.name"},
+		{Name: "total", Type: "numeric"},
+	}
+	if len(got.Columns) != len(want) {
+		t.Fatalf("Columns = %+v", got.Columns)
+	}
+	for i := range want {
+		if got.Columns[i] != want[i] {
+			t.Errorf("Columns[%d] = %+v, want %+v", i, got.Columns[i], want[i])
+		}
+	}
+}
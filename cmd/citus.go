@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// shardPlaceholder is replaced by the shard table name in --citus-direct queries.
+const shardPlaceholder = "{shard}"
+
+// citusStreamBuffer is the number of rows queued between shard readers and the exporter.
+const citusStreamBuffer = 1024
+
+// runCitusExport reads the shards of the --citus-direct table directly from the
+// worker nodes, --citus-workers at a time, and merges them into a single output.
+func runCitusExport(parent context.Context, store db.Store, dbUrl string, flavor db.Flavor, query string, exporter exporters.Exporter, options exporters.ExportOptions) (int, error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	shards, err := db.ListShards(ctx, store.GetConnection(), citusDirect)
+	if err != nil {
+		return 0, err
+	}
+	logger.Debug("Distributed table %s has %d shards", citusDirect, len(shards))
+
+	// Shards are read over their own connections; keep this one from looking abandoned
+	defer db.StartHeartbeat(store.GetConnection(), options.KeepAlive).Stop()
+
+	stream := rowsource.NewStream(citusStreamBuffer)
+	jobs := make(chan db.Shard)
+	var wg sync.WaitGroup
+
+	for i := 0; i < min(citusWorkers, len(shards)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := readShards(ctx, jobs, stream, dbUrl, flavor, query); err != nil {
+				stream.Fail(err)
+				cancel()
+			}
+		}()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+	dispatch:
+		for _, s := range shards {
+			select {
+			case jobs <- s:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		stream.CloseSend()
+	}()
+
+	rowCount, err := exporter.Export(stream, outputPath, options)
+	cancel()
+	stream.Close()
+	<-finished
+
+	if err == nil {
+		err = stream.Err()
+	}
+	return rowCount, err
+}
+
+// readShards exports the shards received on jobs into stream, keeping one
+// connection per worker node.
+func readShards(ctx context.Context, jobs <-chan db.Shard, stream *rowsource.Stream, dbUrl string, flavor db.Flavor, query string) error {
+	nodes := map[string]db.Store{}
+	defer func() {
+		for _, st := range nodes {
+			st.Close()
+		}
+	}()
+
+	for shard := range jobs {
+		st, ok := nodes[shard.Addr()]
+		if !ok {
+			nodeUrl, err := db.ReplaceHost(dbUrl, shard.Host, shard.Port)
+			if err != nil {
+				return err
+			}
+			st = db.NewStoreForFlavor(flavor)
+			if err := st.Open(nodeUrl); err != nil {
+				return fmt.Errorf("worker node %s: %w (drop --citus-direct to read through the coordinator)", shard.Addr(), err)
+			}
+			nodes[shard.Addr()] = st
+		}
+
+		logger.Debug("Reading shard %s from %s", shard.Name, shard.Addr())
+		rows, err := st.ExecuteQuery(ctx, strings.ReplaceAll(query, shardPlaceholder, shard.Name))
+		if err != nil {
+			return fmt.Errorf("shard %s: %w", shard.Name, err)
+		}
+		stream.SetFields(rows.FieldDescriptions())
+
+		for rows.Next() {
+			values, err := rows.Values()
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("shard %s: %w", shard.Name, err)
+			}
+			if !stream.Send(values) {
+				rows.Close()
+				return nil
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("shard %s: %w", shard.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/version"
+	"github.com/spf13/pflag"
+)
+
+// maxRecordedLog bounds the log kept in memory for diagnostics bundles.
+const maxRecordedLog = 1 << 20
+
+const redacted = "***"
+
+// logTail keeps the last max bytes written to it, cut at a line boundary.
+type logTail struct {
+	mu  sync.Mutex
+	buf []byte
+	max int
+}
+
+func (t *logTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		cut := len(t.buf) - t.max
+		if i := strings.IndexByte(string(t.buf[cut:]), '\n'); i >= 0 {
+			cut += i + 1
+		}
+		t.buf = append(t.buf[:0], t.buf[cut:]...)
+	}
+	return len(p), nil
+}
+
+func (t *logTail) Bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]byte(nil), t.buf...)
+}
+
+// diagnostics records the session log so it can be bundled if the run fails.
+type diagnostics struct {
+	logs  *logTail
+	start time.Time
+}
+
+func startDiagnostics() *diagnostics {
+	d := &diagnostics{logs: &logTail{max: maxRecordedLog}, start: time.Now()}
+	logger.SetRecorder(d.logs)
+	return d
+}
+
+// recoverPanic turns a panic into a crash report and exits with exitCrash.
+// It must be deferred directly by the caller.
+func (d *diagnostics) recoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	fmt.Fprintf(os.Stderr, "pgxport crashed: %v\n\n%s\n", r, stack)
+	d.handleFailure(fmt.Errorf("panic: %v", r), stack)
+	os.Exit(exitCrash)
+}
+
+// handleFailure writes the diagnostics bundle when --diagnostics-bundle is set,
+// and suggests it otherwise.
+func (d *diagnostics) handleFailure(failure error, stack []byte) {
+	if diagnosticsBundle == "" {
+		logger.Info("Re-run with --diagnostics-bundle <file.zip> to collect logs, environment and version details for a bug report")
+		return
+	}
+
+	if err := d.writeBundle(diagnosticsBundle, failure, stack); err != nil {
+		logger.Error("Could not write diagnostics bundle: %v", err)
+		return
+	}
+	logger.Info("Diagnostics bundle written to %s (review it before sharing: it contains the query text)", diagnosticsBundle)
+}
+
+// writeBundle stores the log, environment, redacted options, version information
+// and, for crashes, the stack trace in a zip archive at path.
+func (d *diagnostics) writeBundle(path string, failure error, stack []byte) error {
+	// The bundle holds the query text and the environment: keep it private
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", path, err)
+	}
+	defer f.Close()
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"README.txt", []byte(bundleReadme)},
+		{"version.txt", []byte(version.GetInfo() + "\n")},
+		{"error.txt", []byte(fmt.Sprintf("%v\n\nStarted: %s\nFailed:  %s\n",
+			failure, d.start.Format(time.RFC3339), time.Now().Format(time.RFC3339)))},
+		{"options.txt", []byte(describeOptions(rootCmd.Flags()))},
+		{"environment.txt", []byte(describeEnvironment(os.Environ()))},
+		{"log.txt", d.logs.Bytes()},
+	}
+	if stack != nil {
+		files = append(files, struct {
+			name    string
+			content []byte
+		}{"stack.txt", stack})
+	}
+
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return fmt.Errorf("error adding %s: %w", file.name, err)
+		}
+		if _, err := w.Write(file.content); err != nil {
+			return fmt.Errorf("error writing %s: %w", file.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error finalizing bundle: %w", err)
+	}
+	return f.Close()
+}
+
+const bundleReadme = `pgxport diagnostics bundle
+
+  version.txt      pgxport build, Go version and platform
+  error.txt        the error that ended the run
+  stack.txt        stack trace (crashes only)
+  options.txt      command-line options, passwords and URL credentials redacted
+  environment.txt  platform details and PG*, DB_* and PGXPORT_* variables, secrets redacted
+  log.txt          the session log, including debug messages
+
+Exported data is never included. The query text is, so review the bundle
+before attaching it to a public bug report.
+`
+
+// describeOptions lists every flag with its value, redacting credentials.
+func describeOptions(flags *pflag.FlagSet) string {
+	var b strings.Builder
+	flags.VisitAll(func(f *pflag.Flag) {
+		marker := " "
+		if f.Changed {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, "%s %s = %s\n", marker, f.Name, redactOption(f.Name, f.Value.String()))
+	})
+	b.WriteString("\n(* = set on the command line)\n")
+	return b.String()
+}
+
+func redactOption(name, value string) string {
+	switch {
+	case value == "":
+		return value
+	case name == "password":
+		return redacted
+	case name == "dsn", strings.Contains(value, "://"):
+		// URL flags such as --tokenize-url may carry credentials or tokens
+		return db.SanitizeURL(value)
+	default:
+		return value
+	}
+}
+
+// describeEnvironment reports the platform and the environment variables relevant to pgxport.
+func describeEnvironment(environ []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "OS/Arch: %s/%s\nCPUs:    %d\nGo:      %s\n\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.Version())
+
+	var vars []string
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if !isRelevantEnv(name) {
+			continue
+		}
+		vars = append(vars, name+"="+redactEnv(name, value))
+	}
+	sort.Strings(vars)
+	for _, v := range vars {
+		b.WriteString(v + "\n")
+	}
+	return b.String()
+}
+
+func isRelevantEnv(name string) bool {
+	switch name {
+	case "TZ", "LANG", "LC_ALL", "DO_NOT_TRACK":
+		return true
+	}
+	return strings.HasPrefix(name, "PG") || strings.HasPrefix(name, "DB_") || strings.HasPrefix(name, "PGXPORT_")
+}
+
+func redactEnv(name, value string) string {
+	upper := strings.ToUpper(name)
+	for _, secret := range []string{"PASS", "SECRET", "TOKEN", "KEY"} {
+		if strings.Contains(upper, secret) && value != "" {
+			return redacted
+		}
+	}
+	if strings.Contains(value, "://") || strings.Contains(strings.ToLower(value), "password") {
+		return db.SanitizeURL(value)
+	}
+	return value
+}
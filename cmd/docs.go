@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var docsDir string
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate documentation (man pages, markdown)",
+	Long: `Generate reference documentation for pgxport and all of its commands.
+
+Help topics (formats, time-formats, compression) are written as separate pages.`,
+}
+
+var docsManCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Generate man pages",
+	Example: `  pgxport docs man --dir ./man
+  man ./man/pgxport.1`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return generateDocs(cmd.Root(), docsDir, "man")
+	},
+}
+
+var docsMarkdownCmd = &cobra.Command{
+	Use:     "markdown",
+	Short:   "Generate markdown reference pages",
+	Example: `  pgxport docs markdown --dir ./docs/cli`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return generateDocs(cmd.Root(), docsDir, "markdown")
+	},
+}
+
+func init() {
+	docsCmd.PersistentFlags().StringVar(&docsDir, "dir", ".", "Directory where the documentation is written")
+	docsCmd.AddCommand(docsManCmd, docsMarkdownCmd)
+}
+
+// generateDocs writes the documentation tree of root to dir in the given kind (man or markdown).
+func generateDocs(root *cobra.Command, dir string, kind string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating documentation directory: %w", err)
+	}
+
+	// Keep generated files reproducible across runs
+	root.DisableAutoGenTag = true
+
+	header := &doc.GenManHeader{
+		Title:   "PGXPORT",
+		Section: "1",
+		Source:  "pgxport " + version.AppVersion,
+		Manual:  "pgxport manual",
+	}
+
+	var err error
+	switch kind {
+	case "man":
+		err = doc.GenManTree(root, header, dir)
+	case "markdown":
+		err = doc.GenMarkdownTree(root, dir)
+	default:
+		return fmt.Errorf("unsupported documentation kind: %s", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("error generating %s documentation: %w", kind, err)
+	}
+
+	// cobra skips help topics when walking the tree, write them explicitly
+	for _, topic := range root.Commands() {
+		if !topic.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		if err := generateTopic(topic, header, dir, kind); err != nil {
+			return err
+		}
+	}
+
+	logger.Success("Documentation written to %s", dir)
+	return nil
+}
+
+func generateTopic(topic *cobra.Command, header *doc.GenManHeader, dir string, kind string) error {
+	// Follow cobra's naming: dashes for man pages, underscores for markdown
+	var basename string
+	if kind == "man" {
+		basename = topic.Root().Name() + "-" + topic.Name() + "." + header.Section
+	} else {
+		basename = topic.Root().Name() + "_" + topic.Name() + ".md"
+	}
+
+	f, err := os.Create(filepath.Join(dir, basename))
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", basename, err)
+	}
+	defer f.Close()
+
+	if kind == "man" {
+		// GenMan fills missing header fields in place, so give each page its own copy
+		h := *header
+		err = doc.GenMan(topic, &h, f)
+	} else {
+		// Topic pages are preformatted text, keep their layout in a code block
+		_, err = fmt.Fprintf(f, "## %s help %s\n\n%s\n\n```\n%s```\n",
+			topic.Root().Name(), topic.Name(), topic.Short, topic.Long)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", basename, err)
+	}
+	return f.Close()
+}
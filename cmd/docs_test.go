@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	tests := []struct {
+		kind  string
+		files []string
+	}{
+		{kind: "man", files: []string{"pgxport.1", "pgxport-version.1", "pgxport-docs-man.1", "pgxport-formats.1", "pgxport-time-formats.1"}},
+		{kind: "markdown", files: []string{"pgxport.md", "pgxport_version.md", "pgxport_docs_markdown.md", "pgxport_formats.md", "pgxport_compression.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), tt.kind)
+			if err := generateDocs(rootCmd, dir, tt.kind); err != nil {
+				t.Fatalf("generateDocs() error: %v", err)
+			}
+
+			for _, name := range tt.files {
+				content, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Errorf("expected %s to be generated: %v", name, err)
+					continue
+				}
+				if len(content) == 0 {
+					t.Errorf("%s is empty", name)
+				}
+			}
+
+			main, err := os.ReadFile(filepath.Join(dir, tt.files[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(main), "max-row-bytes") {
+				t.Errorf("%s does not document the export flags", tt.files[0])
+			}
+			if strings.Contains(string(main), "Auto generated by") {
+				t.Errorf("%s should not contain a generation date", tt.files[0])
+			}
+		})
+	}
+}
+
+func TestGenerateDocsUnsupportedKind(t *testing.T) {
+	if err := generateDocs(rootCmd, t.TempDir(), "html"); err == nil {
+		t.Error("generateDocs() expected error for unsupported kind")
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestDoctorReportPrint(t *testing.T) {
+	report := &doctorReport{}
+	report.add("DNS resolution", checkOK, "db -> 10.0.0.1", "")
+	report.add("TCP connection", checkFail, "connection refused", "check that PostgreSQL is running")
+	report.add("TLS handshake", checkSkip, "server not reachable", "")
+
+	var buf bytes.Buffer
+	report.print(&buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		"[ OK ] DNS resolution",
+		"[FAIL] TCP connection     connection refused",
+		"-> check that PostgreSQL is running",
+		"[SKIP] TLS handshake",
+		"1 check(s) failed, 0 warning(s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCheckOutputDir(t *testing.T) {
+	dir := t.TempDir()
+
+	report := &doctorReport{}
+	checkOutputDir(report, filepath.Join(dir, "out.csv"))
+	if got := report.checks[0].status; got != checkOK {
+		t.Fatalf("writable dir: status = %v, detail = %s", got, report.checks[0].detail)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("probe file left behind: %v", entries)
+	}
+
+	report = &doctorReport{}
+	checkOutputDir(report, filepath.Join(dir, "missing", "out.csv"))
+	if got := report.checks[0].status; got != checkFail {
+		t.Errorf("missing dir: status = %v, want FAIL", got)
+	}
+}
+
+func TestCheckTimeZoneLocal(t *testing.T) {
+	defer func(old string) { timeZone = old }(timeZone)
+
+	tests := []struct {
+		zone     string
+		expected checkStatus
+	}{
+		{zone: "", expected: checkOK},
+		{zone: "Europe/Paris", expected: checkOK},
+		{zone: "Mars/Olympus", expected: checkFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.zone, func(t *testing.T) {
+			timeZone = tt.zone
+			report := &doctorReport{}
+			checkTimeZone(context.Background(), report, nil, db.Flavor{})
+			if got := report.checks[0].status; got != tt.expected {
+				t.Errorf("status = %v, want %v (%s)", got, tt.expected, report.checks[0].detail)
+			}
+		})
+	}
+}
+
+func TestCheckNetworkRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := uint16(ln.Addr().(*net.TCPAddr).Port)
+
+	report := &doctorReport{}
+	if !checkNetwork(context.Background(), report, "127.0.0.1", port) {
+		t.Fatalf("listening port reported unreachable: %+v", report.checks)
+	}
+	ln.Close()
+
+	report = &doctorReport{}
+	if checkNetwork(context.Background(), report, "127.0.0.1", port) {
+		t.Fatalf("closed port reported reachable: %+v", report.checks)
+	}
+	if report.checks[0].status != checkSkip || report.checks[1].status != checkFail {
+		t.Errorf("unexpected checks: %+v", report.checks)
+	}
+}
+
+func TestAuthHint(t *testing.T) {
+	hint := authHint(&pgconn.PgError{Code: "28P01"})
+	if !strings.Contains(hint, "password") {
+		t.Errorf("authHint(28P01) = %q", hint)
+	}
+	hint = authHint(&pgconn.PgError{Code: "3D000"})
+	if !strings.Contains(hint, "database does not exist") {
+		t.Errorf("authHint(3D000) = %q", hint)
+	}
+}
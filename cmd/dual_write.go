@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// dualWriteBuffer is the number of rows each writer may lag behind the query.
+const dualWriteBuffer = 256
+
+// errDualWriteAborted is reported by a writer stopped because the other one failed.
+var errDualWriteAborted = errors.New("stopped because the other dual-write output failed")
+
+// dualWriteTarget is the second output of --dual-write.
+type dualWriteTarget struct {
+	Format string
+	Path   string
+}
+
+// parseDualWrite parses a --dual-write value of the form format:path.
+func parseDualWrite(spec string) (dualWriteTarget, error) {
+	f, path, ok := strings.Cut(spec, ":")
+	f = strings.ToLower(strings.TrimSpace(f))
+	if !ok || f == "" || strings.TrimSpace(path) == "" {
+		return dualWriteTarget{}, fmt.Errorf("invalid --dual-write %q: expected format:path, e.g. csv:legacy/orders.csv", spec)
+	}
+	if _, err := exporters.GetExporter(f); err != nil {
+		return dualWriteTarget{}, fmt.Errorf("invalid --dual-write %q: %w", spec, err)
+	}
+	if filepath.Clean(path) == filepath.Clean(outputPath) {
+		return dualWriteTarget{}, fmt.Errorf("invalid --dual-write %q: the path must differ from --output", spec)
+	}
+	return dualWriteTarget{Format: f, Path: path}, nil
+}
+
+type dualWriteResult struct {
+	format string
+	path   string // file written, with the extension of its compression
+	rows   int
+	sha256 string // of the file written, empty if it could not be read
+	err    error
+}
+
+func (r dualWriteResult) String() string {
+	return fmt.Sprintf("%s (%s): %d rows, sha256 %s", r.path, r.format, r.rows, r.sha256)
+}
+
+// writtenChecksum returns the file written for path, as recorded in written, and
+// the SHA-256 digest of its content.
+func writtenChecksum(path string, written []exporters.WrittenFile) (string, string) {
+	for _, w := range written {
+		if w.Requested == path {
+			path = w.Path
+		}
+	}
+	_, sum, err := fileChecksum(path)
+	if err != nil {
+		logger.Warn("Unable to checksum %s: %v", path, err)
+	}
+	return path, sum
+}
+
+// runDualWrite exports rows to the --output file and to the --dual-write target at
+// the same time, then checks that both wrote the same number of rows. The digest of
+// each file is reported, as the outputs of two formats cannot be compared byte for
+// byte. It returns the row count of the main output.
+func runDualWrite(rows pgx.Rows, target dualWriteTarget, exporter exporters.Exporter, options exporters.ExportOptions) (int, error) {
+	second, err := exporters.GetExporter(target.Format)
+	if err != nil {
+		return 0, err
+	}
+	if second, err = withTransforms(second); err != nil {
+		return 0, err
+	}
+	if options.Log == nil {
+		options.Log = &exporters.OutputLog{}
+	}
+	secondOptions := options
+	secondOptions.Format = target.Format
+	// Kept apart from the files of the main output, which the manifest lists
+	secondOptions.Log = options.Log.Child()
+
+	outputs := []struct {
+		exporter exporters.Exporter
+		path     string
+		options  exporters.ExportOptions
+	}{
+		{exporter, outputPath, options},
+		{second, target.Path, secondOptions},
+	}
+
+	streams := make([]*rowsource.Stream, len(outputs))
+	results := make([]dualWriteResult, len(outputs))
+	var wg sync.WaitGroup
+	for i, o := range outputs {
+		streams[i] = rowsource.NewStream(dualWriteBuffer)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := o.exporter.Export(streams[i], o.path, o.options)
+			// Unblocks the reader if this writer gave up early
+			streams[i].Close()
+			results[i] = dualWriteResult{format: o.options.Format, path: o.path, rows: n, err: err}
+			if err == nil {
+				results[i].path, results[i].sha256 = writtenChecksum(o.path, o.options.Log.Files())
+			}
+		}()
+	}
+
+	readErr := teeRows(rows, streams)
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.err != nil && !errors.Is(r.err, errDualWriteAborted) {
+			errs = append(errs, fmt.Errorf("%s: %w", r.path, r.err))
+		}
+	}
+	if readErr != nil {
+		errs = append(errs, readErr)
+	}
+	if len(errs) > 0 {
+		return results[0].rows, errors.Join(errs...)
+	}
+
+	if results[0].rows != results[1].rows {
+		return results[0].rows, fmt.Errorf("dual-write mismatch: %s; %s", results[0], results[1])
+	}
+	logger.Success("Dual-write verified: %s and %s received the same %d rows", results[0].path, results[1].path, results[0].rows)
+	logger.Info("Dual-write outputs: %s; %s", results[0], results[1])
+	return results[0].rows, nil
+}
+
+// teeRows sends every row to each stream. If a writer stops reading, the others
+// are stopped too, as their output could no longer be compared.
+func teeRows(rows pgx.Rows, streams []*rowsource.Stream) error {
+	abort := func(err error) {
+		for _, s := range streams {
+			s.Fail(err)
+			s.CloseSend()
+		}
+	}
+
+	fields := rows.FieldDescriptions()
+	for _, s := range streams {
+		s.SetFields(fields)
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			abort(errDualWriteAborted)
+			return fmt.Errorf("error reading row: %w", err)
+		}
+		for _, s := range streams {
+			if !s.Send(values) {
+				abort(errDualWriteAborted)
+				return nil
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		abort(errDualWriteAborted)
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	for _, s := range streams {
+		s.CloseSend()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseDualWrite(t *testing.T) {
+	saved := outputPath
+	defer func() { outputPath = saved }()
+	outputPath = "out/orders.json"
+
+	got, err := parseDualWrite("CSV:legacy/orders.csv")
+	if err != nil {
+		t.Fatalf("parseDualWrite() error: %v", err)
+	}
+	if got != (dualWriteTarget{Format: "csv", Path: "legacy/orders.csv"}) {
+		t.Errorf("parseDualWrite() = %+v", got)
+	}
+
+	for spec, want := range map[string]string{
+		"legacy.csv":          "expected format:path",
+		"csv:":                "expected format:path",
+		"parquet:orders.pq":   "parquet",
+		"csv:out/orders.json": "must differ from --output",
+	} {
+		if _, err := parseDualWrite(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseDualWrite(%q) error = %v, should mention %q", spec, err, want)
+		}
+	}
+}
+
+func dualWriteRows(t *testing.T, n int) pgx.Rows {
+	t.Helper()
+	data := make([][]any, n)
+	for i := range data {
+		data[i] = []any{int32(i + 1), fmt.Sprintf("item-%d", i+1)}
+	}
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "name", OID: pgtype.TextOID},
+	}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func runDualWriteTest(t *testing.T, target dualWriteTarget) (int, error) {
+	t.Helper()
+	saved := outputPath
+	defer func() { outputPath = saved }()
+	outputPath = filepath.Join(t.TempDir(), "items.json")
+
+	exporter, _ := exporters.GetExporter(exporters.FormatJSON)
+	options := exporters.ExportOptions{Format: exporters.FormatJSON, Delimiter: ',', Compression: "none", TimeZone: "UTC"}
+
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := runDualWrite(dualWriteRows(t, 1000), target, exporter, options)
+		done <- result{n, err}
+	}()
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-time.After(10 * time.Second):
+		t.Fatal("runDualWrite() did not return")
+		return 0, nil
+	}
+}
+
+func TestRunDualWrite(t *testing.T) {
+	legacy := filepath.Join(t.TempDir(), "items.csv")
+	n, err := runDualWriteTest(t, dualWriteTarget{Format: exporters.FormatCSV, Path: legacy})
+	if err != nil {
+		t.Fatalf("runDualWrite() error: %v", err)
+	}
+	if n != 1000 {
+		t.Errorf("runDualWrite() = %d rows, want 1000", n)
+	}
+
+	data, err := os.ReadFile(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1001 {
+		t.Errorf("legacy output has %d lines, want 1001", lines)
+	}
+}
+
+func TestRunDualWriteFailure(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing", "items.csv")
+	_, err := runDualWriteTest(t, dualWriteTarget{Format: exporters.FormatCSV, Path: missing})
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Fatalf("runDualWrite() error = %v, should name %s", err, missing)
+	}
+	if strings.Contains(err.Error(), errDualWriteAborted.Error()) {
+		t.Errorf("the aborted output should not be reported: %v", err)
+	}
+}
+
+func TestWrittenChecksum(t *testing.T) {
+	dir := t.TempDir()
+	requested := filepath.Join(dir, "items.csv")
+	if err := os.WriteFile(requested+".gz", []byte("gzip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The digest is of the file emitted by the exporter, not of the requested path
+	path, sum := writtenChecksum(requested, []exporters.WrittenFile{{Path: requested + ".gz", Requested: requested}})
+	if path != requested+".gz" {
+		t.Errorf("writtenChecksum() path = %s, want %s.gz", path, requested)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("gzip"))); sum != want {
+		t.Errorf("writtenChecksum() = %s, want %s", sum, want)
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fbz-tec/pgxport/core/encryption"
+	"github.com/fbz-tec/pgxport/core/exporters"
+)
+
+// columnEncryption is the middleware of --encrypt-column, built once so that
+// keys are only loaded once per run.
+var columnEncryption exporters.Middleware
+
+// parseEncryptColumns parses the --encrypt-column settings.
+func parseEncryptColumns(values []string) ([]encryption.Spec, error) {
+	specs := make([]encryption.Spec, 0, len(values))
+	seen := map[string]bool{}
+	for _, v := range values {
+		spec, err := encryption.ParseSpec(v)
+		if err != nil {
+			return nil, err
+		}
+		if seen[spec.Column] {
+			return nil, fmt.Errorf("column %q is given twice to --encrypt-column", spec.Column)
+		}
+		seen[spec.Column] = true
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// encryptionMiddleware returns the middleware encrypting the --encrypt-column
+// columns, or nil when no column is encrypted.
+func encryptionMiddleware() (exporters.Middleware, error) {
+	if len(encryptColumns) == 0 || columnEncryption != nil {
+		return columnEncryption, nil
+	}
+	specs, err := parseEncryptColumns(encryptColumns)
+	if err != nil {
+		return nil, err
+	}
+	if columnEncryption, err = encryption.Compile(specs); err != nil {
+		return nil, fmt.Errorf("--encrypt-column: %w", err)
+	}
+	return columnEncryption, nil
+}
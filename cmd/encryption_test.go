@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/encryption"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/core/transforms"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestWithTransformsEncryptsAfterTransforms(t *testing.T) {
+	savedColumns, savedSteps, savedMiddleware := encryptColumns, transformSteps, columnEncryption
+	defer func() { encryptColumns, transformSteps, columnEncryption = savedColumns, savedSteps, savedMiddleware }()
+
+	key := strings.Repeat("ab", encryption.KeySize)
+	t.Setenv("PGXPORT_TEST_SSN_KEY", key)
+	columnEncryption = nil
+	// The column is encrypted under the name given by the rename transform
+	transformSteps = []transforms.Step{{Rename: map[string]string{"tax_id": "ssn"}}}
+	encryptColumns = []string{"ssn=aes256:PGXPORT_TEST_SSN_KEY"}
+
+	csv, _ := exporters.GetExporter(exporters.FormatCSV)
+	exporter, err := withTransforms(csv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := rowsource.New([]rowsource.Column{{Name: "tax_id", OID: pgtype.TextOID}}, [][]any{{"123-45-6789"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "out.csv")
+	options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', Compression: "none", NoHeader: true}
+	if _, err := exporter.Export(rows, path, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	raw, _ := encryption.LoadKey("PGXPORT_TEST_SSN_KEY")
+	if ssn, err := encryption.Decrypt(raw, strings.TrimSpace(string(data))); err != nil || ssn != "123-45-6789" {
+		t.Errorf("exported %q, decrypted to %q, %v", data, ssn, err)
+	}
+
+	// Keys are loaded once per run
+	t.Setenv("PGXPORT_TEST_SSN_KEY", "")
+	if _, err := withTransforms(csv); err != nil {
+		t.Errorf("second withTransforms() reloaded the key: %v", err)
+	}
+
+	columnEncryption = nil
+	if _, err := withTransforms(csv); err == nil || !strings.Contains(err.Error(), "PGXPORT_TEST_SSN_KEY is not set") {
+		t.Errorf("withTransforms() error = %v, want a missing key error", err)
+	}
+}
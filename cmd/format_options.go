@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/spf13/pflag"
+)
+
+// formatOptions holds the --opt values of the export format that are not flags.
+var formatOptions map[string]string
+
+// formatsList describes the registered formats and their --opt settings for
+// "pgxport formats".
+func formatsList() string {
+	var b strings.Builder
+	for _, name := range exporters.ListExporters() {
+		info, err := exporters.DescribeFormat(name)
+		if err != nil {
+			continue
+		}
+		copyMode := ""
+		if info.SupportsCopy {
+			copyMode = ", COPY mode"
+		}
+		fmt.Fprintf(&b, "%s (%s%s)\n", name, info.Extension, copyMode)
+
+		for _, o := range info.Options {
+			line := fmt.Sprintf("  %-28s %s", fmt.Sprintf("%s.%s=%s", name, o.Name, o.Default), o.Description)
+			if o.Flag != "" {
+				line += fmt.Sprintf(" (same as --%s)", o.Flag)
+			}
+			b.WriteString(line + "\n")
+		}
+		if len(info.Options) == 0 {
+			b.WriteString("  no options\n")
+		}
+	}
+	b.WriteString("\nSet options with --opt format.name=value; options of other formats than --format are ignored.\n")
+	b.WriteString("See 'pgxport help formats' for the details of each format.\n")
+	return b.String()
+}
+
+// applyFormatOptions applies the --opt format.name=value settings to fs. Options
+// standing for a flag set it; the others are returned for the exporter. Options of
+// other formats than --format are ignored, so one options document may hold them all.
+func applyFormatOptions(fs *pflag.FlagSet) (map[string]string, error) {
+	values := map[string]string{}
+	for _, spec := range formatOpts {
+		setting, value, ok := strings.Cut(spec, "=")
+		formatName, name, dotted := strings.Cut(setting, ".")
+		if !ok || !dotted || formatName == "" || name == "" {
+			return nil, fmt.Errorf("error: invalid --opt %q: expected format.name=value", spec)
+		}
+
+		info, err := exporters.DescribeFormat(strings.ToLower(formatName))
+		if err != nil {
+			return nil, fmt.Errorf("error: invalid --opt %q: %w", spec, err)
+		}
+		option, found := findOption(info, name)
+		if !found {
+			return nil, fmt.Errorf("error: invalid --opt %q: unknown %s option %q (see 'pgxport formats')", spec, info.Name, name)
+		}
+		if info.Name != strings.ToLower(format) {
+			logger.Debug("Ignoring --opt %s: the export format is %s", setting, format)
+			continue
+		}
+
+		if option.Flag == "" {
+			values[option.Name] = value
+			continue
+		}
+		if fs.Changed(option.Flag) {
+			return nil, fmt.Errorf("error: --opt %s.%s and --%s cannot both be set", info.Name, option.Name, option.Flag)
+		}
+		if err := fs.Set(option.Flag, value); err != nil {
+			return nil, fmt.Errorf("error: invalid --opt %q: %w", spec, err)
+		}
+	}
+	return values, nil
+}
+
+func findOption(info exporters.FormatInfo, name string) (exporters.Option, bool) {
+	for _, o := range info.Options {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return exporters.Option{}, false
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/spf13/pflag"
+)
+
+func newFormatOptionsFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("xlsx-sheet-name", "Sheet1", "")
+	flags.Bool("two-pass", false, "")
+	flags.String("delimiter", ",", "")
+	return flags
+}
+
+func TestApplyFormatOptions(t *testing.T) {
+	savedFormat, savedOpts := format, formatOpts
+	defer func() { format, formatOpts = savedFormat, savedOpts }()
+
+	format = "xlsx"
+	formatOpts = []string{"xlsx.sheet=Data", "xlsx.two-pass=true", "csv.quote-all=true", "csv.delimiter=;"}
+	flags := newFormatOptionsFlagSet()
+	values, err := applyFormatOptions(flags)
+	if err != nil {
+		t.Fatalf("applyFormatOptions() error: %v", err)
+	}
+	if got := flags.Lookup("xlsx-sheet-name").Value.String(); got != "Data" {
+		t.Errorf("xlsx-sheet-name = %q, want Data", got)
+	}
+	if got := flags.Lookup("two-pass").Value.String(); got != "true" {
+		t.Errorf("two-pass = %q, want true", got)
+	}
+	// Options of other formats are ignored
+	if got := flags.Lookup("delimiter").Value.String(); got != "," || len(values) != 0 {
+		t.Errorf("delimiter = %q, options = %v: csv options should be ignored", got, values)
+	}
+
+	format = "csv"
+	values, err = applyFormatOptions(newFormatOptionsFlagSet())
+	if err != nil {
+		t.Fatalf("applyFormatOptions() error: %v", err)
+	}
+	if values["quote-all"] != "true" {
+		t.Errorf("options = %v, want quote-all=true", values)
+	}
+}
+
+func TestApplyFormatOptionsErrors(t *testing.T) {
+	savedFormat, savedOpts := format, formatOpts
+	defer func() { format, formatOpts = savedFormat, savedOpts }()
+	format = "xlsx"
+
+	for spec, want := range map[string]string{
+		"sheet=Data":         "expected format.name=value",
+		"xlsx.sheet":         "expected format.name=value",
+		"parquet.codec=zstd": "parquet",
+		"xlsx.sheet-name=x":  `unknown xlsx option "sheet-name"`,
+		"xlsx.two-pass=many": "invalid --opt",
+	} {
+		formatOpts = []string{spec}
+		if _, err := applyFormatOptions(newFormatOptionsFlagSet()); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("applyFormatOptions(%q) error = %v, should mention %q", spec, err, want)
+		}
+	}
+
+	formatOpts = []string{"xlsx.sheet=Data"}
+	flags := newFormatOptionsFlagSet()
+	if err := flags.Parse([]string{"--xlsx-sheet-name", "Other"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := applyFormatOptions(flags); err == nil || !strings.Contains(err.Error(), "cannot both be set") {
+		t.Errorf("applyFormatOptions() error = %v, should reject the conflicting flag", err)
+	}
+}
+
+func TestFormatsList(t *testing.T) {
+	text := formatsList()
+	for _, name := range exporters.ListExporters() {
+		if !strings.Contains(text, name+" (") {
+			t.Errorf("formats list does not describe %q", name)
+		}
+	}
+	for _, want := range []string{
+		"csv.quote-all=false",
+		"Worksheet name (same as --xlsx-sheet-name)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("formats list missing %q", want)
+		}
+	}
+}
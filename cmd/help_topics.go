@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// helpTopics returns the additional help topics shown by "pgxport help <topic>".
+// Their text is generated from the metadata registered by exporters and formatters,
+// so it always matches the binary. flags must hold the export flags of the root command.
+// The formats topic is also a command, "pgxport formats", listing the --opt settings.
+func helpTopics(flags *pflag.FlagSet) []*cobra.Command {
+	return []*cobra.Command{
+		{
+			Use:   "formats",
+			Short: "Output formats and their options",
+			Long:  formatsTopic(flags),
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				fmt.Fprint(cmd.OutOrStdout(), formatsList())
+			},
+		},
+		{
+			Use:   "time-formats",
+			Short: "Pattern tokens accepted by --time-format",
+			Long:  timeFormatsTopic(),
+		},
+		{
+			Use:   "compression",
+			Short: "Compression modes accepted by --compression",
+			Long:  compressionTopic(),
+		},
+	}
+}
+
+func formatsTopic(flags *pflag.FlagSet) string {
+	var b strings.Builder
+	b.WriteString("Output formats supported by pgxport (select one with --format).\n")
+
+	for _, name := range exporters.ListExporters() {
+		info, err := exporters.DescribeFormat(name)
+		if err != nil {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n%s (--format %s, %s)\n", info.Title, name, info.Extension)
+		fmt.Fprintf(&b, "  %s\n", info.Description)
+		if info.SupportsCopy {
+			b.WriteString("  Supports PostgreSQL COPY mode (--with-copy).\n")
+		}
+
+		if len(info.Flags) > 0 {
+			b.WriteString("\n  Options:\n")
+			for _, flagName := range info.Flags {
+				b.WriteString(describeFlag(flags, flagName))
+			}
+		}
+
+		if len(info.Options) > 0 {
+			b.WriteString("\n  Settings for --opt:\n")
+			for _, o := range info.Options {
+				fmt.Fprintf(&b, "    %-24s %s\n", name+"."+o.Name, o.Description)
+			}
+		}
+
+		if len(info.Notes) > 0 {
+			b.WriteString("\n  Notes:\n")
+			for _, note := range info.Notes {
+				fmt.Fprintf(&b, "    - %s\n", note)
+			}
+		}
+	}
+
+	b.WriteString("\nAll formats also accept --compression, --time-format, --time-zone,\n")
+	b.WriteString("--max-row-bytes and --fail-on-empty. See 'pgxport help time-formats'\n")
+	b.WriteString("and 'pgxport help compression'.\n")
+	return b.String()
+}
+
+func describeFlag(flags *pflag.FlagSet, name string) string {
+	f := flags.Lookup(name)
+	if f == nil {
+		return fmt.Sprintf("    --%s\n", name)
+	}
+
+	flag := "--" + f.Name
+	if f.Shorthand != "" {
+		flag = "-" + f.Shorthand + ", " + flag
+	}
+	line := fmt.Sprintf("    %-24s %s", flag, f.Usage)
+	if f.DefValue != "" && f.DefValue != "false" {
+		line += fmt.Sprintf(" (default %q)", f.DefValue)
+	}
+	return line + "\n"
+}
+
+func timeFormatsTopic() string {
+	var b strings.Builder
+	b.WriteString("Date and time values are formatted with the pattern given to --time-format\n")
+	b.WriteString("(-T). Any character that is not a token is copied as is.\n\n")
+
+	fmt.Fprintf(&b, "  %-6s %-26s %s\n", "Token", "Description", "Example")
+	for _, tok := range formatters.TimeTokens {
+		fmt.Fprintf(&b, "  %-6s %-26s %s\n", tok.Token, tok.Description, tok.Example)
+	}
+
+	b.WriteString(`
+Date columns only use the date tokens of the pattern, so "yyyy-MM-dd HH:mm:ss"
+exports a date as "yyyy-MM-dd".
+
+Common patterns:
+  ISO 8601      yyyy-MM-ddTHH:mm:ss.SSS
+  European      dd/MM/yyyy HH:mm:ss
+  US            MM/dd/yyyy HH:mm:ss
+  Date only     yyyy-MM-dd
+
+--time-zone (-Z) converts timestamps to an IANA time zone such as UTC or
+Europe/Paris before formatting. The local time zone is used by default.
+`)
+	return b.String()
+}
+
+func compressionTopic() string {
+	var b strings.Builder
+	b.WriteString("The output file can be compressed with --compression (-z).\n\n")
+	for _, c := range exporters.ListCompressions() {
+		fmt.Fprintf(&b, "  %-6s %s\n", c.Name, c.Description)
+	}
+	b.WriteString("\nCompression applies to every format and to COPY mode.\n")
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/formatters"
+)
+
+func TestHelpTopicsRegistered(t *testing.T) {
+	for _, name := range []string{"formats", "time-formats", "compression"} {
+		topic, _, err := rootCmd.Find([]string{name})
+		if err != nil || topic.Name() != name {
+			t.Fatalf("help topic %q not registered (err=%v)", name, err)
+		}
+		if name != "formats" && !topic.IsAdditionalHelpTopicCommand() {
+			t.Errorf("%q should be a help topic, not a runnable command", name)
+		}
+	}
+}
+
+func TestFormatsTopic(t *testing.T) {
+	text := formatsTopic(rootCmd.Flags())
+
+	for _, name := range exporters.ListExporters() {
+		if !strings.Contains(text, "--format "+name) {
+			t.Errorf("formats topic does not describe %q", name)
+		}
+	}
+
+	// Format-specific flags are rendered from the live flag definitions
+	for _, want := range []string{
+		`-D, --delimiter`,
+		`--xml-root-tag           Sets the root element name for XML exports (default "results")`,
+		`--xlsx-sheet-name`,
+		`-t, --table`,
+		`Supports PostgreSQL COPY mode`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("formats topic missing %q", want)
+		}
+	}
+}
+
+func TestTimeFormatsTopic(t *testing.T) {
+	text := timeFormatsTopic()
+	for _, tok := range formatters.TimeTokens {
+		if !strings.Contains(text, tok.Token+" ") || !strings.Contains(text, tok.Description) {
+			t.Errorf("time-formats topic missing token %q", tok.Token)
+		}
+	}
+}
+
+func TestCompressionTopic(t *testing.T) {
+	text := compressionTopic()
+	for _, c := range exporters.ListCompressions() {
+		if !strings.Contains(text, c.Name) {
+			t.Errorf("compression topic missing %q", c.Name)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var initForce bool
+
+var initCmd = &cobra.Command{
+	Use:   "init [dir]",
+	Short: "Create a project directory for managed exports",
+	Long: `Create the files of a repository of managed exports in dir (default: the current directory):
+
+  templates.yaml          shared export settings, used with --templates-file templates.yaml --template <name>
+  exports/customers.yaml  a sample export, run with --options exports/customers.yaml
+  pipeline.yaml           a sample jobfile, run with pgxport run pipeline.yaml
+  .env.example            the connection settings, to copy to .env
+  .gitignore              keeps .env and the .partial files of failed exports out of the repository
+
+Existing files are kept unless --force is given; entries missing from an existing
+.gitignore are appended to it.`,
+	Example: `  pgxport init extracts
+  cd extracts && cp .env.example .env
+  pgxport --templates-file templates.yaml --options exports/customers.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		return initWorkspace(dir, initForce)
+	},
+}
+
+func init() {
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite existing files")
+}
+
+// scaffoldFile is a file written by pgxport init.
+type scaffoldFile struct {
+	path    string
+	content string
+}
+
+var scaffoldFiles = []scaffoldFile{
+	{templatesFileName, `# Export templates, used with --templates-file templates.yaml --template <name>.
+# Each template is an options document (keys are the long flag names) and may
+# extend another one; the --options document and the command line override it.
+csv-gzip:
+  format: csv
+  compression: gzip
+  time-format: yyyy-MM-dd HH:mm:ss
+
+partner-feed:
+  extends: csv-gzip
+  delimiter: ";"
+  transforms:
+    - mask: {column: email}
+`},
+	{filepath.Join("exports", "customers.yaml"), `# Run with: pgxport --templates-file templates.yaml --options exports/customers.yaml
+# Add --template partner-feed to start from the settings of a template.
+sql: SELECT id, name, email, created_at FROM customers ORDER BY id
+output: customers.csv
+format: csv
+`},
+	{"pipeline.yaml", `# Exports run in dependency order with: pgxport run pipeline.yaml
+# Each job is an options document, like those of exports/, and may name a
+# template of templates.yaml. depends_on lists the jobs a job runs after; when
+# a job fails, its dependents are skipped (re-run them with --resume-failed).
+jobs:
+  customers:
+    sql: SELECT id, name, email, created_at FROM customers ORDER BY id
+    output: customers.csv
+    format: csv
+  orders:
+    depends_on: [customers]
+    sql: SELECT id, customer_id, total, created_at FROM orders ORDER BY id
+    output: orders.csv
+    templates-file: templates.yaml
+    template: csv-gzip
+`},
+	{".env.example", `# Connection settings: copy this file to .env, which is kept out of git
+DB_USER=
+DB_PASS=
+DB_HOST=localhost
+DB_PORT=5432
+DB_NAME=
+# DB_SSLMODE=require
+`},
+}
+
+// gitignoreEntries keeps credentials and the files of failed exports out of the repository.
+var gitignoreEntries = []string{".env", "*.partial"}
+
+// initWorkspace writes the scaffold files to dir, keeping existing ones unless force is set.
+func initWorkspace(dir string, force bool) error {
+	for _, f := range scaffoldFiles {
+		path := filepath.Join(dir, f.path)
+		if _, err := os.Stat(path); err == nil && !force {
+			logger.Warn("Keeping existing %s", path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("error creating %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(f.content), 0o644); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+		logger.Info("Created %s", path)
+	}
+
+	if err := updateGitignore(filepath.Join(dir, ".gitignore")); err != nil {
+		return err
+	}
+
+	logger.Success("Project initialized in %s. Copy .env.example to .env and fill in the connection settings", dir)
+	return nil
+}
+
+// updateGitignore appends the gitignoreEntries missing from path, creating it if needed.
+func updateGitignore(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var missing []string
+	for _, entry := range gitignoreEntries {
+		if !slices.Contains(lines, entry) {
+			missing = append(missing, entry)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	b.Write(content)
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		b.WriteByte('\n')
+	}
+	if len(content) == 0 {
+		b.WriteString("# Credentials and partial exports\n")
+	}
+	b.WriteString(strings.Join(missing, "\n") + "\n")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	logger.Info("Added %s to %s", strings.Join(missing, ", "), path)
+	return nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/internal/jobfile"
+	"github.com/fbz-tec/pgxport/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInitWorkspace(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "extracts")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := initWorkspace(dir, false); err != nil {
+		t.Fatalf("initWorkspace() error: %v", err)
+	}
+	for _, f := range scaffoldFiles {
+		content, err := os.ReadFile(filepath.Join(dir, f.path))
+		if err != nil || string(content) != f.content {
+			t.Errorf("%s not written (err=%v)", f.path, err)
+		}
+	}
+	gitignore, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(gitignore), "*.log\n.env\n*.partial\n"; got != want {
+		t.Errorf(".gitignore = %q, want %q", got, want)
+	}
+
+	// A second run keeps edited files and does not repeat .gitignore entries
+	edited := filepath.Join(dir, ".env.example")
+	if err := os.WriteFile(edited, []byte("DB_NAME=sales\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := initWorkspace(dir, false); err != nil {
+		t.Fatalf("initWorkspace() error: %v", err)
+	}
+	if content, _ := os.ReadFile(edited); string(content) != "DB_NAME=sales\n" {
+		t.Errorf("existing file overwritten: %q", content)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, ".gitignore")); string(again) != string(gitignore) {
+		t.Errorf(".gitignore changed on second run: %q", again)
+	}
+
+	if err := initWorkspace(dir, true); err != nil {
+		t.Fatalf("initWorkspace(force) error: %v", err)
+	}
+	if content, _ := os.ReadFile(edited); !strings.Contains(string(content), "DB_HOST=") {
+		t.Errorf("--force did not overwrite %s", edited)
+	}
+}
+
+// The scaffolded documents must be accepted as they are
+func TestInitWorkspaceDocuments(t *testing.T) {
+	dir := t.TempDir()
+	if err := initWorkspace(dir, false); err != nil {
+		t.Fatal(err)
+	}
+
+	savedName, savedTemplates := templateName, templatesFile
+	savedFile, savedSteps := optionsFile, transformSteps
+	defer func() {
+		templateName, templatesFile = savedName, savedTemplates
+		optionsFile, transformSteps = savedFile, savedSteps
+	}()
+	templateName = "partner-feed"
+	templatesFile = filepath.Join(dir, templatesFileName)
+	optionsFile = filepath.Join(dir, "exports", "customers.yaml")
+
+	flags := newOptionsFlagSet()
+	for _, name := range []string{"format", "compression", "time-format", "delimiter"} {
+		flags.String(name, "", "")
+	}
+	if err := loadOptionsDocument(flags); err != nil {
+		t.Fatalf("loadOptionsDocument() error: %v", err)
+	}
+	if got := flags.Lookup("delimiter").Value.String(); got != ";" {
+		t.Errorf("delimiter = %q, want ;", got)
+	}
+	if len(transformSteps) != 1 || transformSteps[0].Mask == nil || transformSteps[0].Mask.Column != "email" {
+		t.Errorf("transforms = %+v", transformSteps)
+	}
+}
+
+// The sample jobfile runs its jobs in order, each with an options document the
+// export accepts
+func TestInitWorkspaceJobfile(t *testing.T) {
+	dir := t.TempDir()
+	if err := initWorkspace(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(state.EnvFile, filepath.Join(t.TempDir(), "state.json"))
+	// Jobs run from the project directory, where the relative paths resolve
+	t.Chdir(dir)
+
+	savedName, savedTemplates := templateName, templatesFile
+	savedFile, savedInput, savedSteps := optionsFile, optionsInput, transformSteps
+	defer func() {
+		templateName, templatesFile = savedName, savedTemplates
+		optionsFile, optionsInput, transformSteps = savedFile, savedInput, savedSteps
+	}()
+
+	ran := fakeJobs(t)
+	runJob = func(_ context.Context, job jobfile.Job) error {
+		*ran = append(*ran, job.Name)
+		// As the child process of execJob: templates are selected with flags,
+		// the other options are read from stdin
+		templateName, templatesFile = "", ""
+		options := map[string]any{}
+		for key, value := range job.Options {
+			switch key {
+			case "template":
+				templateName = fmt.Sprint(value)
+			case "templates-file":
+				templatesFile = fmt.Sprint(value)
+			default:
+				options[key] = value
+			}
+		}
+		doc, err := yaml.Marshal(options)
+		if err != nil {
+			return err
+		}
+		optionsFile, optionsInput = optionsStdin, bytes.NewReader(doc)
+
+		flags := newOptionsFlagSet()
+		for _, name := range []string{"format", "compression", "time-format", "delimiter"} {
+			flags.String(name, "", "")
+		}
+		if err := loadOptionsDocument(flags); err != nil {
+			return err
+		}
+		if job.Name == "orders" && flags.Lookup("compression").Value.String() != "gzip" {
+			return fmt.Errorf("template %s not applied", templateName)
+		}
+		return nil
+	}
+
+	if err := runJobfile(context.Background(), "pipeline.yaml", nil, false); err != nil {
+		t.Fatalf("runJobfile() error: %v", err)
+	}
+	if got := strings.Join(*ran, ","); got != "customers,orders" {
+		t.Errorf("ran %s, want customers,orders", got)
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// keysTableColumns reads the columns of a --keys-table-from-file CSV from its header,
+// where each column is written name or name:type. Columns without a type are bigint
+// when all their values are integers and text otherwise.
+func keysTableColumns(path string) ([]db.KeyColumn, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keys file: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("keys file %s is empty: the first line must name the columns", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid keys file %s: %w", path, err)
+	}
+
+	columns := make([]db.KeyColumn, len(header))
+	var untyped []int
+	seen := map[string]bool{}
+	for i, h := range header {
+		name, typ, _ := strings.Cut(strings.TrimPrefix(h, "\ufeff"), ":")
+		name, typ = strings.TrimSpace(name), strings.TrimSpace(typ)
+		if name == "" {
+			return nil, fmt.Errorf("invalid keys file %s: column %d has no name", path, i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid keys file %s: column %s appears twice", path, name)
+		}
+		seen[name] = true
+		if typ != "" && !db.ValidTypeName(typ) {
+			return nil, fmt.Errorf("invalid keys file %s: %q is not a type name", path, typ)
+		}
+		columns[i] = db.KeyColumn{Name: name, Type: typ}
+		if typ == "" {
+			untyped = append(untyped, i)
+		}
+	}
+	if len(untyped) == 0 {
+		return columns, nil
+	}
+
+	integer := make([]bool, len(columns))
+	for _, i := range untyped {
+		integer[i] = true
+	}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid keys file %s: %w", path, err)
+		}
+		for _, i := range untyped {
+			if v := record[i]; integer[i] && v != "" {
+				_, perr := strconv.ParseInt(v, 10, 64)
+				integer[i] = perr == nil
+			}
+		}
+	}
+
+	for _, i := range untyped {
+		columns[i].Type = "text"
+		if integer[i] {
+			columns[i].Type = "bigint"
+		}
+		logger.Debug("Key column %s: %s", columns[i].Name, columns[i].Type)
+	}
+	return columns, nil
+}
+
+// loadKeysTable loads the --keys-table-from-file CSV into the pgxport_keys temporary table.
+func loadKeysTable(store db.Store) error {
+	columns, err := keysTableColumns(keysTableFile)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(keysTableFile)
+	if err != nil {
+		return fmt.Errorf("unable to read keys file: %w", err)
+	}
+	defer f.Close()
+
+	n, err := db.LoadKeysTable(context.Background(), store, columns, f)
+	if err != nil {
+		return err
+	}
+	logger.Info("Loaded %d keys from %s into %s", n, keysTableFile, db.KeysTable)
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/db"
+)
+
+func writeKeysFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.csv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeysTableColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []db.KeyColumn
+	}{
+		{
+			name:     "inferred types",
+			content:  "\ufeffid,sku,region\n1,A-1,\n-2,B-2,eu\n,C-3,us\n",
+			expected: []db.KeyColumn{{Name: "id", Type: "bigint"}, {Name: "sku", Type: "text"}, {Name: "region", Type: "text"}},
+		},
+		{
+			name:     "declared types",
+			content:  "order_id:uuid,\" amount : numeric(12,2)\",day:date\n",
+			expected: []db.KeyColumn{{Name: "order_id", Type: "uuid"}, {Name: "amount", Type: "numeric(12,2)"}, {Name: "day", Type: "date"}},
+		},
+		{
+			name:     "integers too large for bigint",
+			content:  "n\n1\n99999999999999999999\n",
+			expected: []db.KeyColumn{{Name: "n", Type: "text"}},
+		},
+		{
+			name:     "header only",
+			content:  "id\n",
+			expected: []db.KeyColumn{{Name: "id", Type: "bigint"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keysTableColumns(writeKeysFile(t, tt.content))
+			if err != nil {
+				t.Fatalf("keysTableColumns() error: %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("keysTableColumns() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("column %d = %v, want %v", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKeysTableColumnsErrors(t *testing.T) {
+	for content, want := range map[string]string{
+		"":                       "is empty",
+		"id,,sku\n":              "column 2 has no name",
+		"id,id\n":                "appears twice",
+		"id:int; DROP TABLE x\n": "is not a type name",
+		"id,sku\n1,a\n2\n":       "wrong number of fields",
+		"id\n\"unterminated\n":   "invalid keys file",
+	} {
+		_, err := keysTableColumns(writeKeysFile(t, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("keysTableColumns(%q) error = %v, should mention %q", content, err, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// Output layouts
+const (
+	layoutFlat  = "flat"  // write --output as given
+	layoutDated = "dated" // write under <dest>/YYYY/MM/DD/run-<id>/
+)
+
+// latestLink is the link to the last successful run of the dated layout.
+const latestLink = "latest"
+
+// datedOutputPath creates the run directory of the dated layout under the
+// directory of output and returns the path of output inside it. Runs started in
+// the same second get numbered directories (run-150405, run-150405-2, ...).
+func datedOutputPath(output string, now time.Time) (string, error) {
+	day := filepath.Join(filepath.Dir(output), now.Format("2006"), now.Format("01"), now.Format("02"))
+	if err := os.MkdirAll(day, 0o755); err != nil {
+		return "", fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	base := "run-" + now.Format("150405")
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name += "-" + strconv.Itoa(n)
+		}
+		runDir := filepath.Join(day, name)
+		err := os.Mkdir(runDir, 0o755)
+		if err == nil {
+			return filepath.Join(runDir, filepath.Base(output)), nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("error creating output directory: %w", err)
+		}
+	}
+}
+
+// updateLatestLink points dest/latest to runDir. The link is created under a
+// temporary name and renamed over the previous one, so that readers always see
+// a complete run.
+func updateLatestLink(dest, runDir string) error {
+	target, err := filepath.Rel(dest, runDir)
+	if err != nil {
+		return fmt.Errorf("error updating %s link: %w", latestLink, err)
+	}
+
+	tmp := filepath.Join(dest, "."+latestLink+"-"+strconv.Itoa(os.Getpid()))
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("error updating %s link: %w", latestLink, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dest, latestLink)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("error updating %s link: %w", latestLink, err)
+	}
+	logger.Debug("%s -> %s", filepath.Join(dest, latestLink), target)
+	return nil
+}
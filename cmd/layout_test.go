@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDatedOutputPath(t *testing.T) {
+	dest := t.TempDir()
+	now := time.Date(2024, 3, 7, 15, 4, 5, 0, time.UTC)
+
+	first, err := datedOutputPath(filepath.Join(dest, "orders.csv"), now)
+	if err != nil {
+		t.Fatalf("datedOutputPath() error: %v", err)
+	}
+	if want := filepath.Join(dest, "2024", "03", "07", "run-150405", "orders.csv"); first != want {
+		t.Errorf("path = %s, want %s", first, want)
+	}
+
+	// A second run in the same second gets its own directory
+	second, err := datedOutputPath(filepath.Join(dest, "orders.csv"), now)
+	if err != nil {
+		t.Fatalf("datedOutputPath() error: %v", err)
+	}
+	if want := filepath.Join(dest, "2024", "03", "07", "run-150405-2", "orders.csv"); second != want {
+		t.Errorf("path = %s, want %s", second, want)
+	}
+	if info, err := os.Stat(filepath.Dir(second)); err != nil || !info.IsDir() {
+		t.Errorf("run directory not created: %v", err)
+	}
+}
+
+func TestUpdateLatestLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need extra privileges on windows")
+	}
+	dest := t.TempDir()
+	now := time.Date(2024, 3, 7, 15, 4, 5, 0, time.UTC)
+
+	for _, day := range []time.Time{now, now.AddDate(0, 0, 1)} {
+		path, err := datedOutputPath(filepath.Join(dest, "orders.csv"), day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(day.Format(time.DateOnly)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := updateLatestLink(dest, filepath.Dir(path)); err != nil {
+			t.Fatalf("updateLatestLink() error: %v", err)
+		}
+
+		target, err := os.Readlink(filepath.Join(dest, latestLink))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := filepath.Rel(dest, filepath.Dir(path)); target != want {
+			t.Errorf("latest -> %s, want %s", target, want)
+		}
+		content, err := os.ReadFile(filepath.Join(dest, latestLink, "orders.csv"))
+		if err != nil || string(content) != day.Format(time.DateOnly) {
+			t.Errorf("latest/orders.csv = %q (err=%v), want the file of %s", content, err, day.Format(time.DateOnly))
+		}
+	}
+
+	entries, _ := os.ReadDir(dest)
+	if len(entries) != 2 { // 2024 and latest
+		t.Errorf("temporary link left behind: %v", entries)
+	}
+}
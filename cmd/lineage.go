@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/internal/lineage"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// lineageRun tracks the OpenLineage run of an export. A nil *lineageRun is valid
+// and emits nothing, which is the case when no OpenLineage URL is configured.
+type lineageRun struct {
+	client  *lineage.Client
+	runID   string
+	job     lineage.Job
+	inputs  []lineage.Dataset
+	outputs []lineage.Dataset
+}
+
+// startLineage emits the START event of the export of query to outputPath.
+func startLineage(store db.Store, dbUrl, query string) *lineageRun {
+	endpoint := openLineageURL
+	if endpoint == "" {
+		endpoint = os.Getenv(lineage.EnvURL)
+	}
+	if endpoint == "" {
+		return nil
+	}
+
+	client, err := lineage.NewClient(endpoint, os.Getenv(lineage.EnvAPIKey))
+	if err != nil {
+		logger.Warn("OpenLineage disabled: %v", err)
+		return nil
+	}
+
+	run := &lineageRun{
+		client: client,
+		runID:  lineage.NewRunID(),
+		job: lineage.Job{
+			Namespace: lineageNamespace(),
+			Name:      lineageJobName(),
+			Facets:    lineage.SQLFacet(query),
+		},
+		inputs:  inputDatasets(store, dbUrl, query),
+		outputs: []lineage.Dataset{outputDataset(outputPath)},
+	}
+	run.emit(lineage.NewEvent(lineage.EventStart, run.runID, run.job, run.inputs, run.outputs))
+	return run
+}
+
+// finish emits the COMPLETE or FAIL event.
+func (r *lineageRun) finish(rows int, err error) {
+	if r == nil {
+		return
+	}
+
+	if err != nil {
+		ev := lineage.NewEvent(lineage.EventFail, r.runID, r.job, r.inputs, r.outputs)
+		ev.Run.Facets = lineage.ErrorFacet(err)
+		r.emit(ev)
+		return
+	}
+
+	size := int64(-1)
+	if info, statErr := os.Stat(outputPath); statErr == nil {
+		size = info.Size()
+	}
+	outputs := make([]lineage.Dataset, len(r.outputs))
+	copy(outputs, r.outputs)
+	outputs[0].OutputFacets = lineage.OutputStatisticsFacet(rows, size)
+	r.emit(lineage.NewEvent(lineage.EventComplete, r.runID, r.job, r.inputs, outputs))
+}
+
+func (r *lineageRun) emit(ev lineage.RunEvent) {
+	if err := r.client.Emit(context.Background(), ev); err != nil {
+		logger.Warn("Unable to send OpenLineage %s event: %v", ev.EventType, err)
+		return
+	}
+	logger.Debug("OpenLineage %s event sent for run %s", ev.EventType, r.runID)
+}
+
+func lineageNamespace() string {
+	if openLineageNamespace != "" {
+		return openLineageNamespace
+	}
+	if ns := os.Getenv(lineage.EnvNamespace); ns != "" {
+		return ns
+	}
+	return "pgxport"
+}
+
+// lineageJobName defaults to the output file name without extension, so that
+// recurring exports to the same file are recognized as the same job.
+func lineageJobName() string {
+	if openLineageJob != "" {
+		return openLineageJob
+	}
+	base := filepath.Base(outputPath)
+	return "export." + strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// inputDatasets names the tables the query reads, following the OpenLineage
+// convention for PostgreSQL: namespace postgres://host:port, name database.schema.table.
+func inputDatasets(store db.Store, dbUrl, query string) []lineage.Dataset {
+	cfg, err := pgx.ParseConfig(dbUrl)
+	if err != nil {
+		return nil
+	}
+	namespace := "postgres://" + cfg.Host + ":" + strconv.Itoa(int(cfg.Port))
+
+	tables, err := db.SourceTables(context.Background(), store, query)
+	if err != nil {
+		logger.Debug("Unable to resolve lineage input tables: %v", err)
+		return nil
+	}
+
+	var datasets []lineage.Dataset
+	for _, t := range tables {
+		datasets = append(datasets, lineage.Dataset{
+			Namespace: namespace,
+			Name:      cfg.Database + "." + t.Schema + "." + t.Name,
+		})
+	}
+	return datasets
+}
+
+// outputDataset names a local output file: namespace file, name the absolute path.
+func outputDataset(path string) lineage.Dataset {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return lineage.Dataset{Namespace: "file", Name: filepath.ToSlash(path)}
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/state"
+	"github.com/fbz-tec/pgxport/internal/version"
+)
+
+// Values of exportManifest.Status
+const (
+	manifestComplete = "complete"
+	manifestPartial  = "partial"
+)
+
+// exportManifest is written next to the output with --manifest or --time-budget, so
+// that downstream loaders can tell a complete delivery from one cut short and check
+// each of its files.
+type exportManifest struct {
+	GeneratedAt    time.Time           `json:"generated_at"`
+	PgxportVersion string              `json:"pgxport_version"`
+	Status         string              `json:"status"`
+	Reason         string              `json:"reason,omitempty"`
+	File           string              `json:"file"`
+	Format         string              `json:"format"`
+	Rows           int                 `json:"rows"`
+	Files          []manifestFile      `json:"files,omitempty"`
+	Checkpoint     *manifestCheckpoint `json:"checkpoint,omitempty"`
+}
+
+// manifestFile describes one file of the export, so that each piece of a split
+// output can be validated independently.
+type manifestFile struct {
+	File   string `json:"file"` // relative to the manifest
+	Rows   int    `json:"rows"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+	// Smallest and largest value of the partition key in the file (--by-chunk)
+	PartitionKey string `json:"partition_key,omitempty"`
+	Min          any    `json:"min,omitempty"`
+	Max          any    `json:"max,omitempty"`
+}
+
+// manifestCheckpoint tells how a partial export can be resumed.
+type manifestCheckpoint struct {
+	// RowsExported is the number of rows in the partial output. The query can be
+	// resumed from there with OFFSET, provided its ORDER BY is deterministic.
+	RowsExported int `json:"rows_exported"`
+	// ProgressKey is the state entry listing the chunks already exported by
+	// --by-chunk; running the same command again exports the PendingChunks only.
+	ProgressKey   string   `json:"progress_key,omitempty"`
+	PendingChunks []string `json:"pending_chunks,omitempty"`
+}
+
+// manifestPath returns the manifest of an export: orders.csv -> orders.csv.manifest.json.
+func manifestPath(output string) string {
+	return output + ".manifest.json"
+}
+
+// newManifest describes the outcome of an export of rows rows to the written
+// files, under budget.
+func newManifest(rows int, written []exporters.WrittenFile, budget *timeBudget) *exportManifest {
+	m := &exportManifest{
+		GeneratedAt:    clock.Now().UTC(),
+		PgxportVersion: version.AppVersion,
+		Status:         manifestComplete,
+		File:           filepath.Base(outputPath),
+		Format:         format,
+		Rows:           rows,
+		Files:          manifestFiles(filepath.Dir(manifestPath(outputPath)), written),
+	}
+	if byChunk != "" {
+		// A resumed export only adds the remaining chunks; the manifest covers them all
+		m.Rows = 0
+		for _, f := range m.Files {
+			m.Rows += f.Rows
+		}
+	}
+	if !budget.wasExceeded() {
+		return m
+	}
+
+	m.Status = manifestPartial
+	m.Reason = fmt.Sprintf("time budget of %s exceeded", budget.limit)
+	m.Checkpoint = &manifestCheckpoint{RowsExported: m.Rows}
+	if byChunk != "" {
+		budget.mu.Lock()
+		m.Checkpoint.PendingChunks = budget.pending
+		budget.mu.Unlock()
+		m.Checkpoint.ProgressKey = state.Key(state.PrefixChunks, outputPath)
+	}
+	return m
+}
+
+// manifestFiles describes the written files of the export to --output: the output
+// itself, or its numbered pieces (--sql-files-per). Paths are relative to dir.
+// With --by-chunk, the chunk files are listed instead, including those of the runs
+// resumed.
+func manifestFiles(dir string, written []exporters.WrittenFile) []manifestFile {
+	var files []manifestFile
+	if byChunk != "" {
+		for _, c := range chunkFiles {
+			f := describeFile(dir, c.File, c.Rows)
+			if c.PartitionKey != "" {
+				f.PartitionKey, f.Min, f.Max = c.PartitionKey, c.Min, c.Max
+			}
+			files = append(files, f)
+		}
+		return files
+	}
+
+	for _, w := range written {
+		files = append(files, describeFile(dir, w.Path, w.Rows))
+	}
+	return files
+}
+
+// describeFile returns the manifest entry of the file at path, holding rows rows.
+func describeFile(dir, path string, rows int) manifestFile {
+	f := manifestFile{File: path, Rows: rows}
+	absDir, err1 := filepath.Abs(dir)
+	absPath, err2 := filepath.Abs(path)
+	if err1 == nil && err2 == nil {
+		if rel, err := filepath.Rel(absDir, absPath); err == nil {
+			f.File = filepath.ToSlash(rel)
+		}
+	}
+	var err error
+	if f.Bytes, f.SHA256, err = fileChecksum(path); err != nil {
+		logger.Warn("Unable to checksum %s for the manifest: %v", path, err)
+	}
+	return f
+}
+
+// fileChecksum returns the size and SHA-256 digest of the file at path.
+func fileChecksum(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeManifest stores the manifest of a successful export; failures are only logged.
+func writeManifest(rows int, written []exporters.WrittenFile, budget *timeBudget) {
+	path := manifestPath(outputPath)
+	if err := newManifest(rows, written, budget).write(path); err != nil {
+		logger.Warn("%v", err)
+		return
+	}
+	logger.Debug("Manifest written to %s", path)
+}
+
+func (m *exportManifest) write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/internal/state"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestManifestFiles(t *testing.T) {
+	savedOutput, savedByChunk, savedFiles := outputPath, byChunk, chunkFiles
+	defer func() { outputPath, byChunk, chunkFiles = savedOutput, savedByChunk, savedFiles }()
+	t.Setenv(state.EnvFile, filepath.Join(t.TempDir(), "state.json"))
+	dir := t.TempDir()
+	outputPath, byChunk = filepath.Join(dir, "metrics.csv"), "metrics"
+
+	// Two chunks, the second one compressed, each exported by its own run: the
+	// second run resumes the first one
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	exporter, _ := exporters.GetExporter(exporters.FormatCSV)
+	chunks := []db.Chunk{
+		{Name: "_hyper_1_1_chunk", TimeColumn: "time", Start: start, End: start.AddDate(0, 0, 7)},
+		{Name: "_hyper_1_2_chunk", TimeColumn: "time", Start: start.AddDate(0, 0, 7), End: start.AddDate(0, 0, 14)},
+	}
+	var progress *chunkProgress
+	for i, compression := range []string{"none", "gzip"} {
+		var err error
+		if progress, err = loadChunkProgress(outputPath, "digest"); err != nil {
+			t.Fatal(err)
+		}
+		data := [][]any{
+			{start.AddDate(0, 0, 7*i+3), 1.5},
+			{start.AddDate(0, 0, 7*i+1), 2.5},
+			{start.AddDate(0, 0, 7*i+5), 3.5},
+		}
+		rows, err := rowsource.New([]rowsource.Column{
+			{Name: "time", OID: pgtype.TimestamptzOID},
+			{Name: "value", OID: pgtype.Float8OID},
+		}, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := chunkOutputPath(outputPath, chunks[i].Name)
+		observed := observePartitionKey(rows, "time")
+		options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', Compression: compression, TimeZone: "UTC", Log: &exporters.OutputLog{}}
+		n, err := exporter.Export(observed, path, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := progress.markDone(describeChunk(chunks[i], path, n, observed, options.Log.Files())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chunkFiles = progress.files(chunks)
+
+	files := manifestFiles(dir, nil)
+	if len(files) != 2 {
+		t.Fatalf("manifest files = %+v, want the 2 chunk files", files)
+	}
+	if files[0].File != "metrics__hyper_1_1_chunk.csv" || files[1].File != "metrics__hyper_1_2_chunk.csv.gz" {
+		t.Errorf("files = %s, %s", files[0].File, files[1].File)
+	}
+	for i, f := range files {
+		content, err := os.ReadFile(filepath.Join(dir, f.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(content)
+		if f.Rows != 3 || f.Bytes != int64(len(content)) || f.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("file %d = %+v", i, f)
+		}
+		wantMin, _ := json.Marshal(start.AddDate(0, 0, 7*i+1))
+		wantMax, _ := json.Marshal(start.AddDate(0, 0, 7*i+5))
+		gotMin, _ := json.Marshal(f.Min)
+		gotMax, _ := json.Marshal(f.Max)
+		if f.PartitionKey != "time" || string(gotMin) != string(wantMin) || string(gotMax) != string(wantMax) {
+			t.Errorf("file %d range = %s [%s, %s], want [%s, %s]", i, f.PartitionKey, gotMin, gotMax, wantMin, wantMax)
+		}
+	}
+	if m := newManifest(3, nil, nil); m.Rows != 6 {
+		t.Errorf("manifest rows = %d, want the 6 rows of both runs", m.Rows)
+	}
+
+	// Without --by-chunk, the files of the export
+	outputPath, byChunk = filepath.Join(dir, "other.csv"), ""
+	rows, err := rowsource.New([]rowsource.Column{{Name: "value", OID: pgtype.Float8OID}}, [][]any{{1.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', Compression: "none", Log: &exporters.OutputLog{}}
+	if _, err := exporter.Export(rows, outputPath, options); err != nil {
+		t.Fatal(err)
+	}
+	if files := manifestFiles(dir, options.Log.Files()); len(files) != 1 || files[0].File != "other.csv" || files[0].Rows != 1 {
+		t.Errorf("manifest files = %+v, want other.csv", files)
+	}
+}
+
+func TestManifestFilesDualWrite(t *testing.T) {
+	saved := outputPath
+	defer func() { outputPath = saved }()
+	dir := t.TempDir()
+	outputPath = filepath.Join(dir, "orders.csv")
+
+	// The target is named like a piece of the output, but is not part of it
+	target := dualWriteTarget{Format: exporters.FormatCSV, Path: filepath.Join(dir, "orders_legacy.csv")}
+	exporter, _ := exporters.GetExporter(exporters.FormatCSV)
+	options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', Compression: "none", TimeZone: "UTC", Log: &exporters.OutputLog{}}
+	if _, err := runDualWrite(dualWriteRows(t, 10), target, exporter, options); err != nil {
+		t.Fatal(err)
+	}
+
+	files := manifestFiles(dir, options.Log.Files())
+	if len(files) != 1 || files[0].File != "orders.csv" || files[0].Rows != 10 {
+		t.Errorf("manifest files = %+v, want orders.csv only", files)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// optionsStdin is the --options value that reads the document from standard input.
+const optionsStdin = "-"
+
+// optionsInput is where "--options -" reads from; tests replace it.
+var optionsInput io.Reader = os.Stdin
+
+// loadOptionsDocument applies the --template and --options documents to the flags
+// of fs. The documents are YAML (or JSON) mapping long flag names to values, lists
+// being used for repeatable flags. Flags given on the command line take precedence
+// over the --options document, which takes precedence over the template. The
+// transforms entry is not a flag: it lists the transforms applied to the rows.
+func loadOptionsDocument(fs *pflag.FlagSet) error {
+	doc := map[string]any{}
+	if templateName != "" {
+		template, err := loadTemplate(templateName)
+		if err != nil {
+			return err
+		}
+		maps.Copy(doc, template)
+	}
+
+	if optionsFile != "" {
+		var data []byte
+		var err error
+		if optionsFile == optionsStdin {
+			data, err = io.ReadAll(optionsInput)
+		} else {
+			data, err = os.ReadFile(optionsFile)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read options: %w", err)
+		}
+
+		var options map[string]any
+		if err := yaml.Unmarshal(data, &options); err != nil {
+			return fmt.Errorf("invalid options document: %w", err)
+		}
+		maps.Copy(doc, options)
+	}
+
+	var err error
+	if value, ok := doc[transformsKey]; ok {
+		transformSteps, err = parseTransforms(value)
+		if err != nil {
+			return fmt.Errorf("invalid options document: %s: %w", transformsKey, err)
+		}
+		delete(doc, transformsKey)
+	}
+	return applyOptions(fs, doc)
+}
+
+// applyOptions sets the flags named in doc, except those already set on the command line.
+func applyOptions(fs *pflag.FlagSet, doc map[string]any) error {
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Decide before setting anything, as setting a flag marks it as changed
+	fromCommandLine := map[string]bool{}
+	for _, name := range names {
+		if f := fs.Lookup(name); f != nil && f.Changed {
+			fromCommandLine[name] = true
+		}
+	}
+
+	for _, name := range names {
+		f := fs.Lookup(name)
+		if f == nil || name == "options" || name == "template" || name == "templates-file" || name == "help" {
+			return fmt.Errorf("invalid options document: unknown option %q (use the long flag names, e.g. sql, output, format)", name)
+		}
+		if fromCommandLine[name] {
+			logger.Debug("Option %s from the command line overrides the options document", name)
+			continue
+		}
+
+		values, err := optionValues(f, doc[name])
+		if err != nil {
+			return fmt.Errorf("invalid options document: %s: %w", name, err)
+		}
+		for _, v := range values {
+			if err := fs.Set(name, v); err != nil {
+				return fmt.Errorf("invalid options document: %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// optionValues converts a document value to the strings passed to the flag.
+func optionValues(f *pflag.Flag, value any) ([]string, error) {
+	repeatable := strings.HasSuffix(f.Value.Type(), "Slice") || strings.HasSuffix(f.Value.Type(), "Array")
+
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("a value is required")
+	case []any:
+		if !repeatable {
+			return nil, fmt.Errorf("a single value is expected")
+		}
+		values := make([]string, len(v))
+		for i, item := range v {
+			switch item.(type) {
+			case nil, []any, map[string]any:
+				return nil, fmt.Errorf("list items must be plain values")
+			}
+			values[i] = fmt.Sprint(item)
+		}
+		return values, nil
+	case map[string]any:
+		return nil, fmt.Errorf("a value or a list is expected")
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func newOptionsFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("sql", "", "")
+	flags.String("output", "", "")
+	flags.Int("row-per-statement", 1, "")
+	flags.Bool("with-copy", false, "")
+	flags.StringArray("param-file", nil, "")
+	flags.String("options", "", "")
+	return flags
+}
+
+func TestLoadOptionsDocumentFromStdin(t *testing.T) {
+	savedFile, savedInput := optionsFile, optionsInput
+	defer func() { optionsFile, optionsInput = savedFile, savedInput }()
+
+	optionsFile = optionsStdin
+	optionsInput = strings.NewReader(`
+sql: SELECT * FROM orders WHERE id = ANY(:ids)
+output: orders.csv
+row-per-statement: 50
+with-copy: true
+param-file:
+  - ids=ids.txt
+  - regions=regions.txt
+`)
+
+	flags := newOptionsFlagSet()
+	if err := flags.Parse([]string{"--output", "cli.csv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadOptionsDocument(flags); err != nil {
+		t.Fatalf("loadOptionsDocument() error: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"sql":               "SELECT * FROM orders WHERE id = ANY(:ids)",
+		"output":            "cli.csv", // the command line wins
+		"row-per-statement": "50",
+		"with-copy":         "true",
+		"param-file":        "[ids=ids.txt,regions=regions.txt]",
+	} {
+		if got := flags.Lookup(name).Value.String(); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestApplyOptionsJSON(t *testing.T) {
+	savedFile, savedInput := optionsFile, optionsInput
+	defer func() { optionsFile, optionsInput = savedFile, savedInput }()
+
+	optionsFile = optionsStdin
+	optionsInput = strings.NewReader(`{"sql": "SELECT 1", "row-per-statement": 10}`)
+
+	flags := newOptionsFlagSet()
+	if err := loadOptionsDocument(flags); err != nil {
+		t.Fatalf("loadOptionsDocument() error: %v", err)
+	}
+	if got := flags.Lookup("row-per-statement").Value.String(); got != "10" {
+		t.Errorf("row-per-statement = %q, want 10", got)
+	}
+}
+
+func TestApplyOptionsErrors(t *testing.T) {
+	tests := []struct {
+		doc  map[string]any
+		want string
+	}{
+		{map[string]any{"sqll": "SELECT 1"}, `unknown option "sqll"`},
+		{map[string]any{"options": "other.yaml"}, `unknown option "options"`},
+		{map[string]any{"sql": []any{"SELECT 1", "SELECT 2"}}, "a single value is expected"},
+		{map[string]any{"sql": nil}, "a value is required"},
+		{map[string]any{"sql": map[string]any{"text": "SELECT 1"}}, "a value or a list is expected"},
+		{map[string]any{"row-per-statement": "many"}, "row-per-statement"},
+	}
+
+	for _, tt := range tests {
+		err := applyOptions(newOptionsFlagSet(), tt.doc)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("applyOptions(%v) error = %v, should mention %q", tt.doc, err, tt.want)
+		}
+	}
+}
+
+func TestLoadOptionsDocumentTransforms(t *testing.T) {
+	savedFile, savedInput, savedSteps := optionsFile, optionsInput, transformSteps
+	defer func() { optionsFile, optionsInput, transformSteps = savedFile, savedInput, savedSteps }()
+
+	optionsFile = optionsStdin
+	optionsInput = strings.NewReader(`
+sql: SELECT id, card FROM customers
+transforms:
+  - mask: {column: card, keep_last: 4}
+  - rename: {card: card_number}
+`)
+	if err := loadOptionsDocument(newOptionsFlagSet()); err != nil {
+		t.Fatalf("loadOptionsDocument() error: %v", err)
+	}
+	if len(transformSteps) != 2 || transformSteps[0].Mask == nil || transformSteps[1].Rename["card"] != "card_number" {
+		t.Errorf("transforms = %+v", transformSteps)
+	}
+
+	optionsInput = strings.NewReader(`
+transforms:
+  - mask: {column: card, keep_first: 4}
+`)
+	if err := loadOptionsDocument(newOptionsFlagSet()); err == nil || !strings.Contains(err.Error(), "keep_first") {
+		t.Errorf("loadOptionsDocument() error = %v, should reject keep_first", err)
+	}
+}
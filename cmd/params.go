@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// queryParam is a --param-file value: a named array parameter read from a file.
+type queryParam struct {
+	Name string
+	Path string
+}
+
+// parseParamFiles parses the --param-file values, each of the form name=path.
+func parseParamFiles(specs []string) ([]queryParam, error) {
+	var params []queryParam
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		name, path, ok := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		if !ok || strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("invalid --param-file %q: expected name=path, e.g. ids=ids.txt", spec)
+		}
+		if !db.ValidParamName(name) {
+			return nil, fmt.Errorf("invalid --param-file %q: %q is not a valid parameter name", spec, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid --param-file %q: parameter %s is given twice", spec, name)
+		}
+		seen[name] = true
+		params = append(params, queryParam{Name: name, Path: path})
+	}
+	return params, nil
+}
+
+// readParamValues reads one value per line, ignoring blank lines.
+// Surrounding whitespace is trimmed.
+func readParamValues(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read parameter file: %w", err)
+	}
+	defer f.Close()
+
+	var values []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if v := strings.TrimSpace(scanner.Text()); v != "" {
+			values = append(values, v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read parameter file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// bindParamFiles replaces the :name references of query by positional parameters
+// and returns the value of each one: an array of the values read from its file.
+func bindParamFiles(query string) (string, []any, error) {
+	params, err := parseParamFiles(paramFiles)
+	if err != nil || len(params) == 0 {
+		return query, nil, err
+	}
+
+	names := make([]string, len(params))
+	args := make([]any, len(params))
+	for i, p := range params {
+		values, err := readParamValues(p.Path)
+		if err != nil {
+			return "", nil, err
+		}
+		names[i] = p.Name
+		args[i] = db.ArrayLiteral(values)
+		logger.Debug("Parameter :%s bound to %d values from %s", p.Name, len(values), p.Path)
+	}
+
+	query, err = db.BindNamed(query, names)
+	if err != nil {
+		return "", nil, err
+	}
+	return query, args, nil
+}
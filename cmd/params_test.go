@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseParamFiles(t *testing.T) {
+	params, err := parseParamFiles([]string{"ids=ids.txt", "skus = data/skus,2024.txt"})
+	if err != nil {
+		t.Fatalf("parseParamFiles() error: %v", err)
+	}
+	if len(params) != 2 || params[0] != (queryParam{"ids", "ids.txt"}) || params[1] != (queryParam{"skus", " data/skus,2024.txt"}) {
+		t.Errorf("parseParamFiles() = %+v", params)
+	}
+
+	for spec, want := range map[string]string{
+		"ids.txt":     "expected name=path",
+		"ids=":        "expected name=path",
+		"1ids=ids.tx": "not a valid parameter name",
+		"my-ids=x":    "not a valid parameter name",
+	} {
+		if _, err := parseParamFiles([]string{spec}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseParamFiles(%q) error = %v, should mention %q", spec, err, want)
+		}
+	}
+	if _, err := parseParamFiles([]string{"ids=a.txt", "ids=b.txt"}); err == nil || !strings.Contains(err.Error(), "given twice") {
+		t.Errorf("duplicate parameter: err = %v", err)
+	}
+}
+
+func TestBindParamFiles(t *testing.T) {
+	saved := paramFiles
+	defer func() { paramFiles = saved }()
+
+	dir := t.TempDir()
+	ids := filepath.Join(dir, "ids.txt")
+	if err := os.WriteFile(ids, []byte("42\r\n 7 \n\n1001\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	names := filepath.Join(dir, "names.txt")
+	if err := os.WriteFile(names, []byte("O'Brien\nRobert\"); DROP TABLE students;--\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	paramFiles = []string{"ids=" + ids, "names=" + names}
+
+	query, args, err := bindParamFiles("SELECT * FROM users WHERE id = ANY(:ids) OR name = ANY(:names)")
+	if err != nil {
+		t.Fatalf("bindParamFiles() error: %v", err)
+	}
+	if query != "SELECT * FROM users WHERE id = ANY($1) OR name = ANY($2)" {
+		t.Errorf("query = %s", query)
+	}
+	if len(args) != 2 || args[0] != `{"42","7","1001"}` || args[1] != `{"O'Brien","Robert\"); DROP TABLE students;--"}` {
+		t.Errorf("args = %q", args)
+	}
+
+	paramFiles = []string{"ids=" + filepath.Join(dir, "missing.txt")}
+	if _, _, err := bindParamFiles("SELECT :ids"); err == nil {
+		t.Error("bindParamFiles() with a missing file should fail")
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/version"
+)
+
+// planSidecar is the document written by --plan-sidecar next to the export.
+type planSidecar struct {
+	GeneratedAt    time.Time       `json:"generated_at"`
+	PgxportVersion string          `json:"pgxport_version"`
+	ServerVersion  string          `json:"server_version"`
+	Query          string          `json:"query"`
+	Analyze        bool            `json:"analyze"`
+	Plan           json.RawMessage `json:"plan"`
+	Export         planExportStats `json:"export"`
+}
+
+type planExportStats struct {
+	Rows       int    `json:"rows"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// planSidecarPath returns the sidecar file for an export: events.csv -> events.csv.plan.json.
+func planSidecarPath(output string) string {
+	return output + ".plan.json"
+}
+
+func newPlanSidecar(query, serverVersion string, plan json.RawMessage) *planSidecar {
+	return &planSidecar{
+		GeneratedAt:    clock.Now().UTC(),
+		PgxportVersion: version.AppVersion,
+		ServerVersion:  serverVersion,
+		Query:          query,
+		Analyze:        planAnalyze,
+		Plan:           plan,
+	}
+}
+
+// write records the export outcome and stores the sidecar at path.
+func (p *planSidecar) write(path string, rows int, elapsed time.Duration, exportErr error) error {
+	p.Export = planExportStats{Rows: rows, DurationMs: elapsed.Milliseconds()}
+	if exportErr != nil {
+		p.Export.Error = exportErr.Error()
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding plan sidecar: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing plan sidecar: %w", err)
+	}
+	return nil
+}
+
+// reportIndexSuggestions prints the --suggest-indexes findings in the export summary.
+func reportIndexSuggestions(suggestions []db.IndexSuggestion) {
+	if len(suggestions) == 0 {
+		logger.Info("Index suggestions: none, the plan has no selective sequential scan of a large table")
+		return
+	}
+	for _, s := range suggestions {
+		logger.Info("Index suggestion: %s", s)
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPlanSidecarWrite(t *testing.T) {
+	path := planSidecarPath(filepath.Join(t.TempDir(), "events.csv"))
+	if filepath.Base(path) != "events.csv.plan.json" {
+		t.Fatalf("planSidecarPath() = %s", path)
+	}
+
+	plan := json.RawMessage(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "events"}}]`)
+	sidecar := newPlanSidecar("SELECT * FROM events", "16.2", plan)
+	if err := sidecar.write(path, 42, 1500*time.Millisecond, errors.New("disk full")); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Query         string `json:"query"`
+		ServerVersion string `json:"server_version"`
+		Plan          []struct {
+			Plan map[string]any `json:"Plan"`
+		} `json:"plan"`
+		Export planExportStats `json:"export"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("sidecar is not valid JSON: %v\n%s", err, data)
+	}
+
+	if got.Query != "SELECT * FROM events" || got.ServerVersion != "16.2" {
+		t.Errorf("unexpected metadata: %+v", got)
+	}
+	if len(got.Plan) != 1 || got.Plan[0].Plan["Node Type"] != "Seq Scan" {
+		t.Errorf("plan not embedded as JSON: %s", data)
+	}
+	if got.Export.Rows != 42 || got.Export.DurationMs != 1500 || got.Export.Error != "disk full" {
+		t.Errorf("unexpected export stats: %+v", got.Export)
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/version"
+	"github.com/jackc/pgx/v5"
+)
+
+// Values of --preview-format
+const (
+	previewMarkdown = "md"
+	previewCSV      = "csv"
+)
+
+// previewCellWidth is the number of characters kept of each markdown cell, so that
+// the preview stays readable with long text or JSON values.
+const previewCellWidth = 60
+
+// previewSidecar keeps the first rows of an export as text, to write them next to
+// the output with --preview-sidecar.
+type previewSidecar struct {
+	limit   int
+	format  string
+	columns []string
+	rows    [][]*string // nil values are NULL
+}
+
+// previewSidecarPath returns the preview of an export: orders.xlsx -> orders.xlsx.preview.md.
+func previewSidecarPath(output, format string) string {
+	return output + ".preview." + format
+}
+
+func newPreviewSidecar(limit int, format string) *previewSidecar {
+	return &previewSidecar{limit: limit, format: format}
+}
+
+// middleware records the first rows the exporter reads. It must be the innermost
+// middleware, so that the preview shows the values actually written.
+func (p *previewSidecar) middleware() exporters.Middleware {
+	return exporters.WrapRows(func(rows pgx.Rows, options exporters.ExportOptions) (pgx.Rows, error) {
+		fields := rows.FieldDescriptions()
+		p.columns = make([]string, len(fields))
+		for i, f := range fields {
+			p.columns[i] = f.Name
+		}
+		return rowsource.Transform(rows, nil, func(values []any) ([]any, error) {
+			if len(p.rows) < p.limit {
+				row := make([]*string, len(values))
+				for i, v := range values {
+					if v != nil {
+						text := formatters.FormatCSVValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+						row[i] = &text
+					}
+				}
+				p.rows = append(p.rows, row)
+			}
+			return values, nil
+		}), nil
+	})
+}
+
+// write stores the preview of an export of total rows at path.
+func (p *previewSidecar) write(path, output string, total int) error {
+	var buf bytes.Buffer
+	switch p.format {
+	case previewCSV:
+		w := csv.NewWriter(&buf)
+		w.Write(p.columns)
+		for _, row := range p.rows {
+			record := make([]string, len(row))
+			for i, v := range row {
+				if v != nil {
+					record[i] = *v
+				}
+			}
+			w.Write(record)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintf(&buf, "# Preview of %s\n\n", filepath.Base(output))
+		fmt.Fprintf(&buf, "First %d of %d rows, exported by pgxport %s on %s.\n\n",
+			len(p.rows), total, version.AppVersion, clock.Now().UTC().Format("2006-01-02 15:04:05 UTC"))
+		header := make([]string, len(p.columns))
+		for i, name := range p.columns {
+			header[i] = markdownCell(name)
+		}
+		writeMarkdownRow(&buf, header)
+		writeMarkdownRow(&buf, slices.Repeat([]string{"---"}, len(p.columns)))
+		for _, row := range p.rows {
+			cells := make([]string, len(row))
+			for i, v := range row {
+				cells[i] = "*NULL*"
+				if v != nil {
+					cells[i] = markdownCell(*v)
+				}
+			}
+			writeMarkdownRow(&buf, cells)
+		}
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing preview sidecar: %w", err)
+	}
+	return nil
+}
+
+func writeMarkdownRow(buf *bytes.Buffer, cells []string) {
+	buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+// markdownCell shortens a value and escapes what would break the table.
+func markdownCell(value string) string {
+	if runes := []rune(value); len(runes) > previewCellWidth {
+		value = string(runes[:previewCellWidth-1]) + "…"
+	}
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ", "\t", " ").Replace(value)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/version"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func exportWithPreview(t *testing.T, preview *previewSidecar, output string) int {
+	t.Helper()
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "note", OID: pgtype.TextOID},
+	}, [][]any{
+		{int32(1), "plain"},
+		{int32(2), nil},
+		{int32(3), "a|b\nc " + strings.Repeat("x", 80)},
+		{int32(4), "not previewed"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exporter, _ := exporters.GetExporter(exporters.FormatJSON)
+	options := exporters.ExportOptions{Format: exporters.FormatJSON, Compression: "gzip"}
+	n, err := exporters.Chain(exporter, preview.middleware()).Export(rows, output, options)
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	return n
+}
+
+func TestPreviewSidecarMarkdown(t *testing.T) {
+	defer clock.Set(clock.Fixed(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)), clock.RandomIDs{})()
+
+	output := filepath.Join(t.TempDir(), "notes.json.gz")
+	preview := newPreviewSidecar(3, previewMarkdown)
+	n := exportWithPreview(t, preview, output)
+
+	path := previewSidecarPath(output, previewMarkdown)
+	if filepath.Base(path) != "notes.json.gz.preview.md" {
+		t.Fatalf("previewSidecarPath() = %s", path)
+	}
+	if err := preview.write(path, output, n); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Preview of notes.json.gz\n\n" +
+		"First 3 of 4 rows, exported by pgxport " + version.AppVersion + " on 2025-03-01 12:00:00 UTC.\n\n" +
+		"| id | note |\n" +
+		"| --- | --- |\n" +
+		"| 1 | plain |\n" +
+		"| 2 | *NULL* |\n" +
+		`| 3 | a\|b c ` + strings.Repeat("x", 53) + "… |\n"
+	if string(data) != want {
+		t.Errorf("preview =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestPreviewSidecarCSV(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "notes.json.gz")
+	preview := newPreviewSidecar(2, previewCSV)
+	n := exportWithPreview(t, preview, output)
+
+	path := previewSidecarPath(output, previewCSV)
+	if err := preview.write(path, output, n); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "id,note\n1,plain\n2,\n"; string(data) != want {
+		t.Errorf("preview = %q, want %q", data, want)
+	}
+}
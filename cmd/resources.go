@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/bytesize"
+	"github.com/fbz-tec/pgxport/internal/procstats"
+)
+
+// resourceReport is the resource usage of an export, printed with the summary
+// to help size the machines running scheduled exports.
+type resourceReport struct {
+	Elapsed         time.Duration
+	Process         procstats.Stats
+	NetworkReceived int64 // bytes read from database connections
+	NetworkSent     int64
+	DiskWritten     int64 // bytes written to output files, after compression
+}
+
+func collectResources(start time.Time, outputs *exporters.OutputLog) resourceReport {
+	received, sent := db.Traffic()
+	return resourceReport{
+		Elapsed:         time.Since(start),
+		Process:         procstats.Read(),
+		NetworkReceived: received,
+		NetworkSent:     sent,
+		DiskWritten:     outputs.BytesWritten(),
+	}
+}
+
+func (r resourceReport) String() string {
+	parts := []string{fmt.Sprintf("%v elapsed", r.Elapsed.Round(time.Millisecond))}
+	if r.Process.Available {
+		parts = append(parts,
+			fmt.Sprintf("CPU %v user + %v system", r.Process.UserCPU.Round(time.Millisecond), r.Process.SystemCPU.Round(time.Millisecond)),
+			"peak RSS "+bytesize.Format(r.Process.PeakRSS))
+	}
+	parts = append(parts,
+		fmt.Sprintf("network %s in / %s out", bytesize.Format(r.NetworkReceived), bytesize.Format(r.NetworkSent)),
+		"disk "+bytesize.Format(r.DiskWritten)+" written")
+	return "Resources: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/procstats"
+)
+
+func TestResourceReportString(t *testing.T) {
+	r := resourceReport{
+		Elapsed: 2345 * time.Millisecond,
+		Process: procstats.Stats{
+			UserCPU:   1100 * time.Millisecond,
+			SystemCPU: 200 * time.Millisecond,
+			PeakRSS:   45 << 20,
+			Available: true,
+		},
+		NetworkReceived: 120 << 20,
+		NetworkSent:     2048,
+		DiskWritten:     98 << 20,
+	}
+	expected := "Resources: 2.345s elapsed, CPU 1.1s user + 200ms system, peak RSS 45.0MB, network 120.0MB in / 2.0KB out, disk 98.0MB written"
+	if got := r.String(); got != expected {
+		t.Errorf("String() =\n%s\nwant\n%s", got, expected)
+	}
+
+	r.Process = procstats.Stats{}
+	expected = "Resources: 2.345s elapsed, network 120.0MB in / 2.0KB out, disk 98.0MB written"
+	if got := r.String(); got != expected {
+		t.Errorf("String() without process stats =\n%s\nwant\n%s", got, expected)
+	}
+}
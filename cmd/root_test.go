@@ -1,10 +1,19 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/transforms"
+	"github.com/fbz-tec/pgxport/internal/memguard"
 )
 
 func TestReadSQLFromFile(t *testing.T) {
@@ -271,9 +280,95 @@ func TestValidateExportParams(t *testing.T) {
 	originalTableName := tableName
 	originalTimeFormat := timeFormat
 	originalTimeZone := timeZone
+	originalDelimiter := delimiter
+	originalXmlRootElement := xmlRootElement
+	originalXlsxSheetName := xlsxSheetName
+	originalMaxRowBytes := maxRowBytes
+	originalLargeRowPolicy := largeRowPolicy
+	originalWithCopy := withCopy
+	originalServerFlavor := serverFlavor
+	originalByChunk := byChunk
+	originalChunkWorkers := chunkWorkers
+	originalRetryFailedChunks := retryFailedChunks
+	originalLockName := lockName
+	originalLockMethod := lockMethod
+	originalCitusDirect := citusDirect
+	originalRefreshMatviews := refreshMatviews
+	originalRefreshConcurrent := refreshConcurrent
+	originalPlanSidecar := planSidecarFlag
+	originalPlanAnalyze := planAnalyze
+	originalSuggestIndexes := suggestIndexes
+	originalIncludeComments := includeComments
+	originalOutputLayout := outputLayout
+	originalMaxMemory := maxMemory
+	originalDualWrite := dualWrite
+	originalParamFiles := paramFiles
+	originalKeysTableFile := keysTableFile
+	originalCopyBuffer := copyBuffer
+	originalCopySpillLimit := copySpillLimit
+	originalKeepAlive := keepAlive
+	originalTimeBudget := timeBudgetLimit
+	originalOnBudgetExceeded := onBudgetExceeded
+	originalTwoPass := twoPass
+	originalTransformSteps := transformSteps
+	originalAutoCopy := autoCopy
+	originalAutoCopyThreshold := autoCopyThreshold
+	originalEncryptColumns := encryptColumns
+	originalCastColumns := castColumns
+	originalTokenizeColumns := tokenizeColumns
+	originalTokenizeURL := tokenizeURL
+	originalTokenizeBatch := tokenizeBatch
+	originalPreviewSidecarRows := previewSidecarRows
+	originalPreviewFormat := previewFormat
+	originalCSVStrict := csvStrict
+	originalJSONOmitNull := jsonOmitNull
+	originalJSONNullKeys := jsonNullKeys
 
 	// Restore original values after test
 	defer func() {
+		delimiter = originalDelimiter
+		xmlRootElement = originalXmlRootElement
+		xlsxSheetName = originalXlsxSheetName
+		maxRowBytes = originalMaxRowBytes
+		largeRowPolicy = originalLargeRowPolicy
+		withCopy = originalWithCopy
+		serverFlavor = originalServerFlavor
+		byChunk = originalByChunk
+		chunkWorkers = originalChunkWorkers
+		retryFailedChunks = originalRetryFailedChunks
+		lockName = originalLockName
+		lockMethod = originalLockMethod
+		citusDirect = originalCitusDirect
+		refreshMatviews = originalRefreshMatviews
+		refreshConcurrent = originalRefreshConcurrent
+		planSidecarFlag = originalPlanSidecar
+		planAnalyze = originalPlanAnalyze
+		suggestIndexes = originalSuggestIndexes
+		includeComments = originalIncludeComments
+		outputLayout = originalOutputLayout
+		maxMemory = originalMaxMemory
+		dualWrite = originalDualWrite
+		paramFiles = originalParamFiles
+		keysTableFile = originalKeysTableFile
+		copyBuffer = originalCopyBuffer
+		copySpillLimit = originalCopySpillLimit
+		keepAlive = originalKeepAlive
+		timeBudgetLimit = originalTimeBudget
+		onBudgetExceeded = originalOnBudgetExceeded
+		twoPass = originalTwoPass
+		transformSteps = originalTransformSteps
+		autoCopy = originalAutoCopy
+		autoCopyThreshold = originalAutoCopyThreshold
+		encryptColumns = originalEncryptColumns
+		castColumns = originalCastColumns
+		tokenizeColumns = originalTokenizeColumns
+		tokenizeURL = originalTokenizeURL
+		tokenizeBatch = originalTokenizeBatch
+		previewSidecarRows = originalPreviewSidecarRows
+		previewFormat = originalPreviewFormat
+		csvStrict = originalCSVStrict
+		jsonOmitNull = originalJSONOmitNull
+		jsonNullKeys = originalJSONNullKeys
 		sqlQuery = originalSqlQuery
 		sqlFile = originalSqlFile
 		format = originalFormat
@@ -522,6 +617,795 @@ func TestValidateExportParams(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "CSV with quote delimiter",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				delimiter = `"`
+			},
+			wantErr:     true,
+			errContains: "invalid CSV delimiter",
+		},
+		{
+			name: "CSV with multi-character delimiter",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				delimiter = ";;"
+			},
+			wantErr:     true,
+			errContains: "invalid delimiter",
+		},
+		{
+			name: "XML with invalid root tag",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "xml"
+				compression = "none"
+				delimiter = ","
+				xmlRootElement = "2024 results"
+			},
+			wantErr:     true,
+			errContains: "invalid --xml-root-tag",
+		},
+		{
+			name: "XLSX with invalid sheet name",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "xlsx"
+				compression = "none"
+				xmlRootElement = "results"
+				xlsxSheetName = "Q1/Q2"
+			},
+			wantErr:     true,
+			errContains: "invalid --xlsx-sheet-name",
+		},
+		{
+			name: "valid max row bytes with skip policy",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "json"
+				compression = "none"
+				xlsxSheetName = "Sheet1"
+				maxRowBytes = "16MB"
+				largeRowPolicy = "skip"
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid max row bytes",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "json"
+				compression = "none"
+				maxRowBytes = "lots"
+				largeRowPolicy = "fail"
+			},
+			wantErr:     true,
+			errContains: "invalid --max-row-bytes",
+		},
+		{
+			name: "invalid large row policy",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "json"
+				compression = "none"
+				maxRowBytes = "1MB"
+				largeRowPolicy = "truncate"
+			},
+			wantErr:     true,
+			errContains: "invalid --large-row-policy",
+		},
+		{
+			name: "max row bytes with COPY mode",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				sqlFile = ""
+				format = "csv"
+				compression = "none"
+				delimiter = ","
+				maxRowBytes = "1MB"
+				largeRowPolicy = "fail"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "cannot be used with --with-copy",
+		},
+		{
+			name: "COPY mode on CockroachDB",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				format = "csv"
+				maxRowBytes = ""
+				withCopy = true
+				serverFlavor = "cockroach"
+			},
+			wantErr:     true,
+			errContains: "--with-copy is not supported by CockroachDB",
+		},
+		{
+			name: "COPY mode on Greenplum",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				format = "csv"
+				maxRowBytes = ""
+				withCopy = true
+				serverFlavor = "greenplum"
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown server flavor",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM users"
+				format = "csv"
+				withCopy = false
+				serverFlavor = "mysql"
+			},
+			wantErr:     true,
+			errContains: "unknown server flavor",
+		},
+		{
+			name: "by chunk without query",
+			setupFunc: func() {
+				sqlQuery = ""
+				sqlFile = ""
+				format = "csv"
+				serverFlavor = "timescale"
+				byChunk = "metrics"
+				chunkWorkers = 4
+			},
+			wantErr: false,
+		},
+		{
+			name: "chunk workers without by chunk",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM metrics"
+				byChunk = ""
+				chunkWorkers = 4
+			},
+			wantErr:     true,
+			errContains: "--chunk-workers requires --by-chunk",
+		},
+		{
+			name: "zero chunk workers",
+			setupFunc: func() {
+				byChunk = "metrics"
+				chunkWorkers = 0
+			},
+			wantErr:     true,
+			errContains: "--chunk-workers must be at least 1",
+		},
+		{
+			name: "retry failed chunks",
+			setupFunc: func() {
+				chunkWorkers = 1
+				retryFailedChunks = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "retry failed chunks without by chunk",
+			setupFunc: func() {
+				byChunk = ""
+			},
+			wantErr:     true,
+			errContains: "--retry-failed-chunks requires --by-chunk",
+		},
+		{
+			name: "lock name",
+			setupFunc: func() {
+				byChunk = ""
+				retryFailedChunks = false
+				lockName = "nightly users feed"
+				lockMethod = lockAdvisory
+			},
+			wantErr: false,
+		},
+		{
+			name: "lock name not usable as file name",
+			setupFunc: func() {
+				lockMethod = lockFile
+			},
+			wantErr:     true,
+			errContains: `--lock-name "nightly users feed" cannot name a lock file`,
+		},
+		{
+			name: "invalid lock method",
+			setupFunc: func() {
+				lockName = "nightly-users-feed"
+				lockMethod = "flock"
+			},
+			wantErr:     true,
+			errContains: "invalid --lock-method 'flock'",
+		},
+		{
+			name: "citus direct without query",
+			setupFunc: func() {
+				sqlQuery = ""
+				byChunk = ""
+				chunkWorkers = 1
+				lockMethod = lockAdvisory
+				serverFlavor = "postgres"
+				citusDirect = "events"
+			},
+			wantErr: false,
+		},
+		{
+			name: "citus direct query without placeholder",
+			setupFunc: func() {
+				sqlQuery = "SELECT id FROM events"
+				citusDirect = "events"
+			},
+			wantErr:     true,
+			errContains: "must refer to the table as {shard}",
+		},
+		{
+			name: "citus direct with COPY",
+			setupFunc: func() {
+				sqlQuery = "SELECT id FROM {shard}"
+				citusDirect = "events"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--with-copy cannot be used with --citus-direct",
+		},
+		{
+			name: "refresh concurrently without view",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM daily_sales"
+				citusDirect = ""
+				withCopy = false
+				refreshMatviews = nil
+				refreshConcurrent = true
+			},
+			wantErr:     true,
+			errContains: "--refresh-concurrently requires --refresh-matview",
+		},
+		{
+			name: "refresh concurrently",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM daily_sales"
+				refreshMatviews = []string{"daily_sales"}
+				refreshConcurrent = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "plan analyze without sidecar",
+			setupFunc: func() {
+				sqlQuery = "SELECT * FROM events"
+				refreshMatviews = nil
+				refreshConcurrent = false
+				planAnalyze = true
+			},
+			wantErr:     true,
+			errContains: "--plan-analyze requires --plan-sidecar",
+		},
+		{
+			name: "plan sidecar with by chunk",
+			setupFunc: func() {
+				planSidecarFlag = true
+				byChunk = "metrics"
+			},
+			wantErr:     true,
+			errContains: "--plan-sidecar cannot be used with --by-chunk",
+		},
+		{
+			name: "suggest indexes with by chunk",
+			setupFunc: func() {
+				planSidecarFlag = false
+				planAnalyze = false
+				suggestIndexes = true
+			},
+			wantErr:     true,
+			errContains: "--suggest-indexes cannot be used with --by-chunk",
+		},
+		{
+			name: "include comments with json",
+			setupFunc: func() {
+				planSidecarFlag = false
+				planAnalyze = false
+				suggestIndexes = false
+				byChunk = ""
+				format = "json"
+				includeComments = true
+			},
+			wantErr:     true,
+			errContains: "--include-comments is only supported for csv and xlsx",
+		},
+		{
+			name: "include comments with xlsx",
+			setupFunc: func() {
+				format = "xlsx"
+				includeComments = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid layout",
+			setupFunc: func() {
+				outputLayout = "daily"
+			},
+			wantErr:     true,
+			errContains: "invalid --layout 'daily'",
+		},
+		{
+			name: "dated layout",
+			setupFunc: func() {
+				outputLayout = layoutDated
+			},
+			wantErr: false,
+		},
+		{
+			name: "max memory",
+			setupFunc: func() {
+				format = "csv"
+				includeComments = false
+				outputLayout = layoutFlat
+				maxMemory = "1GB"
+			},
+			wantErr: false,
+		},
+		{
+			name: "max memory too small",
+			setupFunc: func() {
+				maxMemory = "1MB"
+			},
+			wantErr:     true,
+			errContains: "invalid --max-memory: 1MB is too small",
+		},
+		{
+			name: "invalid max memory",
+			setupFunc: func() {
+				maxMemory = "lots"
+			},
+			wantErr:     true,
+			errContains: "invalid --max-memory",
+		},
+		{
+			name: "dual write",
+			setupFunc: func() {
+				maxMemory = ""
+				dualWrite = "json:legacy.json"
+			},
+			wantErr: false,
+		},
+		{
+			name: "dual write with copy",
+			setupFunc: func() {
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--dual-write cannot be used with --with-copy",
+		},
+		{
+			name: "dual write to sql without table",
+			setupFunc: func() {
+				withCopy = false
+				tableName = ""
+				dualWrite = "sql:legacy.sql"
+			},
+			wantErr:     true,
+			errContains: "--dual-write sql",
+		},
+		{
+			name: "param file",
+			setupFunc: func() {
+				dualWrite = ""
+				paramFiles = []string{"ids=ids.txt"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid param file",
+			setupFunc: func() {
+				paramFiles = []string{"ids.txt"}
+			},
+			wantErr:     true,
+			errContains: "expected name=path",
+		},
+		{
+			name: "param file with copy",
+			setupFunc: func() {
+				paramFiles = []string{"ids=ids.txt"}
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--param-file cannot be used with --with-copy",
+		},
+		{
+			name: "keys table with by chunk",
+			setupFunc: func() {
+				paramFiles = nil
+				withCopy = false
+				keysTableFile = "keys.csv"
+				byChunk = "metrics"
+			},
+			wantErr:     true,
+			errContains: "--keys-table-from-file cannot be used with --by-chunk",
+		},
+		{
+			name: "copy buffer without copy",
+			setupFunc: func() {
+				keysTableFile = ""
+				byChunk = ""
+				copyBuffer = "64MB"
+			},
+			wantErr:     true,
+			errContains: "--copy-buffer requires --format csv with --with-copy",
+		},
+		{
+			name: "invalid copy buffer",
+			setupFunc: func() {
+				withCopy = true
+				copyBuffer = "lots"
+			},
+			wantErr:     true,
+			errContains: "invalid --copy-buffer",
+		},
+		{
+			name: "copy spill limit without copy buffer",
+			setupFunc: func() {
+				copyBuffer = ""
+				copySpillLimit = "1GB"
+			},
+			wantErr:     true,
+			errContains: "require --copy-buffer",
+		},
+		{
+			name: "copy buffer with spill limit",
+			setupFunc: func() {
+				copyBuffer = "64MB"
+				copySpillLimit = "1GB"
+			},
+			wantErr: false,
+		},
+		{
+			name: "keepalive too short",
+			setupFunc: func() {
+				keepAlive = 100 * time.Millisecond
+			},
+			wantErr:     true,
+			errContains: "--keepalive must be 0 (disabled) or at least 1s",
+		},
+		{
+			name: "valid keepalive",
+			setupFunc: func() {
+				keepAlive = 30 * time.Second
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid budget policy",
+			setupFunc: func() {
+				timeBudgetLimit = 2 * time.Hour
+				onBudgetExceeded = "truncate"
+			},
+			wantErr:     true,
+			errContains: "invalid --on-budget-exceeded 'truncate'",
+		},
+		{
+			name: "time budget with copy",
+			setupFunc: func() {
+				onBudgetExceeded = "stop-and-mark"
+			},
+			wantErr:     true,
+			errContains: "--time-budget cannot be used with --with-copy",
+		},
+		{
+			name: "time budget with copy by chunk",
+			setupFunc: func() {
+				byChunk = "metrics"
+			},
+			wantErr: false,
+		},
+		{
+			name: "two pass with csv",
+			setupFunc: func() {
+				timeBudgetLimit = 0
+				byChunk = ""
+				copyBuffer = ""
+				copySpillLimit = ""
+				withCopy = false
+				twoPass = true
+			},
+			wantErr:     true,
+			errContains: "--two-pass is only supported for the xlsx format",
+		},
+		{
+			name: "transforms with copy",
+			setupFunc: func() {
+				twoPass = false
+				withCopy = true
+				transformSteps = []transforms.Step{{Rename: map[string]string{"id": "order_id"}}}
+			},
+			wantErr:     true,
+			errContains: "transforms cannot be used with --with-copy",
+		},
+		{
+			name: "auto copy",
+			setupFunc: func() {
+				withCopy = false
+				transformSteps = nil
+				autoCopy = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "auto copy with json",
+			setupFunc: func() {
+				format = "json"
+			},
+			wantErr:     true,
+			errContains: "--auto-copy requires --format csv",
+		},
+		{
+			name: "invalid auto copy threshold",
+			setupFunc: func() {
+				format = "csv"
+				autoCopy = false
+				autoCopyThreshold = 0
+			},
+			wantErr:     true,
+			errContains: "--auto-copy-threshold must be at least 1",
+		},
+		{
+			name: "cast columns",
+			setupFunc: func() {
+				autoCopyThreshold = defaultAutoCopyThreshold
+				castColumns = []string{"id=int", "amount=numeric(12,2)"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "cast column twice",
+			setupFunc: func() {
+				castColumns = []string{"id=int", "id=bigint"}
+			},
+			wantErr:     true,
+			errContains: `column "id" is given twice to --cast`,
+		},
+		{
+			name: "cast unknown type",
+			setupFunc: func() {
+				castColumns = []string{"amount=money"}
+			},
+			wantErr:     true,
+			errContains: `unknown type "money"`,
+		},
+		{
+			name: "cast with copy",
+			setupFunc: func() {
+				castColumns = []string{"id=int"}
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--cast cannot be used with --with-copy",
+		},
+		{
+			name: "encrypt column",
+			setupFunc: func() {
+				withCopy = false
+				castColumns = nil
+				encryptColumns = []string{"ssn=aes256:SSN_KEY", "email=aes256-det:file:/etc/pgxport/email.key"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "encrypt column twice",
+			setupFunc: func() {
+				encryptColumns = []string{"ssn=aes256:SSN_KEY", "ssn=aes256-det:SSN_KEY"}
+			},
+			wantErr:     true,
+			errContains: `column "ssn" is given twice to --encrypt-column`,
+		},
+		{
+			name: "encrypt column unknown algorithm",
+			setupFunc: func() {
+				encryptColumns = []string{"ssn=des:SSN_KEY"}
+			},
+			wantErr:     true,
+			errContains: `unknown algorithm "des"`,
+		},
+		{
+			name: "encrypt column with copy",
+			setupFunc: func() {
+				encryptColumns = []string{"ssn=aes256:SSN_KEY"}
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--encrypt-column cannot be used with --with-copy",
+		},
+		{
+			name: "tokenize column",
+			setupFunc: func() {
+				withCopy = false
+				encryptColumns = []string{"ssn=aes256:SSN_KEY"}
+				tokenizeColumns = []string{"card_number"}
+				tokenizeURL = "https://vault.internal/tokenize"
+				tokenizeBatch = 500
+			},
+			wantErr: false,
+		},
+		{
+			name: "tokenize column without url",
+			setupFunc: func() {
+				t.Setenv("PGXPORT_TOKENIZE_URL", "")
+				tokenizeURL = ""
+			},
+			wantErr:     true,
+			errContains: "--tokenize-column requires --tokenize-url",
+		},
+		{
+			name: "tokenize column invalid url",
+			setupFunc: func() {
+				tokenizeURL = "vault.internal/tokenize"
+			},
+			wantErr:     true,
+			errContains: "invalid tokenization service URL",
+		},
+		{
+			name: "tokenize batch too small",
+			setupFunc: func() {
+				tokenizeURL = "https://vault.internal/tokenize"
+				tokenizeBatch = 0
+			},
+			wantErr:     true,
+			errContains: "--tokenize-batch must be at least 1",
+		},
+		{
+			name: "tokenize column twice",
+			setupFunc: func() {
+				tokenizeColumns = []string{"card_number", "iban", "card_number"}
+			},
+			wantErr:     true,
+			errContains: `column "card_number" is given twice to --tokenize-column`,
+		},
+		{
+			name: "tokenize and encrypt column",
+			setupFunc: func() {
+				tokenizeBatch = 500
+				tokenizeColumns = []string{"ssn"}
+			},
+			wantErr:     true,
+			errContains: `column "ssn" cannot be both tokenized and encrypted`,
+		},
+		{
+			name: "tokenize column with copy",
+			setupFunc: func() {
+				encryptColumns = nil
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--tokenize-column cannot be used with --with-copy",
+		},
+		{
+			name: "preview sidecar",
+			setupFunc: func() {
+				tokenizeColumns = nil
+				withCopy = false
+				previewSidecarRows = 20
+				previewFormat = "csv"
+			},
+			wantErr: false,
+		},
+		{
+			name: "preview sidecar negative",
+			setupFunc: func() {
+				previewSidecarRows = -1
+			},
+			wantErr:     true,
+			errContains: "--preview-sidecar must be 0 or more rows",
+		},
+		{
+			name: "preview format invalid",
+			setupFunc: func() {
+				previewSidecarRows = 20
+				previewFormat = "html"
+			},
+			wantErr:     true,
+			errContains: `invalid --preview-format "html"`,
+		},
+		{
+			name: "preview sidecar with copy",
+			setupFunc: func() {
+				previewFormat = "md"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--preview-sidecar cannot be used with --with-copy",
+		},
+		{
+			name: "csv strict rfc4180",
+			setupFunc: func() {
+				previewSidecarRows = 0
+				withCopy = false
+				csvStrict = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "csv strict rfc4180 with semicolon",
+			setupFunc: func() {
+				delimiter = ";"
+			},
+			wantErr:     true,
+			errContains: "RFC 4180 only allows the comma",
+		},
+		{
+			name: "csv strict rfc4180 with json",
+			setupFunc: func() {
+				delimiter = ","
+				format = "json"
+			},
+			wantErr:     true,
+			errContains: "--csv-strict-rfc4180 is only supported for the csv format",
+		},
+		{
+			name: "csv strict rfc4180 with copy",
+			setupFunc: func() {
+				format = "csv"
+				withCopy = true
+			},
+			wantErr:     true,
+			errContains: "--csv-strict-rfc4180 cannot be used with --with-copy",
+		},
+		{
+			name: "csv strict rfc4180 with comments",
+			setupFunc: func() {
+				withCopy = false
+				includeComments = true
+			},
+			wantErr:     true,
+			errContains: "--csv-strict-rfc4180 cannot be used with --include-comments",
+		},
+		{
+			name: "json omit null",
+			setupFunc: func() {
+				includeComments = false
+				csvStrict = false
+				format = "json"
+				jsonOmitNull = true
+				jsonNullKeys = "keep"
+			},
+			wantErr: false,
+		},
+		{
+			name: "json null keys explicit",
+			setupFunc: func() {
+				jsonOmitNull = false
+				jsonNullKeys = "explicit"
+			},
+			wantErr: false,
+		},
+		{
+			name: "json null keys invalid",
+			setupFunc: func() {
+				jsonNullKeys = "drop"
+			},
+			wantErr:     true,
+			errContains: `invalid --json-null-keys "drop"`,
+		},
+		{
+			name: "json omit null with explicit null keys",
+			setupFunc: func() {
+				jsonOmitNull = true
+				jsonNullKeys = "explicit"
+			},
+			wantErr:     true,
+			errContains: "--json-omit-null cannot be used with --json-null-keys explicit",
+		},
 	}
 
 	for _, tt := range tests {
@@ -657,3 +1541,50 @@ func TestHandleExportResult(t *testing.T) {
 		})
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	writeErr := &exporters.WriteError{Path: "out.csv", RowsWritten: 10, Err: syscall.ENOSPC}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "generic error", err: errors.New("boom"), expected: exitFailure},
+		{name: "write error", err: writeErr, expected: exitWriteError},
+		{name: "wrapped write error", err: fmt.Errorf("export failed: %w", writeErr), expected: exitWriteError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.expected {
+				t.Errorf("exitCode() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMemoryLimitError(t *testing.T) {
+	exportErr := fmt.Errorf("export failed: %w", context.Canceled)
+
+	if got := memoryLimitError(context.Background(), exportErr); got != exportErr {
+		t.Errorf("errors unrelated to the memory limit must be kept, got %v", got)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(&memguard.LimitError{Limit: 1 << 30, Heap: 1100 << 20})
+
+	if got := memoryLimitError(ctx, nil); got != nil {
+		t.Errorf("memoryLimitError(nil) = %v", got)
+	}
+	got := memoryLimitError(ctx, exportErr)
+	var limitErr *memguard.LimitError
+	if !errors.As(got, &limitErr) {
+		t.Fatalf("memoryLimitError() = %v, want a *memguard.LimitError", got)
+	}
+	for _, want := range []string{"memory limit of 1.0GB exceeded", "--max-row-bytes", "--max-memory"} {
+		if !strings.Contains(got.Error(), want) {
+			t.Errorf("memoryLimitError() = %q, should mention %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/jobfile"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/state"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	runOnly         []string
+	runResumeFailed bool
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <jobfile>",
+	Short: "Run the exports of a jobfile in dependency order",
+	Long: `Run the exports of a jobfile. Each job is an options document (see --options)
+and may list the jobs it runs after in depends_on:
+
+  jobs:
+    customers:
+      sql: SELECT * FROM customers
+      output: customers.csv
+    orders:
+      depends_on: [customers]
+      sqlfile: orders.sql
+      output: orders.csv
+      template: partner-feed
+
+Jobs run one at a time, each after its dependencies. When a job fails, the jobs
+depending on it are skipped and the others still run. The outcome of each job
+is kept in the state store (see pgxport state), so that --resume-failed re-runs
+only the jobs that failed or were skipped.`,
+	Example: `  pgxport run pipeline.yaml
+  pgxport run pipeline.yaml --only orders,invoices
+  pgxport run pipeline.yaml --resume-failed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(runOnly) > 0 && runResumeFailed {
+			return fmt.Errorf("error: Cannot use both --only and --resume-failed")
+		}
+		return runJobfile(cmd.Context(), args[0], runOnly, runResumeFailed)
+	},
+}
+
+func init() {
+	runCmd.Flags().StringSliceVar(&runOnly, "only", nil, "Run only these jobs (comma-separated), assuming their dependencies are up to date")
+	runCmd.Flags().BoolVar(&runResumeFailed, "resume-failed", false, "Run only the jobs that failed or were skipped in the previous run")
+}
+
+// runJob runs one export. It is a variable so that tests can replace the child process.
+var runJob = execJob
+
+// runJobfile runs the selected jobs of the jobfile at path and records their outcome.
+func runJobfile(ctx context.Context, path string, only []string, resumeFailed bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read jobfile: %w", err)
+	}
+	jobs, err := jobfile.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	key := state.Key(state.PrefixJobs, path)
+	results := jobfile.Results{}
+	if _, err := state.Get(key, &results); err != nil {
+		return err
+	}
+	selected, err := selectJobs(jobs, results, only, resumeFailed)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		logger.Success("No failed jobs to re-run")
+		return nil
+	}
+
+	var failed []string
+	for _, job := range jobs {
+		if !selected[job.Name] {
+			continue
+		}
+
+		result := jobfile.Result{Status: jobfile.StatusSucceeded}
+		if blocker := failedDependency(job, selected, results); blocker != "" {
+			result = jobfile.Result{Status: jobfile.StatusSkipped, Error: fmt.Sprintf("dependency %s did not succeed", blocker)}
+			logger.Warn("Job %s skipped: %s", job.Name, result.Error)
+		} else {
+			logger.Info("Running job %s", job.Name)
+			start := time.Now()
+			if err := runJob(ctx, job); err != nil {
+				result = jobfile.Result{Status: jobfile.StatusFailed, Error: err.Error()}
+				logger.Warn("Job %s failed after %v: %v", job.Name, time.Since(start).Round(time.Millisecond), err)
+			} else {
+				logger.Info("Job %s completed in %v", job.Name, time.Since(start).Round(time.Millisecond))
+			}
+		}
+		if result.Status != jobfile.StatusSucceeded {
+			failed = append(failed, job.Name)
+		}
+
+		// Saved after every job so that an interrupted pipeline can be resumed
+		result.Finished = clock.Now().UTC()
+		results[job.Name] = result
+		saved := jobfile.Results{}
+		err := state.Update(key, &saved, func() error {
+			saved[job.Name] = result
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d job(s) did not succeed: %s; run again with --resume-failed once fixed",
+			len(failed), strings.Join(failed, ", "))
+	}
+	logger.Success("%d job(s) completed", len(selected))
+	return nil
+}
+
+// selectJobs returns the names of the jobs to run: all of them, those of only, or
+// those that did not succeed in the previous run.
+func selectJobs(jobs []jobfile.Job, results jobfile.Results, only []string, resumeFailed bool) (map[string]bool, error) {
+	selected := map[string]bool{}
+	switch {
+	case len(only) > 0:
+		for _, name := range only {
+			name = strings.TrimSpace(name)
+			if !slices.ContainsFunc(jobs, func(j jobfile.Job) bool { return j.Name == name }) {
+				return nil, fmt.Errorf("--only: unknown job %q", name)
+			}
+			selected[name] = true
+		}
+	case resumeFailed:
+		if len(results) == 0 {
+			return nil, fmt.Errorf("--resume-failed: no previous run recorded")
+		}
+		for _, job := range jobs {
+			if result, ok := results[job.Name]; !ok || result.Status != jobfile.StatusSucceeded {
+				selected[job.Name] = true
+			}
+		}
+	default:
+		for _, job := range jobs {
+			selected[job.Name] = true
+		}
+	}
+	return selected, nil
+}
+
+// failedDependency returns the first dependency of job that did not succeed. The
+// dependencies that are not run this time count as up to date.
+func failedDependency(job jobfile.Job, selected map[string]bool, results jobfile.Results) string {
+	for _, dep := range job.DependsOn {
+		if selected[dep] && results[dep].Status != jobfile.StatusSucceeded {
+			return dep
+		}
+	}
+	return ""
+}
+
+// execJob runs job in a child pgxport process that reads the job's options
+// document on stdin, so that every export starts from a clean configuration.
+func execJob(ctx context.Context, job jobfile.Job) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := []string{"--options", optionsStdin}
+	options := make(map[string]any, len(job.Options))
+	for key, value := range job.Options {
+		// The template of a job is selected with a flag, templates cannot be set from a document
+		if key == "template" || key == "templates-file" {
+			args = append(args, fmt.Sprintf("--%s=%v", key, value))
+			continue
+		}
+		options[key] = value
+	}
+	doc, err := yaml.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	child := exec.CommandContext(ctx, self, args...)
+	child.Stdin = bytes.NewReader(doc)
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	return child.Run()
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// Ways --lock-name locks a run
+const (
+	lockAdvisory = "advisory"
+	lockFile     = "file"
+)
+
+// lockFileName restricts lock names to what makes a portable file name.
+var lockFileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// runLockPath returns the lock file of a run in dir: .<name>.lock.
+func runLockPath(dir, name string) string {
+	return filepath.Join(dir, "."+name+".lock")
+}
+
+// runLockHolder is the content of a lock file, telling who holds the lock.
+type runLockHolder struct {
+	PID    int       `json:"pid"`
+	Host   string    `json:"host"`
+	Since  time.Time `json:"since"`
+	Output string    `json:"output"`
+}
+
+func (h runLockHolder) String() string {
+	return fmt.Sprintf("pid %d on %s since %s", h.PID, h.Host, h.Since.Format(time.RFC3339))
+}
+
+// acquireRunLock takes the --lock-name lock, so that overlapping runs of the same
+// export do not deliver twice: an advisory lock on the session of store, or a lock
+// file in dir, the output directory. It returns the function releasing the lock.
+func acquireRunLock(store db.Store, dir string) (func(), error) {
+	switch lockMethod {
+	case lockFile:
+		return acquireLockFile(runLockPath(dir, lockName))
+	default:
+		ctx := context.Background()
+		lock, holder, err := db.TryAdvisoryLock(ctx, store, lockName)
+		if err != nil {
+			return nil, fmt.Errorf("--lock-name: %w", err)
+		}
+		if lock == nil {
+			if holder != 0 {
+				return nil, fmt.Errorf("another run holds lock %q (advisory lock held by backend pid %d)", lockName, holder)
+			}
+			return nil, fmt.Errorf("another run holds lock %q (advisory lock)", lockName)
+		}
+		logger.Debug("Advisory lock %q taken (key %d)", lockName, lock.Key)
+		return func() {
+			if err := lock.Release(ctx); err != nil {
+				logger.Warn("%v", err)
+			}
+		}, nil
+	}
+}
+
+// acquireLockFile creates the lock file at path, failing if it exists.
+func acquireLockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		var holder runLockHolder
+		if data, rerr := os.ReadFile(path); rerr == nil && json.Unmarshal(data, &holder) == nil && holder.PID != 0 {
+			return nil, fmt.Errorf("another run holds lock %q (%s, lock file %s); remove the file if that run is no longer active", lockName, holder, path)
+		}
+		return nil, fmt.Errorf("another run holds lock %q (lock file %s); remove the file if that run is no longer active", lockName, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create lock file: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	holder := runLockHolder{PID: os.Getpid(), Host: host, Since: clock.Now().UTC(), Output: outputPath}
+	data, _ := json.Marshal(holder)
+	_, werr := f.Write(append(data, '\n'))
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("unable to write lock file: %w", werr)
+	}
+	logger.Debug("Lock file %s created", path)
+	return func() {
+		if err := os.Remove(path); err != nil {
+			logger.Warn("Unable to remove lock file %s: %v", path, err)
+		}
+	}, nil
+}
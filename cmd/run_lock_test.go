@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAcquireLockFile(t *testing.T) {
+	savedName := lockName
+	defer func() { lockName = savedName }()
+	lockName = "nightly-users-feed"
+
+	dir := t.TempDir()
+	path := runLockPath(dir, lockName)
+	if want := filepath.Join(dir, ".nightly-users-feed.lock"); path != want {
+		t.Errorf("runLockPath() = %q, want %q", path, want)
+	}
+
+	release, err := acquireLockFile(path)
+	if err != nil {
+		t.Fatalf("acquireLockFile() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"pid":`) {
+		t.Errorf("lock file content = %q, %v", data, err)
+	}
+
+	_, err = acquireLockFile(path)
+	if err == nil || !strings.Contains(err.Error(), `another run holds lock "nightly-users-feed" (pid `) {
+		t.Errorf("acquireLockFile() while held error = %v", err)
+	}
+
+	release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file should be removed, stat error = %v", err)
+	}
+	release, err = acquireLockFile(path)
+	if err != nil {
+		t.Fatalf("acquireLockFile() after release error: %v", err)
+	}
+	release()
+
+	// A file left by another tool still holds the lock
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acquireLockFile(path); err == nil || !strings.Contains(err.Error(), "remove the file if that run is no longer active") {
+		t.Errorf("acquireLockFile() with foreign file error = %v", err)
+	}
+}
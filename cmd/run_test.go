@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/internal/jobfile"
+	"github.com/fbz-tec/pgxport/internal/state"
+)
+
+const testJobfile = `
+jobs:
+  customers:
+    sql: SELECT * FROM customers
+  orders:
+    depends_on: [customers]
+    sql: SELECT * FROM orders
+  invoices:
+    depends_on: [orders]
+    sql: SELECT * FROM invoices
+  products:
+    sql: SELECT * FROM products
+`
+
+// fakeJobs replaces the child process, failing the jobs of fail, and returns the jobs run.
+func fakeJobs(t *testing.T, fail ...string) *[]string {
+	t.Helper()
+	saved := runJob
+	t.Cleanup(func() { runJob = saved })
+
+	var ran []string
+	runJob = func(_ context.Context, job jobfile.Job) error {
+		ran = append(ran, job.Name)
+		if slices.Contains(fail, job.Name) {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	return &ran
+}
+
+func TestRunJobfile(t *testing.T) {
+	t.Setenv(state.EnvFile, filepath.Join(t.TempDir(), "state.json"))
+	path := filepath.Join(t.TempDir(), "pipeline.yaml")
+	if err := os.WriteFile(path, []byte(testJobfile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// orders fails: invoices is skipped, the independent products job still runs
+	ran := fakeJobs(t, "orders")
+	err := runJobfile(context.Background(), path, nil, false)
+	if err == nil || !strings.Contains(err.Error(), "2 job(s) did not succeed: orders, invoices") {
+		t.Fatalf("runJobfile() error = %v", err)
+	}
+	if got := strings.Join(*ran, ","); got != "customers,orders,products" {
+		t.Errorf("ran %s", got)
+	}
+	results := jobfile.Results{}
+	if _, err := state.Get(state.Key(state.PrefixJobs, path), &results); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"customers": jobfile.StatusSucceeded,
+		"orders":    jobfile.StatusFailed,
+		"invoices":  jobfile.StatusSkipped,
+		"products":  jobfile.StatusSucceeded,
+	} {
+		if got := results[name].Status; got != want {
+			t.Errorf("%s status = %s, want %s", name, got, want)
+		}
+	}
+
+	// --resume-failed re-runs only orders and invoices
+	ran = fakeJobs(t)
+	if err := runJobfile(context.Background(), path, nil, true); err != nil {
+		t.Fatalf("runJobfile(resume) error: %v", err)
+	}
+	if got := strings.Join(*ran, ","); got != "orders,invoices" {
+		t.Errorf("resume ran %s", got)
+	}
+
+	// Nothing left to resume
+	ran = fakeJobs(t)
+	if err := runJobfile(context.Background(), path, nil, true); err != nil || len(*ran) != 0 {
+		t.Errorf("second resume ran %v (err=%v)", *ran, err)
+	}
+
+	// --only runs the listed jobs, in dependency order
+	ran = fakeJobs(t)
+	if err := runJobfile(context.Background(), path, []string{"invoices", "customers"}, false); err != nil {
+		t.Fatalf("runJobfile(only) error: %v", err)
+	}
+	if got := strings.Join(*ran, ","); got != "customers,invoices" {
+		t.Errorf("only ran %s", got)
+	}
+
+	if err := runJobfile(context.Background(), path, []string{"refunds"}, false); err == nil || !strings.Contains(err.Error(), `unknown job "refunds"`) {
+		t.Errorf("unknown --only job error = %v", err)
+	}
+}
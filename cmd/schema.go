@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/validation"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var schemaFormat string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Describe the columns of a query result without exporting it",
+	Long: `Describe the result of a query without running it: the name, type OID, type
+name, nullability, precision, scale and length of each column, as JSON or YAML
+for code generators.
+
+Nullability is only known for columns read straight from a table column, from
+its NOT NULL constraint; it is left out for computed columns. An outer join can
+still produce NULLs in a NOT NULL column.`,
+	Example: `  pgxport schema -s "SELECT * FROM orders" -o orders.schema.json
+  pgxport schema -F feed.sql -f yaml`,
+	Args: cobra.NoArgs,
+	RunE: runSchema,
+}
+
+func init() {
+	addConnectionFlags(schemaCmd.Flags())
+	schemaCmd.Flags().StringVarP(&sqlQuery, "sql", "s", "", "SQL query to describe")
+	schemaCmd.Flags().StringVarP(&sqlFile, "sqlfile", "F", "", "Path to SQL file containing the query")
+	schemaCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file (defaults to standard output)")
+	schemaCmd.Flags().StringVarP(&schemaFormat, "format", "f", "", "Output format: json or yaml (defaults to the extension of --output, then json)")
+	schemaCmd.MarkFlagsMutuallyExclusive("sql", "sqlfile")
+	schemaCmd.MarkFlagsOneRequired("sql", "sqlfile")
+}
+
+// resultSchema is the document written by "pgxport schema".
+type resultSchema struct {
+	Query   string         `json:"query" yaml:"query"`
+	Columns []schemaColumn `json:"columns" yaml:"columns"`
+}
+
+type schemaColumn struct {
+	Name      string `json:"name" yaml:"name"`
+	Position  int    `json:"position" yaml:"position"`
+	TypeOID   uint32 `json:"type_oid" yaml:"type_oid"`
+	TypeName  string `json:"type_name" yaml:"type_name"`
+	Type      string `json:"type" yaml:"type"`
+	Nullable  *bool  `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	Precision *int   `json:"precision,omitempty" yaml:"precision,omitempty"`
+	Scale     *int   `json:"scale,omitempty" yaml:"scale,omitempty"`
+	Length    *int   `json:"length,omitempty" yaml:"length,omitempty"`
+	Source    string `json:"source,omitempty" yaml:"source,omitempty"` // schema.table.column
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	docFormat, err := schemaOutputFormat(schemaFormat, outputPath)
+	if err != nil {
+		return err
+	}
+
+	query := sqlQuery
+	if sqlFile != "" {
+		if query, err = readSQLFromFile(sqlFile); err != nil {
+			return fmt.Errorf("error reading SQL file: %w", err)
+		}
+	}
+	if err := validation.ValidateQuery(query); err != nil {
+		return err
+	}
+
+	dbUrl, err := resolveConnectionString()
+	if err != nil {
+		return err
+	}
+	flavor, err := db.ParseFlavor(serverFlavor)
+	if err != nil {
+		return err
+	}
+	store := db.NewStoreForFlavor(flavor)
+	if err := store.Open(dbUrl); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer store.Close()
+
+	cols, err := db.DescribeResult(context.Background(), store, query)
+	if err != nil {
+		return err
+	}
+
+	data, err := marshalSchema(newResultSchema(query, cols), docFormat)
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("error writing schema: %w", err)
+	}
+	logger.Success("Schema of %d column(s) written to %s", len(cols), outputPath)
+	return nil
+}
+
+// schemaOutputFormat returns the format of the schema document: the --format flag,
+// or else the extension of the output file.
+func schemaOutputFormat(flag, output string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(flag)); f {
+	case "json", "yaml":
+		return f, nil
+	case "yml":
+		return "yaml", nil
+	case "":
+	default:
+		return "", fmt.Errorf("error: invalid format '%s'. Valid formats are: json, yaml", flag)
+	}
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".yaml", ".yml":
+		return "yaml", nil
+	}
+	return "json", nil
+}
+
+func newResultSchema(query string, cols []db.ResultColumn) *resultSchema {
+	doc := &resultSchema{Query: query, Columns: make([]schemaColumn, len(cols))}
+	for i, c := range cols {
+		col := schemaColumn{
+			Name:      c.Name,
+			Position:  i + 1,
+			TypeOID:   c.TypeOID,
+			TypeName:  c.TypeName,
+			Type:      c.Type,
+			Nullable:  c.Nullable,
+			Precision: c.Precision,
+			Scale:     c.Scale,
+			Length:    c.Length,
+		}
+		if c.Table != nil {
+			col.Source = c.Table.Schema + "." + c.Table.Name + "." + c.Column
+		}
+		doc.Columns[i] = col
+	}
+	return doc
+}
+
+func marshalSchema(doc *resultSchema, docFormat string) ([]byte, error) {
+	if docFormat == "yaml" {
+		return yaml.Marshal(doc)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
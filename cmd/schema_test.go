@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/db"
+)
+
+func TestSchemaOutputFormat(t *testing.T) {
+	tests := []struct {
+		flag, output, want string
+		wantErr            bool
+	}{
+		{"", "", "json", false},
+		{"", "feed.schema.json", "json", false},
+		{"", "feed.schema.YML", "yaml", false},
+		{"yaml", "feed.json", "yaml", false},
+		{"JSON", "feed.yaml", "json", false},
+		{"csv", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := schemaOutputFormat(tt.flag, tt.output)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("schemaOutputFormat(%q, %q) = %q, %v; want %q", tt.flag, tt.output, got, err, tt.want)
+		}
+	}
+}
+
+func TestMarshalSchema(t *testing.T) {
+	notNull, ten, two := false, 10, 2
+	doc := newResultSchema("SELECT id, amount, now()", []db.ResultColumn{
+		{Name: "id", TypeOID: 23, TypeName: "integer", Type: "integer", Nullable: &notNull,
+			Table: &db.Table{Schema: "public", Name: "orders"}, Column: "id"},
+		{Name: "amount", TypeOID: 1700, TypeName: "numeric", Type: "numeric(10,2)", Precision: &ten, Scale: &two},
+		{Name: "now", TypeOID: 1184, TypeName: "timestamp with time zone", Type: "timestamp with time zone"},
+	})
+
+	data, err := marshalSchema(doc, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Columns []map[string]any `json:"columns"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if len(got.Columns) != 3 {
+		t.Fatalf("got %d columns, want 3", len(got.Columns))
+	}
+	if id := got.Columns[0]; id["nullable"] != false || id["source"] != "public.orders.id" || id["position"] != 1.0 {
+		t.Errorf("id = %v", id)
+	}
+	if amount := got.Columns[1]; amount["precision"] != 10.0 || amount["scale"] != 2.0 {
+		t.Errorf("amount = %v", amount)
+	}
+	// Unknown properties are left out rather than guessed
+	if _, ok := got.Columns[2]["nullable"]; ok {
+		t.Errorf("computed column should have no nullability: %v", got.Columns[2])
+	}
+
+	data, err = marshalSchema(doc, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"type_oid: 1700", "type: numeric(10,2)", "nullable: false"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("YAML missing %q:\n%s", want, data)
+		}
+	}
+}
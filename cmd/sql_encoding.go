@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// sqlFileEncodingFor returns the --sqlfile-encoding named name; empty means UTF-8.
+func sqlFileEncodingFor(name string) (encoding.Encoding, error) {
+	if name == "" {
+		return unicode.UTF8, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown --sqlfile-encoding %q (e.g. utf-8, utf-16le, windows-1252, iso-8859-15, shift_jis)", name)
+	}
+	return enc, nil
+}
+
+// decodeSQLFile returns the text of a SQL file. A UTF-8 or UTF-16 byte order mark,
+// as written by many Windows editors, is removed and selects the encoding;
+// otherwise content is decoded from encodingName, UTF-8 by default.
+func decodeSQLFile(content []byte, encodingName string) (string, error) {
+	fallback, err := sqlFileEncodingFor(encodingName)
+	if err != nil {
+		return "", err
+	}
+
+	hasBOM := bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}) ||
+		bytes.HasPrefix(content, []byte{0xFF, 0xFE}) ||
+		bytes.HasPrefix(content, []byte{0xFE, 0xFF})
+	// Without a declared encoding, a legacy file would reach the server as garbage
+	if !hasBOM && encodingName == "" && !utf8.Valid(content) {
+		return "", fmt.Errorf("file is not valid UTF-8; set --sqlfile-encoding to its encoding (e.g. windows-1252)")
+	}
+
+	decoded, _, err := transform.Bytes(unicode.BOMOverride(fallback.NewDecoder()), content)
+	if err != nil {
+		return "", fmt.Errorf("unable to decode file: %w", err)
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeSQLFile(t *testing.T) {
+	const query = "SELECT * FROM users WHERE name = 'José'"
+
+	tests := []struct {
+		name     string
+		content  []byte
+		encoding string
+		want     string
+		wantErr  string
+	}{
+		{name: "utf-8", content: []byte(query), want: query},
+		{name: "utf-8 bom", content: append([]byte{0xEF, 0xBB, 0xBF}, query...), want: query},
+		{name: "utf-16le bom", content: []byte{0xFF, 0xFE, 'S', 0, 'E', 0, 'L', 0, 0xE9, 0}, want: "SELé"},
+		{name: "utf-16be bom", content: []byte{0xFE, 0xFF, 0, 'S', 0, 'E', 0, 'L', 0, 0xE9}, want: "SELé"},
+		{name: "bom wins over declared encoding", content: append([]byte{0xEF, 0xBB, 0xBF}, "é"...), encoding: "windows-1252", want: "é"},
+		{name: "windows-1252", content: []byte("SELECT 'Jos\xe9 \x80'"), encoding: "windows-1252", want: "SELECT 'José €'"},
+		{name: "utf-16le without bom", content: []byte{'S', 0, 'E', 0}, encoding: "utf-16le", want: "SE"},
+		{name: "latin-1 without declared encoding", content: []byte("SELECT 'Jos\xe9'"), wantErr: "not valid UTF-8"},
+		{name: "unknown encoding", content: []byte(query), encoding: "klingon", wantErr: "unknown --sqlfile-encoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSQLFile(tt.content, tt.encoding)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("decodeSQLFile() error = %v, should contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeSQLFile() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("decodeSQLFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSQLFromFileBOM(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "windows.sql")
+	if err := os.WriteFile(tmpFile, []byte("\xEF\xBB\xBFSELECT 1;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := readSQLFromFile(tmpFile)
+	if err != nil {
+		t.Fatalf("readSQLFromFile() error: %v", err)
+	}
+	if result != "SELECT 1;" {
+		t.Errorf("readSQLFromFile() = %q, the byte order mark should be removed", result)
+	}
+}
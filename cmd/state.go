@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var stateResetAll bool
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Show or reset what pgxport remembers between runs",
+	Long: `pgxport keeps the state of resumable operations in a single file, locked on every
+access so that concurrent exports can share it:
+
+  chunks:<output>          chunks already exported by --by-chunk, removed once all are done
+  failed-chunks:<output>   chunks whose export failed, used by --retry-failed-chunks
+  jobs:<jobfile>           outcome of the last run of each job, used by --resume-failed
+
+The file is state.json in the pgxport settings directory, or $PGXPORT_STATE_FILE.`,
+}
+
+var stateShowCmd = &cobra.Command{
+	Use:   "show [key-prefix]",
+	Short: "Print the state entries as JSON",
+	Example: `  pgxport state show
+  pgxport state show jobs:`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefix := ""
+		if len(args) == 1 {
+			prefix = stateKeyArg(args[0])
+		}
+		entries, err := state.List(prefix)
+		if err != nil {
+			return err
+		}
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		return nil
+	},
+}
+
+var stateResetCmd = &cobra.Command{
+	Use:   "reset [key-prefix...]",
+	Short: "Remove state entries, so that the next run starts over",
+	Example: `  pgxport state reset chunks:metrics.csv
+  pgxport state reset jobs:pipeline.yaml
+  pgxport state reset --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if stateResetAll == (len(args) > 0) {
+			return fmt.Errorf("error: give the keys to reset or --all")
+		}
+		n, err := resetState(args)
+		if err != nil {
+			return err
+		}
+		logger.Success("%d state entries removed", n)
+		return nil
+	},
+}
+
+func init() {
+	stateResetCmd.Flags().BoolVar(&stateResetAll, "all", false, "Remove every entry")
+	stateCmd.AddCommand(stateShowCmd, stateResetCmd)
+}
+
+// stateKeyArg turns a key given on the command line into a state key: the path
+// after a known prefix is made absolute, as when the entry was recorded.
+func stateKeyArg(arg string) string {
+	for _, prefix := range []string{state.PrefixChunks, state.PrefixFailedChunks, state.PrefixJobs} {
+		if path, ok := strings.CutPrefix(arg, prefix); ok && path != "" {
+			return state.Key(prefix, path)
+		}
+	}
+	return arg
+}
+
+// resetState removes the entries starting with one of prefixes, every entry when
+// there are none, and returns how many were removed.
+func resetState(prefixes []string) (int, error) {
+	entries, err := state.List("")
+	if err != nil {
+		return 0, err
+	}
+	var keys []string
+	for _, key := range entries.SortedKeys() {
+		if len(prefixes) == 0 {
+			keys = append(keys, key)
+			continue
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, stateKeyArg(prefix)) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return len(keys), state.Delete(keys...)
+}
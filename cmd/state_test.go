@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/internal/state"
+)
+
+func TestResetState(t *testing.T) {
+	t.Setenv(state.EnvFile, filepath.Join(t.TempDir(), "state.json"))
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	for _, key := range []string{
+		state.Key(state.PrefixChunks, "metrics.csv"),
+		state.Key(state.PrefixChunks, "events.csv"),
+		state.Key(state.PrefixJobs, "pipeline.yaml"),
+	} {
+		var v []string
+		if err := state.Update(key, &v, func() error { v = []string{"done"}; return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Relative paths are resolved as when the entries were recorded
+	if n, err := resetState([]string{"chunks:metrics.csv"}); err != nil || n != 1 {
+		t.Errorf("resetState(chunks:metrics.csv) = %d, %v, want 1 entry", n, err)
+	}
+	if n, err := resetState([]string{"jobs:"}); err != nil || n != 1 {
+		t.Errorf("resetState(jobs:) = %d, %v, want 1 entry", n, err)
+	}
+	entries, _ := state.List("")
+	if len(entries) != 1 || entries[state.Key(state.PrefixChunks, "events.csv")] == nil {
+		t.Errorf("remaining entries = %v", entries.SortedKeys())
+	}
+
+	if n, err := resetState(nil); err != nil || n != 1 {
+		t.Errorf("resetState(all) = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/bytesize"
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+const (
+	// statusSampleInterval is how often progress is sampled for the current throughput
+	statusSampleInterval = time.Second
+	// statusWindow is the period over which the current throughput is measured
+	statusWindow = 10 * time.Second
+)
+
+type progressSample struct {
+	at   time.Time
+	rows int64
+}
+
+// statusReporter answers status requests (SIGUSR1 or --status-socket) of a running export.
+type statusReporter struct {
+	start time.Time
+	rows  func() int64
+	bytes func() int64
+
+	mu      sync.Mutex
+	samples []progressSample // oldest first, covering statusWindow
+}
+
+func newStatusReporter(start time.Time, rows, bytes func() int64) *statusReporter {
+	return &statusReporter{start: start, rows: rows, bytes: bytes, samples: []progressSample{{at: start}}}
+}
+
+// sample records the current row count and forgets samples older than statusWindow.
+func (r *statusReporter) sample(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.samples = append(r.samples, progressSample{at: now, rows: r.rows()})
+	for len(r.samples) > 2 && now.Sub(r.samples[1].at) >= statusWindow {
+		r.samples = r.samples[1:]
+	}
+}
+
+// status describes the progress of the export at now.
+func (r *statusReporter) status(now time.Time) string {
+	rows := r.rows()
+	elapsed := now.Sub(r.start)
+
+	r.mu.Lock()
+	oldest := r.samples[0]
+	r.mu.Unlock()
+
+	current := 0.0
+	if d := now.Sub(oldest.at).Seconds(); d > 0 {
+		current = float64(rows-oldest.rows) / d
+	}
+	average := 0.0
+	if elapsed > 0 {
+		average = float64(rows) / elapsed.Seconds()
+	}
+
+	received, _ := db.Traffic()
+	return fmt.Sprintf("Status: %d rows exported in %v (current %.0f rows/s, average %.0f rows/s), %s written, %s received",
+		rows, elapsed.Round(time.Second), current, average,
+		bytesize.Format(r.bytes()), bytesize.Format(received))
+}
+
+// startStatusReporting prints the export status on SIGUSR1 and serves it on the
+// --status-socket Unix socket, if set, until stop is called.
+func startStatusReporting(start time.Time, outputs *exporters.OutputLog) (stop func(), err error) {
+	r := newStatusReporter(start, outputs.RowsExported, outputs.BytesWritten)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	var listener net.Listener
+	if statusSocket != "" {
+		listener, err = listenStatusSocket(statusSocket)
+		if err != nil {
+			return nil, err
+		}
+		logger.Debug("Serving export status on %s", statusSocket)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				fmt.Fprintln(conn, r.status(time.Now()))
+				conn.Close()
+			}
+		}()
+	}
+
+	signals := make(chan os.Signal, 1)
+	notifyStatusSignal(signals)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(statusSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				r.sample(now)
+			case <-signals:
+				// Requested explicitly, so shown even with --quiet
+				fmt.Fprintln(os.Stderr, r.status(time.Now()))
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		if listener != nil {
+			listener.Close()
+		}
+		wg.Wait()
+	}, nil
+}
+
+// listenStatusSocket listens on path, replacing a socket left behind by a previous run.
+func listenStatusSocket(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("cannot use %s as status socket: the file exists", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("cannot use %s as status socket: it is used by another process", path)
+		}
+		os.Remove(path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("cannot use %s as status socket: %w", path, err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create status socket: %w", err)
+	}
+	return listener, nil
+}
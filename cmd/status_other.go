@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// notifyStatusSignal does nothing: SIGUSR1 does not exist on this platform, use --status-socket.
+func notifyStatusSignal(c chan<- os.Signal) {}
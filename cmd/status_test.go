@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+)
+
+func TestStatusReporterThroughput(t *testing.T) {
+	var rows atomic.Int64
+	start := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	r := newStatusReporter(start, rows.Load, func() int64 { return 0 })
+
+	// 100 rows/s for the first minute, then 500 rows/s
+	for s := 1; s <= 70; s++ {
+		if s <= 60 {
+			rows.Add(100)
+		} else {
+			rows.Add(500)
+		}
+		r.sample(start.Add(time.Duration(s) * time.Second))
+	}
+
+	got := r.status(start.Add(70 * time.Second))
+	for _, want := range []string{"Status: 11000 rows exported in 1m10s", "current 500 rows/s", "average 157 rows/s"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("status() = %q, should contain %q", got, want)
+		}
+	}
+}
+
+func TestStatusSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets are not used on Windows in tests")
+	}
+
+	// Socket paths are limited to about 100 bytes, which t.TempDir() may exceed
+	dir, err := os.MkdirTemp("", "pgxport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	original := statusSocket
+	defer func() { statusSocket = original }()
+	statusSocket = filepath.Join(dir, "status.sock")
+
+	stop, err := startStatusReporting(time.Now(), &exporters.OutputLog{})
+	if err != nil {
+		t.Fatalf("startStatusReporting() error: %v", err)
+	}
+
+	if _, err := startStatusReporting(time.Now(), &exporters.OutputLog{}); err == nil || !strings.Contains(err.Error(), "used by another process") {
+		t.Errorf("second reporter on the same socket: err = %v", err)
+	}
+
+	conn, err := net.Dial("unix", statusSocket)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	conn.Close()
+	if err != nil {
+		t.Fatalf("reading status: %v", err)
+	}
+	if !strings.HasPrefix(line, "Status: ") || !strings.Contains(line, "rows exported") {
+		t.Errorf("unexpected status: %q", line)
+	}
+
+	stop()
+	if _, err := os.Stat(statusSocket); !os.IsNotExist(err) {
+		t.Errorf("socket not removed after stop: %v", err)
+	}
+
+	// A socket left behind by a crashed run is replaced
+	l, err := net.Listen("unix", statusSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	stop, err = startStatusReporting(time.Now(), &exporters.OutputLog{})
+	if err != nil {
+		t.Fatalf("stale socket not replaced: %v", err)
+	}
+	stop()
+}
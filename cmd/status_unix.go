@@ -0,0 +1,14 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyStatusSignal relays SIGUSR1, used to ask a running export for its status.
+func notifyStatusSignal(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR1)
+}
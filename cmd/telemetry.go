@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/fbz-tec/pgxport/internal/telemetry"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const telemetryTimeout = 2 * time.Second
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Manage anonymous usage statistics (off by default)",
+	Long: `pgxport can report anonymous usage statistics to help maintainers decide
+what to improve. Telemetry is off until you run 'pgxport telemetry on'.
+
+When enabled, each export sends a single event with:
+  - the pgxport version, operating system and architecture
+  - the output format and compression
+  - the names of the flags that were set (never their values)
+  - whether the export succeeded and, if not, the error class
+
+Queries, exported data, file paths, host names and credentials are never sent.
+Setting DO_NOT_TRACK=1 or PGXPORT_TELEMETRY=off disables telemetry regardless
+of this setting.`,
+}
+
+var telemetryOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Opt in to anonymous usage statistics",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := telemetry.SetEnabled(true); err != nil {
+			return err
+		}
+		logger.Success("Telemetry enabled. Thank you! Run 'pgxport telemetry off' to opt out at any time")
+		return nil
+	},
+}
+
+var telemetryOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Opt out of anonymous usage statistics",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := telemetry.SetEnabled(false); err != nil {
+			return err
+		}
+		logger.Success("Telemetry disabled")
+		return nil
+	},
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether usage statistics are sent and what they contain",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st := telemetry.CurrentStatus()
+		state := "off"
+		if st.Enabled {
+			state = "on"
+		}
+
+		endpoint := st.Endpoint
+		if endpoint == "" {
+			endpoint = "(none)"
+		}
+
+		sample := telemetry.NewEvent()
+		sample.Format = exporters.FormatCSV
+		sample.Compression = exporters.GZIP
+		sample.Flags = []string{"compression", "format", "output", "sql"}
+		sample.Success = true
+		payload, err := json.MarshalIndent(sample, "  ", "  ")
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Telemetry: %s (%s)\n", state, st.Reason)
+		fmt.Fprintf(out, "Endpoint:  %s\n", endpoint)
+		fmt.Fprintf(out, "Settings:  %s\n", st.ConfigPath)
+		fmt.Fprintf(out, "\nExample event:\n  %s\n", payload)
+		return nil
+	},
+}
+
+func init() {
+	telemetryCmd.AddCommand(telemetryOnCmd, telemetryOffCmd, telemetryStatusCmd)
+}
+
+// reportUsage sends the usage event of an export run when telemetry is enabled.
+// Failures are only logged: telemetry must never affect the export result.
+func reportUsage(cmd *cobra.Command, runErr error) {
+	ev := usageEvent(cmd, runErr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryTimeout)
+	defer cancel()
+
+	if err := telemetry.Send(ctx, ev); err != nil {
+		logger.Debug("Telemetry not sent: %v", err)
+	}
+}
+
+// usageEvent builds the telemetry event for an export run. Only flag names are
+// recorded, never their values.
+func usageEvent(cmd *cobra.Command, runErr error) telemetry.Event {
+	ev := telemetry.NewEvent()
+	ev.Format = format
+	ev.Compression = compression
+	ev.Success = runErr == nil
+	ev.ErrorClass = errorClass(runErr)
+
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		ev.Flags = append(ev.Flags, f.Name)
+	})
+	sort.Strings(ev.Flags)
+	return ev
+}
+
+// errorClass reduces an error to a coarse category that carries no user data.
+func errorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var writeErr *exporters.WriteError
+	var pgErr *pgconn.PgError
+	var connectErr *pgconn.ConnectError
+	switch {
+	case errors.As(err, &writeErr):
+		return "write"
+	case errors.As(err, &connectErr):
+		return "connection"
+	case errors.As(err, &pgErr):
+		// SQLSTATE class only (e.g. 42 for syntax errors), never the message
+		return "sqlstate_" + pgErr.Code[:min(2, len(pgErr.Code))]
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "other"
+	}
+}
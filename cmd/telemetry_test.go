@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/spf13/cobra"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "success", err: nil, expected: ""},
+		{name: "write error", err: fmt.Errorf("export failed: %w", &exporters.WriteError{Err: errors.New("disk")}), expected: "write"},
+		{name: "sql error", err: fmt.Errorf("query failed: %w", &pgconn.PgError{Code: "42P01", Message: "relation \"secret\" does not exist"}), expected: "sqlstate_42"},
+		{name: "timeout", err: fmt.Errorf("query: %w", context.DeadlineExceeded), expected: "timeout"},
+		{name: "other", err: errors.New("SELECT * FROM secret"), expected: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorClass(tt.err); got != tt.expected {
+				t.Errorf("errorClass() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUsageEventHasNoValues(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var sql, password string
+	cmd.Flags().StringVar(&sql, "sql", "", "")
+	cmd.Flags().StringVar(&password, "password", "", "")
+	cmd.Flags().StringVar(&sql, "unused", "", "")
+	if err := cmd.Flags().Parse([]string{"--sql", "SELECT * FROM secret", "--password", "hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := usageEvent(cmd, nil)
+
+	if strings.Join(ev.Flags, ",") != "password,sql" {
+		t.Errorf("Flags = %v, want [password sql]", ev.Flags)
+	}
+	if !ev.Success || ev.ErrorClass != "" {
+		t.Errorf("Success = %v, ErrorClass = %q", ev.Success, ev.ErrorClass)
+	}
+	payload := fmt.Sprintf("%+v", ev)
+	if strings.Contains(payload, "secret") || strings.Contains(payload, "hunter2") {
+		t.Errorf("event leaks flag values: %s", payload)
+	}
+}
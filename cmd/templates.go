@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/internal/settings"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvTemplatesFile overrides the default location of the templates file.
+const EnvTemplatesFile = "PGXPORT_TEMPLATES"
+
+// templatesFileName is the templates file of the pgxport settings directory.
+const templatesFileName = "templates.yaml"
+
+// extendsKey names the template a template inherits from.
+const extendsKey = "extends"
+
+// templatesPath returns the file holding the --template definitions: --templates-file,
+// $PGXPORT_TEMPLATES, or templates.yaml next to the other pgxport settings.
+func templatesPath() (string, error) {
+	if templatesFile != "" {
+		return templatesFile, nil
+	}
+	if path := os.Getenv(EnvTemplatesFile); path != "" {
+		return path, nil
+	}
+	return settings.Path(templatesFileName)
+}
+
+// loadTemplate returns the options of the named template, including those it
+// inherits. The templates file maps names to options documents, which may name
+// the template they extend:
+//
+//	masked-csv:
+//	  format: csv
+//	  transforms:
+//	    - mask: {column: email}
+//	partner-feed:
+//	  extends: masked-csv
+//	  compression: gzip
+func loadTemplate(name string) (map[string]any, error) {
+	path, err := templatesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read templates: %w", err)
+	}
+
+	var templates map[string]map[string]any
+	if err := yaml.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("invalid templates file %s: %w", path, err)
+	}
+	template, err := resolveTemplate(templates, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	return template, nil
+}
+
+// resolveTemplate merges the named template over the templates it extends, the
+// options of a template overriding those it inherits. chain lists the templates
+// being resolved, to detect loops.
+func resolveTemplate(templates map[string]map[string]any, name string, chain []string) (map[string]any, error) {
+	if slices.Contains(chain, name) {
+		return nil, fmt.Errorf("inheritance loop %s", strings.Join(append(chain, name), " -> "))
+	}
+	template, ok := templates[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(templates))
+		return nil, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	merged := map[string]any{}
+	if parent, ok := template[extendsKey]; ok {
+		parentName, ok := parent.(string)
+		if !ok || parentName == "" {
+			return nil, fmt.Errorf("%s of %s must be a template name", extendsKey, name)
+		}
+		inherited, err := resolveTemplate(templates, parentName, append(chain, name))
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(merged, inherited)
+	}
+	for key, value := range template {
+		if key != extendsKey {
+			merged[key] = value
+		}
+	}
+	return merged, nil
+}
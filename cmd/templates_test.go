@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTemplates = `
+masked-csv:
+  format: csv
+  output: masked.csv
+  row-per-statement: 10
+partner-feed:
+  extends: masked-csv
+  output: partner.csv
+  sql: SELECT * FROM partners
+loop-a:
+  extends: loop-b
+loop-b:
+  extends: loop-a
+bad-parent:
+  extends: [masked-csv]
+`
+
+func writeTemplates(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), templatesFileName)
+	if err := os.WriteFile(path, []byte(testTemplates), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTemplate(t *testing.T) {
+	saved := templatesFile
+	defer func() { templatesFile = saved }()
+	templatesFile = writeTemplates(t)
+
+	got, err := loadTemplate("partner-feed")
+	if err != nil {
+		t.Fatalf("loadTemplate() error: %v", err)
+	}
+	for key, want := range map[string]any{
+		"format":            "csv",         // inherited
+		"output":            "partner.csv", // overridden
+		"row-per-statement": 10,
+		"sql":               "SELECT * FROM partners",
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+	if _, ok := got[extendsKey]; ok {
+		t.Errorf("%s should not be applied as an option", extendsKey)
+	}
+}
+
+func TestLoadTemplateErrors(t *testing.T) {
+	saved := templatesFile
+	defer func() { templatesFile = saved }()
+	templatesFile = writeTemplates(t)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"missing", `unknown template "missing" (available: bad-parent, loop-a, loop-b, masked-csv, partner-feed)`},
+		{"loop-a", "inheritance loop loop-a -> loop-b -> loop-a"},
+		{"bad-parent", "extends of bad-parent must be a template name"},
+	}
+	for _, tt := range tests {
+		_, err := loadTemplate(tt.name)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("loadTemplate(%q) error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLoadOptionsDocumentTemplate(t *testing.T) {
+	savedName, savedTemplates := templateName, templatesFile
+	savedFile, savedInput := optionsFile, optionsInput
+	defer func() {
+		templateName, templatesFile = savedName, savedTemplates
+		optionsFile, optionsInput = savedFile, savedInput
+	}()
+
+	templateName = "partner-feed"
+	templatesFile = writeTemplates(t)
+	optionsFile = optionsStdin
+	optionsInput = strings.NewReader("row-per-statement: 50\n")
+
+	flags := newOptionsFlagSet()
+	flags.String("format", "", "")
+	if err := flags.Parse([]string{"--output", "cli.csv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadOptionsDocument(flags); err != nil {
+		t.Fatalf("loadOptionsDocument() error: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"format":            "csv",
+		"sql":               "SELECT * FROM partners",
+		"output":            "cli.csv", // the command line wins over the template
+		"row-per-statement": "50",      // the options document wins over the template
+	} {
+		if got := flags.Lookup(name).Value.String(); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Policies applied when --time-budget runs out
+const (
+	budgetFail        = "fail"
+	budgetStopAndMark = "stop-and-mark"
+)
+
+// timeBudget tracks the --time-budget of an export. A nil *timeBudget never expires.
+type timeBudget struct {
+	limit    time.Duration
+	deadline time.Time
+	stop     bool // stop-and-mark: end the export cleanly instead of failing
+
+	mu       sync.Mutex
+	exceeded bool
+	pending  []string // chunks left unexported when the budget ran out
+}
+
+// newTimeBudget returns the budget of an export started at start, or nil without --time-budget.
+func newTimeBudget(start time.Time) *timeBudget {
+	if timeBudgetLimit <= 0 {
+		return nil
+	}
+	return &timeBudget{
+		limit:    timeBudgetLimit,
+		deadline: start.Add(timeBudgetLimit),
+		stop:     strings.ToLower(strings.TrimSpace(onBudgetExceeded)) == budgetStopAndMark,
+	}
+}
+
+// expired reports whether the budget has run out, remembering it if so.
+func (b *timeBudget) expired() bool {
+	if b == nil || time.Now().Before(b.deadline) {
+		return false
+	}
+	b.mu.Lock()
+	b.exceeded = true
+	b.mu.Unlock()
+	return true
+}
+
+// wasExceeded reports whether the export was cut short by the budget.
+func (b *timeBudget) wasExceeded() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+// setPending records the chunks that were not exported.
+func (b *timeBudget) setPending(chunks []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = chunks
+}
+
+func (b *timeBudget) err() error {
+	return fmt.Errorf("time budget of %s exceeded (use --on-budget-exceeded %s to keep the rows exported so far)", b.limit, budgetStopAndMark)
+}
+
+// context returns parent with the budget deadline, for the export query.
+func (b *timeBudget) context(parent context.Context) (context.Context, context.CancelFunc) {
+	if b == nil {
+		return context.WithCancel(parent)
+	}
+	return context.WithDeadline(parent, b.deadline)
+}
+
+// timer returns a channel receiving once the budget has run out, nil without budget.
+func (b *timeBudget) timer() <-chan time.Time {
+	if b == nil {
+		return nil
+	}
+	return time.After(time.Until(b.deadline))
+}
+
+// budgetRows reads the result of a query run under the budget deadline. When the
+// deadline interrupts the query, the rows read so far are complete; with
+// stop-and-mark the result then looks complete to the exporter, which finalizes
+// its file as usual.
+type budgetRows struct {
+	pgx.Rows
+	budget  *timeBudget
+	stopped bool
+}
+
+// limitRows applies budget to rows of a query run with budget.context.
+func limitRows(rows pgx.Rows, budget *timeBudget) pgx.Rows {
+	if budget == nil {
+		return rows
+	}
+	return &budgetRows{Rows: rows, budget: budget}
+}
+
+func (r *budgetRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.stopped = r.Rows.Err() != nil && r.budget.expired()
+	return false
+}
+
+func (r *budgetRows) Err() error {
+	if !r.stopped {
+		return r.Rows.Err()
+	}
+	if r.budget.stop {
+		return nil
+	}
+	return r.budget.err()
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/state"
+	"github.com/jackc/pgx/v5"
+)
+
+// interruptedRows behaves like a query cancelled by its deadline after the available rows.
+type interruptedRows struct {
+	pgx.Rows
+}
+
+func (r interruptedRows) Err() error { return context.DeadlineExceeded }
+
+func expiredBudget(policy string) *timeBudget {
+	return &timeBudget{
+		limit:    time.Hour,
+		deadline: time.Now().Add(-time.Second),
+		stop:     policy == budgetStopAndMark,
+	}
+}
+
+func TestNewTimeBudget(t *testing.T) {
+	savedLimit, savedPolicy := timeBudgetLimit, onBudgetExceeded
+	defer func() { timeBudgetLimit, onBudgetExceeded = savedLimit, savedPolicy }()
+
+	timeBudgetLimit = 0
+	if b := newTimeBudget(time.Now()); b != nil || b.expired() || b.wasExceeded() {
+		t.Error("no budget should never expire")
+	}
+
+	timeBudgetLimit, onBudgetExceeded = 2*time.Hour, "Stop-And-Mark"
+	start := time.Now()
+	b := newTimeBudget(start)
+	if !b.stop || !b.deadline.Equal(start.Add(2*time.Hour)) || b.expired() {
+		t.Errorf("newTimeBudget() = %+v", b)
+	}
+}
+
+func TestBudgetRowsStopAndMark(t *testing.T) {
+	budget := expiredBudget(budgetStopAndMark)
+	rows := limitRows(interruptedRows{dualWriteRows(t, 3)}, budget)
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if count != 3 {
+		t.Errorf("read %d rows, want 3", count)
+	}
+	if err := rows.Err(); err != nil {
+		t.Errorf("Err() = %v, the result should look complete", err)
+	}
+	if !budget.wasExceeded() {
+		t.Error("the budget should be marked exceeded")
+	}
+}
+
+func TestBudgetRowsFail(t *testing.T) {
+	rows := limitRows(interruptedRows{dualWriteRows(t, 3)}, expiredBudget(budgetFail))
+	for rows.Next() {
+	}
+	if err := rows.Err(); err == nil || !strings.Contains(err.Error(), "time budget of 1h0m0s exceeded") {
+		t.Errorf("Err() = %v, want a time budget error", err)
+	}
+}
+
+func TestBudgetRowsCompleteResult(t *testing.T) {
+	// A result read to the end is complete, even if the deadline has passed since
+	budget := expiredBudget(budgetStopAndMark)
+	rows := limitRows(dualWriteRows(t, 3), budget)
+	for rows.Next() {
+	}
+	if rows.Err() != nil || budget.wasExceeded() {
+		t.Errorf("Err() = %v, exceeded = %v", rows.Err(), budget.wasExceeded())
+	}
+}
+
+func TestStopAndMarkExport(t *testing.T) {
+	savedOutput, savedFormat, savedByChunk := outputPath, format, byChunk
+	defer func() { outputPath, format, byChunk = savedOutput, savedFormat, savedByChunk }()
+
+	outputPath = filepath.Join(t.TempDir(), "items.json")
+	format = exporters.FormatJSON
+	byChunk = ""
+
+	budget := expiredBudget(budgetStopAndMark)
+	exporter, _ := exporters.GetExporter(format)
+	options := exporters.ExportOptions{Format: format, Compression: "none", Log: &exporters.OutputLog{}}
+	n, err := exporter.Export(limitRows(interruptedRows{dualWriteRows(t, 5)}, budget), outputPath, options)
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	// The partial output is a well-formed file
+	var items []map[string]any
+	data, _ := os.ReadFile(outputPath)
+	if err := json.Unmarshal(data, &items); err != nil || len(items) != 5 || n != 5 {
+		t.Fatalf("partial output holds %d items (%d exported): %v", len(items), n, err)
+	}
+
+	writeManifest(n, options.Log.Files(), budget)
+	var manifest exportManifest
+	data, err = os.ReadFile(manifestPath(outputPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Status != manifestPartial || manifest.Rows != 5 || manifest.Checkpoint == nil || manifest.Checkpoint.RowsExported != 5 {
+		t.Errorf("manifest = %+v", manifest)
+	}
+}
+
+func TestManifestPendingChunks(t *testing.T) {
+	savedOutput, savedByChunk := outputPath, byChunk
+	defer func() { outputPath, byChunk = savedOutput, savedByChunk }()
+	outputPath, byChunk = "out/events.csv", "metrics"
+
+	budget := expiredBudget(budgetStopAndMark)
+	budget.expired()
+	budget.setPending([]string{"_hyper_1_3_chunk", "_hyper_1_4_chunk"})
+
+	m := newManifest(1200, nil, budget)
+	if m.Status != manifestPartial || m.Reason != "time budget of 1h0m0s exceeded" {
+		t.Errorf("manifest = %+v", m)
+	}
+	if !strings.HasSuffix(m.Checkpoint.ProgressKey, filepath.Join("out", "events.csv")) ||
+		!strings.HasPrefix(m.Checkpoint.ProgressKey, state.PrefixChunks) || len(m.Checkpoint.PendingChunks) != 2 {
+		t.Errorf("checkpoint = %+v", m.Checkpoint)
+	}
+
+	if m := newManifest(1200, nil, nil); m.Status != manifestComplete || m.Checkpoint != nil {
+		t.Errorf("manifest without budget = %+v", m)
+	}
+}
+
+func TestManifestFixedClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 7, 15, 4, 5, 0, time.UTC)
+	defer clock.Set(clock.Fixed(fixed), clock.RandomIDs{})()
+
+	if m := newManifest(10, nil, nil); !m.GeneratedAt.Equal(fixed) {
+		t.Errorf("generated_at = %v, want %v", m.GeneratedAt, fixed)
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/tokenization"
+)
+
+// columnTokenization is the middleware of --tokenize-column, built once so that
+// tokens are cached across the outputs of a run.
+var columnTokenization exporters.Middleware
+
+// validateTokenization checks the --tokenize-* flags.
+func validateTokenization() error {
+	if len(tokenizeColumns) == 0 {
+		return nil
+	}
+	for i, column := range tokenizeColumns {
+		if slices.Contains(tokenizeColumns[:i], column) {
+			return fmt.Errorf("error: column %q is given twice to --tokenize-column", column)
+		}
+	}
+	endpoint := tokenizationURL()
+	if endpoint == "" {
+		return fmt.Errorf("error: --tokenize-column requires --tokenize-url or %s", tokenization.EnvURL)
+	}
+	if _, err := tokenization.NewClient(endpoint, ""); err != nil {
+		return fmt.Errorf("error: %w", err)
+	}
+	if tokenizeBatch < 1 {
+		return fmt.Errorf("error: --tokenize-batch must be at least 1")
+	}
+	specs, _ := parseEncryptColumns(encryptColumns)
+	for _, spec := range specs {
+		for _, column := range tokenizeColumns {
+			if spec.Column == column {
+				return fmt.Errorf("error: column %q cannot be both tokenized and encrypted", column)
+			}
+		}
+	}
+	return nil
+}
+
+// tokenizationURL returns the --tokenize-url endpoint, defaulting to $PGXPORT_TOKENIZE_URL.
+func tokenizationURL() string {
+	if tokenizeURL != "" {
+		return tokenizeURL
+	}
+	return os.Getenv(tokenization.EnvURL)
+}
+
+// tokenizationMiddleware returns the middleware replacing the --tokenize-column
+// columns with tokens, or nil when no column is tokenized.
+func tokenizationMiddleware() (exporters.Middleware, error) {
+	if len(tokenizeColumns) == 0 || columnTokenization != nil {
+		return columnTokenization, nil
+	}
+	client, err := tokenization.NewClient(tokenizationURL(), os.Getenv(tokenization.EnvAPIKey))
+	if err != nil {
+		return nil, fmt.Errorf("--tokenize-column: %w", err)
+	}
+	client.BatchSize = tokenizeBatch
+	columnTokenization = client.Middleware(tokenizeColumns)
+	return columnTokenization, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/transforms"
+	"gopkg.in/yaml.v3"
+)
+
+// transformsKey is the options document entry holding the transforms list.
+const transformsKey = "transforms"
+
+// transformSteps holds the transforms list of the --options document.
+var transformSteps []transforms.Step
+
+// parseTransforms decodes the transforms list of an options document, rejecting
+// unknown settings so that a misspelt one does not silently export raw data.
+func parseTransforms(value any) ([]transforms.Step, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var steps []transforms.Step
+	if err := dec.Decode(&steps); err != nil {
+		return nil, err
+	}
+	if _, err := transforms.Compile(steps); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// parseCastColumns parses the --cast settings into cast steps.
+func parseCastColumns(values []string) ([]transforms.Step, error) {
+	steps := make([]transforms.Step, 0, len(values))
+	seen := map[string]bool{}
+	for _, v := range values {
+		step, err := transforms.ParseCast(v)
+		if err != nil {
+			return nil, err
+		}
+		if seen[step.Cast.Column] {
+			return nil, fmt.Errorf("column %q is given twice to --cast", step.Cast.Column)
+		}
+		seen[step.Cast.Column] = true
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// withTransforms applies the transforms of the options document and the --cast
+// conversions to exporter, then tokenizes the --tokenize-column columns and
+// encrypts the --encrypt-column columns, so that they refer to the transformed
+// columns.
+func withTransforms(exporter exporters.Exporter) (exporters.Exporter, error) {
+	middlewares, err := transforms.Compile(transformSteps)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transforms: %w", err)
+	}
+	castSteps, err := parseCastColumns(castColumns)
+	if err != nil {
+		return nil, err
+	}
+	casts, err := transforms.Compile(castSteps)
+	if err != nil {
+		return nil, fmt.Errorf("--cast: %w", err)
+	}
+	middlewares = append(middlewares, casts...)
+	tokenize, err := tokenizationMiddleware()
+	if err != nil {
+		return nil, err
+	}
+	if tokenize != nil {
+		middlewares = append(middlewares, tokenize)
+	}
+	encrypt, err := encryptionMiddleware()
+	if err != nil {
+		return nil, err
+	}
+	if encrypt != nil {
+		middlewares = append(middlewares, encrypt)
+	}
+	if len(middlewares) == 0 {
+		return exporter, nil
+	}
+	return exporters.Chain(exporter, middlewares...), nil
+}
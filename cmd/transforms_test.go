@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestWithTransformsLookupFile(t *testing.T) {
+	savedSteps := transformSteps
+	defer func() { transformSteps = savedSteps }()
+
+	dir := t.TempDir()
+	table := filepath.Join(dir, "countries.csv")
+	if err := os.WriteFile(table, []byte("FR,France\nDE,Germany\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	steps, err := parseTransforms([]any{
+		map[string]any{"lookup": map[string]any{"column": "country", "file": table}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transformSteps = steps
+
+	// Every export (and every chunk of --by-chunk) compiles the steps again
+	csv, _ := exporters.GetExporter(exporters.FormatCSV)
+	for i := range 2 {
+		exporter, err := withTransforms(csv)
+		if err != nil {
+			t.Fatalf("withTransforms() #%d: %v", i+1, err)
+		}
+		rows, err := rowsource.New([]rowsource.Column{{Name: "country", OID: pgtype.TextOID}}, [][]any{{"DE"}, {"FR"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "out.csv")
+		options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', Compression: "none", NoHeader: true}
+		if _, err := exporter.Export(rows, path, options); err != nil {
+			t.Fatalf("Export() #%d: %v", i+1, err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "Germany\nFrance\n" {
+			t.Errorf("export #%d = %q", i+1, data)
+		}
+	}
+	if steps[0].Lookup.Values != nil {
+		t.Error("compiling the steps should not change them")
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// measureColumns runs query a first time for --two-pass and returns the width each
+// column needs. Rows are discarded as they are read, after the transforms of the
+// options document so that the widths match the columns of the second pass.
+func measureColumns(ctx context.Context, store db.Store, query string, args []any, options exporters.ExportOptions) ([]int, error) {
+	logger.Debug("First pass: measuring column widths")
+	start := time.Now()
+
+	rows, err := store.ExecuteQuery(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("first pass failed: %w", err)
+	}
+	defer rows.Close()
+
+	measure := &widthsExporter{}
+	exporter, err := withTransforms(measure)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exporter.Export(rows, "", options); err != nil {
+		return nil, fmt.Errorf("first pass failed: %w", err)
+	}
+	widths := measure.widths
+	logger.Debug("First pass completed in %v: column widths %v", time.Since(start), widths)
+	return widths, nil
+}
+
+// widthsExporter measures the columns of the rows it is handed instead of writing them.
+type widthsExporter struct {
+	widths []int
+}
+
+func (e *widthsExporter) Export(rows pgx.Rows, _ string, options exporters.ExportOptions) (int, error) {
+	var err error
+	e.widths, err = exporters.MeasureColumnWidths(rows, options)
+	return 0, err
+}
+
+func (e *widthsExporter) Validate(exporters.ExportOptions) error {
+	return nil
+}
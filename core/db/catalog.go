@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Table identifies a table by schema and name.
+type Table struct {
+	Schema string
+	Name   string
+}
+
+// DescribeQuery returns the result columns of query without running it.
+func DescribeQuery(ctx context.Context, conn *pgx.Conn, query string) ([]pgconn.FieldDescription, error) {
+	desc, err := conn.PgConn().Prepare(ctx, "", query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe query: %w", err)
+	}
+	return desc.Fields, nil
+}
+
+// sourceRelations returns the distinct table OIDs the result columns are read from,
+// in column order.
+func sourceRelations(fields []pgconn.FieldDescription) []uint32 {
+	var relids []uint32
+	seen := map[uint32]bool{}
+	for _, fd := range fields {
+		if fd.TableOID != 0 && !seen[fd.TableOID] {
+			seen[fd.TableOID] = true
+			relids = append(relids, fd.TableOID)
+		}
+	}
+	return relids
+}
+
+// SourceTables returns the tables that the result columns of query are read from.
+// Tables only used in joins or filters are not reported.
+func SourceTables(ctx context.Context, store Store, query string) ([]Table, error) {
+	conn := store.GetConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no connection to database")
+	}
+
+	fields, err := DescribeQuery(ctx, conn, query)
+	if err != nil {
+		return nil, err
+	}
+	relids := sourceRelations(fields)
+	if len(relids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := conn.Query(ctx, `SELECT c.oid, n.nspname, c.relname
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.oid = ANY($1)`, relids)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read table names: %w", err)
+	}
+	defer rows.Close()
+
+	names := map[uint32]Table{}
+	for rows.Next() {
+		var oid uint32
+		var t Table
+		if err := rows.Scan(&oid, &t.Schema, &t.Name); err != nil {
+			return nil, fmt.Errorf("unable to read table names: %w", err)
+		}
+		names[oid] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read table names: %w", err)
+	}
+
+	tables := make([]Table, 0, len(relids))
+	for _, oid := range relids {
+		if t, ok := names[oid]; ok {
+			tables = append(tables, t)
+		}
+	}
+	return tables, nil
+}
+
+// TableMetadata holds the catalog information of a source table.
+type TableMetadata struct {
+	Table
+	Owner       string
+	Description string // COMMENT ON TABLE
+}
+
+// ColumnMetadata describes a result column. Table and SourceColumn are empty
+// for computed columns.
+type ColumnMetadata struct {
+	Name         string
+	Type         string
+	Table        *Table
+	SourceColumn string
+	Description  string // COMMENT ON COLUMN of the source column
+}
+
+// QueryMetadata is the business context of a query result taken from the catalog.
+type QueryMetadata struct {
+	Tables  []TableMetadata
+	Columns []ColumnMetadata
+}
+
+// DescribeCatalog returns the owners and comments of the tables that the result
+// columns of query are read from, along with the type and comment of each column.
+func DescribeCatalog(ctx context.Context, store Store, query string) (*QueryMetadata, error) {
+	conn := store.GetConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no connection to database")
+	}
+
+	fields, err := DescribeQuery(ctx, conn, query)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &QueryMetadata{Columns: make([]ColumnMetadata, len(fields))}
+	typeOIDs := make([]uint32, len(fields))
+	typeMods := make([]int32, len(fields))
+	for i, fd := range fields {
+		meta.Columns[i].Name = fd.Name
+		typeOIDs[i] = fd.DataTypeOID
+		typeMods[i] = fd.TypeModifier
+	}
+
+	rows, err := conn.Query(ctx, `SELECT format_type(t, NULLIF(m, -1))
+FROM unnest($1::oid[], $2::int4[]) WITH ORDINALITY AS u(t, m, i)
+ORDER BY i`, typeOIDs, typeMods)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read column types: %w", err)
+	}
+	types, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("unable to read column types: %w", err)
+	}
+	for i := range meta.Columns {
+		if i < len(types) {
+			meta.Columns[i].Type = types[i]
+		}
+	}
+
+	relids := sourceRelations(fields)
+	if len(relids) == 0 {
+		return meta, nil
+	}
+
+	rows, err = conn.Query(ctx, `SELECT c.oid, n.nspname, c.relname, pg_get_userbyid(c.relowner),
+	coalesce(obj_description(c.oid, 'pg_class'), '')
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.oid = ANY($1)`, relids)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read table comments: %w", err)
+	}
+	tables := map[uint32]TableMetadata{}
+	for rows.Next() {
+		var oid uint32
+		var t TableMetadata
+		if err := rows.Scan(&oid, &t.Schema, &t.Name, &t.Owner, &t.Description); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("unable to read table comments: %w", err)
+		}
+		tables[oid] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read table comments: %w", err)
+	}
+
+	type attribute struct {
+		relid  uint32
+		attnum int16
+	}
+	rows, err = conn.Query(ctx, `SELECT attrelid, attnum, attname, coalesce(col_description(attrelid, attnum), '')
+FROM pg_attribute
+WHERE attrelid = ANY($1) AND attnum > 0 AND NOT attisdropped`, relids)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read column comments: %w", err)
+	}
+	type columnComment struct{ name, description string }
+	columns := map[attribute]columnComment{}
+	for rows.Next() {
+		var a attribute
+		var c columnComment
+		if err := rows.Scan(&a.relid, &a.attnum, &c.name, &c.description); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("unable to read column comments: %w", err)
+		}
+		columns[a] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read column comments: %w", err)
+	}
+
+	for _, oid := range relids {
+		if t, ok := tables[oid]; ok {
+			meta.Tables = append(meta.Tables, t)
+		}
+	}
+	for i, fd := range fields {
+		t, ok := tables[fd.TableOID]
+		if !ok {
+			continue
+		}
+		c := columns[attribute{fd.TableOID, int16(fd.TableAttributeNumber)}]
+		meta.Columns[i].Table = &Table{Schema: t.Schema, Name: t.Name}
+		meta.Columns[i].SourceColumn = c.name
+		meta.Columns[i].Description = c.description
+	}
+	return meta, nil
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+// TestDescribeCatalog requires a running PostgreSQL instance (DB_TEST_URL).
+func TestDescribeCatalog(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := NewStore()
+	if err := store.Open(testURL); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	conn := store.GetConnection()
+	for _, stmt := range []string{
+		"CREATE TEMP TABLE pgxport_catalog (id int, label varchar(20))",
+		"COMMENT ON TABLE pgxport_catalog IS 'Catalog test'",
+		"COMMENT ON COLUMN pgxport_catalog.label IS 'Display name'",
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	meta, err := DescribeCatalog(ctx, store, "SELECT label AS name, id + 1 AS next FROM pgxport_catalog")
+	if err != nil {
+		t.Fatalf("DescribeCatalog() error: %v", err)
+	}
+
+	if len(meta.Tables) != 1 || meta.Tables[0].Name != "pgxport_catalog" || meta.Tables[0].Description != "Catalog test" {
+		t.Errorf("Tables = %+v", meta.Tables)
+	}
+	if len(meta.Columns) != 2 {
+		t.Fatalf("Columns = %+v", meta.Columns)
+	}
+	name := meta.Columns[0]
+	if name.Type != "character varying(20)" || name.SourceColumn != "label" || name.Description != "Display name" {
+		t.Errorf("Columns[0] = %+v", name)
+	}
+	if next := meta.Columns[1]; next.Table != nil || next.Type != "integer" {
+		t.Errorf("Columns[1] = %+v, want a computed integer column", next)
+	}
+}
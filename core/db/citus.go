@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Shard is a shard of a Citus distributed table and the worker node holding it.
+type Shard struct {
+	ID   int64
+	Name string // schema-qualified and quoted shard table name
+	Host string
+	Port int
+}
+
+// Addr returns the host:port of the worker node holding the shard.
+func (s Shard) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+const distributedTableQuery = `SELECT partmethod FROM pg_dist_partition WHERE logicalrelid = to_regclass($1)`
+
+// shardsQuery picks one active primary placement per shard.
+const shardsQuery = `SELECT DISTINCT ON (s.shardid) s.shardid, shard_name(s.logicalrelid, s.shardid), n.nodename, n.nodeport
+FROM pg_dist_shard s
+JOIN pg_dist_placement p ON p.shardid = s.shardid
+JOIN pg_dist_node n ON n.groupid = p.groupid
+WHERE s.logicalrelid = to_regclass($1) AND n.isactive AND n.noderole = 'primary'
+ORDER BY s.shardid, n.nodeid`
+
+// ListShards returns the shards of a Citus distributed table, read from the
+// coordinator's metadata.
+func ListShards(ctx context.Context, conn *pgx.Conn, table string) ([]Shard, error) {
+	var method string
+	err := conn.QueryRow(ctx, distributedTableQuery, table).Scan(&method)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%s is not a Citus distributed table", table)
+	}
+	if err != nil {
+		return nil, citusError(err)
+	}
+
+	rows, err := conn.Query(ctx, shardsQuery, table)
+	if err != nil {
+		return nil, citusError(err)
+	}
+	defer rows.Close()
+
+	var shards []Shard
+	for rows.Next() {
+		var s Shard
+		if err := rows.Scan(&s.ID, &s.Name, &s.Host, &s.Port); err != nil {
+			return nil, fmt.Errorf("error reading shard list: %w", err)
+		}
+		shards = append(shards, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, citusError(err)
+	}
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("no active placement found for the shards of %s", table)
+	}
+	return shards, nil
+}
+
+func citusError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == "42P01" || pgErr.Code == "42883") {
+		return fmt.Errorf("shard listing requires the Citus extension on the coordinator: %w", err)
+	}
+	return fmt.Errorf("unable to list shards: %w", err)
+}
@@ -3,7 +3,11 @@ package db
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fbz-tec/pgxport/internal/logger"
@@ -14,15 +18,23 @@ type Store interface {
 	Open(dbUrl string) error
 	Close() error
 	GetConnection() *pgx.Conn
-	ExecuteQuery(ctx context.Context, sql string) (pgx.Rows, error)
+	ExecuteQuery(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Server() ServerInfo
 }
 
 type dbStore struct {
-	conn *pgx.Conn
+	conn   *pgx.Conn
+	flavor Flavor
+	server ServerInfo
 }
 
 func NewStore() Store {
-	return &dbStore{}
+	return NewStoreForFlavor(DefaultFlavor())
+}
+
+// NewStoreForFlavor returns a Store that checks the server against the given flavor.
+func NewStoreForFlavor(flavor Flavor) Store {
+	return &dbStore{flavor: flavor}
 }
 
 func (store *dbStore) Open(dbUrl string) error {
@@ -30,9 +42,15 @@ func (store *dbStore) Open(dbUrl string) error {
 	defer cancel()
 
 	logger.Debug("Connection timeout: 10s")
-	logger.Debug("Attempting to connect to database host: %s", sanitizeURL(dbUrl))
+	logger.Debug("Attempting to connect to database host: %s", SanitizeURL(dbUrl))
 
-	conn, err := pgx.Connect(ctx, dbUrl)
+	cfg, err := pgx.ParseConfig(dbUrl)
+	if err != nil {
+		return fmt.Errorf("unable to connect to database: %w", err)
+	}
+	countTraffic(&cfg.Config)
+
+	conn, err := pgx.ConnectConfig(ctx, cfg)
 
 	if err != nil {
 		return fmt.Errorf("unable to connect to database: %w", err)
@@ -47,7 +65,26 @@ func (store *dbStore) Open(dbUrl string) error {
 	}
 
 	logger.Debug("Database ping successful")
+
+	server, err := DetectServer(conn)
+	if err != nil && !store.flavor.IgnoreVersion {
+		logger.Warn("Unable to identify server version, assuming a recent %s: %v", store.flavor.Title, err)
+	}
+	server = server.WithFlavor(store.flavor)
+	if server.Version == "" {
+		server.Version = conn.PgConn().ParameterStatus("server_version")
+	}
+	if conn.PgConn().ParameterStatus("crdb_version") != "" && store.flavor.Name != FlavorCockroach {
+		logger.Warn("The server looks like CockroachDB; use --server-flavor %s to adapt to it", FlavorCockroach)
+	}
+	if err := server.CheckSupported(); err != nil {
+		conn.Close(ctx)
+		return err
+	}
+	logger.Debug("Server version: %s %s (%d)", server.Flavor.Title, server.Version, server.VersionNumber)
+
 	store.conn = conn
+	store.server = server
 	return nil
 }
 
@@ -68,7 +105,7 @@ func (store *dbStore) Close() error {
 	return nil
 }
 
-func (store *dbStore) ExecuteQuery(ctx context.Context, sql string) (pgx.Rows, error) {
+func (store *dbStore) ExecuteQuery(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
 
 	if store.conn == nil {
 		logger.Debug("No active database connection; query cannot be executed")
@@ -79,7 +116,7 @@ func (store *dbStore) ExecuteQuery(ctx context.Context, sql string) (pgx.Rows, e
 	logger.Debug("Query: %s", sql)
 
 	startTime := time.Now()
-	rows, err := store.conn.Query(ctx, sql)
+	rows, err := store.conn.Query(ctx, sql, args...)
 	duration := time.Since(startTime)
 
 	if err != nil {
@@ -94,8 +131,18 @@ func (store *dbStore) GetConnection() *pgx.Conn {
 	return store.conn
 }
 
-// sanitizeURL removes the password part from a PostgreSQL DSN before logging.
-func sanitizeURL(dbUrl string) string {
+// Server returns the version of the connected server, detected by Open.
+func (store *dbStore) Server() ServerInfo {
+	return store.server
+}
+
+// SanitizeURL removes the password part from a PostgreSQL DSN before logging.
+// Both URL and keyword/value ("host=... password=...") forms are supported.
+func SanitizeURL(dbUrl string) string {
+	if !strings.Contains(dbUrl, "://") {
+		return kvPasswordPattern.ReplaceAllString(dbUrl, "${1}***")
+	}
+
 	u, err := url.Parse(dbUrl)
 	if err != nil {
 		return "<invalid-url>"
@@ -119,3 +166,23 @@ func sanitizeURL(dbUrl string) string {
 
 	return fmt.Sprintf("%s://%s%s%s", u.Scheme, userInfo, u.Host, path)
 }
+
+// ReplaceHost returns dbUrl pointing at host:port, keeping credentials and every
+// other setting. It is used to reach Citus worker nodes with the coordinator's settings.
+func ReplaceHost(dbUrl, host string, port int) (string, error) {
+	if !strings.Contains(dbUrl, "://") {
+		// Later keywords override earlier ones in a keyword/value DSN
+		return fmt.Sprintf("%s host='%s' port=%d", dbUrl, strings.ReplaceAll(host, "'", `\'`), port), nil
+	}
+
+	u, err := url.Parse(dbUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid connection string: %w", err)
+	}
+	u.Host = net.JoinHostPort(host, strconv.Itoa(port))
+	return u.String(), nil
+}
+
+// kvPasswordPattern matches the value of a password setting in a keyword/value DSN,
+// quoted or not.
+var kvPasswordPattern = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Explain returns the JSON plan of query. With analyze the query is executed, so
+// the plan includes actual row counts, timings and buffer usage. args are the
+// values of the query parameters, if any.
+func Explain(ctx context.Context, store Store, query string, analyze bool, args ...any) (json.RawMessage, error) {
+	conn := store.GetConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no connection to database")
+	}
+
+	options := "FORMAT JSON"
+	if analyze {
+		options = "ANALYZE, BUFFERS, FORMAT JSON"
+	}
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+
+	var plan []byte
+	if err := conn.QueryRow(ctx, fmt.Sprintf("EXPLAIN (%s) %s", options, query), args...).Scan(&plan); err != nil {
+		return nil, fmt.Errorf("EXPLAIN failed: %w", err)
+	}
+	return json.RawMessage(plan), nil
+}
+
+// EstimatedRows returns the number of rows the planner expects the query of plan
+// (as returned by Explain) to return.
+func EstimatedRows(plan json.RawMessage) (float64, error) {
+	var statements []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &statements); err != nil {
+		return 0, fmt.Errorf("unable to read query plan: %w", err)
+	}
+	if len(statements) == 0 {
+		return 0, fmt.Errorf("unable to read query plan: no statement")
+	}
+	return statements[0].Plan.PlanRows, nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestExplain requires a running PostgreSQL instance (DB_TEST_URL).
+func TestExplain(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := NewStore()
+	if err := store.Open(testURL); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+
+	for _, analyze := range []bool{false, true} {
+		plan, err := Explain(context.Background(), store, "SELECT * FROM generate_series(1, 10);", analyze)
+		if err != nil {
+			t.Fatalf("Explain(analyze=%v) error: %v", analyze, err)
+		}
+		if !strings.Contains(string(plan), `"Node Type"`) {
+			t.Errorf("unexpected plan: %s", plan)
+		}
+		if analyze != strings.Contains(string(plan), `"Actual Rows"`) {
+			t.Errorf("analyze=%v but plan is %s", analyze, plan)
+		}
+	}
+}
+
+func TestEstimatedRows(t *testing.T) {
+	rows, err := EstimatedRows([]byte(`[{"Plan": {"Node Type": "Limit", "Plan Rows": 2500000,
+		"Plans": [{"Node Type": "Seq Scan", "Relation Name": "events", "Plan Rows": 9000000}]}}]`))
+	if err != nil || rows != 2500000 {
+		t.Errorf("EstimatedRows() = %v, %v; want the rows of the top node", rows, err)
+	}
+	if _, err := EstimatedRows([]byte(`[]`)); err == nil {
+		t.Error("EstimatedRows() of an empty plan should fail")
+	}
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Flavor describes a PostgreSQL-compatible engine and the quirks pgxport must adapt to.
+type Flavor struct {
+	Name        string
+	Title       string
+	Description string
+	// MinVersion is the oldest server_version_num accepted for this engine.
+	MinVersion int
+	// CopyTo reports whether "COPY (query) TO STDOUT" can be used for --with-copy.
+	CopyTo bool
+	// IgnoreVersion is set for engines whose server_version does not reflect their
+	// feature set; Unsupported is then the only source of truth.
+	IgnoreVersion bool
+	// Unsupported lists features the engine lacks regardless of its reported version.
+	Unsupported []Feature
+	// TimeZoneQuery checks whether the server knows the time zone passed as $1.
+	TimeZoneQuery string
+}
+
+const (
+	FlavorPostgres  = "postgres"
+	FlavorCockroach = "cockroach"
+	FlavorGreenplum = "greenplum"
+	FlavorTimescale = "timescale"
+)
+
+var flavors = []Flavor{
+	{
+		Name:          FlavorPostgres,
+		Title:         "PostgreSQL",
+		Description:   "Standard PostgreSQL (default)",
+		MinVersion:    MinServerVersion,
+		CopyTo:        true,
+		TimeZoneQuery: "SELECT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = $1)",
+	},
+	{
+		Name:          FlavorCockroach,
+		Title:         "CockroachDB",
+		Description:   "CockroachDB; reports server_version 13 but has its own feature set and no COPY TO for queries",
+		IgnoreVersion: true,
+		Unsupported:   []Feature{FeatureTableSample, FeatureAdvisoryLocks},
+		// pg_timezone_names is not populated by CockroachDB, so the zone is resolved instead
+		TimeZoneQuery: "SELECT now() AT TIME ZONE $1::text IS NOT NULL",
+	},
+	{
+		Name:          FlavorGreenplum,
+		Title:         "Greenplum",
+		Description:   "Greenplum 4.3 and later (PostgreSQL 8.2 based and newer); COPY runs through the coordinator",
+		MinVersion:    80200,
+		CopyTo:        true,
+		TimeZoneQuery: "SELECT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = $1)",
+	},
+	{
+		Name:          FlavorTimescale,
+		Title:         "TimescaleDB",
+		Description:   "PostgreSQL with the TimescaleDB extension; hypertables are exported through their parent table",
+		MinVersion:    MinServerVersion,
+		CopyTo:        true,
+		TimeZoneQuery: "SELECT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = $1)",
+	},
+}
+
+// Flavors returns the supported server flavors, PostgreSQL first.
+func Flavors() []Flavor {
+	return append([]Flavor(nil), flavors...)
+}
+
+// DefaultFlavor returns the standard PostgreSQL flavor.
+func DefaultFlavor() Flavor {
+	return flavors[0]
+}
+
+// ParseFlavor looks up a flavor by name. An empty name selects PostgreSQL.
+func ParseFlavor(name string) (Flavor, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return DefaultFlavor(), nil
+	}
+	var names []string
+	for _, f := range flavors {
+		if f.Name == name {
+			return f, nil
+		}
+		names = append(names, f.Name)
+	}
+	return Flavor{}, fmt.Errorf("unknown server flavor %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+// lacks reports whether the engine is known not to provide feature.
+func (f Flavor) lacks(feature Feature) bool {
+	for _, u := range f.Unsupported {
+		if u == feature {
+			return true
+		}
+	}
+	return false
+}
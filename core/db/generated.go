@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+var (
+	FeatureIdentityColumns  = Feature{Name: "identity columns", MinVersion: 100000}
+	FeatureGeneratedColumns = Feature{Name: "generated columns", MinVersion: 120000}
+)
+
+// GeneratedColumn is a result column that comes from a table column the target
+// database computes itself.
+type GeneratedColumn struct {
+	Index int    // position in the result
+	Name  string // result column name
+	Kind  string // "generated" (GENERATED ALWAYS AS) or "identity" (GENERATED ALWAYS AS IDENTITY)
+}
+
+// GeneratedColumns describes query without running it and returns the result columns
+// that map to generated or GENERATED ALWAYS identity columns of their source table.
+// INSERT statements must leave such columns out.
+func GeneratedColumns(ctx context.Context, store Store, query string) ([]GeneratedColumn, error) {
+	conn := store.GetConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no connection to database")
+	}
+
+	server := store.Server()
+	var kinds []string
+	if server.Supports(FeatureGeneratedColumns) {
+		kinds = append(kinds, "CASE WHEN a.attgenerated <> '' THEN 'generated' END")
+	}
+	if server.Supports(FeatureIdentityColumns) {
+		kinds = append(kinds, "CASE WHEN a.attidentity = 'a' THEN 'identity' END")
+	}
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	fields, err := DescribeQuery(ctx, conn, query)
+	if err != nil {
+		return nil, err
+	}
+	relids := sourceRelations(fields)
+	if len(relids) == 0 {
+		return nil, nil
+	}
+
+	catalogQuery := fmt.Sprintf(`SELECT a.attrelid, a.attnum, COALESCE(%s)
+FROM pg_attribute a
+WHERE a.attrelid = ANY($1) AND a.attnum > 0 AND COALESCE(%s) IS NOT NULL`,
+		strings.Join(kinds, ", "), strings.Join(kinds, ", "))
+
+	rows, err := conn.Query(ctx, catalogQuery, relids)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read column metadata: %w", err)
+	}
+	defer rows.Close()
+
+	type attr struct {
+		relid  uint32
+		attnum uint16
+	}
+	generated := map[attr]string{}
+	for rows.Next() {
+		var a attr
+		var kind string
+		if err := rows.Scan(&a.relid, &a.attnum, &kind); err != nil {
+			return nil, fmt.Errorf("unable to read column metadata: %w", err)
+		}
+		generated[a] = kind
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read column metadata: %w", err)
+	}
+
+	var cols []GeneratedColumn
+	for i, fd := range fields {
+		if kind, ok := generated[attr{fd.TableOID, fd.TableAttributeNumber}]; ok {
+			cols = append(cols, GeneratedColumn{Index: i, Name: fd.Name, Kind: kind})
+		}
+	}
+	return cols, nil
+}
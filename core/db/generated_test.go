@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+// TestGeneratedColumns requires PostgreSQL 12 or later (DB_TEST_URL).
+func TestGeneratedColumns(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := NewStore()
+	if err := store.Open(testURL); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+
+	if !store.Server().Supports(FeatureGeneratedColumns) {
+		t.Skip("generated columns require PostgreSQL 12")
+	}
+
+	ctx := context.Background()
+	_, err := store.GetConnection().Exec(ctx, `CREATE TEMP TABLE pgxport_generated (
+		id int GENERATED ALWAYS AS IDENTITY,
+		seq int GENERATED BY DEFAULT AS IDENTITY,
+		name text,
+		name_upper text GENERATED ALWAYS AS (upper(name)) STORED
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cols, err := GeneratedColumns(ctx, store, "SELECT name, name_upper, seq, id, 1 AS one FROM pgxport_generated")
+	if err != nil {
+		t.Fatalf("GeneratedColumns() error: %v", err)
+	}
+
+	expected := []GeneratedColumn{
+		{Index: 1, Name: "name_upper", Kind: "generated"},
+		{Index: 3, Name: "id", Kind: "identity"},
+	}
+	if len(cols) != len(expected) {
+		t.Fatalf("GeneratedColumns() = %+v, want %+v", cols, expected)
+	}
+	for i := range expected {
+		if cols[i] != expected[i] {
+			t.Errorf("column %d = %+v, want %+v", i, cols[i], expected[i])
+		}
+	}
+}
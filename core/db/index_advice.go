@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Thresholds of SuggestIndexes
+const (
+	// Smaller tables are read faster sequentially than through an index
+	minIndexScanRows = 10000
+	// Filters keeping a larger share of the rows gain little from an index
+	maxIndexSelectivity = 0.05
+	// Columns beyond the first few rarely make the index more selective
+	maxIndexColumns = 3
+)
+
+// IndexSuggestion is an index that would let the server skip a sequential scan
+// filtering most rows out of a large table.
+type IndexSuggestion struct {
+	Table        string // schema-qualified when the plan tells the schema
+	Columns      []string
+	Filter       string
+	ScannedRows  float64
+	MatchingRows float64
+}
+
+// Statement returns the CREATE INDEX statement of the suggestion.
+func (s IndexSuggestion) Statement() string {
+	return fmt.Sprintf("CREATE INDEX ON %s (%s);", s.Table, strings.Join(s.Columns, ", "))
+}
+
+func (s IndexSuggestion) String() string {
+	return fmt.Sprintf("%s -- sequential scan reads %.0f rows to keep %.0f (%.2f%%) with filter %s",
+		s.Statement(), s.ScannedRows, s.MatchingRows, 100*s.MatchingRows/s.ScannedRows, s.Filter)
+}
+
+// planNode holds the fields of an EXPLAIN (FORMAT JSON) node used to find scans.
+type planNode struct {
+	NodeType    string     `json:"Node Type"`
+	Relation    string     `json:"Relation Name"`
+	Schema      string     `json:"Schema"`
+	Filter      string     `json:"Filter"`
+	PlanRows    float64    `json:"Plan Rows"`
+	ActualRows  *float64   `json:"Actual Rows"`
+	ActualLoops float64    `json:"Actual Loops"`
+	RowsRemoved float64    `json:"Rows Removed by Filter"`
+	Plans       []planNode `json:"Plans"`
+}
+
+// seqScan is a filtered sequential scan of a plan. Scanned is negative when the
+// plan was not analyzed, the table size then coming from the statistics.
+type seqScan struct {
+	table    string
+	relation string
+	filter   string
+	scanned  float64
+	matching float64
+}
+
+// SuggestIndexes looks for sequential scans of plan (as returned by Explain) that
+// read large tables to keep few rows, and suggests an index on the filtered
+// columns of each. Plans without ANALYZE are sized with the table statistics.
+func SuggestIndexes(ctx context.Context, store Store, plan json.RawMessage) ([]IndexSuggestion, error) {
+	scans, err := filteredSeqScans(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range scans {
+		if scans[i].scanned >= 0 {
+			continue
+		}
+		conn := store.GetConnection()
+		if conn == nil {
+			return nil, fmt.Errorf("no connection to database")
+		}
+		var tuples *float64
+		err := conn.QueryRow(ctx, "SELECT reltuples::float8 FROM pg_class WHERE oid = to_regclass($1)", scans[i].table).Scan(&tuples)
+		if err != nil && err != pgx.ErrNoRows {
+			return nil, fmt.Errorf("unable to read the size of %s: %w", scans[i].table, err)
+		}
+		if tuples != nil {
+			scans[i].scanned = *tuples
+		}
+	}
+	return adviseIndexes(scans), nil
+}
+
+// filteredSeqScans returns the sequential scans with a filter in plan.
+func filteredSeqScans(plan json.RawMessage) ([]seqScan, error) {
+	var statements []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &statements); err != nil {
+		return nil, fmt.Errorf("unable to read plan: %w", err)
+	}
+
+	var scans []seqScan
+	var walk func(n planNode)
+	walk = func(n planNode) {
+		if n.NodeType == "Seq Scan" && n.Filter != "" && n.Relation != "" {
+			table := pgx.Identifier{n.Relation}
+			if n.Schema != "" {
+				table = pgx.Identifier{n.Schema, n.Relation}
+			}
+			s := seqScan{table: table.Sanitize(), relation: n.Relation, filter: n.Filter, scanned: -1, matching: n.PlanRows}
+			if n.ActualRows != nil {
+				loops := max(n.ActualLoops, 1)
+				s.matching = *n.ActualRows * loops
+				s.scanned = s.matching + n.RowsRemoved*loops
+			}
+			scans = append(scans, s)
+		}
+		for _, child := range n.Plans {
+			walk(child)
+		}
+	}
+	for _, s := range statements {
+		walk(s.Plan)
+	}
+	return scans, nil
+}
+
+// adviseIndexes keeps the selective scans of large tables whose filter columns
+// are known, one suggestion per index.
+func adviseIndexes(scans []seqScan) []IndexSuggestion {
+	var suggestions []IndexSuggestion
+	seen := map[string]bool{}
+	for _, s := range scans {
+		if s.scanned < minIndexScanRows || s.matching > s.scanned*maxIndexSelectivity {
+			continue
+		}
+		columns := filterColumns(s.filter)
+		if len(columns) == 0 {
+			continue
+		}
+		suggestion := IndexSuggestion{Table: s.table, Columns: columns, Filter: s.filter, ScannedRows: s.scanned, MatchingRows: s.matching}
+		if !seen[suggestion.Statement()] {
+			seen[suggestion.Statement()] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// filterComparison matches a column compared in a deparsed plan filter, such as
+// (status = 'shipped'::text), ((o.code)::text = ANY (...)) or (deleted_at IS NULL).
+var filterComparison = regexp.MustCompile(`(?:(?:"(?:[^"]|"")+"|[a-z_][a-z0-9_$]*)\.)?("(?:[^"]|"")+"|[a-z_][a-z0-9_$]*)\)?(?:::[a-z ]+?)?\s+(=|<=|>=|<|>|IS NULL)`)
+
+var filterLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// filterColumns returns the columns an index should cover for filter: those
+// compared for equality first, then those compared by range. Filters with OR
+// are left out, as a single index cannot serve them.
+func filterColumns(filter string) []string {
+	if strings.Contains(filter, " OR ") {
+		return nil
+	}
+
+	// Literals may look like comparisons, as in (note = 'a = b'::text)
+	filter = filterLiteral.ReplaceAllString(filter, "''")
+
+	var equality, ranges []string
+	seen := map[string]bool{}
+	for _, m := range filterComparison.FindAllStringSubmatchIndex(filter, -1) {
+		// Skip type names of casts, as in 'x'::text = ..., and function arguments,
+		// as in lower(email) = ..., which need an expression index
+		if m[0] >= 2 && filter[m[0]-2:m[0]] == "::" || m[0] >= 2 && filter[m[0]-1] == '(' && isIdentChar(filter[m[0]-2]) {
+			continue
+		}
+		column := filter[m[2]:m[3]] // without the alias of o.status
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+		switch filter[m[4]:m[5]] {
+		case "=", "IS NULL":
+			equality = append(equality, column)
+		default:
+			ranges = append(ranges, column)
+		}
+	}
+	columns := append(equality, ranges...)
+	if len(columns) > maxIndexColumns {
+		columns = columns[:maxIndexColumns]
+	}
+	return columns
+}
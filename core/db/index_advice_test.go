@@ -0,0 +1,77 @@
+package db
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFilterColumns(t *testing.T) {
+	tests := map[string]string{
+		`(status = 'shipped'::text)`: "status",
+		`((created_at >= '2024-01-01 00:00:00'::timestamp without time zone) AND (status = 'shipped'::text))`: "status,created_at",
+		`((o.code)::text = ANY ('{a,b}'::text[]))`:                                                            "code",
+		`((deleted_at IS NULL) AND ("Region" = 'EU'::text))`:                                                  `deleted_at,"Region"`,
+		`(note = 'a = b'::text)`:                                                                              "note",
+		`((status = 'a'::text) OR (status = 'b'::text))`:                                                      "",
+		`((a = 1) AND (b = 2) AND (c = 3) AND (d = 4))`:                                                       "a,b,c",
+		`(lower(email) = 'x'::text)`:                                                                          "",
+		`(amount > '100'::numeric)`:                                                                           "amount",
+	}
+	for filter, want := range tests {
+		if got := strings.Join(filterColumns(filter), ","); got != want {
+			t.Errorf("filterColumns(%s) = %q, want %q", filter, got, want)
+		}
+	}
+}
+
+func TestAdviseIndexesFromAnalyzedPlan(t *testing.T) {
+	plan := json.RawMessage(`[{"Plan": {
+		"Node Type": "Hash Join",
+		"Plans": [
+			{"Node Type": "Seq Scan", "Relation Name": "orders", "Schema": "sales", "Filter": "(status = 'returned'::text)",
+			 "Plan Rows": 500, "Actual Rows": 120, "Actual Loops": 1, "Rows Removed by Filter": 2000000},
+			{"Node Type": "Seq Scan", "Relation Name": "customers", "Filter": "(active)",
+			 "Plan Rows": 900, "Actual Rows": 900, "Actual Loops": 1, "Rows Removed by Filter": 100},
+			{"Node Type": "Seq Scan", "Relation Name": "events", "Filter": "(kind = 'click'::text)",
+			 "Plan Rows": 900, "Actual Rows": 800000, "Actual Loops": 1, "Rows Removed by Filter": 200000}
+		]}}]`)
+
+	scans, err := filteredSeqScans(plan)
+	if err != nil {
+		t.Fatalf("filteredSeqScans() error: %v", err)
+	}
+	if len(scans) != 3 {
+		t.Fatalf("filteredSeqScans() = %d scans, want 3", len(scans))
+	}
+
+	// customers is too small and events keeps 80% of its rows
+	suggestions := adviseIndexes(scans)
+	if len(suggestions) != 1 {
+		t.Fatalf("adviseIndexes() = %v, want 1 suggestion", suggestions)
+	}
+	s := suggestions[0]
+	if got := s.Statement(); got != `CREATE INDEX ON "sales"."orders" (status);` {
+		t.Errorf("Statement() = %s", got)
+	}
+	if s.ScannedRows != 2000120 || s.MatchingRows != 120 {
+		t.Errorf("rows = %.0f scanned, %.0f matching", s.ScannedRows, s.MatchingRows)
+	}
+	if !strings.Contains(s.String(), "reads 2000120 rows to keep 120 (0.01%)") {
+		t.Errorf("String() = %s", s)
+	}
+}
+
+func TestFilteredSeqScansWithoutAnalyze(t *testing.T) {
+	scans, err := filteredSeqScans(json.RawMessage(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "orders", "Filter": "(id = 1)", "Plan Rows": 1}}]`))
+	if err != nil {
+		t.Fatalf("filteredSeqScans() error: %v", err)
+	}
+	if len(scans) != 1 || scans[0].scanned >= 0 || scans[0].matching != 1 || scans[0].table != `"orders"` {
+		t.Errorf("filteredSeqScans() = %+v, the table size should be left to the statistics", scans)
+	}
+
+	if _, err := filteredSeqScans(json.RawMessage(`{`)); err == nil {
+		t.Error("filteredSeqScans() expected an error for an invalid plan")
+	}
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// Heartbeat pings a connection while the client is busy with something else
+// (finishing a compressed file, draining buffered output), so that idle-session
+// killers such as idle_session_timeout, connection poolers or firewalls do not
+// terminate the session in the meantime.
+type Heartbeat struct {
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// StartHeartbeat pings conn every interval until Stop is called. conn must not be
+// used by anything else in between. A zero interval or a nil conn disables the
+// heartbeat; the returned value can still be stopped.
+func StartHeartbeat(conn *pgx.Conn, interval time.Duration) *Heartbeat {
+	if conn == nil || interval <= 0 {
+		return nil
+	}
+
+	h := &Heartbeat{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+				if conn.IsClosed() {
+					return // e.g. after a cancelled query
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				err := conn.Ping(ctx)
+				cancel()
+				if err != nil {
+					logger.Warn("Keepalive ping failed, giving up on keepalives: %v", err)
+					return
+				}
+				logger.Debug("Keepalive ping sent")
+			}
+		}
+	}()
+	return h
+}
+
+// Stop ends the heartbeat and waits for a ping in flight, after which the
+// connection can be used again. It is safe to call on a nil Heartbeat and more than once.
+func (h *Heartbeat) Stop() {
+	if h == nil {
+		return
+	}
+	h.once.Do(func() { close(h.stop) })
+	<-h.done
+}
+
+// keepAliveRows starts a heartbeat once the last row has been read, while the
+// exporter finishes its output.
+type keepAliveRows struct {
+	pgx.Rows
+	conn      *pgx.Conn
+	interval  time.Duration
+	heartbeat *Heartbeat
+}
+
+func (r *keepAliveRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	// The result is consumed and the connection is idle from now on
+	if r.heartbeat == nil {
+		r.heartbeat = StartHeartbeat(r.conn, r.interval)
+	}
+	return false
+}
+
+// KeepAliveAfterRows wraps rows read from conn so that conn is pinged every interval
+// once the last row has been read. The returned function stops the pings; it must
+// be called before conn is used again.
+func KeepAliveAfterRows(rows pgx.Rows, conn *pgx.Conn, interval time.Duration) (pgx.Rows, func()) {
+	if interval <= 0 {
+		return rows, func() {}
+	}
+	r := &keepAliveRows{Rows: rows, conn: conn, interval: interval}
+	return r, func() { r.heartbeat.Stop() }
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStartHeartbeatDisabled(t *testing.T) {
+	if h := StartHeartbeat(nil, time.Second); h != nil {
+		t.Error("StartHeartbeat() without connection should be disabled")
+	}
+	var h *Heartbeat
+	h.Stop() // must not panic
+
+	rows, stop := KeepAliveAfterRows(nil, nil, 0)
+	if rows != nil {
+		t.Error("KeepAliveAfterRows() with a zero interval should return rows unchanged")
+	}
+	stop()
+}
+
+// TestKeepAliveAfterRows requires a running PostgreSQL instance (DB_TEST_URL).
+func TestKeepAliveAfterRows(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := NewStore()
+	if err := store.Open(testURL); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+
+	// Sessions idle for more than 200ms are terminated by the server
+	ctx := context.Background()
+	conn := store.GetConnection()
+	if _, err := conn.Exec(ctx, "SET idle_session_timeout = '200ms'"); err != nil {
+		t.Skipf("idle_session_timeout not supported: %v", err)
+	}
+
+	rows, err := store.ExecuteQuery(ctx, "SELECT generate_series(1, 10)")
+	if err != nil {
+		t.Fatalf("ExecuteQuery() failed: %v", err)
+	}
+	rows, stop := KeepAliveAfterRows(rows, conn, 50*time.Millisecond)
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if count != 10 {
+		t.Fatalf("read %d rows, want 10", count)
+	}
+
+	time.Sleep(500 * time.Millisecond) // a slow output
+	stop()
+
+	if err := conn.Ping(ctx); err != nil {
+		t.Errorf("connection was terminated despite the keepalives: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// KeysTable is the name of the temporary table loaded by LoadKeysTable.
+const KeysTable = "pgxport_keys"
+
+// typeNamePattern limits key column types to plain type names such as bigint,
+// uuid, varchar(20) or timestamp with time zone.
+var typeNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ]*(\(\d+(,\s*\d+)?\))?(\[\])?$`)
+
+// KeyColumn is a column of the keys table.
+type KeyColumn struct {
+	Name string
+	Type string
+}
+
+// ValidTypeName reports whether t can be used as the type of a key column.
+func ValidTypeName(t string) bool {
+	return typeNamePattern.MatchString(strings.TrimSpace(t))
+}
+
+// LoadKeysTable creates the temporary table KeysTable with columns and fills it
+// with the CSV read from r, whose first line is a header. The table is analyzed so
+// that the planner can choose a good join strategy. It is dropped when the
+// connection closes.
+func LoadKeysTable(ctx context.Context, store Store, columns []KeyColumn, r io.Reader) (int64, error) {
+	conn := store.GetConnection()
+	if conn == nil {
+		return 0, fmt.Errorf("no connection to database")
+	}
+
+	defs := make([]string, len(columns))
+	for i, c := range columns {
+		if !ValidTypeName(c.Type) {
+			return 0, fmt.Errorf("invalid type %q for key column %s", c.Type, c.Name)
+		}
+		defs[i] = pgx.Identifier{c.Name}.Sanitize() + " " + c.Type
+	}
+
+	start := time.Now()
+	create := fmt.Sprintf("CREATE TEMPORARY TABLE %s (%s)", KeysTable, strings.Join(defs, ", "))
+	logger.Debug("Creating keys table: %s", create)
+	if _, err := conn.Exec(ctx, create); err != nil {
+		return 0, fmt.Errorf("unable to create %s: %w", KeysTable, err)
+	}
+
+	tag, err := conn.PgConn().CopyFrom(ctx, r, fmt.Sprintf("COPY %s FROM STDIN WITH CSV HEADER", KeysTable))
+	if err != nil {
+		return 0, fmt.Errorf("unable to load %s: %w", KeysTable, err)
+	}
+
+	if _, err := conn.Exec(ctx, "ANALYZE "+KeysTable); err != nil {
+		return 0, fmt.Errorf("unable to analyze %s: %w", KeysTable, err)
+	}
+
+	logger.Debug("Loaded %d keys into %s in %v", tag.RowsAffected(), KeysTable, time.Since(start))
+	return tag.RowsAffected(), nil
+}
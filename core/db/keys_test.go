@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidTypeName(t *testing.T) {
+	for _, name := range []string{"bigint", "uuid", "varchar(20)", "numeric(12, 2)", "timestamp with time zone", "text[]"} {
+		if !ValidTypeName(name) {
+			t.Errorf("ValidTypeName(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"", "int; DROP TABLE x", "text)", "int -- comment", "1abc"} {
+		if ValidTypeName(name) {
+			t.Errorf("ValidTypeName(%q) = true, want false", name)
+		}
+	}
+}
+
+// TestLoadKeysTable requires a running PostgreSQL instance (DB_TEST_URL).
+func TestLoadKeysTable(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := NewStore()
+	if err := store.Open(testURL); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	columns := []KeyColumn{{Name: "id", Type: "bigint"}, {Name: "Label", Type: "text"}}
+	n, err := LoadKeysTable(ctx, store, columns, strings.NewReader("id,Label\n3,three\n7,\"se,ven\"\n"))
+	if err != nil {
+		t.Fatalf("LoadKeysTable() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("LoadKeysTable() = %d rows, want 2", n)
+	}
+
+	var count int
+	err = store.GetConnection().QueryRow(ctx,
+		`SELECT count(*) FROM generate_series(1, 10) x JOIN pgxport_keys k ON k.id = x WHERE k."Label" IS NOT NULL`).Scan(&count)
+	if err != nil {
+		t.Fatalf("join on %s failed: %v", KeysTable, err)
+	}
+	if count != 2 {
+		t.Errorf("join returned %d rows, want 2", count)
+	}
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FeatureAdvisoryLocks is pg_try_advisory_lock and friends.
+var FeatureAdvisoryLocks = Feature{Name: "advisory locks", MinVersion: 80200}
+
+// AdvisoryLockKey returns the advisory lock key of a lock name: the first 8 bytes
+// of its SHA-256 hash, so that every pgxport process derives the same key.
+func AdvisoryLockKey(name string) int64 {
+	sum := sha256.Sum256([]byte(name))
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+// AdvisoryLock is a session-level advisory lock held by a connection.
+type AdvisoryLock struct {
+	conn *pgx.Conn
+	Name string
+	Key  int64
+}
+
+// TryAdvisoryLock takes the advisory lock of name without waiting. When another
+// session holds it, it returns a nil lock and the backend pid of that session, if
+// visible. The lock is released by Release, or when the connection is closed.
+func TryAdvisoryLock(ctx context.Context, store Store, name string) (*AdvisoryLock, int, error) {
+	conn := store.GetConnection()
+	if conn == nil {
+		return nil, 0, fmt.Errorf("no connection to database")
+	}
+	if err := store.Server().Require(FeatureAdvisoryLocks); err != nil {
+		return nil, 0, err
+	}
+
+	key := AdvisoryLockKey(name)
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		return nil, 0, fmt.Errorf("unable to take advisory lock %q: %w", name, err)
+	}
+	if acquired {
+		return &AdvisoryLock{conn: conn, Name: name, Key: key}, 0, nil
+	}
+
+	// A bigint key is stored as its high and low 32 bits, with objsubid 1
+	var holder int
+	err := conn.QueryRow(ctx, `SELECT pid FROM pg_locks
+WHERE locktype = 'advisory' AND granted AND objsubid = 1
+  AND classid::bigint = ($1::bigint >> 32) & 4294967295 AND objid::bigint = $1::bigint & 4294967295
+LIMIT 1`, key).Scan(&holder)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, 0, fmt.Errorf("unable to look up the holder of advisory lock %q: %w", name, err)
+	}
+	return nil, holder, nil
+}
+
+// Release releases the lock.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	var released bool
+	if err := l.conn.QueryRow(ctx, "SELECT pg_advisory_unlock($1)", l.Key).Scan(&released); err != nil {
+		return fmt.Errorf("unable to release advisory lock %q: %w", l.Name, err)
+	}
+	if !released {
+		return fmt.Errorf("advisory lock %q was not held", l.Name)
+	}
+	return nil
+}
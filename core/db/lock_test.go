@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAdvisoryLockKey(t *testing.T) {
+	key := AdvisoryLockKey("nightly-users-feed")
+	if key != AdvisoryLockKey("nightly-users-feed") {
+		t.Error("AdvisoryLockKey() should be deterministic")
+	}
+	if key == AdvisoryLockKey("nightly-orders-feed") {
+		t.Error("AdvisoryLockKey() should differ between names")
+	}
+}
+
+func TestTryAdvisoryLockWithoutConnection(t *testing.T) {
+	_, _, err := TryAdvisoryLock(context.Background(), NewStore(), "nightly-users-feed")
+	if err == nil || !strings.Contains(err.Error(), "no connection") {
+		t.Errorf("expected a no connection error, got %v", err)
+	}
+}
+
+// TestTryAdvisoryLock requires a running PostgreSQL instance (DB_TEST_URL).
+func TestTryAdvisoryLock(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	first, second := NewStore(), NewStore()
+	for _, store := range []Store{first, second} {
+		if err := store.Open(testURL); err != nil {
+			t.Fatalf("Open() failed: %v", err)
+		}
+		defer store.Close()
+	}
+
+	ctx := context.Background()
+	lock, _, err := TryAdvisoryLock(ctx, first, "pgxport-lock-test")
+	if err != nil || lock == nil {
+		t.Fatalf("TryAdvisoryLock() = %v, %v", lock, err)
+	}
+
+	var pid int
+	if err := first.GetConnection().QueryRow(ctx, "SELECT pg_backend_pid()").Scan(&pid); err != nil {
+		t.Fatal(err)
+	}
+	other, holder, err := TryAdvisoryLock(ctx, second, "pgxport-lock-test")
+	if err != nil || other != nil || holder != pid {
+		t.Errorf("TryAdvisoryLock() while held = %v, holder %d (want %d), %v", other, holder, pid, err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	other, _, err = TryAdvisoryLock(ctx, second, "pgxport-lock-test")
+	if err != nil || other == nil {
+		t.Fatalf("TryAdvisoryLock() after release = %v, %v", other, err)
+	}
+	other.Release(ctx)
+}
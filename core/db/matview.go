@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// FeatureRefreshConcurrently is REFRESH MATERIALIZED VIEW CONCURRENTLY.
+var FeatureRefreshConcurrently = Feature{Name: "REFRESH MATERIALIZED VIEW CONCURRENTLY", MinVersion: 90400}
+
+// RefreshMaterializedView refreshes the named materialized view. With concurrently,
+// readers are not blocked, but the view needs a unique index and must already be populated.
+func RefreshMaterializedView(ctx context.Context, store Store, name string, concurrently bool) error {
+	conn := store.GetConnection()
+	if conn == nil {
+		return fmt.Errorf("no connection to database")
+	}
+
+	var qualified, kind string
+	err := conn.QueryRow(ctx, "SELECT c.oid::regclass::text, c.relkind::text FROM pg_class c WHERE c.oid = to_regclass($1)", name).
+		Scan(&qualified, &kind)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("materialized view %s does not exist", name)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to look up materialized view %s: %w", name, err)
+	}
+	if kind != "m" {
+		return fmt.Errorf("%s is not a materialized view", qualified)
+	}
+
+	stmt := "REFRESH MATERIALIZED VIEW " + qualified
+	if concurrently {
+		if err := store.Server().Require(FeatureRefreshConcurrently); err != nil {
+			return err
+		}
+		stmt = "REFRESH MATERIALIZED VIEW CONCURRENTLY " + qualified
+	}
+
+	logger.Info("Refreshing materialized view %s...", qualified)
+	start := time.Now()
+	if _, err := conn.Exec(ctx, stmt); err != nil {
+		return fmt.Errorf("refresh of materialized view %s failed: %w", qualified, err)
+	}
+	logger.Debug("Materialized view %s refreshed in %v", qualified, time.Since(start))
+	return nil
+}
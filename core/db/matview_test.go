@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRefreshMaterializedViewWithoutConnection(t *testing.T) {
+	err := RefreshMaterializedView(context.Background(), NewStore(), "daily_sales", false)
+	if err == nil || !strings.Contains(err.Error(), "no connection") {
+		t.Errorf("expected a no connection error, got %v", err)
+	}
+}
+
+// TestRefreshMaterializedView requires a running PostgreSQL instance (DB_TEST_URL).
+func TestRefreshMaterializedView(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := NewStore()
+	if err := store.Open(testURL); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	conn := store.GetConnection()
+	for _, stmt := range []string{
+		"CREATE TEMP TABLE pgxport_mv_source (id int PRIMARY KEY)",
+		"CREATE MATERIALIZED VIEW pgxport_mv_test AS SELECT id FROM pgxport_mv_source",
+		"CREATE UNIQUE INDEX ON pgxport_mv_test (id)",
+		"INSERT INTO pgxport_mv_source VALUES (1), (2), (3)",
+	} {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	defer conn.Exec(ctx, "DROP MATERIALIZED VIEW IF EXISTS pgxport_mv_test")
+
+	for _, concurrently := range []bool{false, true} {
+		if err := RefreshMaterializedView(ctx, store, "pgxport_mv_test", concurrently); err != nil {
+			t.Fatalf("RefreshMaterializedView(concurrently=%v) error: %v", concurrently, err)
+		}
+	}
+
+	var count int
+	if err := conn.QueryRow(ctx, "SELECT count(*) FROM pgxport_mv_test").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("materialized view has %d rows after refresh, want 3", count)
+	}
+
+	err := RefreshMaterializedView(ctx, store, "pgxport_mv_source", false)
+	if err == nil || !strings.Contains(err.Error(), "is not a materialized view") {
+		t.Errorf("expected an error for a plain table, got %v", err)
+	}
+	err = RefreshMaterializedView(ctx, store, "pgxport_missing_view", false)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected an error for a missing view, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// paramNamePattern matches the names accepted for query parameters.
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// dollarTagPattern matches the opening tag of a dollar-quoted string ($$ or $tag$).
+var dollarTagPattern = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// ValidParamName reports whether name can be used as :name in a query.
+func ValidParamName(name string) bool {
+	return paramNamePattern.MatchString(name)
+}
+
+// BindNamed replaces the :name references of query with positional parameters,
+// names[0] becoming $1, names[1] $2 and so on. String literals, quoted identifiers,
+// comments and :: casts are left alone, so "WHERE id = ANY(:ids::int[])" works.
+// Every name must be referenced at least once.
+func BindNamed(query string, names []string) (string, error) {
+	if len(names) == 0 {
+		return query, nil
+	}
+
+	position := make(map[string]int, len(names))
+	for i, n := range names {
+		position[n] = i + 1
+	}
+	used := make(map[string]bool, len(names))
+
+	var b strings.Builder
+	for i := 0; i < len(query); {
+		rest := query[i:]
+		switch {
+		case rest[0] == '\'' || rest[0] == '"':
+			end := quotedEnd(rest, rest[0])
+			b.WriteString(rest[:end])
+			i += end
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			b.WriteString(rest[:end])
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := blockCommentEnd(rest)
+			b.WriteString(rest[:end])
+			i += end
+		case rest[0] == '$' && dollarTagPattern.MatchString(rest):
+			tag := dollarTagPattern.FindString(rest)
+			end := len(rest)
+			if close := strings.Index(rest[len(tag):], tag); close >= 0 {
+				end = len(tag) + close + len(tag)
+			}
+			b.WriteString(rest[:end])
+			i += end
+		case strings.HasPrefix(rest, "::"):
+			b.WriteString("::")
+			i += 2
+		case rest[0] == ':' && (i == 0 || !isIdentChar(query[i-1])):
+			name := identPrefix(rest[1:])
+			if pos, ok := position[name]; ok {
+				fmt.Fprintf(&b, "$%d", pos)
+				used[name] = true
+				i += 1 + len(name)
+				continue
+			}
+			b.WriteByte(':')
+			i++
+		default:
+			b.WriteByte(rest[0])
+			i++
+		}
+	}
+
+	for _, n := range names {
+		if !used[n] {
+			return "", fmt.Errorf("parameter %q is not used in the query (reference it as :%s)", n, n)
+		}
+	}
+	return b.String(), nil
+}
+
+// ArrayLiteral returns the PostgreSQL array literal holding values. It is sent as a
+// text parameter, so the server converts the elements to the type it expects
+// (integer, uuid, date...) and no value is ever interpreted as SQL.
+func ArrayLiteral(values []string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('"')
+		for j := 0; j < len(v); j++ {
+			if v[j] == '"' || v[j] == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(v[j])
+		}
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// quotedEnd returns the length of the quoted string or identifier at the start of s,
+// doubled quotes being part of it.
+func quotedEnd(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// blockCommentEnd returns the length of the (possibly nested) comment at the start of s.
+func blockCommentEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s)-1; i++ {
+		switch {
+		case s[i] == '/' && s[i+1] == '*':
+			depth++
+			i++
+		case s[i] == '*' && s[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+func identPrefix(s string) string {
+	i := 0
+	for i < len(s) && isIdentChar(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
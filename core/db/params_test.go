@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBindNamed(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		names    []string
+		expected string
+	}{
+		{
+			name:     "single parameter",
+			query:    "SELECT * FROM orders WHERE id = ANY(:ids)",
+			names:    []string{"ids"},
+			expected: "SELECT * FROM orders WHERE id = ANY($1)",
+		},
+		{
+			name:     "casts and repeated references",
+			query:    "SELECT id::text FROM t WHERE id = ANY(:ids::int[]) OR parent = ANY(:ids::int[]) AND sku = ANY(:skus)",
+			names:    []string{"skus", "ids"},
+			expected: "SELECT id::text FROM t WHERE id = ANY($2::int[]) OR parent = ANY($2::int[]) AND sku = ANY($1)",
+		},
+		{
+			name:     "literals and comments are left alone",
+			query:    "SELECT ':ids', \":ids\", $$ :ids $$, $f$ :ids $f$ -- :ids\nFROM t /* :ids /* :ids */ */ WHERE id = ANY(:ids)",
+			names:    []string{"ids"},
+			expected: "SELECT ':ids', \":ids\", $$ :ids $$, $f$ :ids $f$ -- :ids\nFROM t /* :ids /* :ids */ */ WHERE id = ANY($1)",
+		},
+		{
+			name:     "undeclared names and array slices",
+			query:    "SELECT a[1:2], :other FROM t WHERE k = ANY(:keys)",
+			names:    []string{"keys"},
+			expected: "SELECT a[1:2], :other FROM t WHERE k = ANY($1)",
+		},
+		{
+			name:     "longer name with common prefix",
+			query:    "SELECT * FROM t WHERE a = ANY(:ids_old) AND b = ANY(:ids)",
+			names:    []string{"ids"},
+			expected: "SELECT * FROM t WHERE a = ANY(:ids_old) AND b = ANY($1)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BindNamed(tt.query, tt.names)
+			if err != nil {
+				t.Fatalf("BindNamed() error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("BindNamed() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := BindNamed("SELECT ':ids'", []string{"ids"}); err == nil || !strings.Contains(err.Error(), "not used") {
+		t.Errorf("unused parameter: err = %v", err)
+	}
+}
+
+func TestArrayLiteral(t *testing.T) {
+	tests := []struct {
+		values   []string
+		expected string
+	}{
+		{values: nil, expected: `{}`},
+		{values: []string{"1", "2"}, expected: `{"1","2"}`},
+		{values: []string{`a"b`, `c\d`, "e,f}", "NULL"}, expected: `{"a\"b","c\\d","e,f}","NULL"}`},
+	}
+	for _, tt := range tests {
+		if got := ArrayLiteral(tt.values); got != tt.expected {
+			t.Errorf("ArrayLiteral(%q) = %s, want %s", tt.values, got, tt.expected)
+		}
+	}
+}
+
+// TestArrayLiteralParameter requires a running PostgreSQL instance (DB_TEST_URL).
+func TestArrayLiteralParameter(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := NewStore()
+	if err := store.Open(testURL); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+
+	query, err := BindNamed("SELECT x FROM generate_series(1, 10) x WHERE x = ANY(:ids) ORDER BY x", []string{"ids"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := store.ExecuteQuery(context.Background(), query, ArrayLiteral([]string{"3", "7", "42"}))
+	if err != nil {
+		t.Fatalf("ExecuteQuery() error: %v", err)
+	}
+	defer rows.Close()
+
+	var got []int32
+	for rows.Next() {
+		var x int32
+		if err := rows.Scan(&x); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, x)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != 3 || got[1] != 7 {
+		t.Errorf("rows = %v, want [3 7]", got)
+	}
+}
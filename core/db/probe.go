@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// ErrTLSNotSupported is returned by ProbeTLS when the server refuses TLS connections.
+var ErrTLSNotSupported = errors.New("server does not accept TLS connections")
+
+// sslRequest is the PostgreSQL SSLRequest message: length 8 followed by the code 80877103.
+var sslRequest = []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}
+
+// ProbeTLS connects to addr, asks the server to switch to TLS the way a PostgreSQL
+// client does, and performs the handshake with tlsConfig. No authentication is attempted.
+func ProbeTLS(ctx context.Context, addr string, tlsConfig *tls.Config) (tls.ConnectionState, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(sslRequest); err != nil {
+		return tls.ConnectionState{}, fmt.Errorf("error sending TLS request: %w", err)
+	}
+
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return tls.ConnectionState{}, fmt.Errorf("error reading TLS response: %w", err)
+	}
+	switch reply[0] {
+	case 'S':
+	case 'N':
+		return tls.ConnectionState{}, ErrTLSNotSupported
+	default:
+		return tls.ConnectionState{}, fmt.Errorf("unexpected response %q to TLS request: is this a PostgreSQL server?", reply[0])
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	return tlsConn.ConnectionState(), nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeServer answers a single SSLRequest with reply and, for 'S', completes a TLS handshake.
+func fakeServer(t *testing.T, reply byte) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		req := make([]byte, len(sslRequest))
+		if _, err := io.ReadFull(conn, req); err != nil || !bytes.Equal(req, sslRequest) {
+			return
+		}
+		conn.Write([]byte{reply})
+		if reply == 'S' {
+			tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}})
+			tlsConn.Handshake()
+			tlsConn.Close()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func selfSignedCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Errorf("generate key: %v", err)
+		return tls.Certificate{}
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Errorf("create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestProbeTLS(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("accepted", func(t *testing.T) {
+		addr := fakeServer(t, 'S')
+		state, err := ProbeTLS(ctx, addr, &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("ProbeTLS() error: %v", err)
+		}
+		if !state.HandshakeComplete {
+			t.Error("handshake not complete")
+		}
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		addr := fakeServer(t, 'N')
+		if _, err := ProbeTLS(ctx, addr, &tls.Config{InsecureSkipVerify: true}); !errors.Is(err, ErrTLSNotSupported) {
+			t.Errorf("ProbeTLS() error = %v, want ErrTLSNotSupported", err)
+		}
+	})
+
+	t.Run("certificate rejected", func(t *testing.T) {
+		addr := fakeServer(t, 'S')
+		if _, err := ProbeTLS(ctx, addr, &tls.Config{ServerName: "localhost"}); err == nil {
+			t.Error("ProbeTLS() expected error for untrusted certificate")
+		}
+	})
+
+	t.Run("not postgres", func(t *testing.T) {
+		addr := fakeServer(t, 'H')
+		if _, err := ProbeTLS(ctx, addr, &tls.Config{}); err == nil {
+			t.Error("ProbeTLS() expected error for unexpected reply")
+		}
+	})
+}
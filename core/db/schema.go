@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ResultColumn describes a column of a query result for code generators.
+// Nullable, Precision, Scale and Length are nil when they cannot be derived.
+type ResultColumn struct {
+	Name      string
+	TypeOID   uint32
+	TypeName  string // base type, e.g. "numeric"
+	Type      string // type with its modifier, e.g. "numeric(10,2)"
+	Nullable  *bool
+	Precision *int
+	Scale     *int
+	Length    *int
+	Table     *Table
+	Column    string // source column, empty for computed columns
+}
+
+// DescribeResult describes query without running it. The nullability of a column
+// is only known when it is read straight from a table column: NOT NULL columns
+// are reported as not nullable, although an outer join may still produce NULLs.
+func DescribeResult(ctx context.Context, store Store, query string) ([]ResultColumn, error) {
+	conn := store.GetConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no connection to database")
+	}
+
+	fields, err := DescribeQuery(ctx, conn, query)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]ResultColumn, len(fields))
+	typeOIDs := make([]uint32, len(fields))
+	typeMods := make([]int32, len(fields))
+	for i, fd := range fields {
+		cols[i].Name = fd.Name
+		cols[i].TypeOID = fd.DataTypeOID
+		cols[i].Precision, cols[i].Scale, cols[i].Length = typeModifiers(fd.DataTypeOID, fd.TypeModifier)
+		typeOIDs[i] = fd.DataTypeOID
+		typeMods[i] = fd.TypeModifier
+	}
+
+	rows, err := conn.Query(ctx, `SELECT format_type(t, NULL), format_type(t, NULLIF(m, -1))
+FROM unnest($1::oid[], $2::int4[]) WITH ORDINALITY AS u(t, m, i)
+ORDER BY i`, typeOIDs, typeMods)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read column types: %w", err)
+	}
+	i := 0
+	for rows.Next() {
+		if err := rows.Scan(&cols[i].TypeName, &cols[i].Type); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("unable to read column types: %w", err)
+		}
+		i++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read column types: %w", err)
+	}
+
+	relids := sourceRelations(fields)
+	if len(relids) == 0 {
+		return cols, nil
+	}
+
+	type attribute struct {
+		relid  uint32
+		attnum uint16
+	}
+	type source struct {
+		table   Table
+		column  string
+		notNull bool
+	}
+	rows, err = conn.Query(ctx, `SELECT a.attrelid, a.attnum, n.nspname, c.relname, a.attname, a.attnotnull
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE a.attrelid = ANY($1) AND a.attnum > 0 AND NOT a.attisdropped`, relids)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read column nullability: %w", err)
+	}
+	byAttribute := map[attribute]source{}
+	for rows.Next() {
+		var a attribute
+		var s source
+		if err := rows.Scan(&a.relid, &a.attnum, &s.table.Schema, &s.table.Name, &s.column, &s.notNull); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("unable to read column nullability: %w", err)
+		}
+		byAttribute[a] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read column nullability: %w", err)
+	}
+
+	for i, fd := range fields {
+		s, ok := byAttribute[attribute{fd.TableOID, fd.TableAttributeNumber}]
+		if !ok {
+			continue
+		}
+		nullable := !s.notNull
+		cols[i].Nullable = &nullable
+		cols[i].Table = &s.table
+		cols[i].Column = s.column
+	}
+	return cols, nil
+}
+
+// typeModifiers decodes the precision, scale and length that PostgreSQL packs into
+// the type modifier of numeric, character, bit and time columns. typmod is -1
+// when the column has no modifier, as for numeric columns without a precision.
+func typeModifiers(oid uint32, typmod int32) (precision, scale, length *int) {
+	if typmod < 0 {
+		return nil, nil, nil
+	}
+	value := func(v int32) *int {
+		n := int(v)
+		return &n
+	}
+	switch oid {
+	case pgtype.NumericOID:
+		// The scale is a signed 11-bit value since PostgreSQL 15 (numeric(2,-3))
+		s := (typmod - 4) & 0x7ff
+		if s > 1023 {
+			s -= 2048
+		}
+		return value(((typmod - 4) >> 16) & 0xffff), value(s), nil
+	case pgtype.VarcharOID, pgtype.BPCharOID:
+		return nil, nil, value(typmod - 4)
+	case pgtype.BitOID, pgtype.VarbitOID:
+		return nil, nil, value(typmod)
+	case pgtype.TimestampOID, pgtype.TimestamptzOID, pgtype.TimeOID, pgtype.TimetzOID, pgtype.IntervalOID:
+		// Interval modifiers also carry the field restriction in the upper bits,
+		// and 0xffff when only the fields are restricted
+		if p := typmod & 0xffff; p != 0xffff {
+			return value(p), nil, nil
+		}
+	}
+	return nil, nil, nil
+}
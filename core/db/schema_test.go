@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestTypeModifiers(t *testing.T) {
+	tests := []struct {
+		name                     string
+		oid                      uint32
+		typmod                   int32
+		precision, scale, length int // -1 when not derived
+	}{
+		{"numeric(10,2)", pgtype.NumericOID, 10<<16 | 2 + 4, 10, 2, -1},
+		{"numeric(2,-3)", pgtype.NumericOID, 2<<16 | 0x7fd + 4, 2, -3, -1},
+		{"numeric", pgtype.NumericOID, -1, -1, -1, -1},
+		{"varchar(20)", pgtype.VarcharOID, 24, -1, -1, 20},
+		{"char(3)", pgtype.BPCharOID, 7, -1, -1, 3},
+		{"bit(8)", pgtype.BitOID, 8, -1, -1, 8},
+		{"timestamptz(3)", pgtype.TimestamptzOID, 3, 3, -1, -1},
+		{"interval day to second(2)", pgtype.IntervalOID, 0x1c000002, 2, -1, -1},
+		{"interval day", pgtype.IntervalOID, 0x0008ffff, -1, -1, -1},
+		{"integer", pgtype.Int4OID, -1, -1, -1, -1},
+	}
+	deref := func(p *int) int {
+		if p == nil {
+			return -1
+		}
+		return *p
+	}
+	for _, tt := range tests {
+		p, s, l := typeModifiers(tt.oid, tt.typmod)
+		if deref(p) != tt.precision || deref(s) != tt.scale || deref(l) != tt.length {
+			t.Errorf("%s: got precision=%d scale=%d length=%d, want %d %d %d",
+				tt.name, deref(p), deref(s), deref(l), tt.precision, tt.scale, tt.length)
+		}
+	}
+}
+
+// TestDescribeResult requires a running PostgreSQL instance (DB_TEST_URL).
+func TestDescribeResult(t *testing.T) {
+	testURL := getTestDatabaseURL()
+	if testURL == "" {
+		t.Skip("Skipping integration test: DB_TEST_URL not set")
+	}
+
+	store := NewStore()
+	if err := store.Open(testURL); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if _, err := store.GetConnection().Exec(ctx, "CREATE TEMP TABLE pgxport_schema (id int NOT NULL, amount numeric(10,2))"); err != nil {
+		t.Fatal(err)
+	}
+
+	cols, err := DescribeResult(ctx, store, "SELECT id, amount, id + 1 AS next FROM pgxport_schema")
+	if err != nil {
+		t.Fatalf("DescribeResult() error: %v", err)
+	}
+	if len(cols) != 3 {
+		t.Fatalf("got %d columns, want 3", len(cols))
+	}
+	if id := cols[0]; id.Nullable == nil || *id.Nullable || id.TypeName != "integer" || id.Column != "id" {
+		t.Errorf("cols[0] = %+v, want a NOT NULL integer read from id", id)
+	}
+	if amount := cols[1]; amount.Nullable == nil || !*amount.Nullable || amount.Type != "numeric(10,2)" ||
+		amount.Precision == nil || *amount.Precision != 10 || amount.Scale == nil || *amount.Scale != 2 {
+		t.Errorf("cols[1] = %+v, want a nullable numeric(10,2)", amount)
+	}
+	if next := cols[2]; next.Nullable != nil || next.Table != nil {
+		t.Errorf("cols[2] = %+v, want a computed column of unknown nullability", next)
+	}
+}
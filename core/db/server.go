@@ -0,0 +1,160 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MinServerVersion is the oldest PostgreSQL release pgxport can export from (8.4).
+const MinServerVersion = 80400
+
+// Feature is a server capability that depends on the PostgreSQL version.
+type Feature struct {
+	Name       string
+	MinVersion int // in server_version_num form, e.g. 90500 for 9.5
+}
+
+var (
+	// FeatureCopyOptions is the parenthesized COPY option list, e.g. (FORMAT csv, HEADER true).
+	// Older servers only accept the legacy "WITH CSV HEADER" syntax.
+	FeatureCopyOptions = Feature{Name: "COPY option list", MinVersion: 90000}
+	FeatureLateral     = Feature{Name: "LATERAL", MinVersion: 90300}
+	FeatureJSONB       = Feature{Name: "jsonb", MinVersion: 90400}
+	FeatureTableSample = Feature{Name: "TABLESAMPLE", MinVersion: 90500}
+	FeatureSCRAM       = Feature{Name: "SCRAM-SHA-256 authentication", MinVersion: 100000}
+)
+
+// Features lists the version-dependent capabilities reported by ServerInfo.Capabilities.
+var Features = []Feature{
+	FeatureCopyOptions,
+	FeatureLateral,
+	FeatureJSONB,
+	FeatureRefreshConcurrently,
+	FeatureTableSample,
+	FeatureSCRAM,
+	FeatureIdentityColumns,
+	FeatureGeneratedColumns,
+}
+
+// queryFeatures maps SQL keywords to the feature they require.
+var queryFeatures = []struct {
+	pattern *regexp.Regexp
+	feature Feature
+}{
+	{regexp.MustCompile(`(?i)\bTABLESAMPLE\b`), FeatureTableSample},
+	{regexp.MustCompile(`(?i)\bLATERAL\b`), FeatureLateral},
+	{regexp.MustCompile(`(?i)\bJSONB`), FeatureJSONB},
+}
+
+// ServerInfo describes the PostgreSQL server a connection is attached to.
+// A zero VersionNumber means the version could not be identified; such servers are
+// assumed to support every feature.
+type ServerInfo struct {
+	Version       string // server_version as reported by the server, e.g. "16.2 (Debian 16.2-1)"
+	VersionNumber int    // server_version_num form, e.g. 160002
+	Flavor        Flavor
+}
+
+var versionPattern = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// ParseServerVersion converts a server_version string into a ServerInfo.
+// Development and beta releases ("17beta1", "16devel") are treated as the first minor release.
+func ParseServerVersion(version string) (ServerInfo, error) {
+	m := versionPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return ServerInfo{}, fmt.Errorf("cannot identify server version in %q", version)
+	}
+
+	parts := make([]int, 3)
+	for i, s := range m[1:] {
+		if s != "" {
+			parts[i], _ = strconv.Atoi(s)
+		}
+	}
+
+	num := parts[0]*10000 + parts[1]
+	if parts[0] < 10 {
+		// Before PostgreSQL 10 the major version had two components: 9.6.24 -> 90624
+		num = parts[0]*10000 + parts[1]*100 + parts[2]
+	}
+	return ServerInfo{Version: version, VersionNumber: num, Flavor: DefaultFlavor()}, nil
+}
+
+// DetectServer returns the version of the server conn is connected to.
+// The version is read from the startup parameters, so no query is sent.
+func DetectServer(conn *pgx.Conn) (ServerInfo, error) {
+	return ParseServerVersion(conn.PgConn().ParameterStatus("server_version"))
+}
+
+// Supports reports whether the server provides f.
+func (s ServerInfo) Supports(f Feature) bool {
+	if s.Flavor.lacks(f) {
+		return false
+	}
+	return s.Flavor.IgnoreVersion || s.VersionNumber == 0 || s.VersionNumber >= f.MinVersion
+}
+
+// WithFlavor returns a copy of s describing an engine of the given flavor.
+func (s ServerInfo) WithFlavor(f Flavor) ServerInfo {
+	s.Flavor = f
+	return s
+}
+
+// Require returns an error explaining the required version if the server lacks f.
+func (s ServerInfo) Require(f Feature) error {
+	if s.Supports(f) {
+		return nil
+	}
+	if s.Flavor.lacks(f) {
+		return fmt.Errorf("%s is not supported by %s", f.Name, s.Flavor.Title)
+	}
+	return fmt.Errorf("%s requires PostgreSQL %s or later, but the server runs %s",
+		f.Name, FormatVersion(f.MinVersion), s.Version)
+}
+
+// CheckSupported returns an error if the server is older than its flavor's minimum version.
+func (s ServerInfo) CheckSupported() error {
+	if s.Flavor.IgnoreVersion || s.VersionNumber == 0 {
+		return nil
+	}
+	if s.VersionNumber < s.Flavor.MinVersion {
+		return fmt.Errorf("%s %s is not supported (minimum is %s)", s.Flavor.Title, s.Version, FormatVersion(s.Flavor.MinVersion))
+	}
+	return nil
+}
+
+// CheckQuery returns an error if query uses syntax the server does not understand,
+// so that the export fails before any output is written.
+func (s ServerInfo) CheckQuery(query string) error {
+	for _, qf := range queryFeatures {
+		if qf.pattern.MatchString(query) {
+			if err := s.Require(qf.feature); err != nil {
+				return fmt.Errorf("query uses %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Capabilities returns the names of the version-dependent features the server supports.
+func (s ServerInfo) Capabilities() []string {
+	var names []string
+	for _, f := range Features {
+		if s.Supports(f) {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// FormatVersion renders a server_version_num value as a release number, e.g. 90500 -> "9.5".
+func FormatVersion(num int) string {
+	if num >= 100000 {
+		return strconv.Itoa(num / 10000)
+	}
+	return fmt.Sprintf("%d.%d", num/10000, num/100%100)
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected int
+	}{
+		{version: "16.2", expected: 160002},
+		{version: "16.2 (Debian 16.2-1.pgdg120+2)", expected: 160002},
+		{version: "10.23", expected: 100023},
+		{version: "17beta1", expected: 170000},
+		{version: "9.6.24", expected: 90624},
+		{version: "9.4.26", expected: 90426},
+		{version: "8.4.22", expected: 80422},
+		{version: "9.5", expected: 90500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			info, err := ParseServerVersion(tt.version)
+			if err != nil {
+				t.Fatalf("ParseServerVersion(%q) error: %v", tt.version, err)
+			}
+			if info.VersionNumber != tt.expected {
+				t.Errorf("VersionNumber = %d, want %d", info.VersionNumber, tt.expected)
+			}
+		})
+	}
+
+	if _, err := ParseServerVersion("unknown"); err == nil {
+		t.Error("ParseServerVersion(\"unknown\") should fail")
+	}
+}
+
+func TestServerInfoCheckQuery(t *testing.T) {
+	old := mustParseServerVersion(t, "9.4.26")
+	recent := mustParseServerVersion(t, "16.2")
+
+	query := "SELECT * FROM events TABLESAMPLE SYSTEM (1)"
+	err := old.CheckQuery(query)
+	if err == nil {
+		t.Fatal("CheckQuery() on 9.4 should reject TABLESAMPLE")
+	}
+	if !strings.Contains(err.Error(), "TABLESAMPLE requires PostgreSQL 9.5 or later, but the server runs 9.4.26") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := recent.CheckQuery(query); err != nil {
+		t.Errorf("CheckQuery() on 16 returned %v", err)
+	}
+	if err := old.CheckQuery("SELECT payload::json FROM events"); err != nil {
+		t.Errorf("CheckQuery() rejected a query without version-dependent syntax: %v", err)
+	}
+	if err := (ServerInfo{Version: "unknown"}).CheckQuery(query); err != nil {
+		t.Errorf("unknown server versions should not be rejected: %v", err)
+	}
+}
+
+func TestServerInfoCheckSupported(t *testing.T) {
+	if err := mustParseServerVersion(t, "8.3.23").CheckSupported(); err == nil {
+		t.Error("CheckSupported() should reject 8.3")
+	}
+	if err := mustParseServerVersion(t, "8.4.22").CheckSupported(); err != nil {
+		t.Errorf("CheckSupported() rejected 8.4: %v", err)
+	}
+}
+
+func TestServerInfoCapabilities(t *testing.T) {
+	caps := mustParseServerVersion(t, "9.4.26").Capabilities()
+	got := strings.Join(caps, ",")
+	if got != "COPY option list,LATERAL,jsonb,REFRESH MATERIALIZED VIEW CONCURRENTLY" {
+		t.Errorf("Capabilities() = %s", got)
+	}
+}
+
+func TestFormatVersion(t *testing.T) {
+	for num, expected := range map[int]string{90500: "9.5", 80400: "8.4", 100000: "10", 160002: "16"} {
+		if got := FormatVersion(num); got != expected {
+			t.Errorf("FormatVersion(%d) = %q, want %q", num, got, expected)
+		}
+	}
+}
+
+func TestServerInfoFlavor(t *testing.T) {
+	greenplum, _ := ParseFlavor(FlavorGreenplum)
+	gp5 := mustParseServerVersion(t, "8.3.23").WithFlavor(greenplum)
+	if err := gp5.CheckSupported(); err != nil {
+		t.Errorf("Greenplum 5 (8.3) should be accepted: %v", err)
+	}
+	if gp5.Supports(FeatureCopyOptions) {
+		t.Error("Greenplum 5 should use the legacy COPY syntax")
+	}
+
+	cockroach, _ := ParseFlavor(FlavorCockroach)
+	crdb := mustParseServerVersion(t, "13.0.0").WithFlavor(cockroach)
+	if !crdb.Supports(FeatureJSONB) || !crdb.Supports(FeatureLateral) {
+		t.Error("CockroachDB supports jsonb and LATERAL")
+	}
+	err := crdb.CheckQuery("SELECT * FROM t TABLESAMPLE SYSTEM (10)")
+	if err == nil || !strings.Contains(err.Error(), "TABLESAMPLE is not supported by CockroachDB") {
+		t.Errorf("CheckQuery() = %v", err)
+	}
+}
+
+func TestParseFlavor(t *testing.T) {
+	if f, err := ParseFlavor(""); err != nil || f.Name != FlavorPostgres {
+		t.Errorf("ParseFlavor(\"\") = %v, %v", f.Name, err)
+	}
+	if f, err := ParseFlavor(" Timescale "); err != nil || f.Name != FlavorTimescale {
+		t.Errorf("ParseFlavor(timescale) = %v, %v", f.Name, err)
+	}
+	if _, err := ParseFlavor("mysql"); err == nil {
+		t.Error("ParseFlavor(mysql) should fail")
+	}
+}
+
+func mustParseServerVersion(t *testing.T, version string) ServerInfo {
+	t.Helper()
+	info, err := ParseServerVersion(version)
+	if err != nil {
+		t.Fatalf("ParseServerVersion(%q): %v", version, err)
+	}
+	return info
+}
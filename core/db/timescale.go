@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Chunk is a TimescaleDB hypertable chunk and the time range it covers.
+type Chunk struct {
+	Schema     string
+	Name       string
+	TimeColumn string
+	// Start and End bound the chunk as [Start, End). Hypertables partitioned on an
+	// integer column use StartInt and EndInt instead.
+	Start, End       time.Time
+	StartInt, EndInt int64
+	Integer          bool
+}
+
+// Hypertable identifies a TimescaleDB hypertable.
+type Hypertable struct {
+	Schema string
+	Name   string
+}
+
+const hypertableQuery = `SELECT h.hypertable_schema, h.hypertable_name
+FROM timescaledb_information.hypertables h
+WHERE format('%I.%I', h.hypertable_schema, h.hypertable_name)::regclass = to_regclass($1)`
+
+const chunksQuery = `SELECT c.chunk_schema, c.chunk_name, d.column_name,
+       c.range_start, c.range_end, c.range_start_integer, c.range_end_integer
+FROM timescaledb_information.chunks c
+JOIN timescaledb_information.dimensions d
+  ON d.hypertable_schema = c.hypertable_schema
+ AND d.hypertable_name = c.hypertable_name
+ AND d.dimension_number = 1
+WHERE c.hypertable_schema = $1 AND c.hypertable_name = $2
+ORDER BY c.range_start, c.range_start_integer, c.chunk_name`
+
+// ListChunks returns the chunks of hypertable ordered by time. The name may be
+// schema-qualified and is resolved with the connection's search_path.
+func ListChunks(ctx context.Context, conn *pgx.Conn, hypertable string) (Hypertable, []Chunk, error) {
+	var ht Hypertable
+	err := conn.QueryRow(ctx, hypertableQuery, hypertable).Scan(&ht.Schema, &ht.Name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ht, nil, fmt.Errorf("%s is not a TimescaleDB hypertable", hypertable)
+	}
+	if err != nil {
+		return ht, nil, timescaleError(err)
+	}
+
+	rows, err := conn.Query(ctx, chunksQuery, ht.Schema, ht.Name)
+	if err != nil {
+		return ht, nil, timescaleError(err)
+	}
+	defer rows.Close()
+
+	var chunks []Chunk
+	for rows.Next() {
+		var c Chunk
+		var start, end *time.Time
+		var startInt, endInt *int64
+		if err := rows.Scan(&c.Schema, &c.Name, &c.TimeColumn, &start, &end, &startInt, &endInt); err != nil {
+			return ht, nil, fmt.Errorf("error reading chunk list: %w", err)
+		}
+		switch {
+		case start != nil && end != nil:
+			c.Start, c.End = *start, *end
+		case startInt != nil && endInt != nil:
+			c.StartInt, c.EndInt, c.Integer = *startInt, *endInt, true
+		default:
+			return ht, nil, fmt.Errorf("chunk %s.%s has no time range", c.Schema, c.Name)
+		}
+		chunks = append(chunks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return ht, nil, timescaleError(err)
+	}
+	return ht, chunks, nil
+}
+
+func timescaleError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == "3F000" || pgErr.Code == "42P01") {
+		return fmt.Errorf("chunk listing requires the TimescaleDB 2.x extension in this database: %w", err)
+	}
+	return fmt.Errorf("unable to list hypertable chunks: %w", err)
+}
+
+// Filter returns a WHERE condition selecting the rows of the chunk.
+//
+// Time bounds are rendered as untyped literals in UTC so that they take the type of
+// the time column: TimescaleDB stores ranges of timestamp columns as UTC wall time.
+func (c Chunk) Filter() string {
+	col := formatters.QuoteIdent(c.TimeColumn)
+	if c.Integer {
+		return fmt.Sprintf("%s >= %s AND %s < %s", col, strconv.FormatInt(c.StartInt, 10), col, strconv.FormatInt(c.EndInt, 10))
+	}
+	const layout = "2006-01-02 15:04:05.999999-07"
+	return fmt.Sprintf("%s >= '%s' AND %s < '%s'", col, c.Start.UTC().Format(layout), col, c.End.UTC().Format(layout))
+}
+
+// Query restricts query to the rows of the chunk, ordered by time. The query must
+// return the hypertable's time column. It is kept on its own lines so that a
+// trailing line comment cannot swallow the chunk filter.
+func (c Chunk) Query(query string) string {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) AS chunk_rows WHERE %s ORDER BY %s",
+		query, c.Filter(), formatters.QuoteIdent(c.TimeColumn))
+}
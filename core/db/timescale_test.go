@@ -0,0 +1,75 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChunkFilter(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	tests := []struct {
+		name     string
+		chunk    Chunk
+		expected string
+	}{
+		{
+			name: "time range in UTC",
+			chunk: Chunk{
+				TimeColumn: "time",
+				Start:      time.Date(2024, 1, 1, 1, 0, 0, 0, paris),
+				End:        time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+			},
+			expected: `"time" >= '2024-01-01 00:00:00+00' AND "time" < '2024-01-08 00:00:00+00'`,
+		},
+		{
+			name:     "integer range",
+			chunk:    Chunk{TimeColumn: "seq", StartInt: 1000, EndInt: 2000, Integer: true},
+			expected: `"seq" >= 1000 AND "seq" < 2000`,
+		},
+		{
+			name: "quoted column",
+			chunk: Chunk{
+				TimeColumn: `Created "At"`,
+				Start:      time.Date(2024, 1, 1, 0, 0, 0, 500000000, time.UTC),
+				End:        time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+			expected: `"Created ""At""" >= '2024-01-01 00:00:00.5+00' AND "Created ""At""" < '2024-01-02 00:00:00+00'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.chunk.Filter(); got != tt.expected {
+				t.Errorf("Filter() = %s\nwant       %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestChunkQuery(t *testing.T) {
+	c := Chunk{TimeColumn: "ts", StartInt: 0, EndInt: 10, Integer: true}
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "trailing semicolon",
+			query:    "SELECT ts, value FROM metrics WHERE value > 0;\n",
+			expected: "SELECT * FROM (\nSELECT ts, value FROM metrics WHERE value > 0\n) AS chunk_rows WHERE \"ts\" >= 0 AND \"ts\" < 10 ORDER BY \"ts\"",
+		},
+		{
+			// The comment must end before the chunk filter
+			name:     "trailing line comment",
+			query:    "SELECT * FROM events -- all events",
+			expected: "SELECT * FROM (\nSELECT * FROM events -- all events\n) AS chunk_rows WHERE \"ts\" >= 0 AND \"ts\" < 10 ORDER BY \"ts\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Query(tt.query); got != tt.expected {
+				t.Errorf("Query() = %q\nwant      %q", got, tt.expected)
+			}
+		})
+	}
+}
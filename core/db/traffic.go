@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Bytes exchanged with database servers by all connections opened through a Store,
+// measured on the socket (after TLS encryption, before any decompression).
+var bytesReceived, bytesSent atomic.Int64
+
+// Traffic returns the number of bytes received from and sent to database servers
+// since the process started. PostgreSQL does not expose per-session network
+// counters in its statistics views, so they are measured on the client socket.
+func Traffic() (received, sent int64) {
+	return bytesReceived.Load(), bytesSent.Load()
+}
+
+// countingConn counts the bytes going through a connection.
+type countingConn struct {
+	net.Conn
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	bytesReceived.Add(int64(n))
+	return n, err
+}
+
+func (c countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	bytesSent.Add(int64(n))
+	return n, err
+}
+
+// countTraffic makes connections created with cfg count their traffic.
+func countTraffic(cfg *pgconn.Config) {
+	dial := cfg.DialFunc
+	cfg.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return countingConn{conn}, nil
+	}
+}
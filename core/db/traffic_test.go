@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestCountTraffic(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	cfg := &pgconn.Config{DialFunc: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return client, nil
+	}}
+	countTraffic(cfg)
+
+	conn, err := cfg.DialFunc(context.Background(), "tcp", "db:5432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	receivedBefore, sentBefore := Traffic()
+
+	go func() {
+		buf := make([]byte, 5)
+		io.ReadFull(server, buf)
+		server.Write([]byte("hello world"))
+	}()
+
+	if _, err := conn.Write([]byte("query")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, 11)); err != nil {
+		t.Fatal(err)
+	}
+
+	received, sent := Traffic()
+	if received-receivedBefore != 11 || sent-sentBefore != 5 {
+		t.Errorf("Traffic() delta = %d received, %d sent; want 11, 5", received-receivedBefore, sent-sentBefore)
+	}
+}
@@ -12,6 +12,11 @@ import (
 type OrderedJsonEncoder struct {
 	timeLayout string
 	timezone   string
+	// OmitNull leaves out the keys of NULL values
+	OmitNull bool
+	// NullsKey, when set with OmitNull, names a key listing the keys left out,
+	// so that consumers can tell a NULL value from a missing column
+	NullsKey string
 }
 
 // NewOrderedJsonEncoder creates a new ordered JSON encoder with time formatting options
@@ -35,10 +40,17 @@ func (o OrderedJsonEncoder) EncodeRow(keys []string, dataTypes []uint32, values
 
 	row.WriteString("{\n")
 
+	written := 0
+	var nulls []string
 	for i, key := range keys {
-		if i > 0 {
+		if o.OmitNull && values[i] == nil {
+			nulls = append(nulls, key)
+			continue
+		}
+		if written > 0 {
 			row.WriteString(",\n")
 		}
+		written++
 
 		// Add indentation (4 spaces for inner content)
 		row.WriteString("    ")
@@ -58,6 +70,21 @@ func (o OrderedJsonEncoder) EncodeRow(keys []string, dataTypes []uint32, values
 		row.Write(valueJSON)
 	}
 
+	if o.NullsKey != "" && len(nulls) > 0 {
+		if written > 0 {
+			row.WriteString(",\n")
+		}
+		written++
+		list, err := marshalWithoutHTMLEscape(nulls)
+		if err != nil {
+			return nil, err
+		}
+		row.WriteString(fmt.Sprintf("    %q: %s", o.NullsKey, list))
+	}
+	if written == 0 {
+		return []byte("{}"), nil
+	}
+
 	row.WriteString("\n  }")
 	return row.Bytes(), nil
 }
@@ -0,0 +1,186 @@
+// Package encryption encrypts the values of selected result columns during an
+// export, so that restricted fields can flow through intermediate systems while
+// the rest of the dataset stays usable.
+//
+// Values are encrypted with AES-256-GCM and written as the base64 encoding of the
+// 12-byte nonce followed by the ciphertext and its tag. NULL values stay NULL.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Supported algorithms
+const (
+	// AES256 uses a random nonce: equal values get different ciphertexts.
+	AES256 = "aes256"
+	// AES256Deterministic derives the nonce from the value, so that equal values get
+	// equal ciphertexts and can still be joined or grouped. It reveals which values
+	// are equal.
+	AES256Deterministic = "aes256-det"
+)
+
+// KeySize is the size of the keys, in bytes.
+const KeySize = 32
+
+// nonceLabel derives the key of the deterministic nonces from the column key.
+const nonceLabel = "pgxport aes256-det nonce"
+
+// Spec is one --encrypt-column setting: column=algorithm:keyref.
+type Spec struct {
+	Column    string
+	Algorithm string
+	KeyRef    string
+}
+
+// ParseSpec parses column=algorithm:keyref. The key reference may itself contain
+// colons, as in ssn=aes256:file:/etc/pgxport/ssn.key.
+func ParseSpec(s string) (Spec, error) {
+	column, rest, ok := strings.Cut(s, "=")
+	algorithm, keyRef, ok2 := strings.Cut(rest, ":")
+	spec := Spec{Column: strings.TrimSpace(column), Algorithm: strings.ToLower(strings.TrimSpace(algorithm)), KeyRef: strings.TrimSpace(keyRef)}
+	if !ok || !ok2 || spec.Column == "" || spec.KeyRef == "" {
+		return Spec{}, fmt.Errorf("invalid column encryption %q: expected column=algorithm:keyref, e.g. ssn=%s:SSN_KEY", s, AES256)
+	}
+	if spec.Algorithm != AES256 && spec.Algorithm != AES256Deterministic {
+		return Spec{}, fmt.Errorf("invalid column encryption %q: unknown algorithm %q. Valid algorithms are: %s, %s",
+			s, algorithm, AES256, AES256Deterministic)
+	}
+	return spec, nil
+}
+
+// Encrypter encrypts the values of one column.
+type Encrypter struct {
+	aead     cipher.AEAD
+	nonceKey []byte // set in deterministic mode
+}
+
+// NewEncrypter returns an encrypter for algorithm with a KeySize-byte key.
+func NewEncrypter(algorithm string, key []byte) (*Encrypter, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	e := &Encrypter{aead: aead}
+	switch algorithm {
+	case AES256:
+	case AES256Deterministic:
+		e.nonceKey = mac(key, []byte(nonceLabel))
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", algorithm)
+	}
+	return e, nil
+}
+
+// Encrypt returns the encrypted, base64-encoded form of plaintext.
+func (e *Encrypter) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize(), e.aead.NonceSize()+len(plaintext)+e.aead.Overhead())
+	if e.nonceKey != nil {
+		copy(nonce, mac(e.nonceKey, []byte(plaintext)))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("unable to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(e.aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// Decrypt returns the plaintext of a value written by Encrypt, in either mode.
+func Decrypt(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return "", fmt.Errorf("invalid encrypted value: too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption keys must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func mac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// Compile loads the key of each spec and returns the middleware encrypting the
+// columns. Columns are turned into text, formatted as in CSV output, before they
+// are encrypted.
+func Compile(specs []Spec) (exporters.Middleware, error) {
+	encrypters := make(map[string]*Encrypter, len(specs))
+	for _, spec := range specs {
+		if _, ok := encrypters[spec.Column]; ok {
+			return nil, fmt.Errorf("column %q is encrypted twice", spec.Column)
+		}
+		key, err := LoadKey(spec.KeyRef)
+		if err != nil {
+			return nil, fmt.Errorf("key of column %q: %w", spec.Column, err)
+		}
+		if encrypters[spec.Column], err = NewEncrypter(spec.Algorithm, key); err != nil {
+			return nil, fmt.Errorf("key of column %q: %w", spec.Column, err)
+		}
+	}
+
+	return exporters.WrapRows(func(rows pgx.Rows, options exporters.ExportOptions) (pgx.Rows, error) {
+		fields := slices.Clone(rows.FieldDescriptions())
+		cols := make(map[int]*Encrypter, len(encrypters))
+		oids := make(map[int]uint32, len(encrypters))
+		for _, spec := range specs {
+			col := slices.IndexFunc(fields, func(f pgconn.FieldDescription) bool { return f.Name == spec.Column })
+			if col < 0 {
+				return nil, fmt.Errorf("cannot encrypt column %q: no such column in the result", spec.Column)
+			}
+			cols[col] = encrypters[spec.Column]
+			oids[col] = fields[col].DataTypeOID
+			fields[col].DataTypeOID = pgtype.TextOID
+		}
+
+		return rowsource.Transform(rows, fields, func(values []any) ([]any, error) {
+			out := slices.Clone(values)
+			for col, enc := range cols {
+				if values[col] == nil {
+					continue
+				}
+				text := formatters.FormatCSVValue(values[col], oids[col], options.TimeFormat, options.TimeZone)
+				encrypted, err := enc.Encrypt(text)
+				if err != nil {
+					return nil, err
+				}
+				out[col] = encrypted
+			}
+			return out, nil
+		}), nil
+	}), nil
+}
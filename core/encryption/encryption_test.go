@@ -0,0 +1,198 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var testKey = bytes.Repeat([]byte{0x42}, KeySize)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Spec
+		wantErr string
+	}{
+		{in: "ssn=aes256:SSN_KEY", want: Spec{Column: "ssn", Algorithm: AES256, KeyRef: "SSN_KEY"}},
+		{in: "email=AES256-DET:file:/etc/keys/email.key", want: Spec{Column: "email", Algorithm: AES256Deterministic, KeyRef: "file:/etc/keys/email.key"}},
+		{in: "ssn", wantErr: "expected column=algorithm:keyref"},
+		{in: "ssn=aes256", wantErr: "expected column=algorithm:keyref"},
+		{in: "=aes256:KEY", wantErr: "expected column=algorithm:keyref"},
+		{in: "ssn=rot13:KEY", wantErr: `unknown algorithm "rot13"`},
+	}
+	for _, tt := range tests {
+		got, err := ParseSpec(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSpec(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSpec(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestEncryptModes(t *testing.T) {
+	for _, algorithm := range []string{AES256, AES256Deterministic} {
+		enc, err := NewEncrypter(algorithm, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, err := enc.Encrypt("123-45-6789")
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _ := enc.Encrypt("123-45-6789")
+		other, _ := enc.Encrypt("987-65-4321")
+
+		if deterministic := first == second; deterministic != (algorithm == AES256Deterministic) {
+			t.Errorf("%s: equal values gave equal ciphertexts = %v", algorithm, deterministic)
+		}
+		if first == other {
+			t.Errorf("%s: different values gave the same ciphertext", algorithm)
+		}
+		for _, value := range []string{first, second} {
+			if plain, err := Decrypt(testKey, value); err != nil || plain != "123-45-6789" {
+				t.Errorf("%s: Decrypt() = %q, %v", algorithm, plain, err)
+			}
+		}
+	}
+
+	if _, err := Decrypt(bytes.Repeat([]byte{1}, KeySize), mustEncrypt(t, "secret")); err == nil {
+		t.Error("Decrypt() with the wrong key should fail")
+	}
+	if _, err := NewEncrypter(AES256, []byte("short")); err == nil {
+		t.Error("NewEncrypter() should reject keys that are not 32 bytes")
+	}
+}
+
+func mustEncrypt(t *testing.T, plaintext string) string {
+	t.Helper()
+	enc, err := NewEncrypter(AES256, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := enc.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestLoadKey(t *testing.T) {
+	t.Setenv("PGXPORT_TEST_HEX_KEY", hex.EncodeToString(testKey))
+	t.Setenv("PGXPORT_TEST_B64_KEY", base64.StdEncoding.EncodeToString(testKey)+"\n")
+	t.Setenv("PGXPORT_TEST_SHORT_KEY", "abc")
+	raw := filepath.Join(t.TempDir(), "raw.key")
+	if err := os.WriteFile(raw, testKey, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range []string{"PGXPORT_TEST_HEX_KEY", "env:PGXPORT_TEST_B64_KEY", "file:" + raw} {
+		key, err := LoadKey(ref)
+		if err != nil || !bytes.Equal(key, testKey) {
+			t.Errorf("LoadKey(%q) = %x, %v", ref, key, err)
+		}
+	}
+
+	for ref, want := range map[string]string{
+		"PGXPORT_TEST_UNSET_KEY": "is not set",
+		"PGXPORT_TEST_SHORT_KEY": "expected a 32-byte key",
+		"vault:secret/ssn":       `unknown key provider "vault"`,
+	} {
+		if _, err := LoadKey(ref); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadKey(%q) error = %v, want %q", ref, err, want)
+		}
+	}
+}
+
+func TestRegisterKeyProvider(t *testing.T) {
+	err := RegisterKeyProvider("test-kms", func(ref string) ([]byte, error) {
+		if ref != "alias/ssn" {
+			t.Errorf("provider called with %q", ref)
+		}
+		return []byte(hex.EncodeToString(testKey)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := LoadKey("test-kms:alias/ssn"); err != nil || !bytes.Equal(key, testKey) {
+		t.Errorf("LoadKey() = %x, %v", key, err)
+	}
+	if err := RegisterKeyProvider("env", envKey); err == nil {
+		t.Error("registering a provider twice should fail")
+	}
+}
+
+func TestCompile(t *testing.T) {
+	t.Setenv("PGXPORT_TEST_KEY", hex.EncodeToString(testKey))
+	middleware, err := Compile([]Spec{
+		{Column: "ssn", Algorithm: AES256, KeyRef: "PGXPORT_TEST_KEY"},
+		{Column: "age", Algorithm: AES256Deterministic, KeyRef: "PGXPORT_TEST_KEY"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "ssn", OID: pgtype.TextOID},
+		{Name: "age", OID: pgtype.Int4OID},
+	}, [][]any{
+		{int32(1), "123-45-6789", int32(42)},
+		{int32(2), nil, int32(42)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csvExporter, _ := exporters.GetExporter(exporters.FormatCSV)
+	path := filepath.Join(t.TempDir(), "out.csv")
+	options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', Compression: "none"}
+	if _, err := exporters.Chain(csvExporter, middleware).Export(rows, path, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[1][0] != "1" {
+		t.Fatalf("records = %v", records)
+	}
+	if ssn, err := Decrypt(testKey, records[1][1]); err != nil || ssn != "123-45-6789" {
+		t.Errorf("ssn = %q, %v", ssn, err)
+	}
+	if records[2][1] != "" {
+		t.Errorf("NULL ssn written as %q, want an empty field", records[2][1])
+	}
+	// Deterministic values can still be compared
+	if records[1][2] != records[2][2] {
+		t.Errorf("equal ages encrypted differently: %q, %q", records[1][2], records[2][2])
+	}
+	if age, _ := Decrypt(testKey, records[1][2]); age != "42" {
+		t.Errorf("age = %q, want 42", age)
+	}
+
+	rows.Reset()
+	missing, _ := Compile([]Spec{{Column: "email", Algorithm: AES256, KeyRef: "PGXPORT_TEST_KEY"}})
+	if _, err := exporters.Chain(csvExporter, missing).Export(rows, path, options); err == nil || !strings.Contains(err.Error(), `cannot encrypt column "email"`) {
+		t.Errorf("Export() error = %v, want a missing column error", err)
+	}
+}
@@ -0,0 +1,116 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// KeyProvider returns the key named by ref, the part of a key reference after
+// its scheme. Keys may be returned raw or hex or base64 encoded.
+type KeyProvider func(ref string) ([]byte, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]KeyProvider{
+		"env":  envKey,
+		"file": os.ReadFile,
+		"cmd":  commandKey,
+	}
+)
+
+// RegisterKeyProvider makes the keys of a KMS or secret store available as
+// scheme:ref key references.
+func RegisterKeyProvider(scheme string, provider KeyProvider) error {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if _, ok := providers[scheme]; ok {
+		return fmt.Errorf("key provider %q is already registered", scheme)
+	}
+	providers[scheme] = provider
+	return nil
+}
+
+// KeyProviders returns the registered key reference schemes, sorted.
+func KeyProviders() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// LoadKey resolves a key reference: env:NAME, file:PATH, cmd:COMMAND or the scheme
+// of a registered provider. A reference without scheme names an environment variable.
+func LoadKey(ref string) ([]byte, error) {
+	scheme, name, ok := strings.Cut(ref, ":")
+	if !ok {
+		scheme, name = "env", ref
+	}
+	providersMu.RLock()
+	provider, found := providers[scheme]
+	providersMu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unknown key provider %q in %q. Valid providers are: %s", scheme, ref, strings.Join(KeyProviders(), ", "))
+	}
+
+	data, err := provider(name)
+	if err != nil {
+		return nil, err
+	}
+	key, err := decodeKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	return key, nil
+}
+
+// decodeKey accepts KeySize raw bytes, or their hex or base64 encoding.
+func decodeKey(data []byte) ([]byte, error) {
+	text := string(bytes.TrimSpace(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if len(data) == KeySize {
+		return data, nil
+	}
+	return nil, fmt.Errorf("expected a %d-byte key, raw or hex or base64 encoded", KeySize)
+}
+
+func envKey(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	return []byte(value), nil
+}
+
+// commandKey runs a command, without shell, and returns what it prints. It lets a
+// KMS command line tool decrypt the key, e.g.
+// cmd:aws kms decrypt --ciphertext-blob fileb://ssn.key.enc --query Plaintext --output text
+func commandKey(command string) ([]byte, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("cmd: no command given")
+	}
+	var stderr bytes.Buffer
+	c := exec.Command(args[0], args[1:]...)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("key command %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,193 @@
+package escaping
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var errInvalidDelim = errors.New("escaping: invalid CSV field delimiter")
+
+// CSVWriter writes CSV records using the same quoting rules as encoding/csv,
+// so exported files stay readable by standard parsers regardless of the data.
+type CSVWriter struct {
+	Comma   rune // Field delimiter (set to ',' by NewCSVWriter)
+	UseCRLF bool // True to use \r\n as the line terminator
+	// QuoteAll quotes every field, not only those that need it
+	QuoteAll bool
+	// RFC4180 ends records with \r\n, keeps line breaks inside fields as they are
+	// and rejects fields that RFC 4180 cannot represent (see CheckRFC4180)
+	RFC4180 bool
+	w       *bufio.Writer
+}
+
+// RFC4180Error reports a field that cannot be written in strict RFC 4180 mode.
+type RFC4180Error struct {
+	Field  int    // position of the field in the record
+	Offset int    // byte offset of the offending character in the field
+	Reason string // what the RFC does not allow
+}
+
+func (e *RFC4180Error) Error() string {
+	return fmt.Sprintf("field %d: %s at byte %d, which RFC 4180 does not allow", e.Field+1, e.Reason, e.Offset)
+}
+
+// NewCSVWriter returns a new CSVWriter that writes to w.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{
+		Comma: ',',
+		w:     bufio.NewWriter(w),
+	}
+}
+
+// Write writes a single CSV record along with any necessary quoting.
+func (w *CSVWriter) Write(record []string) error {
+	if !ValidCSVDelimiter(w.Comma) {
+		return errInvalidDelim
+	}
+
+	if w.RFC4180 {
+		for n, field := range record {
+			if err := CheckRFC4180(field); err != nil {
+				err.Field = n
+				return err
+			}
+		}
+	}
+
+	for n, field := range record {
+		if n > 0 {
+			if _, err := w.w.WriteRune(w.Comma); err != nil {
+				return err
+			}
+		}
+		if err := w.writeField(field); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if w.UseCRLF || w.RFC4180 {
+		_, err = w.w.WriteString("\r\n")
+	} else {
+		err = w.w.WriteByte('\n')
+	}
+	return err
+}
+
+func (w *CSVWriter) writeField(field string) error {
+	if !w.QuoteAll && !NeedsCSVQuotes(field, w.Comma) {
+		_, err := w.w.WriteString(field)
+		return err
+	}
+
+	if err := w.w.WriteByte('"'); err != nil {
+		return err
+	}
+	for len(field) > 0 {
+		// Search for special characters.
+		i := strings.IndexAny(field, "\"\r\n")
+		if i < 0 {
+			i = len(field)
+		}
+
+		// Copy verbatim everything before the special character.
+		if _, err := w.w.WriteString(field[:i]); err != nil {
+			return err
+		}
+		field = field[i:]
+
+		// Encode the special character. Quoted line breaks are data: RFC 4180
+		// allows them as they are, so they are only normalized outside strict mode.
+		if len(field) > 0 {
+			var err error
+			switch field[0] {
+			case '"':
+				_, err = w.w.WriteString(`""`)
+			case '\r':
+				if !w.UseCRLF || w.RFC4180 {
+					err = w.w.WriteByte('\r')
+				}
+			case '\n':
+				if w.UseCRLF && !w.RFC4180 {
+					_, err = w.w.WriteString("\r\n")
+				} else {
+					err = w.w.WriteByte('\n')
+				}
+			}
+			field = field[1:]
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return w.w.WriteByte('"')
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *CSVWriter) Flush() {
+	w.w.Flush()
+}
+
+// Error reports any error that has occurred during a previous Write or Flush.
+func (w *CSVWriter) Error() error {
+	_, err := w.w.Write(nil)
+	return err
+}
+
+// NeedsCSVQuotes reports whether field must be enclosed in quotes.
+// Fields are quoted when they contain the delimiter, a quote, a carriage
+// return or a newline, when they begin with a space, or when they are the
+// PostgreSQL end-of-data marker `\.`.
+func NeedsCSVQuotes(field string, delim rune) bool {
+	if field == "" {
+		return false
+	}
+
+	if field == `\.` {
+		return true
+	}
+
+	if delim < utf8.RuneSelf {
+		for i := 0; i < len(field); i++ {
+			c := field[i]
+			if c == '\n' || c == '\r' || c == '"' || c == byte(delim) {
+				return true
+			}
+		}
+	} else {
+		if strings.ContainsRune(field, delim) || strings.ContainsAny(field, "\"\r\n") {
+			return true
+		}
+	}
+
+	r1, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r1)
+}
+
+// CheckRFC4180 reports whether field can be written as an RFC 4180 field: its
+// text must be valid UTF-8 without control characters other than the carriage
+// returns and line feeds that quoting allows. Tabs, NUL and DEL are rejected.
+func CheckRFC4180(field string) *RFC4180Error {
+	for i, r := range field {
+		switch {
+		case r == utf8.RuneError:
+			if _, size := utf8.DecodeRuneInString(field[i:]); size == 1 {
+				return &RFC4180Error{Offset: i, Reason: "invalid UTF-8"}
+			}
+		case r == '\r' || r == '\n':
+		case r < 0x20 || r == 0x7f:
+			return &RFC4180Error{Offset: i, Reason: fmt.Sprintf("control character %U", r)}
+		}
+	}
+	return nil
+}
+
+// ValidCSVDelimiter reports whether r can be used as a CSV field delimiter.
+func ValidCSVDelimiter(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
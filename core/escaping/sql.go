@@ -0,0 +1,60 @@
+package escaping
+
+import (
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+)
+
+// SQLString returns s as a single-quoted SQL string literal.
+// Embedded single quotes are doubled, which is the only escaping required
+// with standard_conforming_strings enabled (the default since PostgreSQL 9.1).
+func SQLString(s string) string {
+	return string(AppendSQLString(make([]byte, 0, len(s)+2), s))
+}
+
+// AppendSQLString appends s as a single-quoted SQL string literal to dst.
+func AppendSQLString(dst []byte, s string) []byte {
+	dst = append(dst, '\'')
+	for {
+		i := strings.IndexByte(s, '\'')
+		if i < 0 {
+			dst = append(dst, s...)
+			break
+		}
+		dst = append(dst, s[:i+1]...)
+		dst = append(dst, '\'')
+		s = s[i+1:]
+	}
+	return append(dst, '\'')
+}
+
+// SQLBytea returns data as a SQL string literal suitable for a bytea cast.
+// Printable UTF-8 content is kept readable; anything containing NUL bytes,
+// backslashes, control characters or invalid UTF-8 is emitted in hex format.
+func SQLBytea(data []byte) string {
+	return string(AppendSQLBytea(nil, data))
+}
+
+// AppendSQLBytea appends the bytea literal for data to dst.
+func AppendSQLBytea(dst []byte, data []byte) []byte {
+	if isPrintableText(data) {
+		return AppendSQLString(dst, string(data))
+	}
+
+	dst = append(dst, `'\x`...)
+	dst = hex.AppendEncode(dst, data)
+	return append(dst, '\'')
+}
+
+func isPrintableText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, c := range data {
+		if c < 0x20 || c == 0x7f || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package escaping
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSQLString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "''"},
+		{"plain", "'plain'"},
+		{"O'Brien", "'O''Brien'"},
+		{"''", "''''''"},
+		{`back\slash`, `'back\slash'`},
+		{"line1\r\nline2", "'line1\r\nline2'"},
+	}
+
+	for _, tt := range tests {
+		if got := SQLString(tt.input); got != tt.expected {
+			t.Errorf("SQLString(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSQLBytea(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected string
+	}{
+		{[]byte("binary data"), "'binary data'"},
+		{[]byte("O'Connor"), "'O''Connor'"},
+		{[]byte{0x00, 0xff, 0x10}, `'\x00ff10'`},
+		{[]byte(`a\b`), `'\x615c62'`},
+		{[]byte("a\rb"), `'\x610d62'`},
+	}
+
+	for _, tt := range tests {
+		if got := SQLBytea(tt.input); got != tt.expected {
+			t.Errorf("SQLBytea(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// unquoteSQL reverses SQLString, failing on any unescaped quote.
+func unquoteSQL(t *testing.T, lit string) string {
+	t.Helper()
+	if len(lit) < 2 || lit[0] != '\'' || lit[len(lit)-1] != '\'' {
+		t.Fatalf("literal %q is not single-quoted", lit)
+	}
+	inner := lit[1 : len(lit)-1]
+	if strings.Count(inner, "'")%2 != 0 || strings.Contains(strings.ReplaceAll(inner, "''", ""), "'") {
+		t.Fatalf("literal %q contains an unescaped quote", lit)
+	}
+	return strings.ReplaceAll(inner, "''", "'")
+}
+
+func FuzzSQLString(f *testing.F) {
+	for _, s := range []string{"", "O'Brien", "'; DROP TABLE users; --", "a\x00b", "line\r\n", "日本語", `\'`} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if got := unquoteSQL(t, SQLString(s)); got != s {
+			t.Errorf("round trip mismatch: got %q, want %q", got, s)
+		}
+	})
+}
+
+func FuzzSQLBytea(f *testing.F) {
+	for _, s := range [][]byte{{}, []byte("text"), {0x00}, []byte("it's"), {0xc3, 0x28}, []byte(`\x`)} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		inner := unquoteSQL(t, SQLBytea(data))
+
+		var got []byte
+		if strings.HasPrefix(inner, `\x`) {
+			decoded, err := hex.DecodeString(inner[2:])
+			if err != nil {
+				t.Fatalf("invalid hex literal %q: %v", inner, err)
+			}
+			got = decoded
+		} else {
+			if strings.ContainsAny(inner, "\\\x00") {
+				t.Fatalf("escape-format literal %q contains characters requiring escapes", inner)
+			}
+			got = []byte(inner)
+		}
+
+		if string(got) != string(data) {
+			t.Errorf("round trip mismatch: got %q, want %q", got, data)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package escaping
+
+import (
+	"encoding/xml"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// XMLText escapes s for use as XML character data.
+// Markup characters are replaced by entities, carriage returns are written as
+// character references so they survive XML line-ending normalization, and
+// characters that are not allowed in XML 1.0 are replaced with U+FFFD.
+func XMLText(s string) string {
+	if !needsXMLEscape(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 16)
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && width == 1 {
+			b.WriteRune(utf8.RuneError)
+			i++
+			continue
+		}
+		i += width
+
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '\r':
+			b.WriteString("&#xD;")
+		default:
+			if !isXMLChar(r) {
+				b.WriteRune(utf8.RuneError)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsValidXMLName reports whether name can be used as an XML element name.
+// It applies the conservative XML 1.0 (Fourth Edition) name rules, which are
+// accepted by every conforming parser. Names containing a colon are rejected
+// since they would be interpreted as namespace prefixes.
+func IsValidXMLName(name string) bool {
+	if name == "" || strings.ContainsAny(name, ": \t\r\n<>/&\"'=") {
+		return false
+	}
+
+	d := xml.NewDecoder(strings.NewReader("<" + name + "/>"))
+	tok, err := d.Token()
+	if err != nil {
+		return false
+	}
+	start, ok := tok.(xml.StartElement)
+	return ok && start.Name.Space == "" && start.Name.Local == name
+}
+
+// SanitizeXMLName turns name into a valid XML element name. Characters that may
+// not appear in a name are replaced with '_', and names that cannot start as they
+// do get a leading '_', so "count(*)" becomes "count___" and "2024_total"
+// becomes "_2024_total". The mapping is deterministic; valid names are unchanged.
+func SanitizeXMLName(name string) string {
+	if IsValidXMLName(name) {
+		return name
+	}
+
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+			b.WriteRune(r)
+		case unicode.IsDigit(r) || r == '-' || r == '.':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if sanitized := b.String(); IsValidXMLName(sanitized) {
+		return sanitized
+	}
+	return "_"
+}
+
+func needsXMLEscape(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '&' || c == '<' || c == '>' || c == '\r' || c < 0x20 && c != '\t' && c != '\n' || c >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+// isXMLChar reports whether r is in the XML 1.0 Char production.
+func isXMLChar(r rune) bool {
+	return r == 0x09 || r == 0x0A || r == 0x0D ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
@@ -0,0 +1,130 @@
+package escaping
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestXMLText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"a & b", "a &amp; b"},
+		{"<tag>", "&lt;tag&gt;"},
+		{`{"key": "value"}`, `{"key": "value"}`},
+		{"line1\r\nline2", "line1&#xD;\nline2"},
+		{"nul\x00byte", "nul�byte"},
+		{"bell\x07", "bell�"},
+		{"tab\tok", "tab\tok"},
+		{"José", "José"},
+		{"bad\xffutf8", "bad�utf8"},
+	}
+
+	for _, tt := range tests {
+		if got := XMLText(tt.input); got != tt.expected {
+			t.Errorf("XMLText(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestIsValidXMLName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"row", true},
+		{"_private", true},
+		{"user-id", true},
+		{"total.amount", true},
+		{"xml_data", true},
+		{"café", true},
+		{"", false},
+		{"2024_total", false},
+		{"count(*)", false},
+		{"first name", false},
+		{"ns:tag", false},
+		{"-dash", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsValidXMLName(tt.name); got != tt.want {
+			t.Errorf("IsValidXMLName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeXMLName(t *testing.T) {
+	tests := map[string]string{
+		"row":        "row",
+		"café":       "café",
+		"count(*)":   "count___",
+		"2024_total": "_2024_total",
+		"first name": "first_name",
+		"ns:tag":     "ns_tag",
+		"-dash":      "_-dash",
+		"?column?":   "_column_",
+		"":           "_",
+	}
+	for name, want := range tests {
+		if got := SanitizeXMLName(name); got != want {
+			t.Errorf("SanitizeXMLName(%q) = %q, want %q", name, got, want)
+		}
+		if !IsValidXMLName(SanitizeXMLName(name)) {
+			t.Errorf("SanitizeXMLName(%q) is not a valid name", name)
+		}
+	}
+}
+
+// sanitizeXMLChars mirrors the replacement performed by XMLText.
+func sanitizeXMLChars(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		i += width
+		if r == utf8.RuneError && width == 1 || !isXMLChar(r) {
+			b.WriteRune(utf8.RuneError)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func FuzzXMLText(f *testing.F) {
+	for _, s := range []string{"", "a & b", "<![CDATA[x]]>", "line\r\n", "\r", "nul\x00", "\xff\xfe", `{"a":"<b>"}`, "]]>", "￾"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		doc := "<v>" + XMLText(s) + "</v>"
+
+		var got string
+		if err := xml.Unmarshal([]byte(doc), &got); err != nil {
+			t.Fatalf("output is not well-formed XML: %v\n%q", err, doc)
+		}
+
+		if want := sanitizeXMLChars(s); got != want {
+			t.Errorf("round trip mismatch: got %q, want %q", got, want)
+		}
+	})
+}
+
+func FuzzIsValidXMLName(f *testing.F) {
+	for _, s := range []string{"row", "count(*)", "2024_total", "first name", "é", "a:b"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, name string) {
+		if !IsValidXMLName(name) {
+			return
+		}
+		doc := "<" + name + "></" + name + ">"
+		if err := xml.Unmarshal([]byte(doc), new(struct{})); err != nil {
+			t.Errorf("IsValidXMLName(%q) = true but %q does not parse: %v", name, doc, err)
+		}
+	})
+}
@@ -4,8 +4,6 @@ import (
 	"archive/zip"
 	"compress/gzip"
 	"fmt"
-	"io"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -19,43 +17,36 @@ const (
 	ZIP  = "zip"
 )
 
-type compositeWriteCloser struct {
-	io.Writer
-	closeFunc func() error
-}
-
-// Close implements io.WriteCloser.
-func (c *compositeWriteCloser) Close() error {
-	if c.closeFunc == nil {
-		return nil
-	}
-	return c.closeFunc()
-}
-
-func createOutputWriter(path string, options ExportOptions, format string) (io.WriteCloser, error) {
+// createOutputWriter opens the output file for path, wrapping it in the requested compression.
+func createOutputWriter(path string, options ExportOptions, format string) (*outputWriter, error) {
 	start := time.Now()
+	requested := path
 	compression := strings.ToLower(strings.TrimSpace(options.Compression))
 	switch compression {
 	case None:
 		logger.Debug("Creating uncompressed output file: %s", path)
-		file, err := os.Create(path)
+		file, err := createFile(path, options.Log)
 		if err != nil {
 			return nil, fmt.Errorf("error creating file: %w", err)
 		}
-		return file, nil
+		return &outputWriter{path: path, requested: requested, log: options.Log, dest: file, closeFunc: file.Close}, nil
 
 	case GZIP:
 		if !strings.HasSuffix(strings.ToLower(path), ".gz") {
 			path += ".gz"
 		}
 		logger.Debug("Creating gzip-compressed output file: %s", path)
-		file, err := os.Create(path)
+		file, err := createFile(path, options.Log)
 		if err != nil {
 			return nil, fmt.Errorf("error creating file: %w", err)
 		}
 		gzipWriter := gzip.NewWriter(file)
-		return &compositeWriteCloser{
-			Writer: gzipWriter,
+		return &outputWriter{
+			path:       path,
+			requested:  requested,
+			compressed: true,
+			log:        options.Log,
+			dest:       gzipWriter,
 			closeFunc: func() error {
 				logger.Debug("Finalizing gzip compression for: %s", path)
 				var err error
@@ -73,7 +64,7 @@ func createOutputWriter(path string, options ExportOptions, format string) (io.W
 	case ZIP:
 		fixedPath := fixExtension(path, ".zip")
 		logger.Debug("Creating zip-compressed output file: %s", fixedPath)
-		file, err := os.Create(fixedPath)
+		file, err := createFile(fixedPath, options.Log)
 		if err != nil {
 			return nil, fmt.Errorf("error creating file: %w", err)
 		}
@@ -86,8 +77,12 @@ func createOutputWriter(path string, options ExportOptions, format string) (io.W
 			file.Close()
 			return nil, fmt.Errorf("error creating zip entry: %w", err)
 		}
-		return &compositeWriteCloser{
-			Writer: entryWriter,
+		return &outputWriter{
+			path:       fixedPath,
+			requested:  requested,
+			compressed: true,
+			log:        options.Log,
+			dest:       entryWriter,
 			closeFunc: func() error {
 				logger.Debug("Finalizing zip archive: %s", fixedPath)
 				var err error
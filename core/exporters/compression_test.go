@@ -460,10 +460,10 @@ func TestCreateOutputWriter_LargeFile(t *testing.T) {
 	}
 }
 
-func TestCompositeWriteCloser_NilCloseFunc(t *testing.T) {
+func TestOutputWriter_NilCloseFunc(t *testing.T) {
 	var buf bytes.Buffer
-	writer := &compositeWriteCloser{
-		Writer:    &buf,
+	writer := &outputWriter{
+		dest:      &buf,
 		closeFunc: nil,
 	}
 
@@ -0,0 +1,212 @@
+package exporters
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/fbz-tec/pgxport/internal/bytesize"
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// spillReadSize is the size of the reads from the spill file.
+const spillReadSize = 256 * 1024
+
+// spillBuffer sits between COPY and a slow output so the server is not kept waiting
+// on the output. Data is queued in memory up to memLimit, then appended to a
+// temporary spill file until the output catches up. When diskLimit is reached,
+// writes block until the output drains part of the file.
+type spillBuffer struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	memLimit  int64
+	diskLimit int64 // 0 means unlimited
+	dir       string
+
+	mem       []byte // data queued in memory, older than anything in the spill file
+	memQueued int64  // bytes in mem or being written to the output
+
+	file      *os.File
+	fileRead  int64 // next offset to hand to the output
+	fileWrite int64 // end of the spilled data
+	spilling  bool  // new data goes to the file until it is drained
+
+	spilled int64 // total bytes that went through the spill file
+	closed  bool  // no more writes
+	err     error // first failure of the output or of the spill file
+}
+
+func newSpillBuffer(memLimit, diskLimit int64, dir string) *spillBuffer {
+	b := &spillBuffer{memLimit: memLimit, diskLimit: diskLimit, dir: dir}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Write queues p for the output. It fails once the output has failed.
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return 0, b.err
+	}
+	if !b.spilling && b.memQueued+int64(len(p)) <= b.memLimit {
+		b.mem = append(b.mem, p...)
+		b.memQueued += int64(len(p))
+		b.cond.Broadcast()
+		return len(p), nil
+	}
+
+	if !b.spilling {
+		logger.Debug("Output is falling behind COPY, spilling to disk after %s in memory", bytesize.Format(b.memQueued))
+	}
+	for b.diskLimit > 0 && b.err == nil && b.fileWrite > b.fileRead && b.fileWrite-b.fileRead+int64(len(p)) > b.diskLimit {
+		b.cond.Wait()
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	// Set after waiting: the output may have drained the file in the meantime
+	b.spilling = true
+
+	if b.file == nil {
+		file, err := os.CreateTemp(b.dir, "pgxport-copy-*.spill")
+		if err != nil {
+			b.err = fmt.Errorf("error creating COPY spill file: %w", err)
+			b.cond.Broadcast()
+			return 0, b.err
+		}
+		b.file = file
+	}
+	n, err := b.file.WriteAt(p, b.fileWrite)
+	b.fileWrite += int64(n)
+	b.spilled += int64(n)
+	b.cond.Broadcast()
+	if err != nil {
+		b.err = fmt.Errorf("error writing COPY spill file: %w", err)
+		return n, b.err
+	}
+	return n, nil
+}
+
+// Close signals that no more data will be written.
+func (b *spillBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+// drain copies the queued data to w, in order, until the buffer is closed and empty.
+func (b *spillBuffer) drain(w io.Writer) error {
+	buf := make([]byte, spillReadSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		for b.err == nil && len(b.mem) == 0 && b.fileRead == b.fileWrite && !b.closed {
+			b.cond.Wait()
+		}
+		if b.err != nil {
+			return b.err
+		}
+
+		var chunk []byte
+		fromMem := false
+		switch {
+		case len(b.mem) > 0:
+			chunk, b.mem = b.mem, nil
+			fromMem = true
+		case b.fileRead < b.fileWrite:
+			n, err := b.file.ReadAt(buf[:min(int64(len(buf)), b.fileWrite-b.fileRead)], b.fileRead)
+			if err != nil && n == 0 {
+				return b.fail(fmt.Errorf("error reading COPY spill file: %w", err))
+			}
+			b.fileRead += int64(n)
+			chunk = buf[:n]
+			if b.fileRead == b.fileWrite {
+				// Caught up: reuse the file from the start and go back to memory
+				if err := b.file.Truncate(0); err != nil {
+					return b.fail(fmt.Errorf("error truncating COPY spill file: %w", err))
+				}
+				b.fileRead, b.fileWrite = 0, 0
+				b.spilling = false
+			}
+			b.cond.Broadcast()
+		default:
+			return nil // closed and empty
+		}
+
+		// The output may be slow: let COPY keep queueing meanwhile
+		b.mu.Unlock()
+		_, err := w.Write(chunk)
+		b.mu.Lock()
+
+		if fromMem {
+			b.memQueued -= int64(len(chunk))
+		}
+		if err != nil {
+			return b.fail(err)
+		}
+		b.cond.Broadcast()
+	}
+}
+
+// fail records err, waking up a blocked writer. Called with b.mu held.
+func (b *spillBuffer) fail(err error) error {
+	if b.err == nil {
+		b.err = err
+	}
+	b.cond.Broadcast()
+	return b.err
+}
+
+// remove deletes the spill file, if one was created.
+func (b *spillBuffer) remove() {
+	if b.file == nil {
+		return
+	}
+	b.file.Close()
+	if err := os.Remove(b.file.Name()); err != nil {
+		logger.Warn("Could not remove COPY spill file %s: %v", b.file.Name(), err)
+	}
+}
+
+// copyThroughSpillBuffer runs copyTo against a spill buffer drained into w, so that
+// copyTo can finish at the pace of the server even when w is slow. It returns once
+// all the data has reached w.
+func copyThroughSpillBuffer(w io.Writer, options ExportOptions, copyTo func(io.Writer) error) error {
+	b := newSpillBuffer(options.CopyBuffer, options.CopySpillLimit, options.CopySpillDir)
+	defer b.remove()
+
+	drained := make(chan error, 1)
+	go func() { drained <- b.drain(w) }()
+
+	err := copyTo(b)
+	b.Close()
+	if err != nil {
+		b.mu.Lock()
+		b.fail(err)
+		b.mu.Unlock()
+		<-drained
+		return err
+	}
+
+	b.mu.Lock()
+	pending := b.memQueued + b.fileWrite - b.fileRead
+	b.mu.Unlock()
+	if pending > 0 {
+		logger.Debug("COPY finished, the connection is free; writing the remaining %s of buffered output", bytesize.Format(pending))
+	}
+
+	if err := <-drained; err != nil {
+		return err
+	}
+	if b.spilled > 0 {
+		logger.Debug("%s of COPY output went through the spill file", bytesize.Format(b.spilled))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package exporters
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter accepts writes only when released, like an output falling behind.
+type slowWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+	err     error
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.buf.Write(p)
+}
+
+// copyChunks returns a copyTo func writing n numbered lines, and the expected output.
+func copyChunks(n int) (func(io.Writer) error, string) {
+	var want strings.Builder
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = strings.Repeat("x", i%50) + "\n"
+		want.WriteString(lines[i])
+	}
+	return func(w io.Writer) error {
+		for _, line := range lines {
+			if _, err := w.Write([]byte(line)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, want.String()
+}
+
+func TestCopyThroughSpillBuffer(t *testing.T) {
+	dir := t.TempDir()
+	out := &slowWriter{release: make(chan struct{})}
+	copyTo, want := copyChunks(2000)
+
+	copied := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- copyThroughSpillBuffer(out, ExportOptions{CopyBuffer: 1024, CopySpillDir: dir}, func(w io.Writer) error {
+			defer close(copied)
+			return copyTo(w)
+		})
+	}()
+
+	// COPY completes although the output has not accepted anything yet
+	select {
+	case <-copied:
+	case <-time.After(5 * time.Second):
+		t.Fatal("COPY was held by the slow output")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected one spill file, found %d", len(entries))
+	}
+
+	close(out.release)
+	if err := <-done; err != nil {
+		t.Fatalf("copyThroughSpillBuffer() error: %v", err)
+	}
+	if out.buf.String() != want {
+		t.Errorf("output differs from the COPY data (%d bytes, want %d)", out.buf.Len(), len(want))
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("spill file was not removed: %v", entries)
+	}
+}
+
+func TestCopyThroughSpillBufferLimit(t *testing.T) {
+	out := &slowWriter{release: make(chan struct{})}
+	copyTo, want := copyChunks(2000)
+
+	copied := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		options := ExportOptions{CopyBuffer: 512, CopySpillLimit: 4096, CopySpillDir: t.TempDir()}
+		done <- copyThroughSpillBuffer(out, options, func(w io.Writer) error {
+			defer close(copied)
+			return copyTo(w)
+		})
+	}()
+
+	// The spill limit makes COPY wait for the output
+	select {
+	case <-copied:
+		t.Fatal("COPY did not wait once the spill limit was reached")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(out.release)
+	if err := <-done; err != nil {
+		t.Fatalf("copyThroughSpillBuffer() error: %v", err)
+	}
+	if out.buf.String() != want {
+		t.Errorf("output differs from the COPY data (%d bytes, want %d)", out.buf.Len(), len(want))
+	}
+}
+
+func TestCopyThroughSpillBufferOutputError(t *testing.T) {
+	diskFull := errors.New("no space left on device")
+	out := &slowWriter{release: make(chan struct{}), err: diskFull}
+	close(out.release)
+	copyTo, _ := copyChunks(2000)
+
+	err := copyThroughSpillBuffer(out, ExportOptions{CopyBuffer: 512, CopySpillDir: t.TempDir()}, copyTo)
+	if !errors.Is(err, diskFull) {
+		t.Errorf("copyThroughSpillBuffer() error = %v, want %v", err, diskFull)
+	}
+}
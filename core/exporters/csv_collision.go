@@ -0,0 +1,92 @@
+package exporters
+
+import (
+	"strings"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// Values of the csv.delimiter-collision option
+const (
+	CollisionWarn     = "warn"
+	CollisionQuoteAll = "quote-all"
+	CollisionIgnore   = "ignore"
+)
+
+const (
+	// collisionSampleRows is the number of rows sampled for delimiter collisions.
+	collisionSampleRows = 1000
+	// collisionRate is the share of the sampled values of a column that must contain
+	// the delimiter for the column to be reported.
+	collisionRate = 0.01
+)
+
+// delimiterCollision is a column whose sampled values often contain the delimiter.
+type delimiterCollision struct {
+	column        string
+	hits, sampled int
+}
+
+// checkDelimiterCollisions samples the first rows when the delimiter is not a comma:
+// consumers of such files often split lines on the delimiter instead of parsing
+// quotes, and break on values containing it. Frequent collisions are reported, or
+// switch the export to quoting every field with csv.delimiter-collision=quote-all.
+// It returns the rows to export and whether every field must be quoted.
+func checkDelimiterCollisions(rows pgx.Rows, options ExportOptions, quoteAll bool) (pgx.Rows, bool, error) {
+	policy, err := options.choiceOption("delimiter-collision", CollisionWarn, CollisionQuoteAll, CollisionIgnore)
+	if err != nil {
+		return nil, false, err
+	}
+	if options.Delimiter == ',' || quoteAll || policy == CollisionIgnore {
+		return rows, quoteAll, nil
+	}
+
+	peeked, sample, err := rowsource.Peek(rows, collisionSampleRows)
+	if err != nil {
+		return nil, false, err
+	}
+	collisions := findDelimiterCollisions(sample, rows, options)
+	if len(collisions) == 0 {
+		return peeked, false, nil
+	}
+
+	for _, c := range collisions {
+		logger.Warn("Delimiter %q appears in %d of %d sampled values of column %q",
+			string(options.Delimiter), c.hits, c.sampled, c.column)
+	}
+	if policy == CollisionQuoteAll {
+		logger.Warn("Quoting every field so that consumers notice values containing the delimiter")
+		return peeked, true, nil
+	}
+	logger.Warn("These values are quoted, which parsers splitting lines on the delimiter do not handle: " +
+		"choose another --delimiter or use --opt csv.delimiter-collision=quote-all")
+	return peeked, false, nil
+}
+
+// findDelimiterCollisions returns the columns of which at least collisionRate of
+// the non-NULL sampled values contain the delimiter.
+func findDelimiterCollisions(sample [][]any, rows pgx.Rows, options ExportOptions) []delimiterCollision {
+	fields := rows.FieldDescriptions()
+	delimiter := string(options.Delimiter)
+
+	var collisions []delimiterCollision
+	for col, fd := range fields {
+		c := delimiterCollision{column: fd.Name}
+		for _, values := range sample {
+			if values[col] == nil {
+				continue
+			}
+			c.sampled++
+			if strings.Contains(formatters.FormatCSVValue(values[col], fd.DataTypeOID, options.TimeFormat, options.TimeZone), delimiter) {
+				c.hits++
+			}
+		}
+		if c.hits > 0 && float64(c.hits) >= collisionRate*float64(c.sampled) {
+			collisions = append(collisions, c)
+		}
+	}
+	return collisions
+}
@@ -0,0 +1,101 @@
+package exporters
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestWriteCSVDelimiterCollision(t *testing.T) {
+	// One address in ten contains the semicolon delimiter
+	data := make([][]any, 1200)
+	for i := range data {
+		address := fmt.Sprintf("%d Main St", i)
+		if i%10 == 0 {
+			address = fmt.Sprintf("%d Main St; Apt 2", i)
+		}
+		data[i] = []any{int32(i), address}
+	}
+
+	tests := []struct {
+		name      string
+		delimiter rune
+		opts      map[string]string
+		wantWarn  bool
+		wantQuote bool
+	}{
+		{name: "warn", delimiter: ';', wantWarn: true},
+		{name: "quote all", delimiter: ';', opts: map[string]string{"delimiter-collision": CollisionQuoteAll}, wantWarn: true, wantQuote: true},
+		{name: "ignore", delimiter: ';', opts: map[string]string{"delimiter-collision": CollisionIgnore}},
+		{name: "quote-all already set", delimiter: ';', opts: map[string]string{"quote-all": "true"}, wantQuote: true},
+		{name: "comma", delimiter: ','},
+		{name: "no collision", delimiter: '|'},
+	}
+
+	exporter, _ := GetExporter(FormatCSV)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := rowsource.New([]rowsource.Column{
+				{Name: "id", OID: pgtype.Int4OID},
+				{Name: "address", OID: pgtype.TextOID},
+			}, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var log bytes.Buffer
+			logger.SetRecorder(&log)
+			defer logger.SetRecorder(nil)
+
+			path := filepath.Join(t.TempDir(), "addresses.csv")
+			options := ExportOptions{Format: FormatCSV, Delimiter: tt.delimiter, Compression: "none", FormatOptions: tt.opts}
+			n, err := exporter.Export(rows, path, options)
+			if err != nil || n != len(data) {
+				t.Fatalf("Export() = %d, %v", n, err)
+			}
+
+			warned := strings.Contains(log.String(), `appears in 100 of 1000 sampled values of column "address"`)
+			if warned != tt.wantWarn {
+				t.Errorf("collision warning = %v, want %v\n%s", warned, tt.wantWarn, log.String())
+			}
+			if strings.Contains(log.String(), `column "id"`) {
+				t.Errorf("id column reported:\n%s", log.String())
+			}
+
+			out, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+			if len(lines) != len(data)+1 {
+				t.Fatalf("got %d lines, want %d", len(lines), len(data)+1)
+			}
+			d := string(tt.delimiter)
+			wantHeader, wantFirst := "id"+d+"address", "0"+d+`"0 Main St; Apt 2"`
+			if tt.delimiter == ',' || tt.delimiter == '|' {
+				wantFirst = "0" + d + "0 Main St; Apt 2"
+			}
+			if tt.wantQuote {
+				wantHeader, wantFirst = `"id"`+d+`"address"`, `"0"`+d+`"0 Main St; Apt 2"`
+			}
+			if lines[0] != wantHeader || lines[1] != wantFirst {
+				t.Errorf("got %q, %q, want %q, %q", lines[0], lines[1], wantHeader, wantFirst)
+			}
+		})
+	}
+}
+
+func TestCSVDelimiterCollisionOption(t *testing.T) {
+	exporter := &csvExporter{}
+	options := ExportOptions{Format: FormatCSV, Delimiter: ';', FormatOptions: map[string]string{"delimiter-collision": "fix"}}
+	err := exporter.Validate(options)
+	if err == nil || !strings.Contains(err.Error(), `invalid csv.delimiter-collision value "fix"`) {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
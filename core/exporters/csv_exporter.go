@@ -3,11 +3,14 @@ package exporters
 import (
 	"bufio"
 	"context"
-	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/db"
+	"github.com/fbz-tec/pgxport/core/escaping"
 	"github.com/fbz-tec/pgxport/core/formatters"
 	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/jackc/pgx/v5"
@@ -16,37 +19,61 @@ import (
 type csvExporter struct{}
 
 // Export writes query results to a CSV file with buffered I/O.
-func (e *csvExporter) Export(rows pgx.Rows, csvPath string, options ExportOptions) (int, error) {
+func (e *csvExporter) Export(rows pgx.Rows, csvPath string, options ExportOptions) (rowCount int, err error) {
 	start := time.Now()
 
 	logger.Debug("Preparing CSV export (delimiter=%q, noHeader=%v, compression=%s)",
 		string(options.Delimiter), options.NoHeader, options.Compression)
 
-	writerCloser, err := createOutputWriter(csvPath, options, FormatCSV)
+	rows, err = checkColumns(rows, options)
 	if err != nil {
 		return 0, err
 	}
 
-	defer writerCloser.Close()
+	quoteAll, err := options.boolOption("quote-all")
+	if err != nil {
+		return 0, err
+	}
+	rows, quoteAll, err = checkDelimiterCollisions(rows, options, quoteAll)
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := createOutputWriter(csvPath, options, FormatCSV)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { rowCount, err = out.finish(rowCount, err) }()
 
 	// Use buffered writer for better performance
-	bufferedWriter := bufio.NewWriter(writerCloser)
+	bufferedWriter := bufio.NewWriter(out)
 	defer bufferedWriter.Flush()
 
-	writer := csv.NewWriter(bufferedWriter)
+	writer := escaping.NewCSVWriter(bufferedWriter)
 	writer.Comma = options.Delimiter
+	writer.QuoteAll = quoteAll
+	writer.RFC4180 = options.CSVStrict
 	defer writer.Flush()
 
+	if err := writeCSVComments(bufferedWriter, options.ColumnComments); err != nil {
+		return 0, err
+	}
+
 	// Write headers
 	fields := rows.FieldDescriptions()
 
-	if !options.NoHeader {
+	// A result without columns has no header line, the file stays empty
+	if !options.NoHeader && len(fields) > 0 {
 		headers := make([]string, len(fields))
 		for i, fd := range fields {
 			headers[i] = string(fd.Name)
 		}
 
 		if err := writer.Write(headers); err != nil {
+			var rfcErr *escaping.RFC4180Error
+			if errors.As(err, &rfcErr) {
+				return 0, fmt.Errorf("column name %q: %s, which RFC 4180 does not allow", headers[rfcErr.Field], rfcErr.Reason)
+			}
 			return 0, fmt.Errorf("error writing headers: %w", err)
 		}
 		logger.Debug("CSV headers written: %s", strings.Join(headers, string(options.Delimiter)))
@@ -55,7 +82,8 @@ func (e *csvExporter) Export(rows pgx.Rows, csvPath string, options ExportOption
 	// Write data rows
 	logger.Debug("Starting to write CSV rows...")
 
-	rowCount := 0
+	rowNum := 0
+	guard := newRowSizeGuard(options)
 	lastLog := time.Now()
 	var fetchTime time.Duration // Track time spent waiting for rows from PostgreSQL
 
@@ -72,17 +100,35 @@ func (e *csvExporter) Export(rows pgx.Rows, csvPath string, options ExportOption
 		if err != nil {
 			return rowCount, fmt.Errorf("error reading row: %w", err)
 		}
+		rowNum++
+
 		//format values to strings
 		record := make([]string, len(values))
+		size := len(record) // delimiters and line break
 		for i, v := range values {
 			record[i] = formatters.FormatCSVValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+			size += len(record[i])
+		}
+
+		ok, err := guard.allow(rowNum, size)
+		if err != nil {
+			return rowCount, err
+		}
+		if !ok {
+			continue
 		}
 
 		rowCount++
 
 		if err := writer.Write(record); err != nil {
-			return 0, fmt.Errorf("error writing row %d: %w", rowCount, err)
+			var rfcErr *escaping.RFC4180Error
+			if errors.As(err, &rfcErr) {
+				return rowCount, fmt.Errorf("row %d, column %q: %s at byte %d, which RFC 4180 does not allow",
+					rowNum, fields[rfcErr.Field].Name, rfcErr.Reason, rfcErr.Offset)
+			}
+			return rowCount, fmt.Errorf("error writing row %d: %w", rowCount, err)
 		}
+		out.markRows(rowCount, bufferedWriter.Buffered())
 
 		if logger.IsVerbose() && (rowCount%10000 == 0 || time.Since(lastLog) > 2*time.Second) {
 			elapsed := time.Since(start)
@@ -108,6 +154,8 @@ func (e *csvExporter) Export(rows pgx.Rows, csvPath string, options ExportOption
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
 
+	guard.report()
+
 	elapsed := time.Since(start)
 	logger.Debug("CSV export completed successfully: %d rows written in %v (%.0f rows/s)",
 		rowCount, elapsed.Round(time.Millisecond), float64(rowCount)/elapsed.Seconds())
@@ -127,30 +175,160 @@ func (e *csvExporter) Export(rows pgx.Rows, csvPath string, options ExportOption
 	return rowCount, nil
 }
 
+// Validate checks that the delimiter can be used to produce well-formed CSV.
+func (e *csvExporter) Validate(options ExportOptions) error {
+	if !escaping.ValidCSVDelimiter(options.Delimiter) {
+		return fmt.Errorf("invalid CSV delimiter %q: quotes, line breaks and NUL cannot be used as delimiter", string(options.Delimiter))
+	}
+	if options.CSVStrict && options.Delimiter != ',' {
+		return fmt.Errorf("invalid CSV delimiter %q: RFC 4180 only allows the comma with --csv-strict-rfc4180", string(options.Delimiter))
+	}
+	if _, err := options.boolOption("quote-all"); err != nil {
+		return err
+	}
+	_, err := options.choiceOption("delimiter-collision", CollisionWarn, CollisionQuoteAll, CollisionIgnore)
+	return err
+}
+
 func (e *csvExporter) ExportCopy(conn *pgx.Conn, query string, csvPath string, options ExportOptions) (int, error) {
 
+	if options.CSVStrict {
+		return 0, fmt.Errorf("--csv-strict-rfc4180 cannot be used in COPY mode: COPY ends lines with LF and does not check values")
+	}
+
 	start := time.Now()
 	logger.Debug("Starting PostgreSQL COPY export (noHeader=%v, compression=%s)", options.NoHeader, options.Compression)
 
-	writerCloser, err := createOutputWriter(csvPath, options, FormatCSV)
+	// COPY output cannot be inspected, describe the result first
+	fields, err := db.DescribeQuery(context.Background(), conn, query)
 	if err != nil {
 		return 0, err
 	}
+	if len(fields) == 0 && !options.AllowEmptySchema {
+		return 0, ErrNoColumns
+	}
 
-	defer writerCloser.Close()
+	server, _ := db.DetectServer(conn)
+	copySql := copyStatement(query, options, server.Supports(db.FeatureCopyOptions))
 
-	copySql := fmt.Sprintf("COPY (%s) TO STDOUT WITH (FORMAT csv, HEADER %t, DELIMITER '%c')", query, !options.NoHeader, options.Delimiter)
+	var heartbeat *db.Heartbeat
+	defer func() { heartbeat.Stop() }()
+	copyTo := func(w io.Writer) (int64, error) {
+		tag, err := conn.PgConn().CopyTo(context.Background(), w, copySql)
+		if err != nil {
+			return 0, err
+		}
+		// Buffered output may still be on its way to the file
+		heartbeat = db.StartHeartbeat(conn, options.KeepAlive)
+		return tag.RowsAffected(), nil
+	}
 
-	tag, err := conn.PgConn().CopyTo(context.Background(), writerCloser, copySql)
+	rowCount, err := writeCopyOutput(csvPath, options, len(fields) == 0, copyTo)
 	if err != nil {
-		return 0, fmt.Errorf("COPY TO STDOUT failed: %w", err)
+		return rowCount, err
 	}
-
-	rowCount := int(tag.RowsAffected())
 	logger.Debug("COPY export completed successfully: %d rows written in %v", rowCount, time.Since(start))
+	return rowCount, nil
+}
+
+// writeCopyOutput writes the output of copyTo, which returns the number of rows
+// it copied, to csvPath. Write failures are reported as by Export; since COPY does
+// not tell where rows end, the output of a failed COPY is always removed.
+func writeCopyOutput(csvPath string, options ExportOptions, noColumns bool, copyTo func(io.Writer) (int64, error)) (rowCount int, err error) {
+	out, err := createOutputWriter(csvPath, options, FormatCSV)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { rowCount, err = out.finish(rowCount, err) }()
+
+	if noColumns {
+		logger.Warn("The query returned no columns: writing an empty file")
+		return 0, nil
+	}
 
+	if err := writeCSVComments(out, options.ColumnComments); err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	copyRows := func(w io.Writer) (err error) {
+		rows, err = copyTo(w)
+		return err
+	}
+	if options.CopyBuffer > 0 {
+		err = copyThroughSpillBuffer(out, options, copyRows)
+	} else {
+		err = copyRows(out)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("COPY TO STDOUT failed: %w", err)
+	}
+
+	rowCount = int(rows)
+	if err := out.Close(); err != nil {
+		return rowCount, fmt.Errorf("error closing output: %w", err)
+	}
 	return rowCount, nil
+}
+
+// writeCSVComments writes a "# column: comment" line for each commented column
+// ahead of the CSV header. Line breaks inside comments are folded into spaces.
+func writeCSVComments(w io.Writer, comments []ColumnComment) error {
+	for _, c := range comments {
+		if c.Text == "" {
+			continue
+		}
+		text := strings.Join(strings.Fields(c.Text), " ")
+		if _, err := fmt.Fprintf(w, "# %s: %s\n", c.Name, text); err != nil {
+			return fmt.Errorf("error writing column comments: %w", err)
+		}
+	}
+	return nil
+}
 
+// copyStatement builds the COPY command for query. Servers older than 9.0 do not accept
+// the parenthesized option list, so the legacy syntax is used for them.
+func copyStatement(query string, options ExportOptions, optionList bool) string {
+	quoteAll, _ := options.boolOption("quote-all") // checked by Validate
+	if !optionList {
+		header := " HEADER"
+		if options.NoHeader {
+			header = ""
+		}
+		forceQuote := ""
+		if quoteAll {
+			forceQuote = " FORCE QUOTE *"
+		}
+		return fmt.Sprintf("COPY (%s) TO STDOUT WITH DELIMITER '%c' CSV%s%s", query, options.Delimiter, header, forceQuote)
+	}
+	forceQuote := ""
+	if quoteAll {
+		forceQuote = ", FORCE_QUOTE *"
+	}
+	return fmt.Sprintf("COPY (%s) TO STDOUT WITH (FORMAT csv, HEADER %t, DELIMITER '%c'%s)", query, !options.NoHeader, options.Delimiter, forceQuote)
+}
+
+// Info describes the format for help pages and generated documentation.
+func (e *csvExporter) Info() FormatInfo {
+	return FormatInfo{
+		Title:       "CSV",
+		Description: "Comma-separated values with a header row, quoted according to RFC 4180.",
+		Extension:   ".csv",
+		Flags:       []string{"delimiter", "no-header", "with-copy", "include-comments", "csv-strict-rfc4180"},
+		Options: []Option{
+			{Name: "delimiter", Flag: "delimiter", Default: ",", Description: "Field delimiter"},
+			{Name: "quote-all", Default: "false", Description: "Quote every field, not only those that need it"},
+			{Name: "strict-rfc4180", Flag: "csv-strict-rfc4180", Default: "false", Description: "Follow RFC 4180 strictly: CRLF line endings, and an error for values with control characters or invalid UTF-8"},
+			{Name: "delimiter-collision", Default: CollisionWarn, Description: "When the first rows often contain a delimiter other than a comma: warn, quote-all fields, or ignore"},
+		},
+		Notes: []string{
+			"NULL values are written as empty fields.",
+			"With a delimiter other than a comma, the first 1000 rows are checked for values containing it, which break parsers that split lines on the delimiter (except in COPY mode).",
+			"With --csv-strict-rfc4180, records end with CRLF, line breaks inside quoted values are kept as they are, and values containing control characters other than line breaks (including tabs) or invalid UTF-8 fail the export.",
+			"In COPY mode values are formatted by PostgreSQL, so --time-format and --time-zone are ignored.",
+			"With --include-comments, column comments are written as \"# column: comment\" lines before the header.",
+		},
+	}
 }
 
 func init() {
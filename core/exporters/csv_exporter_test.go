@@ -10,7 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/rowsource"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportCSV(t *testing.T) {
@@ -904,3 +906,133 @@ func BenchmarkExportCSV(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+func TestCSVExporterValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		delimiter rune
+		wantErr   bool
+	}{
+		{name: "comma", delimiter: ',', wantErr: false},
+		{name: "tab", delimiter: '\t', wantErr: false},
+		{name: "unicode", delimiter: '→', wantErr: false},
+		{name: "quote", delimiter: '"', wantErr: true},
+		{name: "newline", delimiter: '\n', wantErr: true},
+		{name: "carriage return", delimiter: '\r', wantErr: true},
+		{name: "NUL", delimiter: 0, wantErr: true},
+	}
+
+	exporter := &csvExporter{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := exporter.Validate(ExportOptions{Format: FormatCSV, Delimiter: tt.delimiter})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCopyStatement(t *testing.T) {
+	query := "SELECT 1"
+
+	got := copyStatement(query, ExportOptions{Delimiter: ';'}, true)
+	if got != "COPY (SELECT 1) TO STDOUT WITH (FORMAT csv, HEADER true, DELIMITER ';')" {
+		t.Errorf("option list syntax: %s", got)
+	}
+
+	got = copyStatement(query, ExportOptions{Delimiter: ','}, false)
+	if got != "COPY (SELECT 1) TO STDOUT WITH DELIMITER ',' CSV HEADER" {
+		t.Errorf("legacy syntax: %s", got)
+	}
+
+	got = copyStatement(query, ExportOptions{Delimiter: ',', NoHeader: true}, false)
+	if got != "COPY (SELECT 1) TO STDOUT WITH DELIMITER ',' CSV" {
+		t.Errorf("legacy syntax without header: %s", got)
+	}
+
+	quoteAll := map[string]string{"quote-all": "true"}
+	got = copyStatement(query, ExportOptions{Delimiter: ',', FormatOptions: quoteAll}, true)
+	if got != "COPY (SELECT 1) TO STDOUT WITH (FORMAT csv, HEADER true, DELIMITER ',', FORCE_QUOTE *)" {
+		t.Errorf("option list syntax with quote-all: %s", got)
+	}
+	got = copyStatement(query, ExportOptions{Delimiter: ',', FormatOptions: quoteAll}, false)
+	if got != "COPY (SELECT 1) TO STDOUT WITH DELIMITER ',' CSV HEADER FORCE QUOTE *" {
+		t.Errorf("legacy syntax with quote-all: %s", got)
+	}
+}
+
+func TestWriteCSVColumnComments(t *testing.T) {
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "label", OID: pgtype.TextOID},
+	}, [][]any{{int32(1), "one"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "comments.csv")
+	options := ExportOptions{
+		Format:      FormatCSV,
+		Delimiter:   ',',
+		Compression: "none",
+		ColumnComments: []ColumnComment{
+			{Name: "id", Text: "Primary key"},
+			{Name: "label", Text: "Display name,\nshown in the UI"},
+		},
+	}
+	exporter, _ := GetExporter(FormatCSV)
+	if _, err := exporter.Export(rows, path, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# id: Primary key\n# label: Display name, shown in the UI\nid,label\n1,one\n"
+	if string(data) != expected {
+		t.Errorf("got:\n%s\nwant:\n%s", data, expected)
+	}
+}
+
+func TestWriteCSVStrictRFC4180(t *testing.T) {
+	columns := []rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "note", OID: pgtype.TextOID},
+	}
+	rows, err := rowsource.New(columns, [][]any{
+		{int32(1), "multi\nline"},
+		{int32(2), nil},
+		{int32(3), " padded, \"quoted\""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporter, _ := GetExporter(FormatCSV)
+	options := ExportOptions{Format: FormatCSV, Delimiter: ',', Compression: "none", CSVStrict: true}
+	path := filepath.Join(t.TempDir(), "strict.csv")
+	if _, err := exporter.Export(rows, path, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "id,note\r\n1,\"multi\nline\"\r\n2,\r\n3,\" padded, \"\"quoted\"\"\"\r\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+
+	rows, _ = rowsource.New(columns, [][]any{{int32(1), "ok"}, {int32(2), "tab\there"}})
+	_, err = exporter.Export(rows, path, options)
+	if err == nil || err.Error() != `row 2, column "note": control character U+0009 at byte 3, which RFC 4180 does not allow` {
+		t.Errorf("Export() error = %v", err)
+	}
+
+	options.Delimiter = ';'
+	if err := exporter.Validate(options); err == nil || !strings.Contains(err.Error(), "RFC 4180 only allows the comma") {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package exporters
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoColumns is returned for results without columns, such as the result of a
+// function returning void, which no format can represent.
+var ErrNoColumns = errors.New("the query returned no columns (e.g. a function returning void): " +
+	"select at least one column, or use --allow-empty-schema to write an empty file")
+
+// checkColumns rejects results without columns before anything is written. With
+// AllowEmptySchema their rows are discarded instead, and the returned empty result
+// produces a valid file holding no rows.
+func checkColumns(rows pgx.Rows, options ExportOptions) (pgx.Rows, error) {
+	if len(rows.FieldDescriptions()) > 0 {
+		return rows, nil
+	}
+	if !options.AllowEmptySchema {
+		return nil, ErrNoColumns
+	}
+
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	logger.Warn("The query returned no columns (%d row(s) discarded): writing an empty file", n)
+	return rowsource.FromFieldDescriptions(nil, nil)
+}
@@ -0,0 +1,87 @@
+package exporters
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExportNoColumns(t *testing.T) {
+	for _, format := range ListExporters() {
+		t.Run(format, func(t *testing.T) {
+			exporter, _ := GetExporter(format)
+			options := ExportOptions{
+				Format: format, Delimiter: ',', Compression: None, TableName: "t", RowPerStatement: 1,
+				XmlRootElement: "results", XmlRowElement: "row", XlsxSheetName: defaultSheetName, TimeZone: "UTC",
+			}
+			path := filepath.Join(t.TempDir(), "out."+format)
+
+			// SELECT FROM generate_series(1, 2): two rows, no columns
+			rows, _ := rowsource.New(nil, [][]any{{}, {}})
+			if _, err := exporter.Export(rows, path, options); !errors.Is(err, ErrNoColumns) {
+				t.Fatalf("Export() error = %v, want ErrNoColumns", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("no file should be created, stat error = %v", err)
+			}
+
+			options.AllowEmptySchema = true
+			rows, _ = rowsource.New(nil, [][]any{{}, {}})
+			n, err := exporter.Export(rows, path, options)
+			if err != nil || n != 0 {
+				t.Fatalf("Export() = %d, %v, want an empty export", n, err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkEmptyArtifact(t, format, path, content)
+		})
+	}
+}
+
+// checkEmptyArtifact checks that content is a well-formed file of format holding no rows.
+func checkEmptyArtifact(t *testing.T, format, path string, content []byte) {
+	t.Helper()
+	switch format {
+	case FormatCSV:
+		if len(content) != 0 {
+			t.Errorf("csv = %q, want an empty file", content)
+		}
+	case FormatJSON:
+		var items []any
+		if err := json.Unmarshal(content, &items); err != nil || len(items) != 0 {
+			t.Errorf("json = %q (%v), want []", content, err)
+		}
+	case FormatYAML:
+		var items []any
+		if err := yaml.Unmarshal(content, &items); err != nil || len(items) != 0 {
+			t.Errorf("yaml = %q (%v), want an empty list", content, err)
+		}
+	case FormatXML:
+		if want := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<results></results>\n"; string(content) != want {
+			t.Errorf("xml = %q, want %q", content, want)
+		}
+	case FormatSQL:
+		if len(content) != 0 {
+			t.Errorf("sql = %q, want no statements", content)
+		}
+	case FormatXLSX:
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if rows, err := f.GetRows(defaultSheetName); err != nil || len(rows) != 0 {
+			t.Errorf("xlsx rows = %v (%v), want none", rows, err)
+		}
+	default:
+		t.Errorf("no check for format %s", format)
+	}
+}
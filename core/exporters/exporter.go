@@ -1,6 +1,12 @@
 package exporters
 
 import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/jackc/pgx/v5"
 )
 
@@ -15,21 +21,74 @@ const (
 
 // ExportOptions holds export configuration
 type ExportOptions struct {
-	Format          string
-	Delimiter       rune
-	TableName       string
-	Compression     string
-	TimeFormat      string
-	TimeZone        string
-	NoHeader        bool
-	XmlRootElement  string
-	XmlRowElement   string
-	RowPerStatement int
+	Format           string
+	Delimiter        rune
+	TableName        string
+	Compression      string
+	TimeFormat       string
+	TimeZone         string
+	NoHeader         bool
+	XmlRootElement   string
+	XmlRowElement    string
+	XmlNamePolicy    string // XMLNameSanitize (or empty), XMLNameAttr or XMLNameFail
+	XlsxSheetName    string
+	RowPerStatement  int
+	MaxRowBytes      int64             // 0 disables the per-row size limit
+	LargeRowPolicy   string            // fail or skip rows larger than MaxRowBytes
+	SkipColumns      []int             // result column positions left out of SQL INSERT statements
+	DisableTriggers  string            // "", TriggersAlter or TriggersReplica: wrap SQL INSERTs to disable triggers
+	SQLFiles         int               // split SQL output into this many files, each in its own transaction (0 = single file)
+	ColumnComments   []ColumnComment   // catalog comment of each result column, in column order
+	CopyBuffer       int64             // COPY mode: bytes queued in memory before spilling to disk (0 = no buffering)
+	CopySpillLimit   int64             // COPY mode: spilled bytes at which COPY waits for the output (0 = unlimited)
+	CopySpillDir     string            // COPY mode: directory of the spill file (empty = system temporary directory)
+	KeepAlive        time.Duration     // ping the connection at this interval while it waits on the output (0 = never)
+	ColumnWidths     []int             // XLSX: width of each column in characters, from a first pass over the result
+	FormatOptions    map[string]string // --opt values of the export format without a flag, by option name
+	AllowEmptySchema bool              // write an empty file for results without columns instead of failing
+	CSVStrict        bool              // CSV: follow RFC 4180 strictly (comma, CRLF, no control characters)
+	JSONNullKeys     string            // JSON: JSONNullKeep (or empty), JSONNullOmit or JSONNullExplicit
+	Log              *OutputLog        // collects the rows, bytes and files written (nil = not collected)
+}
+
+// boolOption returns the value of a boolean format option, false when it is unset.
+func (o ExportOptions) boolOption(name string) (bool, error) {
+	v, ok := o.FormatOptions[name]
+	if !ok {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s.%s value %q: expected true or false", o.Format, name, v)
+	}
+	return b, nil
+}
+
+// choiceOption returns the value of a format option taking one of choices, the
+// first choice when it is unset.
+func (o ExportOptions) choiceOption(name string, choices ...string) (string, error) {
+	v, ok := o.FormatOptions[name]
+	if !ok {
+		return choices[0], nil
+	}
+	if !slices.Contains(choices, v) {
+		return "", fmt.Errorf("invalid %s.%s value %q: expected one of %s", o.Format, name, v, strings.Join(choices, ", "))
+	}
+	return v, nil
+}
+
+// ColumnComment is the catalog comment (COMMENT ON COLUMN) of a result column.
+// Text is empty for columns without comment.
+type ColumnComment struct {
+	Name string
+	Text string
 }
 
 // Exporter interface defines export operations
 type Exporter interface {
 	Export(rows pgx.Rows, outputPath string, options ExportOptions) (int, error)
+	// Validate checks format-specific options before any database work is done
+	Validate(options ExportOptions) error
 }
 
 // Optional capability interface for exporters that can use PostgreSQL COPY
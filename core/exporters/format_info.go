@@ -0,0 +1,68 @@
+package exporters
+
+// FormatInfo describes an export format for help pages and generated documentation.
+type FormatInfo struct {
+	Name         string
+	Title        string
+	Description  string
+	Extension    string
+	SupportsCopy bool
+	// Flags lists the command-line flags that only apply to this format
+	Flags []string
+	// Options lists the settings accepted by --opt <format>.<name>=<value>
+	Options []Option
+	Notes   []string
+}
+
+// Option is a format setting given as --opt <format>.<name>=<value>. Options
+// standing for a command-line flag set that flag; the others are handed to the
+// exporter in ExportOptions.FormatOptions, so exporters can add settings without
+// a new flag.
+type Option struct {
+	Name        string
+	Description string
+	Default     string
+	Flag        string // command-line flag set by the option, if any
+}
+
+// Describer is implemented by exporters that provide documentation metadata.
+type Describer interface {
+	Info() FormatInfo
+}
+
+// DescribeFormat returns the documentation metadata of a registered format.
+// Exporters that do not implement Describer get a minimal description.
+func DescribeFormat(format string) (FormatInfo, error) {
+	exporter, err := GetExporter(format)
+	if err != nil {
+		return FormatInfo{}, err
+	}
+
+	info := FormatInfo{Name: format, Title: format}
+	if d, ok := exporter.(Describer); ok {
+		info = d.Info()
+		info.Name = format
+	}
+	if _, ok := exporter.(CopyCapable); ok {
+		info.SupportsCopy = true
+	}
+	return info, nil
+}
+
+// CompressionInfo describes an output compression mode.
+type CompressionInfo struct {
+	Name        string
+	Extension   string
+	Description string
+}
+
+var compressions = []CompressionInfo{
+	{Name: None, Description: "Write the output file as is"},
+	{Name: GZIP, Extension: ".gz", Description: "Stream the output through gzip; .gz is appended to the output path when missing"},
+	{Name: ZIP, Extension: ".zip", Description: "Store the output as a single entry of a zip archive; the output path extension is replaced by .zip"},
+}
+
+// ListCompressions returns the supported compression modes in display order.
+func ListCompressions() []CompressionInfo {
+	return append([]CompressionInfo(nil), compressions...)
+}
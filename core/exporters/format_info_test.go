@@ -0,0 +1,40 @@
+package exporters
+
+import "testing"
+
+func TestDescribeFormat(t *testing.T) {
+	for _, name := range ListExporters() {
+		t.Run(name, func(t *testing.T) {
+			info, err := DescribeFormat(name)
+			if err != nil {
+				t.Fatalf("DescribeFormat() error: %v", err)
+			}
+			if info.Name != name {
+				t.Errorf("Name = %q, want %q", info.Name, name)
+			}
+			if info.Title == "" || info.Description == "" || info.Extension == "" {
+				t.Errorf("incomplete format metadata: %+v", info)
+			}
+			if info.SupportsCopy != (name == FormatCSV) {
+				t.Errorf("SupportsCopy = %v for %s", info.SupportsCopy, name)
+			}
+		})
+	}
+
+	if _, err := DescribeFormat("parquet"); err == nil {
+		t.Error("DescribeFormat() expected error for unknown format")
+	}
+}
+
+func TestListCompressions(t *testing.T) {
+	got := ListCompressions()
+	want := []string{None, GZIP, ZIP}
+	if len(got) != len(want) {
+		t.Fatalf("ListCompressions() returned %d entries, want %d", len(got), len(want))
+	}
+	for i, c := range got {
+		if c.Name != want[i] || c.Description == "" {
+			t.Errorf("ListCompressions()[%d] = %+v", i, c)
+		}
+	}
+}
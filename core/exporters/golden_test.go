@@ -0,0 +1,228 @@
+package exporters
+
+import (
+	"bytes"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/xuri/excelize/v2"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+// goldenColumns is the canonical column layout shared by all snapshot tests.
+var goldenColumns = []rowsource.Column{
+	{Name: "id", OID: pgtype.Int4OID},
+	{Name: "name", OID: pgtype.TextOID},
+	{Name: "price", OID: pgtype.NumericOID},
+	{Name: "active", OID: pgtype.BoolOID},
+	{Name: "birth_date", OID: pgtype.DateOID},
+	{Name: "created_at", OID: pgtype.TimestampOID},
+	{Name: "updated_at", OID: pgtype.TimestamptzOID},
+	{Name: "uuid", OID: pgtype.UUIDOID},
+	{Name: "tags", OID: pgtype.TextArrayOID},
+	{Name: "metadata", OID: pgtype.JSONBOID},
+	{Name: "note", OID: pgtype.TextOID},
+}
+
+// goldenRows returns a fresh row source with canonical data covering
+// NULLs, special characters and all commonly used PostgreSQL types.
+func goldenRows(t *testing.T) pgx.Rows {
+	t.Helper()
+
+	created := time.Date(2024, 3, 15, 14, 30, 45, 123000000, time.UTC)
+	updated := time.Date(2024, 3, 16, 8, 5, 0, 0, time.UTC)
+	birth := time.Date(1990, 7, 4, 0, 0, 0, 0, time.UTC)
+
+	rows, err := rowsource.New(goldenColumns, [][]any{
+		{
+			int32(1), "Alice", pgtype.Numeric{Int: big.NewInt(1999), Exp: -2, Valid: true}, true,
+			birth, created, updated,
+			[16]byte{0x9f, 0x4d, 0xaf, 0x39, 0x5b, 0x76, 0x4b, 0x9c, 0xa1, 0x47, 0x82, 0x0f, 0x8f, 0x0c, 0x94, 0x5f},
+			[]interface{}{"admin", "staff"},
+			map[string]any{"plan": "pro", "seats": float64(5)},
+			"plain text",
+		},
+		{
+			int32(2), "O'Brien, Bob", pgtype.Numeric{Int: big.NewInt(-5), Exp: 0, Valid: true}, false,
+			nil, created.Add(24 * time.Hour), nil,
+			nil,
+			[]interface{}{},
+			[]any{float64(1), "two"},
+			"quote \" and <tag> & ampersand\nsecond line",
+		},
+		{
+			int32(3), nil, nil, nil,
+			nil, nil, nil,
+			nil, nil, nil,
+			nil,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build golden rows: %v", err)
+	}
+	return rows
+}
+
+func emptyGoldenRows(t *testing.T) pgx.Rows {
+	t.Helper()
+	rows, err := rowsource.New(goldenColumns, nil)
+	if err != nil {
+		t.Fatalf("Failed to build empty rows: %v", err)
+	}
+	return rows
+}
+
+func goldenOptions(format string) ExportOptions {
+	return ExportOptions{
+		Format:          format,
+		Delimiter:       ',',
+		Compression:     None,
+		TimeFormat:      "yyyy-MM-dd HH:mm:ss",
+		TimeZone:        "UTC",
+		XmlRootElement:  "results",
+		XmlRowElement:   "row",
+		TableName:       "public.users",
+		RowPerStatement: 1,
+	}
+}
+
+func TestExportersGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		modify func(o *ExportOptions)
+		rows   func(t *testing.T) pgx.Rows
+	}{
+		{name: "csv_default", format: FormatCSV},
+		{name: "csv_semicolon_no_header", format: FormatCSV, modify: func(o *ExportOptions) {
+			o.Delimiter = ';'
+			o.NoHeader = true
+		}},
+		{name: "csv_custom_time", format: FormatCSV, modify: func(o *ExportOptions) {
+			o.TimeFormat = "dd/MM/yyyy HH:mm:ss.SSS"
+			o.TimeZone = "Europe/Paris"
+		}},
+		{name: "csv_empty", format: FormatCSV, rows: emptyGoldenRows},
+		{name: "json_default", format: FormatJSON},
+		{name: "json_custom_time", format: FormatJSON, modify: func(o *ExportOptions) {
+			o.TimeFormat = "yyyy-MM-ddTHH:mm:ss"
+			o.TimeZone = "America/New_York"
+		}},
+		{name: "json_empty", format: FormatJSON, rows: emptyGoldenRows},
+		{name: "json_omit_null", format: FormatJSON, modify: func(o *ExportOptions) {
+			o.JSONNullKeys = JSONNullOmit
+		}},
+		{name: "json_null_explicit", format: FormatJSON, modify: func(o *ExportOptions) {
+			o.JSONNullKeys = JSONNullExplicit
+		}},
+		{name: "xml_default", format: FormatXML},
+		{name: "xml_custom_tags", format: FormatXML, modify: func(o *ExportOptions) {
+			o.XmlRootElement = "users"
+			o.XmlRowElement = "user"
+		}},
+		{name: "yaml_default", format: FormatYAML},
+		{name: "sql_default", format: FormatSQL},
+		{name: "sql_batch", format: FormatSQL, modify: func(o *ExportOptions) {
+			o.RowPerStatement = 2
+		}},
+		{name: "xlsx_default", format: FormatXLSX},
+		{name: "xlsx_no_header", format: FormatXLSX, modify: func(o *ExportOptions) {
+			o.NoHeader = true
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := goldenOptions(tt.format)
+			if tt.modify != nil {
+				tt.modify(&options)
+			}
+			var rows pgx.Rows
+			if tt.rows != nil {
+				rows = tt.rows(t)
+			} else {
+				rows = goldenRows(t)
+			}
+
+			exporter, err := GetExporter(tt.format)
+			if err != nil {
+				t.Fatalf("GetExporter(%q) error: %v", tt.format, err)
+			}
+
+			outputPath := filepath.Join(t.TempDir(), "output."+tt.format)
+			if _, err := exporter.Export(rows, outputPath, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			got := readSnapshot(t, tt.format, outputPath)
+			assertGolden(t, tt.name, got)
+		})
+	}
+}
+
+// readSnapshot returns a stable textual representation of an export output.
+func readSnapshot(t *testing.T, format, path string) []byte {
+	t.Helper()
+
+	if format != FormatXLSX {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read output file: %v", err)
+		}
+		return content
+	}
+
+	// XLSX files are zip archives with volatile metadata, so compare cell contents
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open XLSX file: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows("Sheet1")
+	if err != nil {
+		t.Fatalf("Failed to read XLSX rows: %v", err)
+	}
+
+	var buf bytes.Buffer
+	for _, row := range rows {
+		buf.WriteString(strings.Join(row, "\t"))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	goldenPath := filepath.Join("testdata", "golden", name+".golden")
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatalf("Failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output does not match %s (run with -update to refresh)\n--- got ---\n%s\n--- want ---\n%s",
+			goldenPath, got, want)
+	}
+}
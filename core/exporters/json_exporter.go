@@ -3,6 +3,7 @@ package exporters
 import (
 	"bufio"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/fbz-tec/pgxport/core/encoders"
@@ -10,21 +11,36 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// Values of ExportOptions.JSONNullKeys
+const (
+	JSONNullKeep     = "keep"     // write "key": null
+	JSONNullOmit     = "omit"     // leave the key out
+	JSONNullExplicit = "explicit" // leave the key out and list it under JSONNullsKey
+)
+
+// JSONNullsKey lists the keys left out of an object with JSONNullExplicit.
+const JSONNullsKey = "_nulls"
+
 type jsonExporter struct{}
 
 // writes query results to a JSON file with buffered I/O
-func (e *jsonExporter) Export(rows pgx.Rows, jsonPath string, options ExportOptions) (int, error) {
+func (e *jsonExporter) Export(rows pgx.Rows, jsonPath string, options ExportOptions) (rowCount int, err error) {
 	start := time.Now()
 	logger.Debug("Preparing JSON export (indent=2 spaces, compression=%s)", options.Compression)
 
-	writeCloser, err := createOutputWriter(jsonPath, options, FormatJSON)
+	rows, err = checkColumns(rows, options)
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := createOutputWriter(jsonPath, options, FormatJSON)
 	if err != nil {
 		return 0, err
 	}
-	defer writeCloser.Close()
+	defer func() { rowCount, err = out.finish(rowCount, err) }()
 
 	// Use buffered writer for better performance
-	bufferedWriter := bufio.NewWriter(writeCloser)
+	bufferedWriter := bufio.NewWriter(out)
 	defer bufferedWriter.Flush()
 
 	// Get column names (keys)
@@ -43,8 +59,19 @@ func (e *jsonExporter) Export(rows pgx.Rows, jsonPath string, options ExportOpti
 
 	// Create ordered JSON encoder
 	orderedEncoder := encoders.NewOrderedJsonEncoder(options.TimeFormat, options.TimeZone)
+	switch options.JSONNullKeys {
+	case JSONNullOmit:
+		orderedEncoder.OmitNull = true
+	case JSONNullExplicit:
+		if slices.Contains(keys, JSONNullsKey) {
+			return 0, fmt.Errorf("column %q clashes with the list of NULL keys written by --json-null-keys %s", JSONNullsKey, JSONNullExplicit)
+		}
+		orderedEncoder.OmitNull = true
+		orderedEncoder.NullsKey = JSONNullsKey
+	}
 
-	rowCount := 0
+	rowNum := 0
+	guard := newRowSizeGuard(options)
 	logger.Debug("Starting to write JSON objects...")
 
 	for rows.Next() {
@@ -53,6 +80,22 @@ func (e *jsonExporter) Export(rows pgx.Rows, jsonPath string, options ExportOpti
 			return rowCount, fmt.Errorf("error reading row: %w", err)
 		}
 
+		rowNum++
+
+		// Encode with preserved order
+		jsonBytes, err := orderedEncoder.EncodeRow(keys, dataTypes, values)
+		if err != nil {
+			return rowCount, fmt.Errorf("error encoding JSON for row %d: %w", rowNum, err)
+		}
+
+		ok, err := guard.allow(rowNum, len(jsonBytes))
+		if err != nil {
+			return rowCount, err
+		}
+		if !ok {
+			continue
+		}
+
 		// Write comma separator for subsequent entries
 		if rowCount > 0 {
 			if _, err := bufferedWriter.WriteString(",\n"); err != nil {
@@ -60,12 +103,6 @@ func (e *jsonExporter) Export(rows pgx.Rows, jsonPath string, options ExportOpti
 			}
 		}
 
-		// Encode with preserved order
-		jsonBytes, err := orderedEncoder.EncodeRow(keys, dataTypes, values)
-		if err != nil {
-			return rowCount, fmt.Errorf("error encoding JSON for row %d: %w", rowCount, err)
-		}
-
 		// Write with indentation
 		if _, err := bufferedWriter.WriteString("  "); err != nil {
 			return rowCount, fmt.Errorf("error writing indentation for row %d: %w", rowCount, err)
@@ -75,6 +112,7 @@ func (e *jsonExporter) Export(rows pgx.Rows, jsonPath string, options ExportOpti
 		}
 
 		rowCount++
+		out.markRows(rowCount, bufferedWriter.Buffered())
 
 		if rowCount%10000 == 0 {
 			bufferedWriter.Flush()
@@ -86,6 +124,8 @@ func (e *jsonExporter) Export(rows pgx.Rows, jsonPath string, options ExportOpti
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
 
+	guard.report()
+
 	// Write closing bracket
 	if _, err := bufferedWriter.WriteString("\n]\n"); err != nil {
 		return rowCount, fmt.Errorf("error writing end of JSON array: %w", err)
@@ -96,6 +136,32 @@ func (e *jsonExporter) Export(rows pgx.Rows, jsonPath string, options ExportOpti
 	return rowCount, nil
 }
 
+// Validate checks the NULL keys policy.
+func (e *jsonExporter) Validate(options ExportOptions) error {
+	switch options.JSONNullKeys {
+	case "", JSONNullKeep, JSONNullOmit, JSONNullExplicit:
+		return nil
+	}
+	return fmt.Errorf("invalid --json-null-keys %q. Valid values are: %s, %s, %s", options.JSONNullKeys, JSONNullKeep, JSONNullOmit, JSONNullExplicit)
+}
+
+// Info describes the format for help pages and generated documentation.
+func (e *jsonExporter) Info() FormatInfo {
+	return FormatInfo{
+		Title:       "JSON",
+		Description: "An array of objects whose keys follow the column order of the query.",
+		Extension:   ".json",
+		Flags:       []string{"json-null-keys", "json-omit-null"},
+		Options: []Option{
+			{Name: "null-keys", Flag: "json-null-keys", Default: JSONNullKeep, Description: "Keys of NULL values: keep them, omit them, or omit them and list them under \"_nulls\" (explicit)"},
+		},
+		Notes: []string{
+			"NULL values are written as null; json and jsonb columns are embedded as JSON values.",
+			"With --json-omit-null (--json-null-keys omit), keys of NULL values are left out, which shrinks sparse wide tables; explicit also lists them in a \"_nulls\" array so that a NULL can be told from a missing column.",
+		},
+	}
+}
+
 func init() {
 	MustRegisterExporter(FormatJSON, func() Exporter { return &jsonExporter{} })
 }
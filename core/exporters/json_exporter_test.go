@@ -9,7 +9,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/rowsource"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportJSON(t *testing.T) {
@@ -562,3 +564,36 @@ func BenchmarkExportJSON(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+func TestJSONNullKeys(t *testing.T) {
+	exporter := &jsonExporter{}
+	if err := exporter.Validate(ExportOptions{Format: FormatJSON, JSONNullKeys: "drop"}); err == nil || !strings.Contains(err.Error(), `invalid --json-null-keys "drop"`) {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// A column can clash with the list of NULL keys
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: JSONNullsKey, OID: pgtype.TextOID},
+	}, [][]any{{int32(1), nil}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := ExportOptions{Format: FormatJSON, Compression: "none", JSONNullKeys: JSONNullExplicit}
+	_, err = exporter.Export(rows, filepath.Join(t.TempDir(), "out.json"), options)
+	if err == nil || !strings.Contains(err.Error(), `column "_nulls" clashes`) {
+		t.Errorf("Export() error = %v", err)
+	}
+
+	// Objects whose values are all NULL stay valid JSON
+	rows, _ = rowsource.New([]rowsource.Column{{Name: "note", OID: pgtype.TextOID}}, [][]any{{nil}, {"x"}})
+	path := filepath.Join(t.TempDir(), "out.json")
+	options.JSONNullKeys = JSONNullOmit
+	if _, err := exporter.Export(rows, path, options); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "[\n  {},\n  {\n    \"note\": \"x\"\n  }\n]\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,100 @@
+package exporters
+
+import (
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Middleware decorates an Exporter, typically by changing or observing the rows
+// it reads, so that behaviors such as masking, filtering or metrics are written
+// once for every format.
+//
+// Decorated exporters do not use COPY, which bypasses the rows.
+type Middleware func(Exporter) Exporter
+
+// Chain returns exporter decorated with middlewares. The first middleware is the
+// outermost one: it sees the rows first.
+func Chain(exporter Exporter, middlewares ...Middleware) Exporter {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		exporter = middlewares[i](exporter)
+	}
+	return exporter
+}
+
+// RowsWrapper replaces the rows handed to an exporter.
+type RowsWrapper func(rows pgx.Rows, options ExportOptions) (pgx.Rows, error)
+
+// WrapRows returns a Middleware handing the exporter the rows returned by wrap.
+func WrapRows(wrap RowsWrapper) Middleware {
+	return func(next Exporter) Exporter {
+		return &wrappedExporter{next: next, wrap: wrap}
+	}
+}
+
+// wrappedExporter exports the rows of a RowsWrapper with the next exporter.
+type wrappedExporter struct {
+	next Exporter
+	wrap RowsWrapper
+}
+
+func (e *wrappedExporter) Export(rows pgx.Rows, outputPath string, options ExportOptions) (int, error) {
+	wrapped, err := e.wrap(rows, options)
+	if err != nil {
+		return 0, err
+	}
+	return e.next.Export(wrapped, outputPath, options)
+}
+
+func (e *wrappedExporter) Validate(options ExportOptions) error {
+	return e.next.Validate(options)
+}
+
+// Info describes the decorated format.
+func (e *wrappedExporter) Info() FormatInfo {
+	if d, ok := e.next.(Describer); ok {
+		return d.Info()
+	}
+	return FormatInfo{}
+}
+
+// RowTransformer prepares the transformation of a result from its columns. It
+// returns the columns of the transformed rows (nil to keep them) and the
+// function applied to each row.
+type RowTransformer func(fields []pgconn.FieldDescription) ([]pgconn.FieldDescription, rowsource.RowFunc, error)
+
+// TransformRows returns a Middleware passing every row through the RowFunc
+// prepared by t. Rows for which it returns nil are left out.
+func TransformRows(t RowTransformer) Middleware {
+	return WrapRows(func(rows pgx.Rows, options ExportOptions) (pgx.Rows, error) {
+		fields, fn, err := t(rows.FieldDescriptions())
+		if err != nil {
+			return nil, err
+		}
+		return rowsource.Transform(rows, fields, fn), nil
+	})
+}
+
+// FilterRows returns a Middleware leaving out the rows for which keep returns false.
+func FilterRows(keep func(fields []pgconn.FieldDescription, values []any) (bool, error)) Middleware {
+	return TransformRows(func(fields []pgconn.FieldDescription) ([]pgconn.FieldDescription, rowsource.RowFunc, error) {
+		return nil, func(values []any) ([]any, error) {
+			ok, err := keep(fields, values)
+			if err != nil || !ok {
+				return nil, err
+			}
+			return values, nil
+		}, nil
+	})
+}
+
+// ObserveRows returns a Middleware calling observe with every row the exporter
+// reads, for metrics or checksums. observe must not modify values.
+func ObserveRows(observe func(values []any)) Middleware {
+	return TransformRows(func([]pgconn.FieldDescription) ([]pgconn.FieldDescription, rowsource.RowFunc, error) {
+		return nil, func(values []any) ([]any, error) {
+			observe(values)
+			return values, nil
+		}, nil
+	})
+}
@@ -0,0 +1,105 @@
+package exporters
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func middlewareRows(t *testing.T) *rowsource.Rows {
+	t.Helper()
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "email", OID: pgtype.TextOID},
+	}, [][]any{
+		{int32(1), "ann@example.com"},
+		{int32(2), "bob@example.com"},
+		{int32(3), "eve@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+// maskColumn replaces the values of a column with asterisks.
+func maskColumn(name string) Middleware {
+	return TransformRows(func(fields []pgconn.FieldDescription) ([]pgconn.FieldDescription, rowsource.RowFunc, error) {
+		col := -1
+		for i, f := range fields {
+			if f.Name == name {
+				col = i
+			}
+		}
+		if col < 0 {
+			return nil, nil, errors.New("no column " + name)
+		}
+		return nil, func(values []any) ([]any, error) {
+			masked := append([]any(nil), values...)
+			masked[col] = "***"
+			return masked, nil
+		}, nil
+	})
+}
+
+func exportWith(t *testing.T, middlewares ...Middleware) (string, int, error) {
+	t.Helper()
+	csv, _ := GetExporter(FormatCSV)
+	path := filepath.Join(t.TempDir(), "out.csv")
+	n, err := Chain(csv, middlewares...).Export(middlewareRows(t), path, ExportOptions{Format: FormatCSV, Delimiter: ',', Compression: "none"})
+	data, _ := os.ReadFile(path)
+	return string(data), n, err
+}
+
+func TestChain(t *testing.T) {
+	var seen []any
+	dropBob := FilterRows(func(fields []pgconn.FieldDescription, values []any) (bool, error) {
+		return values[1] != "bob@example.com", nil
+	})
+	observe := ObserveRows(func(values []any) { seen = append(seen, values[1]) })
+
+	// The filter runs first, so bob is neither observed nor exported; the observer sees unmasked values
+	out, n, err := exportWith(t, dropBob, observe, maskColumn("email"))
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("exported %d rows, want 2", n)
+	}
+	if want := "id,email\n1,***\n3,***\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	if len(seen) != 2 || seen[0] != "ann@example.com" || seen[1] != "eve@example.com" {
+		t.Errorf("observed %v", seen)
+	}
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	if _, _, err := exportWith(t, maskColumn("ssn")); err == nil || !strings.Contains(err.Error(), "no column ssn") {
+		t.Errorf("Export() error = %v, want the transformer error", err)
+	}
+
+	failing := FilterRows(func(fields []pgconn.FieldDescription, values []any) (bool, error) {
+		return false, errors.New("lookup service unavailable")
+	})
+	if _, _, err := exportWith(t, failing); err == nil || !strings.Contains(err.Error(), "lookup service unavailable") {
+		t.Errorf("Export() error = %v, want the filter error", err)
+	}
+}
+
+func TestWrappedExporterKeepsFormatInfo(t *testing.T) {
+	csv, _ := GetExporter(FormatCSV)
+	wrapped := Chain(csv, ObserveRows(func([]any) {}))
+	if wrapped.(Describer).Info().Title != "CSV" {
+		t.Error("a decorated exporter should describe the decorated format")
+	}
+	if _, ok := wrapped.(CopyCapable); ok {
+		t.Error("a decorated exporter must not use COPY, which bypasses the middlewares")
+	}
+}
@@ -0,0 +1,266 @@
+package exporters
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/fbz-tec/pgxport/internal/logger"
+)
+
+// partialSuffix is appended to output files left incomplete by a write failure.
+const partialSuffix = ".partial"
+
+// WriteError reports a failure to write the output file once the export has started.
+// RowsWritten is the number of complete rows that reached the file before the failure;
+// it is always 0 for compressed outputs, whose archive cannot be read without its trailer.
+type WriteError struct {
+	Path        string // output file that was being written
+	RowsWritten int
+	PartialPath string // where the incomplete output was kept, empty if it was removed
+	Err         error
+}
+
+func (e *WriteError) Error() string {
+	msg := fmt.Sprintf("writing %s failed (%s) after %d rows: %v", e.Path, e.Reason(), e.RowsWritten, e.Err)
+	if e.PartialPath != "" {
+		return msg + "; incomplete output kept as " + e.PartialPath
+	}
+	return msg + "; incomplete output removed"
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// Reason returns a short description of the underlying failure.
+func (e *WriteError) Reason() string {
+	switch {
+	case errors.Is(e.Err, syscall.ENOSPC):
+		return "disk full"
+	case errors.Is(e.Err, syscall.EPIPE):
+		return "broken pipe"
+	default:
+		return "I/O error"
+	}
+}
+
+// openOutputFile creates the destination file; tests replace it to simulate failing devices.
+var openOutputFile = func(path string) (io.WriteCloser, error) {
+	return os.Create(path)
+}
+
+// WrittenFile is an output file completed by an export.
+type WrittenFile struct {
+	Requested string // path handed to the exporter
+	Path      string // path of the file, with the extension of its compression
+	Rows      int
+}
+
+// OutputLog collects what the exports given it in ExportOptions.Log write: the rows
+// and bytes written and the files completed. A child log adds its counts to its
+// parent but keeps its own files, so that a run can follow its overall progress
+// while each of its exports knows the files it produced. It is safe for concurrent
+// use; a nil *OutputLog records nothing.
+type OutputLog struct {
+	parent *OutputLog
+	rows   atomic.Int64
+	bytes  atomic.Int64
+
+	mu    sync.Mutex
+	files []WrittenFile
+}
+
+// Child returns an empty log whose counts are also added to l.
+func (l *OutputLog) Child() *OutputLog {
+	return &OutputLog{parent: l}
+}
+
+// RowsExported returns the number of rows handed to output files so far. Rows
+// exported in COPY mode are not counted, as they are never decoded.
+func (l *OutputLog) RowsExported() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.rows.Load()
+}
+
+// BytesWritten returns the number of bytes written to output files so far,
+// after compression.
+func (l *OutputLog) BytesWritten() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.bytes.Load()
+}
+
+// Files returns the output files completed so far, in completion order. The files
+// of child logs are not included.
+func (l *OutputLog) Files() []WrittenFile {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.files)
+}
+
+func (l *OutputLog) addRows(n int64) {
+	for ; l != nil; l = l.parent {
+		l.rows.Add(n)
+	}
+}
+
+func (l *OutputLog) addBytes(n int64) {
+	for ; l != nil; l = l.parent {
+		l.bytes.Add(n)
+	}
+}
+
+func (l *OutputLog) addFile(requested, path string, rows int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.files = append(l.files, WrittenFile{Requested: requested, Path: path, Rows: rows})
+	l.mu.Unlock()
+}
+
+// countingFile counts the bytes written to an output file.
+type countingFile struct {
+	io.WriteCloser
+	log *OutputLog
+}
+
+func (f countingFile) Write(p []byte) (int, error) {
+	n, err := f.WriteCloser.Write(p)
+	f.log.addBytes(int64(n))
+	return n, err
+}
+
+// createFile opens path with openOutputFile and counts the bytes written to it in log.
+func createFile(path string, log *OutputLog) (io.WriteCloser, error) {
+	file, err := openOutputFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return countingFile{file, log}, nil
+}
+
+// rowMark records the logical offset at which a given number of rows ends.
+type rowMark struct {
+	end  int64
+	rows int
+}
+
+// outputWriter is the destination handed to exporters. It remembers the first write
+// failure and how many complete rows had been accepted by the file at that point.
+type outputWriter struct {
+	path       string
+	requested  string // path asked for, before the compression extension
+	compressed bool
+	log        *OutputLog
+	dest       io.Writer
+	closeFunc  func() error
+	closed     bool
+
+	written int64 // bytes accepted by dest
+	err     error // first write or close failure
+	marks   []rowMark
+	durable int
+}
+
+func (o *outputWriter) Write(p []byte) (int, error) {
+	n, err := o.dest.Write(p)
+	o.written += int64(n)
+	o.advance()
+	if err != nil && o.err == nil {
+		o.err = err
+	}
+	return n, err
+}
+
+// Close closes the underlying file. It is safe to call more than once.
+func (o *outputWriter) Close() error {
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	if o.closeFunc == nil {
+		return nil
+	}
+	err := o.closeFunc()
+	if err != nil && o.err == nil {
+		o.err = err
+	}
+	return err
+}
+
+// markRows records that the first rows rows have been handed to the writer, buffered
+// being the number of bytes the caller still holds in its own buffer.
+func (o *outputWriter) markRows(rows int, buffered int) {
+	o.marks = append(o.marks, rowMark{end: o.written + int64(buffered), rows: rows})
+	o.advance()
+}
+
+func (o *outputWriter) advance() {
+	i := 0
+	for i < len(o.marks) && o.marks[i].end <= o.written {
+		o.durable = o.marks[i].rows
+		i++
+	}
+	if i > 0 {
+		o.marks = append(o.marks[:0], o.marks[i:]...)
+	}
+}
+
+// finish closes the output and turns any write failure into a *WriteError. The
+// output of a failed export, whatever the failure, is removed or renamed so that
+// it cannot pass for complete. Errors other than write failures are returned unchanged.
+func (o *outputWriter) finish(rowCount int, err error) (int, error) {
+	o.Close()
+	rowsWritten := o.durable
+	if o.compressed {
+		rowsWritten = 0
+	}
+
+	if o.err == nil {
+		if err == nil {
+			o.log.addFile(o.requested, o.path, rowCount)
+		} else if partial := discardIncomplete(o.path, rowsWritten); partial != "" {
+			logger.Warn("Incomplete output kept as %s (%d rows)", partial, rowsWritten)
+		}
+		return rowCount, err
+	}
+	return rowsWritten, newWriteError(o.path, rowsWritten, o.err)
+}
+
+// newWriteError cleans up the incomplete output at path and describes the failure.
+func newWriteError(path string, rowsWritten int, err error) *WriteError {
+	return &WriteError{Path: path, RowsWritten: rowsWritten, PartialPath: discardIncomplete(path, rowsWritten), Err: err}
+}
+
+// discardIncomplete moves the incomplete output at path out of the way and returns
+// where it was kept, or "" if it was removed. Files holding complete rows are kept
+// with a .partial suffix, others are removed.
+func discardIncomplete(path string, rowsWritten int) string {
+	if rowsWritten == 0 {
+		if rmErr := os.Remove(path); rmErr != nil && !os.IsNotExist(rmErr) {
+			logger.Warn("Could not remove incomplete output %s: %v", path, rmErr)
+			return path
+		}
+		return ""
+	}
+
+	partial := path + partialSuffix
+	if mvErr := os.Rename(path, partial); mvErr != nil {
+		logger.Warn("Could not rename incomplete output %s: %v", path, mvErr)
+		return path
+	}
+	return partial
+}
@@ -0,0 +1,306 @@
+package exporters
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// failingFile behaves like a file on a device that runs out of space after limit bytes.
+type failingFile struct {
+	f        *os.File
+	limit    int
+	written  int
+	closeErr error
+}
+
+func (w *failingFile) Write(p []byte) (int, error) {
+	room := w.limit - w.written
+	if room >= len(p) {
+		n, err := w.f.Write(p)
+		w.written += n
+		return n, err
+	}
+	n, _ := w.f.Write(p[:room])
+	w.written += n
+	return n, &os.PathError{Op: "write", Path: w.f.Name(), Err: syscall.ENOSPC}
+}
+
+func (w *failingFile) Close() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	return w.closeErr
+}
+
+// withFailingOutput makes every output file fail after limit bytes for the duration of the test.
+func withFailingOutput(t *testing.T, limit int, closeErr error) {
+	t.Helper()
+	original := openOutputFile
+	openOutputFile = func(path string) (io.WriteCloser, error) {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		return &failingFile{f: f, limit: limit, closeErr: closeErr}, nil
+	}
+	t.Cleanup(func() { openOutputFile = original })
+}
+
+func manyRows(t *testing.T, n int) pgx.Rows {
+	t.Helper()
+	data := make([][]any, n)
+	for i := range data {
+		data[i] = []any{int32(i + 1), fmt.Sprintf("name-%d", i+1)}
+	}
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "name", OID: pgtype.TextOID},
+	}, data)
+	if err != nil {
+		t.Fatalf("Failed to build rows: %v", err)
+	}
+	return rows
+}
+
+func writeFailureOptions(format, compression string) ExportOptions {
+	return ExportOptions{
+		Format:          format,
+		Delimiter:       ',',
+		Compression:     compression,
+		TimeZone:        "UTC",
+		XmlRootElement:  "results",
+		XmlRowElement:   "row",
+		TableName:       "items",
+		RowPerStatement: 7,
+		XlsxSheetName:   "Sheet1",
+	}
+}
+
+func TestExportDiskFullKeepsCompleteRows(t *testing.T) {
+	withFailingOutput(t, 5000, nil)
+
+	outputPath := filepath.Join(t.TempDir(), "out.csv")
+	exporter, _ := GetExporter(FormatCSV)
+	count, err := exporter.Export(manyRows(t, 1000), outputPath, writeFailureOptions(FormatCSV, None))
+
+	var writeErr *WriteError
+	if !errors.As(err, &writeErr) {
+		t.Fatalf("Export() error = %v, want *WriteError", err)
+	}
+	if writeErr.Reason() != "disk full" {
+		t.Errorf("Reason() = %q, want %q", writeErr.Reason(), "disk full")
+	}
+	if count != writeErr.RowsWritten {
+		t.Errorf("Export() count = %d, WriteError.RowsWritten = %d", count, writeErr.RowsWritten)
+	}
+	if writeErr.PartialPath != outputPath+partialSuffix {
+		t.Errorf("PartialPath = %q, want %q", writeErr.PartialPath, outputPath+partialSuffix)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("incomplete output should have been renamed, stat error = %v", err)
+	}
+
+	content, err := os.ReadFile(writeErr.PartialPath)
+	if err != nil {
+		t.Fatalf("Failed to read partial output: %v", err)
+	}
+	// Header plus complete data rows; the remainder is a truncated line
+	completeLines := strings.Count(string(content), "\n")
+	if completeLines-1 != writeErr.RowsWritten {
+		t.Errorf("RowsWritten = %d, partial file holds %d complete rows", writeErr.RowsWritten, completeLines-1)
+	}
+}
+
+func TestExportWriteFailureAllFormats(t *testing.T) {
+	for _, format := range ListExporters() {
+		for _, compression := range []string{None, GZIP} {
+			// XLSX writes uncompressed workbooks itself, without going through openOutputFile
+			if format == FormatXLSX && compression == None {
+				continue
+			}
+			t.Run(format+"_"+compression, func(t *testing.T) {
+				withFailingOutput(t, 3000, nil)
+
+				outputPath := filepath.Join(t.TempDir(), "out."+format)
+				exporter, _ := GetExporter(format)
+				_, err := exporter.Export(manyRows(t, 5000), outputPath, writeFailureOptions(format, compression))
+
+				var writeErr *WriteError
+				if !errors.As(err, &writeErr) {
+					t.Fatalf("Export() error = %v, want *WriteError", err)
+				}
+				if !errors.Is(err, syscall.ENOSPC) {
+					t.Errorf("Export() error should wrap ENOSPC: %v", err)
+				}
+				if _, statErr := os.Stat(writeErr.Path); !os.IsNotExist(statErr) {
+					t.Errorf("incomplete output %s should not be left in place", writeErr.Path)
+				}
+
+				if compression != None || format == FormatYAML {
+					if writeErr.RowsWritten != 0 || writeErr.PartialPath != "" {
+						t.Errorf("RowsWritten = %d, PartialPath = %q: no complete rows expected",
+							writeErr.RowsWritten, writeErr.PartialPath)
+					}
+					return
+				}
+				if writeErr.RowsWritten == 0 {
+					t.Error("RowsWritten = 0, expected complete rows before the failure")
+				}
+			})
+		}
+	}
+}
+
+func TestExportCloseFailure(t *testing.T) {
+	withFailingOutput(t, 1<<30, errors.New("network share disconnected"))
+
+	outputPath := filepath.Join(t.TempDir(), "out.json")
+	exporter, _ := GetExporter(FormatJSON)
+	count, err := exporter.Export(manyRows(t, 10), outputPath, writeFailureOptions(FormatJSON, None))
+
+	var writeErr *WriteError
+	if !errors.As(err, &writeErr) {
+		t.Fatalf("Export() error = %v, want *WriteError", err)
+	}
+	if count != 10 || writeErr.RowsWritten != 10 {
+		t.Errorf("count = %d, RowsWritten = %d, want 10", count, writeErr.RowsWritten)
+	}
+	if writeErr.Reason() != "I/O error" {
+		t.Errorf("Reason() = %q, want %q", writeErr.Reason(), "I/O error")
+	}
+}
+
+// fakeCopy writes n CSV lines as COPY TO STDOUT would, then returns err.
+func fakeCopy(n int, err error) func(io.Writer) (int64, error) {
+	return func(w io.Writer) (int64, error) {
+		for i := range n {
+			if _, werr := fmt.Fprintf(w, "%d,name-%d\n", i+1, i+1); werr != nil {
+				return 0, werr
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		return int64(n), nil
+	}
+}
+
+func TestCopyWriteFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		limit    int
+		closeErr error
+		buffer   int64
+	}{
+		{name: "disk full", limit: 3000},
+		{name: "disk full through spill buffer", limit: 3000, buffer: 1024},
+		{name: "close failure", limit: 1 << 30, closeErr: errors.New("network share disconnected")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			withFailingOutput(t, tc.limit, tc.closeErr)
+
+			outputPath := filepath.Join(t.TempDir(), "out.csv")
+			options := writeFailureOptions(FormatCSV, None)
+			options.CopyBuffer = tc.buffer
+			options.Log = &OutputLog{}
+			count, err := writeCopyOutput(outputPath, options, false, fakeCopy(1000, nil))
+
+			var writeErr *WriteError
+			if !errors.As(err, &writeErr) {
+				t.Fatalf("writeCopyOutput() error = %v, want *WriteError", err)
+			}
+			if count != 0 || writeErr.RowsWritten != 0 || writeErr.PartialPath != "" {
+				t.Errorf("count = %d, RowsWritten = %d, PartialPath = %q: COPY output should be removed",
+					count, writeErr.RowsWritten, writeErr.PartialPath)
+			}
+			if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+				t.Errorf("incomplete output %s should not be left in place", outputPath)
+			}
+			if files := options.Log.Files(); len(files) != 0 {
+				t.Errorf("failed COPY output should not be recorded as written: %v", files)
+			}
+		})
+	}
+}
+
+func TestCopyFailureRemovesOutput(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "out.csv")
+	options := writeFailureOptions(FormatCSV, None)
+	options.Log = &OutputLog{}
+	_, err := writeCopyOutput(outputPath, options, false, fakeCopy(100, errors.New("canceling statement")))
+	if err == nil || isWriteError(err) || !strings.Contains(err.Error(), "COPY TO STDOUT failed") {
+		t.Fatalf("writeCopyOutput() error = %v", err)
+	}
+	if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+		t.Errorf("output of a failed COPY should be removed")
+	}
+	if files := options.Log.Files(); len(files) != 0 {
+		t.Errorf("failed COPY output should not be recorded as written: %v", files)
+	}
+
+	count, err := writeCopyOutput(outputPath, options, false, fakeCopy(100, nil))
+	if err != nil || count != 100 {
+		t.Fatalf("writeCopyOutput() = %d, %v", count, err)
+	}
+	if files := options.Log.Files(); len(files) != 1 || files[0].Path != outputPath || files[0].Rows != 100 {
+		t.Errorf("Files() = %v", files)
+	}
+}
+
+func TestWriteErrorMessage(t *testing.T) {
+	kept := &WriteError{Path: "out.csv", RowsWritten: 42, PartialPath: "out.csv.partial", Err: syscall.ENOSPC}
+	if msg := kept.Error(); !strings.Contains(msg, "disk full") || !strings.Contains(msg, "42 rows") ||
+		!strings.Contains(msg, "kept as out.csv.partial") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	removed := &WriteError{Path: "out.csv.gz", Err: syscall.EPIPE}
+	if msg := removed.Error(); !strings.Contains(msg, "broken pipe") || !strings.Contains(msg, "removed") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func TestBytesWritten(t *testing.T) {
+	for _, tc := range []struct{ format, compression, file string }{
+		{FormatCSV, "none", "out.csv"},
+		{FormatJSON, "gzip", "out.json.gz"},
+		{FormatXLSX, "none", "out.xlsx"},
+	} {
+		t.Run(tc.format+"/"+tc.compression, func(t *testing.T) {
+			exporter, err := GetExporter(tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The counts of an export are added to the log of its run
+			dir := t.TempDir()
+			run := &OutputLog{}
+			options := writeFailureOptions(tc.format, tc.compression)
+			options.Log = run.Child()
+			if _, err := exporter.Export(manyRows(t, 500), filepath.Join(dir, strings.TrimSuffix(tc.file, ".gz")), options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			info, err := os.Stat(filepath.Join(dir, tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := options.Log.BytesWritten(); got != info.Size() || run.BytesWritten() != got {
+				t.Errorf("BytesWritten() = %d (run %d), file size is %d", got, run.BytesWritten(), info.Size())
+			}
+			if run.RowsExported() != 500 || len(options.Log.Files()) != 1 || len(run.Files()) != 0 {
+				t.Errorf("rows = %d, files = %v, run files = %v", run.RowsExported(), options.Log.Files(), run.Files())
+			}
+		})
+	}
+}
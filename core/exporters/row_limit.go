@@ -0,0 +1,84 @@
+package exporters
+
+import (
+	"fmt"
+
+	"github.com/fbz-tec/pgxport/internal/bytesize"
+	"github.com/fbz-tec/pgxport/internal/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// Policies applied to rows larger than ExportOptions.MaxRowBytes
+const (
+	LargeRowFail = "fail"
+	LargeRowSkip = "skip"
+)
+
+// rowSizeGuard enforces the --max-row-bytes limit on encoded rows.
+type rowSizeGuard struct {
+	limit   int64
+	skip    bool
+	skipped int
+	log     *OutputLog
+}
+
+func newRowSizeGuard(options ExportOptions) *rowSizeGuard {
+	return &rowSizeGuard{
+		limit: options.MaxRowBytes,
+		skip:  options.LargeRowPolicy == LargeRowSkip,
+		log:   options.Log,
+	}
+}
+
+// enabled reports whether row sizes need to be measured at all.
+func (g *rowSizeGuard) enabled() bool {
+	return g.limit > 0
+}
+
+// allow reports whether a row of the given encoded size may be written, counting it
+// as exported if so. Oversized rows are either counted as skipped or turned into an
+// error, depending on the policy.
+func (g *rowSizeGuard) allow(rowNum int, size int) (bool, error) {
+	if !g.enabled() || int64(size) <= g.limit {
+		g.log.addRows(1)
+		return true, nil
+	}
+	if !g.skip {
+		return false, fmt.Errorf("row %d is %s, which exceeds --max-row-bytes (%s); use --large-row-policy skip to leave such rows out",
+			rowNum, bytesize.Format(int64(size)), bytesize.Format(g.limit))
+	}
+	g.skipped++
+	logger.Debug("Skipping row %d: %s exceeds --max-row-bytes (%s)",
+		rowNum, bytesize.Format(int64(size)), bytesize.Format(g.limit))
+	return false, nil
+}
+
+// report logs a summary of skipped rows once the export is complete.
+func (g *rowSizeGuard) report() {
+	if g.skipped > 0 {
+		logger.Warn("%d row(s) skipped because they exceeded --max-row-bytes (%s)", g.skipped, bytesize.Format(g.limit))
+	}
+}
+
+// valueSize approximates the encoded size of a formatted value.
+func valueSize(v any) int {
+	switch val := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(val)
+	case []byte:
+		return len(val)
+	default:
+		return len(fmt.Sprint(val))
+	}
+}
+
+// yamlNodeSize sums the scalar payload of a YAML node tree.
+func yamlNodeSize(n *yaml.Node) int {
+	size := len(n.Value)
+	for _, child := range n.Content {
+		size += yamlNodeSize(child)
+	}
+	return size
+}
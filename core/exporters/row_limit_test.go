@@ -0,0 +1,184 @@
+package exporters
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	largeTextSize  = 5 << 20
+	largeJSONBSize = 3 << 20
+)
+
+var largeRowColumns = []rowsource.Column{
+	{Name: "id", OID: pgtype.Int4OID},
+	{Name: "body", OID: pgtype.TextOID},
+	{Name: "payload", OID: pgtype.JSONBOID},
+}
+
+// largeRows returns a small row, a row with a multi-megabyte text column,
+// a row with a multi-megabyte jsonb column and a final small row.
+func largeRows(t *testing.T) pgx.Rows {
+	t.Helper()
+
+	rows, err := rowsource.New(largeRowColumns, [][]any{
+		{int32(1), "small", map[string]any{"k": "v"}},
+		{int32(2), strings.Repeat("a<&>\"'", largeTextSize/6), nil},
+		{int32(3), "jsonb", map[string]any{"blob": strings.Repeat("x", largeJSONBSize)}},
+		{int32(4), "tail", nil},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build large rows: %v", err)
+	}
+	return rows
+}
+
+func exportLargeRows(t *testing.T, format string, options ExportOptions) (string, int, error) {
+	t.Helper()
+
+	exporter, err := GetExporter(format)
+	if err != nil {
+		t.Fatalf("Failed to get %s exporter: %v", format, err)
+	}
+
+	options.Format = format
+	options.Delimiter = ','
+	options.Compression = "none"
+	options.TimeZone = "UTC"
+	options.XmlRootElement = "results"
+	options.XmlRowElement = "row"
+	options.TableName = "public.docs"
+	if options.RowPerStatement == 0 {
+		options.RowPerStatement = 1
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "large."+format)
+	count, err := exporter.Export(largeRows(t), outputPath, options)
+	return outputPath, count, err
+}
+
+func TestExportMultiMegabyteRows(t *testing.T) {
+	for _, format := range ListExporters() {
+		t.Run(format, func(t *testing.T) {
+			outputPath, count, err := exportLargeRows(t, format, ExportOptions{})
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if count != 4 {
+				t.Errorf("Export() count = %d, want 4", count)
+			}
+
+			// XLSX stores cells compressed and truncated to Excel's per-cell limit
+			if format == FormatXLSX {
+				return
+			}
+
+			info, err := os.Stat(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to stat output: %v", err)
+			}
+			if info.Size() < largeTextSize+largeJSONBSize {
+				t.Errorf("output size = %d bytes, want at least %d", info.Size(), largeTextSize+largeJSONBSize)
+			}
+		})
+	}
+}
+
+func TestMaxRowBytesSkip(t *testing.T) {
+	for _, format := range ListExporters() {
+		t.Run(format, func(t *testing.T) {
+			log := &OutputLog{}
+			outputPath, count, err := exportLargeRows(t, format, ExportOptions{
+				MaxRowBytes:     1 << 20,
+				LargeRowPolicy:  LargeRowSkip,
+				RowPerStatement: 10,
+				Log:             log,
+			})
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			if count != 2 {
+				t.Errorf("Export() count = %d, want 2", count)
+			}
+			if exported := log.RowsExported(); exported != 2 {
+				t.Errorf("RowsExported() = %d, want 2", exported)
+			}
+
+			info, err := os.Stat(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to stat output: %v", err)
+			}
+			if info.Size() > 1<<20 {
+				t.Errorf("output size = %d bytes, oversized rows should have been skipped", info.Size())
+			}
+		})
+	}
+}
+
+func TestMaxRowBytesSkipKeepsOutputWellFormed(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		outputPath, _, err := exportLargeRows(t, FormatJSON, ExportOptions{MaxRowBytes: 1 << 20, LargeRowPolicy: LargeRowSkip})
+		if err != nil {
+			t.Fatalf("Export() error: %v", err)
+		}
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		var result []map[string]any
+		if err := json.Unmarshal(content, &result); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, content)
+		}
+		if len(result) != 2 || result[0]["id"] != float64(1) || result[1]["id"] != float64(4) {
+			t.Errorf("unexpected rows: %v", result)
+		}
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		outputPath, _, err := exportLargeRows(t, FormatSQL, ExportOptions{
+			MaxRowBytes:     1 << 20,
+			LargeRowPolicy:  LargeRowSkip,
+			RowPerStatement: 10,
+		})
+		if err != nil {
+			t.Fatalf("Export() error: %v", err)
+		}
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		expected := "INSERT INTO \"public\".\"docs\" (\"id\", \"body\", \"payload\") VALUES\n" +
+			"\t(1, 'small', '{\"k\":\"v\"}'::jsonb),\n" +
+			"\t(4, 'tail', NULL);\n"
+		if string(content) != expected {
+			t.Errorf("unexpected SQL output:\n%s\nwant:\n%s", content, expected)
+		}
+	})
+}
+
+func TestMaxRowBytesFail(t *testing.T) {
+	for _, format := range ListExporters() {
+		t.Run(format, func(t *testing.T) {
+			_, count, err := exportLargeRows(t, format, ExportOptions{
+				MaxRowBytes:    1 << 20,
+				LargeRowPolicy: LargeRowFail,
+			})
+			if err == nil {
+				t.Fatal("Export() expected error for oversized row, got nil")
+			}
+			if !strings.Contains(err.Error(), "row 2") || !strings.Contains(err.Error(), "exceeds --max-row-bytes") {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if count != 1 {
+				t.Errorf("Export() count = %d, want 1 row written before the failure", count)
+			}
+		})
+	}
+}
@@ -2,122 +2,348 @@ package exporters
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fbz-tec/pgxport/core/formatters"
 	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type sqlExporter struct{}
 
-func (e *sqlExporter) Export(rows pgx.Rows, sqlPath string, options ExportOptions) (int, error) {
+// Ways to disable triggers around the INSERT statements of SQL exports
+const (
+	TriggersAlter   = "alter"   // ALTER TABLE ... DISABLE TRIGGER ALL
+	TriggersReplica = "replica" // SET session_replication_role = replica
+)
+
+const (
+	stmtBufferSize = 64 * 1024
+	// Buffers grown past this size by very large rows are released after each statement
+	maxRetainedStmtBuffer = 4 * 1024 * 1024
+)
+
+// sqlPart is one of the files an SQL export is written to.
+type sqlPart struct {
+	out  *outputWriter
+	w    *bufio.Writer
+	rows int
+}
+
+func (e *sqlExporter) Export(rows pgx.Rows, sqlPath string, options ExportOptions) (rowCount int, err error) {
 
 	start := time.Now()
-	logger.Debug("Preparing SQL export (table=%s, compression=%s, rows-per-statement=%d)",
-		options.TableName, options.Compression, options.RowPerStatement)
+	logger.Debug("Preparing SQL export (table=%s, compression=%s, rows-per-statement=%d, files=%d)",
+		options.TableName, options.Compression, options.RowPerStatement, max(options.SQLFiles, 1))
 
-	writeCloser, err := createOutputWriter(sqlPath, options, FormatSQL)
+	rows, err = checkColumns(rows, options)
 	if err != nil {
 		return 0, err
 	}
-	defer writeCloser.Close()
 
-	// Use buffered writer for better performance
-	bufferedWriter := bufio.NewWriter(writeCloser)
-	defer bufferedWriter.Flush()
+	var parts []*sqlPart
+	defer func() {
+		total := 0
+		for _, p := range parts {
+			p.w.Flush()
+			n, ferr := p.out.finish(p.rows, err)
+			total += n
+			if ferr != nil && (err == nil || !isWriteError(err)) {
+				err = ferr
+			}
+		}
+		if isWriteError(err) {
+			rowCount = total
+		}
+	}()
+
+	for _, path := range sqlPartPaths(sqlPath, options.SQLFiles) {
+		out, err := createOutputWriter(path, options, FormatSQL)
+		if err != nil {
+			return 0, err
+		}
+		// Use buffered writer for better performance
+		parts = append(parts, &sqlPart{out: out, w: bufio.NewWriter(out)})
+	}
 
 	fields := rows.FieldDescriptions()
-	columns := make([]string, len(fields))
-	for i, fd := range fields {
-		columns[i] = formatters.QuoteIdent(fd.Name)
+	keep := keptColumns(len(fields), options.SkipColumns)
+	if len(keep) == 0 && len(fields) > 0 {
+		return 0, fmt.Errorf("no column left to insert: every result column is generated by the target table")
+	}
+	if len(keep) < len(fields) {
+		logger.Debug("Leaving %d generated column(s) out of INSERT statements", len(fields)-len(keep))
+		fields = selectColumns(fields, keep, nil)
+	} else {
+		keep = nil
+	}
+	header := buildInsertHeader(options.TableName, fields)
+	var kept []any
+
+	before, after := triggerStatements(options.TableName, options.DisableTriggers)
+	if options.SQLFiles > 0 {
+		// Each file is restored on its own session, so it carries its own transaction
+		before = "BEGIN;\n\n" + before
+		after = after + "\nCOMMIT;\n"
+	}
+	for _, p := range parts {
+		if _, err := p.w.WriteString(before); err != nil {
+			return 0, fmt.Errorf("error writing statement prologue: %w", err)
+		}
 	}
-	size := len(columns)
 
 	logger.Debug("Starting to write SQL INSERT statements...")
 
-	var rowCount int
 	var statementCount int
-	batchInsertValues := make([][]string, 0, options.RowPerStatement)
+	var batchRows int
+	var rowNum int
+	guard := newRowSizeGuard(options)
 
-	for rows.Next() {
-		record := make([]string, size)
+	// The statement is assembled in a reusable buffer to avoid per-row allocations
+	stmt := make([]byte, 0, stmtBufferSize)
 
+	// writeBatch sends the pending statement to the next file in turn
+	writeBatch := func() error {
+		p := parts[statementCount%len(parts)]
+		stmt = append(stmt, ";\n"...)
+		if _, err := p.w.Write(stmt); err != nil {
+			return fmt.Errorf("error writing batch statement %d: %w", statementCount+1, err)
+		}
+		p.rows += batchRows
+		p.out.markRows(p.rows, p.w.Buffered())
+		statementCount++
+		batchRows = 0
+		return nil
+	}
+
+	for rows.Next() {
 		values, err := rows.Values()
 		if err != nil {
 			return 0, fmt.Errorf("error reading row: %w", err)
 		}
+		if keep != nil {
+			kept = selectColumns(values, keep, kept[:0])
+			values = kept
+		}
+
+		rowNum++
+
+		mark := len(stmt)
+		if batchRows == 0 {
+			stmt = append(stmt, header...)
+		} else {
+			stmt = append(stmt, ",\n"...)
+		}
+		tupleStart := len(stmt)
+		stmt = appendValuesRow(stmt, values, fields)
 
-		//format values
-		for i, val := range values {
-			record[i] = formatters.FormatSQLValue(val, fields[i].DataTypeOID)
+		ok, err := guard.allow(rowNum, len(stmt)-tupleStart)
+		if err != nil {
+			return rowCount, err
+		}
+		if !ok {
+			stmt = stmt[:mark]
+			continue
 		}
 
 		rowCount++
-		batchInsertValues = append(batchInsertValues, record)
+		batchRows++
 
 		// Write batch when full
-		if len(batchInsertValues) == options.RowPerStatement {
-			if err := e.writeBatchInsert(bufferedWriter, options.TableName, columns, batchInsertValues); err != nil {
-				return 0, fmt.Errorf("error writing batch statement %d: %w", statementCount+1, err)
+		if batchRows == options.RowPerStatement {
+			if err := writeBatch(); err != nil {
+				return rowCount, err
+			}
+			if cap(stmt) > maxRetainedStmtBuffer {
+				stmt = make([]byte, 0, stmtBufferSize)
+			} else {
+				stmt = stmt[:0]
 			}
-			statementCount++
-			batchInsertValues = batchInsertValues[:0]
 
 			// Periodic flush for large exports
 			if statementCount%1000 == 0 {
-				bufferedWriter.Flush()
+				for _, p := range parts {
+					p.w.Flush()
+				}
 				logger.Debug("%d rows processed (%d INSERT statements written)...", rowCount, statementCount)
 			}
 		}
 	}
 
 	// Write remaining rows as final batch
-	if len(batchInsertValues) > 0 {
-		if err := e.writeBatchInsert(bufferedWriter, options.TableName, columns, batchInsertValues); err != nil {
-			return 0, fmt.Errorf("error writing final batch statement: %w", err)
+	if batchRows > 0 {
+		if err := writeBatch(); err != nil {
+			return rowCount, fmt.Errorf("error writing final batch statement: %w", err)
 		}
-		statementCount++
 	}
 
-	logger.Debug("Flushing remaining SQL statements to disk...")
-	bufferedWriter.Flush()
-
+	// A failed query must not leave files ending in COMMIT, which would load partial data
 	if err := rows.Err(); err != nil {
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	logger.Debug("SQL export completed successfully: %d rows written in %d INSERT statements (%v)",
-		rowCount, statementCount, time.Since(start))
+	for _, p := range parts {
+		if _, err := p.w.WriteString(after); err != nil {
+			return rowCount, fmt.Errorf("error writing statement epilogue: %w", err)
+		}
+	}
+
+	guard.report()
+
+	logger.Debug("Flushing remaining SQL statements to disk...")
+	for _, p := range parts {
+		p.w.Flush()
+	}
+
+	logger.Debug("SQL export completed successfully: %d rows written in %d INSERT statements across %d file(s) (%v)",
+		rowCount, statementCount, len(parts), time.Since(start))
 
 	return rowCount, nil
 }
 
-// writeBatchInsert writes a single or multi-row INSERT statement
-func (e *sqlExporter) writeBatchInsert(writer *bufio.Writer, table string, columns []string, rows [][]string) error {
-	if len(rows) == 0 {
-		return nil
+// sqlPartPaths returns the files written for an SQL export. With files > 0 the output
+// is split into numbered files: orders.sql -> orders_1.sql ... orders_N.sql.
+func sqlPartPaths(path string, files int) []string {
+	if files <= 0 {
+		return []string{path}
 	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	width := len(strconv.Itoa(files))
 
-	var stmt strings.Builder
+	paths := make([]string, files)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("%s_%0*d%s", base, width, i+1, ext)
+	}
+	return paths
+}
 
-	// Write INSERT header
-	stmt.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES\n",
-		formatters.QuoteIdent(table), strings.Join(columns, ", ")))
+func isWriteError(err error) bool {
+	var writeErr *WriteError
+	return errors.As(err, &writeErr)
+}
 
-	// Write value rows
-	for i, record := range rows {
-		separator := ","
-		if i == len(rows)-1 {
-			separator = ";"
+// buildInsertHeader returns the "INSERT INTO table (columns) VALUES" prefix shared by all statements.
+func buildInsertHeader(table string, fields []pgconn.FieldDescription) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(formatters.QuoteIdent(table))
+	b.WriteString(" (")
+	for i, fd := range fields {
+		if i > 0 {
+			b.WriteString(", ")
 		}
-		stmt.WriteString(fmt.Sprintf("\t(%s)%s\n", strings.Join(record, ", "), separator))
+		b.WriteString(formatters.QuoteIdent(fd.Name))
 	}
+	b.WriteString(") VALUES\n")
+	return b.String()
+}
+
+// triggerStatements returns the statements written before and after the INSERT
+// statements to disable the triggers of table while loading.
+func triggerStatements(table, mode string) (before, after string) {
+	switch mode {
+	case TriggersAlter:
+		quoted := formatters.QuoteIdent(table)
+		return "ALTER TABLE " + quoted + " DISABLE TRIGGER ALL;\n\n",
+			"\nALTER TABLE " + quoted + " ENABLE TRIGGER ALL;\n"
+	case TriggersReplica:
+		return "SET session_replication_role = replica;\n\n",
+			"\nSET session_replication_role = DEFAULT;\n"
+	}
+	return "", ""
+}
 
-	_, err := writer.WriteString(stmt.String())
-	return err
+// keptColumns returns the positions of the n result columns that are not in skip.
+func keptColumns(n int, skip []int) []int {
+	skipped := make(map[int]bool, len(skip))
+	for _, i := range skip {
+		skipped[i] = true
+	}
+	keep := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !skipped[i] {
+			keep = append(keep, i)
+		}
+	}
+	return keep
+}
+
+// selectColumns appends the elements of src at the positions in keep to dst.
+func selectColumns[T any](src []T, keep []int, dst []T) []T {
+	for _, i := range keep {
+		dst = append(dst, src[i])
+	}
+	return dst
+}
+
+// appendValuesRow appends one "\t(v1, v2, ...)" tuple to stmt.
+func appendValuesRow(stmt []byte, values []any, fields []pgconn.FieldDescription) []byte {
+	stmt = append(stmt, '\t', '(')
+	for i, val := range values {
+		if i > 0 {
+			stmt = append(stmt, ", "...)
+		}
+		stmt = formatters.AppendSQLValue(stmt, val, fields[i].DataTypeOID)
+	}
+	return append(stmt, ')')
+}
+
+// Validate checks that a target table is provided and the batch size is usable.
+func (e *sqlExporter) Validate(options ExportOptions) error {
+	table := strings.TrimSpace(options.TableName)
+	if table == "" {
+		return fmt.Errorf("--table (-t) is required when using SQL format")
+	}
+	for _, part := range strings.Split(table, ".") {
+		if strings.TrimSpace(part) == "" {
+			return fmt.Errorf("invalid table name %q: expected 'table' or 'schema.table'", options.TableName)
+		}
+	}
+	if options.RowPerStatement < 1 {
+		return fmt.Errorf("--insert-batch must be at least 1 (got %d)", options.RowPerStatement)
+	}
+	switch options.DisableTriggers {
+	case "", TriggersAlter, TriggersReplica:
+	default:
+		return fmt.Errorf("invalid --disable-triggers-method '%s'. Valid options are: %s, %s",
+			options.DisableTriggers, TriggersAlter, TriggersReplica)
+	}
+	if options.SQLFiles < 0 {
+		return fmt.Errorf("--sql-files-per must be positive (got %d)", options.SQLFiles)
+	}
+	if options.SQLFiles > 1 && options.DisableTriggers == TriggersAlter {
+		// ALTER TABLE holds an exclusive lock until COMMIT, which would serialize the parallel sessions
+		return fmt.Errorf("--disable-triggers-method %s cannot be used with --sql-files-per; use %s", TriggersAlter, TriggersReplica)
+	}
+	return nil
+}
+
+// Info describes the format for help pages and generated documentation.
+func (e *sqlExporter) Info() FormatInfo {
+	return FormatInfo{
+		Title:       "SQL",
+		Description: "INSERT statements that recreate the exported rows in another table.",
+		Extension:   ".sql",
+		Flags:       []string{"table", "insert-batch", "skip-generated", "disable-triggers", "disable-triggers-method", "sql-files-per"},
+		Options: []Option{
+			{Name: "table", Flag: "table", Description: "Target table of the INSERT statements"},
+			{Name: "insert-batch", Flag: "insert-batch", Default: "1", Description: "Rows per INSERT statement"},
+		},
+		Notes: []string{
+			"--table is required and accepts table or schema.table.",
+			"Identifiers are double-quoted and values are escaped as SQL literals.",
+			"Columns read from generated or GENERATED ALWAYS identity columns are left out unless --skip-generated=false.",
+			"--disable-triggers wraps the INSERT statements in ALTER TABLE ... DISABLE/ENABLE TRIGGER ALL, or sets session_replication_role with --disable-triggers-method replica; both need superuser rights when loading.",
+			"--sql-files-per N writes N numbered files, each in its own transaction, for restoring with N parallel sessions.",
+		},
+	}
 }
 
 func init() {
@@ -2,6 +2,7 @@ package exporters
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,7 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/rowsource"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportSQL(t *testing.T) {
@@ -1090,3 +1093,352 @@ func BenchmarkExportSQL(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+func TestSQLExporterValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    string
+		batch    int
+		files    int
+		triggers string
+		wantErr  string
+	}{
+		{name: "simple table", table: "users", batch: 1},
+		{name: "schema qualified", table: "public.users", batch: 100},
+		{name: "missing table", table: "", batch: 1, wantErr: "--table (-t) is required"},
+		{name: "whitespace table", table: "  ", batch: 1, wantErr: "--table (-t) is required"},
+		{name: "empty schema part", table: "public.", batch: 1, wantErr: "invalid table name"},
+		{name: "empty middle part", table: "a..b", batch: 1, wantErr: "invalid table name"},
+		{name: "zero batch", table: "users", batch: 0, wantErr: "--insert-batch must be at least 1"},
+		{name: "disable triggers", table: "users", batch: 1, triggers: TriggersReplica},
+		{name: "unknown trigger method", table: "users", batch: 1, triggers: "drop", wantErr: "invalid --disable-triggers-method"},
+		{name: "split with ALTER TABLE triggers", table: "users", batch: 1, files: 4, triggers: TriggersAlter, wantErr: "cannot be used with --sql-files-per"},
+		{name: "split with replica triggers", table: "users", batch: 1, files: 4, triggers: TriggersReplica},
+	}
+
+	exporter := &sqlExporter{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := exporter.Validate(ExportOptions{Format: FormatSQL, TableName: tt.table, RowPerStatement: tt.batch, SQLFiles: tt.files, DisableTriggers: tt.triggers})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, should contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteSQLSkipColumns(t *testing.T) {
+	columns := []rowsource.Column{
+		{Name: "id", OID: pgtype.Int8OID},
+		{Name: "name", OID: pgtype.TextOID},
+		{Name: "name_upper", OID: pgtype.TextOID},
+	}
+	data := [][]any{
+		{int64(1), "alice", "ALICE"},
+		{int64(2), "bob", "BOB"},
+	}
+
+	exporter, err := GetExporter(FormatSQL)
+	if err != nil {
+		t.Fatalf("Failed to get sql exporter: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		skip     []int
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "generated column left out",
+			skip:     []int{0, 2},
+			expected: "INSERT INTO \"users\" (\"name\") VALUES\n\t('alice'),\n\t('bob');\n",
+		},
+		{
+			name:     "no column skipped",
+			expected: "INSERT INTO \"users\" (\"id\", \"name\", \"name_upper\") VALUES\n\t(1, 'alice', 'ALICE'),\n\t(2, 'bob', 'BOB');\n",
+		},
+		{
+			name:    "every column skipped",
+			skip:    []int{0, 1, 2},
+			wantErr: "no column left to insert",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := rowsource.New(columns, data)
+			if err != nil {
+				t.Fatalf("Failed to build rows: %v", err)
+			}
+			outputPath := filepath.Join(t.TempDir(), "users.sql")
+			options := ExportOptions{
+				Format:          FormatSQL,
+				TableName:       "users",
+				Compression:     "none",
+				RowPerStatement: 10,
+				SkipColumns:     tt.skip,
+			}
+
+			_, err = exporter.Export(rows, outputPath, options)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Export() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("output = %q\nwant     %q", content, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteSQLDisableTriggers(t *testing.T) {
+	columns := []rowsource.Column{{Name: "id", OID: pgtype.Int4OID}}
+
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{
+			mode: TriggersAlter,
+			expected: "ALTER TABLE \"app\".\"users\" DISABLE TRIGGER ALL;\n\n" +
+				"INSERT INTO \"app\".\"users\" (\"id\") VALUES\n\t(1);\n" +
+				"\nALTER TABLE \"app\".\"users\" ENABLE TRIGGER ALL;\n",
+		},
+		{
+			mode: TriggersReplica,
+			expected: "SET session_replication_role = replica;\n\n" +
+				"INSERT INTO \"app\".\"users\" (\"id\") VALUES\n\t(1);\n" +
+				"\nSET session_replication_role = DEFAULT;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			rows, err := rowsource.New(columns, [][]any{{int32(1)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputPath := filepath.Join(t.TempDir(), "users.sql")
+			options := ExportOptions{
+				Format:          FormatSQL,
+				TableName:       "app.users",
+				Compression:     "none",
+				RowPerStatement: 1,
+				DisableTriggers: tt.mode,
+			}
+			if _, err := (&sqlExporter{}).Export(rows, outputPath, options); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("output = %q\nwant     %q", content, tt.expected)
+			}
+		})
+	}
+}
+
+func BenchmarkExportSQLInMemory(b *testing.B) {
+	columns := []rowsource.Column{
+		{Name: "id", OID: pgtype.Int8OID},
+		{Name: "name", OID: pgtype.TextOID},
+		{Name: "amount", OID: pgtype.Float8OID},
+		{Name: "created_at", OID: pgtype.TimestamptzOID},
+		{Name: "active", OID: pgtype.BoolOID},
+	}
+	created := time.Date(2024, 3, 15, 14, 30, 45, 0, time.UTC)
+	data := make([][]any, 10000)
+	for i := range data {
+		data[i] = []any{int64(i), fmt.Sprintf("O'Name %d", i), float64(i) * 1.5, created, i%2 == 0}
+	}
+
+	rows, err := rowsource.New(columns, data)
+	if err != nil {
+		b.Fatalf("Failed to build rows: %v", err)
+	}
+
+	exporter, err := GetExporter(FormatSQL)
+	if err != nil {
+		b.Fatalf("Failed to get sql exporter: %v", err)
+	}
+	outputPath := filepath.Join(b.TempDir(), "bench.sql")
+
+	for _, batch := range []int{1, 100} {
+		b.Run(fmt.Sprintf("batch_%d", batch), func(b *testing.B) {
+			options := ExportOptions{
+				Format:          FormatSQL,
+				TableName:       "bench_table",
+				Compression:     "none",
+				RowPerStatement: batch,
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				rows.Reset()
+				if _, err := exporter.Export(rows, outputPath, options); err != nil {
+					b.Fatalf("Export() error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteSQLFilesPer(t *testing.T) {
+	columns := []rowsource.Column{{Name: "id", OID: pgtype.Int4OID}}
+	var data [][]any
+	for i := 1; i <= 5; i++ {
+		data = append(data, []any{int32(i)})
+	}
+	rows, err := rowsource.New(columns, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	options := ExportOptions{
+		Format:          FormatSQL,
+		TableName:       "users",
+		Compression:     "none",
+		RowPerStatement: 2,
+		SQLFiles:        2,
+		DisableTriggers: TriggersReplica,
+		Log:             &OutputLog{},
+	}
+	count, err := (&sqlExporter{}).Export(rows, filepath.Join(dir, "users.sql"), options)
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("Export() = %d rows, want 5", count)
+	}
+
+	expected := map[string]string{
+		"users_1.sql": "BEGIN;\n\nSET session_replication_role = replica;\n\n" +
+			"INSERT INTO \"users\" (\"id\") VALUES\n\t(1),\n\t(2);\n" +
+			"INSERT INTO \"users\" (\"id\") VALUES\n\t(5);\n" +
+			"\nSET session_replication_role = DEFAULT;\n\nCOMMIT;\n",
+		"users_2.sql": "BEGIN;\n\nSET session_replication_role = replica;\n\n" +
+			"INSERT INTO \"users\" (\"id\") VALUES\n\t(3),\n\t(4);\n" +
+			"\nSET session_replication_role = DEFAULT;\n\nCOMMIT;\n",
+	}
+	for name, want := range expected {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Errorf("%s = %q\nwant %q", name, content, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "users.sql")); !os.IsNotExist(err) {
+		t.Errorf("users.sql should not be created when splitting, stat error = %v", err)
+	}
+
+	// Each part is recorded with its own row count for the manifest
+	parts := map[string]int{}
+	for _, f := range options.Log.Files() {
+		parts[filepath.Base(f.Path)] = f.Rows
+	}
+	if parts["users_1.sql"] != 3 || parts["users_2.sql"] != 2 || len(parts) != 2 {
+		t.Errorf("written files = %v, want users_1.sql with 3 rows and users_2.sql with 2", parts)
+	}
+}
+
+func TestSQLPartPaths(t *testing.T) {
+	if got := sqlPartPaths("out/orders.sql", 0); len(got) != 1 || got[0] != "out/orders.sql" {
+		t.Errorf("sqlPartPaths(0) = %v", got)
+	}
+	got := sqlPartPaths("out/orders.sql", 12)
+	if len(got) != 12 || got[0] != "out/orders_01.sql" || got[11] != "out/orders_12.sql" {
+		t.Errorf("sqlPartPaths(12) = %v", got)
+	}
+}
+
+// failingRows stops after n rows with err, as a query failing mid-stream does.
+type failingRows struct {
+	pgx.Rows
+	n   int
+	err error
+}
+
+func (r *failingRows) Next() bool {
+	if r.n == 0 {
+		return false
+	}
+	r.n--
+	return r.Rows.Next()
+}
+
+func (r *failingRows) Err() error {
+	if r.n == 0 {
+		return r.err
+	}
+	return r.Rows.Err()
+}
+
+func TestWriteSQLQueryFailure(t *testing.T) {
+	columns := []rowsource.Column{{Name: "id", OID: pgtype.Int4OID}}
+	var data [][]any
+	for i := 1; i <= 10; i++ {
+		data = append(data, []any{int32(i)})
+	}
+
+	for _, files := range []int{0, 2} {
+		t.Run(fmt.Sprintf("files=%d", files), func(t *testing.T) {
+			rows, err := rowsource.New(columns, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			options := ExportOptions{
+				Format:          FormatSQL,
+				TableName:       "users",
+				Compression:     "none",
+				RowPerStatement: 2,
+				SQLFiles:        files,
+				DisableTriggers: TriggersAlter,
+				Log:             &OutputLog{},
+			}
+			_, err = (&sqlExporter{}).Export(&failingRows{Rows: rows, n: 5, err: errors.New("connection reset")}, filepath.Join(dir, "users.sql"), options)
+			if err == nil || !strings.Contains(err.Error(), "connection reset") {
+				t.Fatalf("Export() error = %v, want the query error", err)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) == 0 {
+				t.Fatal("complete statements should be kept as .partial files")
+			}
+			for _, e := range entries {
+				if !strings.HasSuffix(e.Name(), partialSuffix) {
+					t.Errorf("%s should have been renamed with %s", e.Name(), partialSuffix)
+				}
+				content, _ := os.ReadFile(filepath.Join(dir, e.Name()))
+				if strings.Contains(string(content), "COMMIT") || strings.Contains(string(content), "ENABLE TRIGGER") {
+					t.Errorf("%s should not end with the epilogue:\n%s", e.Name(), content)
+				}
+			}
+			if files := options.Log.Files(); len(files) != 0 {
+				t.Errorf("failed parts should not be recorded as written: %v", files)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+//go:build perf
+
+package exporters
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The throughput suite is opt-in (go test -tags perf, or task bench-e2e): it exports
+// synthetic datasets of fixed shapes through every format and compares the rows per
+// second with a baseline recorded on the same machine.
+var (
+	perfBaseline  = flag.String("perf-baseline", filepath.Join("testdata", "perf", "baseline.json"), "throughput baseline to compare with, or to write with -perf-update")
+	perfUpdate    = flag.Bool("perf-update", false, "record the measured throughput as the new baseline")
+	perfTolerance = flag.Float64("perf-tolerance", 0.25, "fail when throughput drops by more than this fraction of the baseline")
+	perfRuns      = flag.Int("perf-runs", 3, "exports per dataset and format; the fastest one is kept")
+	perfReport    = flag.String("perf-report", "", "also write the measurements to this JSON file")
+)
+
+// perfShape is a synthetic dataset. Values only depend on the row number, so every
+// run exports exactly the same bytes.
+type perfShape struct {
+	name    string
+	rows    int
+	columns []rowsource.Column
+	row     func(i int) []any
+}
+
+var perfShapes = []perfShape{
+	{
+		name: "narrow",
+		rows: 200_000,
+		columns: []rowsource.Column{
+			{Name: "id", OID: pgtype.Int8OID},
+			{Name: "name", OID: pgtype.TextOID},
+			{Name: "active", OID: pgtype.BoolOID},
+		},
+		row: func(i int) []any {
+			return []any{int64(i), fmt.Sprintf("customer %d", i), i%3 == 0}
+		},
+	},
+	{
+		name:    "wide",
+		rows:    20_000,
+		columns: wideColumns(40),
+		row: func(i int) []any {
+			values := make([]any, 40)
+			for c := range values {
+				switch c % 4 {
+				case 0:
+					values[c] = int32(i + c)
+				case 1:
+					values[c] = fmt.Sprintf("value %d/%d", i, c)
+				case 2:
+					values[c] = pgtype.Numeric{Int: big.NewInt(int64(i*100 + c)), Exp: -2, Valid: true}
+				default:
+					if i%5 != 0 {
+						values[c] = float64(i) / float64(c+1)
+					}
+				}
+			}
+			return values
+		},
+	},
+	{
+		name: "text",
+		rows: 50_000,
+		columns: []rowsource.Column{
+			{Name: "id", OID: pgtype.Int4OID},
+			{Name: "title", OID: pgtype.TextOID},
+			{Name: "body", OID: pgtype.TextOID},
+		},
+		row: func(i int) []any {
+			body := strings.Repeat(fmt.Sprintf("Line %d with \"quotes\", <tags> & commas;\n", i), 8)
+			return []any{int32(i), fmt.Sprintf("O'Brien #%d", i), body}
+		},
+	},
+	{
+		name: "typed",
+		rows: 50_000,
+		columns: []rowsource.Column{
+			{Name: "id", OID: pgtype.Int4OID},
+			{Name: "day", OID: pgtype.DateOID},
+			{Name: "created_at", OID: pgtype.TimestampOID},
+			{Name: "updated_at", OID: pgtype.TimestamptzOID},
+			{Name: "amount", OID: pgtype.NumericOID},
+			{Name: "uuid", OID: pgtype.UUIDOID},
+			{Name: "tags", OID: pgtype.TextArrayOID},
+			{Name: "metadata", OID: pgtype.JSONBOID},
+		},
+		row: func(i int) []any {
+			t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Minute)
+			var uuid [16]byte
+			uuid[0], uuid[1], uuid[15] = byte(i>>8), byte(i), 0x42
+			return []any{
+				int32(i), t.Truncate(24 * time.Hour), t, t.Add(90 * time.Second),
+				pgtype.Numeric{Int: big.NewInt(int64(i) * 1999), Exp: -2, Valid: true}, uuid,
+				[]any{"alpha", fmt.Sprintf("tag%d", i%10)},
+				map[string]any{"plan": "pro", "seats": float64(i % 50)},
+			}
+		},
+	},
+}
+
+func wideColumns(n int) []rowsource.Column {
+	oids := []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.NumericOID, pgtype.Float8OID}
+	columns := make([]rowsource.Column, n)
+	for c := range columns {
+		columns[c] = rowsource.Column{Name: fmt.Sprintf("col_%02d", c), OID: oids[c%len(oids)]}
+	}
+	return columns
+}
+
+// perfResult is the throughput of one dataset exported to one format.
+type perfResult struct {
+	Rows        int     `json:"rows"`
+	Bytes       int64   `json:"bytes"`
+	RowsPerSec  float64 `json:"rows_per_sec"`
+	BytesPerSec float64 `json:"bytes_per_sec"`
+}
+
+func TestThroughput(t *testing.T) {
+	if *perfRuns < 1 {
+		t.Fatalf("-perf-runs must be at least 1")
+	}
+	baseline := map[string]perfResult{}
+	if data, err := os.ReadFile(*perfBaseline); err == nil {
+		if err := json.Unmarshal(data, &baseline); err != nil {
+			t.Fatalf("invalid baseline %s: %v", *perfBaseline, err)
+		}
+	} else if !*perfUpdate {
+		t.Logf("No baseline in %s: recording only (run with -perf-update to create it)", *perfBaseline)
+	}
+
+	results := map[string]perfResult{}
+	for _, shape := range perfShapes {
+		data := make([][]any, shape.rows)
+		for i := range data {
+			data[i] = shape.row(i)
+		}
+		source, err := rowsource.New(shape.columns, data)
+		if err != nil {
+			t.Fatalf("%s: %v", shape.name, err)
+		}
+
+		for _, format := range ListExporters() {
+			name := shape.name + "/" + format
+			t.Run(name, func(t *testing.T) {
+				result := measureThroughput(t, source, format)
+				results[name] = result
+				t.Logf("%8.0f rows/s %8.1f MB/s", result.RowsPerSec, result.BytesPerSec/1e6)
+
+				base, ok := baseline[name]
+				if !ok || *perfUpdate {
+					return
+				}
+				if drop := 1 - result.RowsPerSec/base.RowsPerSec; drop > *perfTolerance {
+					t.Errorf("throughput dropped by %.0f%%: %.0f rows/s, baseline %.0f rows/s (tolerance %.0f%%)",
+						100*drop, result.RowsPerSec, base.RowsPerSec, 100**perfTolerance)
+				}
+			})
+		}
+	}
+
+	if *perfUpdate {
+		writePerfResults(t, *perfBaseline, results)
+	}
+	if *perfReport != "" {
+		writePerfResults(t, *perfReport, results)
+	}
+}
+
+// measureThroughput exports source to format -perf-runs times and keeps the fastest run.
+func measureThroughput(t *testing.T, source *rowsource.Rows, format string) perfResult {
+	t.Helper()
+	exporter, err := GetExporter(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "perf."+format)
+
+	var best time.Duration
+	var result perfResult
+	for run := 0; run < *perfRuns; run++ {
+		source.Reset()
+		start := time.Now()
+		rows, err := exporter.Export(source, output, goldenOptions(format))
+		elapsed := time.Since(start)
+		if err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		info, err := os.Stat(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run == 0 || elapsed < best {
+			best = elapsed
+			result = perfResult{Rows: rows, Bytes: info.Size()}
+		}
+	}
+	result.RowsPerSec = float64(result.Rows) / best.Seconds()
+	result.BytesPerSec = float64(result.Bytes) / best.Seconds()
+	return result
+}
+
+func writePerfResults(t *testing.T, path string, results map[string]perfResult) {
+	t.Helper()
+	t.Logf("Writing %d measurements to %s", len(results), path)
+
+	// encoding/json sorts map keys, so baselines diff cleanly
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
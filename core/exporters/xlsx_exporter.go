@@ -1,8 +1,12 @@
 package exporters
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fbz-tec/pgxport/core/formatters"
 	"github.com/fbz-tec/pgxport/internal/logger"
@@ -12,12 +16,22 @@ import (
 
 type xlsxExporter struct{}
 
+const (
+	defaultSheetName  = "Sheet1"
+	maxSheetNameChars = 31
+)
+
 // Export writes query results to an Excel XLSX file.
 func (e *xlsxExporter) Export(rows pgx.Rows, xlsxPath string, options ExportOptions) (int, error) {
 	start := time.Now()
 
 	logger.Debug("Preparing XLSX export (compression=%s)", options.Compression)
 
+	rows, err := checkColumns(rows, options)
+	if err != nil {
+		return 0, err
+	}
+
 	// Create new Excel file
 	f := excelize.NewFile()
 	defer func() {
@@ -26,7 +40,13 @@ func (e *xlsxExporter) Export(rows pgx.Rows, xlsxPath string, options ExportOpti
 		}
 	}()
 
-	sheetName := "Sheet1"
+	sheetName := defaultSheetName
+	if options.XlsxSheetName != "" && options.XlsxSheetName != defaultSheetName {
+		if err := f.SetSheetName(defaultSheetName, options.XlsxSheetName); err != nil {
+			return 0, fmt.Errorf("error renaming sheet: %w", err)
+		}
+		sheetName = options.XlsxSheetName
+	}
 
 	fields := rows.FieldDescriptions()
 
@@ -46,12 +66,24 @@ func (e *xlsxExporter) Export(rows pgx.Rows, xlsxPath string, options ExportOpti
 		}
 	}
 
+	// Header notes must exist before the stream writer takes over the sheet
+	if !options.NoHeader {
+		if err := addHeaderNotes(f, sheetName, options.ColumnComments); err != nil {
+			return 0, err
+		}
+	}
+
 	// Use StreamWriter for better performance
 	sw, err := f.NewStreamWriter(sheetName)
 	if err != nil {
 		return 0, fmt.Errorf("error creating stream writer: %w", err)
 	}
 
+	// Column widths must be set before the first row
+	if err := setColumnWidths(sw, options.ColumnWidths); err != nil {
+		return 0, err
+	}
+
 	// Write headers
 	currentRow := 1
 	if !options.NoHeader {
@@ -76,6 +108,8 @@ func (e *xlsxExporter) Export(rows pgx.Rows, xlsxPath string, options ExportOpti
 	logger.Debug("Starting to write XLSX rows...")
 
 	rowCount := 0
+	rowNum := 0
+	guard := newRowSizeGuard(options)
 	lastLog := time.Now()
 
 	for rows.Next() {
@@ -84,9 +118,23 @@ func (e *xlsxExporter) Export(rows pgx.Rows, xlsxPath string, options ExportOpti
 			return rowCount, fmt.Errorf("error reading row: %w", err)
 		}
 
+		rowNum++
+
 		excelValues := make([]interface{}, len(values))
+		size := 0
 		for i, v := range values {
 			excelValues[i] = formatters.FormatXLSXValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+			if guard.enabled() {
+				size += valueSize(excelValues[i])
+			}
+		}
+
+		ok, err := guard.allow(rowNum, size)
+		if err != nil {
+			return rowCount, err
+		}
+		if !ok {
+			continue
 		}
 
 		cell, _ := excelize.CoordinatesToCellName(1, currentRow)
@@ -109,24 +157,35 @@ func (e *xlsxExporter) Export(rows pgx.Rows, xlsxPath string, options ExportOpti
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
 
+	guard.report()
+
 	// Flush stream writer
 	if err := sw.Flush(); err != nil {
 		return rowCount, fmt.Errorf("error flushing stream: %w", err)
 	}
 
+	// The workbook is written in one piece, so a write failure leaves no usable rows behind
 	if options.Compression == "none" {
 		if err := f.SaveAs(xlsxPath); err != nil {
-			return rowCount, fmt.Errorf("error saving Excel file: %w", err)
+			return 0, newWriteError(xlsxPath, 0, err)
+		}
+		if info, err := os.Stat(xlsxPath); err == nil {
+			options.Log.addBytes(info.Size())
 		}
+		options.Log.addFile(xlsxPath, xlsxPath, rowCount)
 	} else {
-		writerCloser, err := createOutputWriter(xlsxPath, options, FormatXLSX)
+		out, err := createOutputWriter(xlsxPath, options, FormatXLSX)
 		if err != nil {
 			return rowCount, err
 		}
-		defer writerCloser.Close()
 
-		if err := f.Write(writerCloser); err != nil {
-			return rowCount, fmt.Errorf("error writing compressed Excel file: %w", err)
+		err = f.Write(out)
+		if _, ferr := out.finish(rowCount, err); ferr != nil {
+			var werr *WriteError
+			if errors.As(ferr, &werr) {
+				return 0, werr
+			}
+			return 0, fmt.Errorf("error writing compressed Excel file: %w", ferr)
 		}
 	}
 
@@ -137,6 +196,61 @@ func (e *xlsxExporter) Export(rows pgx.Rows, xlsxPath string, options ExportOpti
 	return rowCount, nil
 }
 
+// addHeaderNotes attaches the column comments to the header cells as notes.
+func addHeaderNotes(f *excelize.File, sheetName string, comments []ColumnComment) error {
+	for i, c := range comments {
+		if c.Text == "" {
+			continue
+		}
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := f.AddComment(sheetName, excelize.Comment{Cell: cell, Author: "pgxport", Text: c.Text}); err != nil {
+			return fmt.Errorf("error adding note to %s: %w", cell, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the sheet name against Excel naming rules.
+func (e *xlsxExporter) Validate(options ExportOptions) error {
+	name := options.XlsxSheetName
+	if name == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(name) > maxSheetNameChars {
+		return fmt.Errorf("invalid --xlsx-sheet-name %q: sheet names are limited to %d characters", name, maxSheetNameChars)
+	}
+	if strings.ContainsAny(name, `:\/?*[]`) {
+		return fmt.Errorf("invalid --xlsx-sheet-name %q: sheet names cannot contain any of : \\ / ? * [ ]", name)
+	}
+	if strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'") {
+		return fmt.Errorf("invalid --xlsx-sheet-name %q: sheet names cannot begin or end with an apostrophe", name)
+	}
+	if strings.EqualFold(name, "History") {
+		return fmt.Errorf("invalid --xlsx-sheet-name %q: the name is reserved by Excel", name)
+	}
+	return nil
+}
+
+// Info describes the format for help pages and generated documentation.
+func (e *xlsxExporter) Info() FormatInfo {
+	return FormatInfo{
+		Title:       "XLSX",
+		Description: "An Excel workbook with a single worksheet and a bold header row.",
+		Extension:   ".xlsx",
+		Flags:       []string{"no-header", "xlsx-sheet-name", "include-comments", "two-pass"},
+		Options: []Option{
+			{Name: "sheet", Flag: "xlsx-sheet-name", Default: "Sheet1", Description: "Worksheet name"},
+			{Name: "two-pass", Flag: "two-pass", Default: "false", Description: "Size the columns from a first run of the query"},
+		},
+		Notes: []string{
+			"Dates and timestamps are stored as Excel dates; --time-zone is not applied.",
+			"Excel truncates cell values longer than 32,767 characters.",
+			"With --include-comments, column comments are attached to the header cells as notes.",
+			"With --two-pass, the query is run a first time to size the columns to their content.",
+		},
+	}
+}
+
 func init() {
 	MustRegisterExporter(FormatXLSX, func() Exporter {
 		return &xlsxExporter{}
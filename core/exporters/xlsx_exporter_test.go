@@ -5,10 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/rowsource"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -564,3 +567,75 @@ func BenchmarkExportXLSX(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+func TestXLSXExporterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sheet   string
+		wantErr string
+	}{
+		{name: "default", sheet: "Sheet1"},
+		{name: "empty uses default", sheet: ""},
+		{name: "custom", sheet: "Monthly Report"},
+		{name: "too long", sheet: strings.Repeat("a", 32), wantErr: "limited to 31 characters"},
+		{name: "forbidden character", sheet: "Q1/Q2", wantErr: "cannot contain"},
+		{name: "leading apostrophe", sheet: "'quoted", wantErr: "apostrophe"},
+		{name: "reserved", sheet: "history", wantErr: "reserved"},
+	}
+
+	exporter := &xlsxExporter{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := exporter.Validate(ExportOptions{Format: FormatXLSX, XlsxSheetName: tt.sheet})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, should contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteXLSXHeaderNotes(t *testing.T) {
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "label", OID: pgtype.TextOID},
+	}, [][]any{{int32(1), "one"}, {int32(2), "two"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notes.xlsx")
+	options := ExportOptions{
+		Format:         FormatXLSX,
+		Compression:    "none",
+		ColumnComments: []ColumnComment{{Name: "id"}, {Name: "label", Text: "Display name"}},
+	}
+	exporter, _ := GetExporter(FormatXLSX)
+	if _, err := exporter.Export(rows, path, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open XLSX: %v", err)
+	}
+	defer f.Close()
+
+	comments, err := f.GetComments(defaultSheetName)
+	if err != nil {
+		t.Fatalf("GetComments() error: %v", err)
+	}
+	if len(comments) != 1 || comments[0].Cell != "B1" || !strings.Contains(comments[0].Text, "Display name") {
+		t.Errorf("unexpected notes: %+v", comments)
+	}
+
+	value, _ := f.GetCellValue(defaultSheetName, "A3")
+	if value != "2" {
+		t.Errorf("A3 = %q, want 2", value)
+	}
+}
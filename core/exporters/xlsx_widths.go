@@ -0,0 +1,75 @@
+package exporters
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/jackc/pgx/v5"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// maxColumnWidth is the widest column Excel accepts, in characters.
+	maxColumnWidth = 255
+	// columnPadding leaves room around the widest value of a column.
+	columnPadding = 2
+	// dateTimeWidth is the width of a date or timestamp in the default Excel format.
+	dateTimeWidth = 19
+)
+
+// MeasureColumnWidths reads rows to the end and returns the width, in characters,
+// of the widest value of each column as it will be written to an XLSX sheet,
+// header included. Only the widths are kept, not the rows, so it can run as a
+// first pass over results of any size.
+func MeasureColumnWidths(rows pgx.Rows, options ExportOptions) ([]int, error) {
+	fields := rows.FieldDescriptions()
+	widths := make([]int, len(fields))
+	if !options.NoHeader {
+		for i, fd := range fields {
+			widths[i] = utf8.RuneCountInString(fd.Name)
+		}
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("error reading row: %w", err)
+		}
+		for i, v := range values {
+			widths[i] = max(widths[i], cellWidth(formatters.FormatXLSXValue(v, fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return widths, nil
+}
+
+// cellWidth approximates the number of characters Excel displays for a cell value.
+func cellWidth(v any) int {
+	switch val := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return utf8.RuneCountInString(val)
+	case time.Time:
+		return dateTimeWidth
+	default:
+		return utf8.RuneCountInString(fmt.Sprint(val))
+	}
+}
+
+// setColumnWidths sizes the columns of a sheet to the measured widths.
+func setColumnWidths(sw *excelize.StreamWriter, widths []int) error {
+	for i, w := range widths {
+		if w == 0 {
+			continue
+		}
+		if err := sw.SetColWidth(i+1, i+1, float64(min(w+columnPadding, maxColumnWidth))); err != nil {
+			return fmt.Errorf("error setting column widths: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package exporters
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/xuri/excelize/v2"
+)
+
+func widthRows(t *testing.T) *rowsource.Rows {
+	t.Helper()
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "description", OID: pgtype.TextOID},
+		{Name: "created_at", OID: pgtype.TimestampOID},
+		{Name: "note", OID: pgtype.TextOID},
+	}, [][]any{
+		{int32(1), "short", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), nil},
+		{int32(123456), "a much longer description", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), nil},
+		{int32(2), "café crème", nil, nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestMeasureColumnWidths(t *testing.T) {
+	widths, err := MeasureColumnWidths(widthRows(t), ExportOptions{})
+	if err != nil {
+		t.Fatalf("MeasureColumnWidths() error: %v", err)
+	}
+	want := []int{6, 25, dateTimeWidth, 4}
+	for i := range want {
+		if widths[i] != want[i] {
+			t.Errorf("widths = %v, want %v", widths, want)
+			break
+		}
+	}
+
+	widths, _ = MeasureColumnWidths(widthRows(t), ExportOptions{NoHeader: true})
+	if widths[3] != 0 {
+		t.Errorf("without header, an all-NULL column should have no width, got %v", widths)
+	}
+}
+
+func TestExportXLSXColumnWidths(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "widths.xlsx")
+	options := ExportOptions{Format: FormatXLSX, Compression: "none", ColumnWidths: []int{6, 300, 0, 4}}
+
+	exporter, _ := GetExporter(FormatXLSX)
+	if _, err := exporter.Export(widthRows(t), path, options); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for col, want := range map[string]float64{"A": 8, "B": maxColumnWidth, "D": 6} {
+		got, err := f.GetColWidth("Sheet1", col)
+		if err != nil || got != want {
+			t.Errorf("width of column %s = %v (%v), want %v", col, got, err, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/escaping"
 	"github.com/fbz-tec/pgxport/core/formatters"
 	"github.com/fbz-tec/pgxport/internal/logger"
 	"github.com/jackc/pgx/v5"
@@ -14,20 +15,46 @@ import (
 
 type xmlExporter struct{}
 
+// Ways to write columns whose names are not valid XML element names
+const (
+	XMLNameSanitize = "sanitize" // rename the element, e.g. count(*) -> count___
+	XMLNameAttr     = "attr"     // write <column name="count(*)"> elements
+	XMLNameFail     = "fail"     // refuse to export
+)
+
+// xmlColumnElement is the element of columns written with the attr name policy.
+const xmlColumnElement = "column"
+
 // writes query results to an XML file with buffered I/O
-func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOptions) (int, error) {
+func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOptions) (rowCount int, err error) {
 
 	start := time.Now()
 	logger.Debug("Preparing XML export (indent=2 spaces, compression=%s)", options.Compression)
 
-	writeCloser, err := createOutputWriter(xmlPath, options, FormatXML)
+	rows, err = checkColumns(rows, options)
+	if err != nil {
+		return 0, err
+	}
+
+	// get fields names
+	fields := rows.FieldDescriptions()
+	keys := make([]string, len(fields))
+	for i, fd := range fields {
+		keys[i] = string(fd.Name)
+	}
+	elems, err := xmlColumnElements(keys, options.XmlNamePolicy)
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := createOutputWriter(xmlPath, options, FormatXML)
 	if err != nil {
 		return 0, err
 	}
-	defer writeCloser.Close()
+	defer func() { rowCount, err = out.finish(rowCount, err) }()
 
 	// Use buffered writer for better performance
-	bufferedWriter := bufio.NewWriter(writeCloser)
+	bufferedWriter := bufio.NewWriter(out)
 	defer bufferedWriter.Flush()
 
 	// Encode to XML with indentation
@@ -46,14 +73,9 @@ func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOption
 		return 0, fmt.Errorf("error starting <%s>: %w", options.XmlRootElement, err)
 	}
 
-	// get fields names
-	fields := rows.FieldDescriptions()
-	keys := make([]string, len(fields))
-	for i, fd := range fields {
-		keys[i] = string(fd.Name)
-	}
-
-	rowCount := 0
+	rowNum := 0
+	guard := newRowSizeGuard(options)
+	vals := make([]string, len(fields))
 
 	logger.Debug("Starting to write XML rows...")
 
@@ -62,6 +84,22 @@ func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOption
 		if err != nil {
 			return 0, fmt.Errorf("error reading row: %w", err)
 		}
+		rowNum++
+
+		// Format the whole row first so its size can be checked before anything is written
+		size := 0
+		for i := range keys {
+			vals[i] = formatters.FormatXMLValue(values[i], fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+			size += len(vals[i]) + 2*len(keys[i]) + 5 // <key></key>
+		}
+
+		ok, err := guard.allow(rowNum, size)
+		if err != nil {
+			return rowCount, err
+		}
+		if !ok {
+			continue
+		}
 
 		startRow := xml.StartElement{Name: xml.Name{Local: options.XmlRowElement}}
 
@@ -69,15 +107,15 @@ func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOption
 			return rowCount, fmt.Errorf("error opening <%s>: %w", options.XmlRowElement, err)
 		}
 
-		for i, field := range keys {
-			elem := xml.StartElement{Name: xml.Name{Local: field}}
-			val := formatters.FormatXMLValue(values[i], fields[i].DataTypeOID, options.TimeFormat, options.TimeZone)
+		for i, elem := range elems {
+			field := elem.Name.Local
+			val := vals[i]
 			if val == "" {
-				if err := encoder.EncodeToken(xml.StartElement{Name: elem.Name}); err != nil {
-					return rowCount, fmt.Errorf("error opening <%s>: %w", elem, err)
+				if err := encoder.EncodeToken(elem); err != nil {
+					return rowCount, fmt.Errorf("error opening <%s>: %w", field, err)
 				}
 				if err := encoder.EncodeToken(xml.EndElement{Name: elem.Name}); err != nil {
-					return rowCount, fmt.Errorf("error closing </%s>: %w", elem, err)
+					return rowCount, fmt.Errorf("error closing </%s>: %w", field, err)
 				}
 				continue
 			}
@@ -86,7 +124,7 @@ func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOption
 				if err := encoder.EncodeToken(elem); err != nil {
 					return rowCount, fmt.Errorf("error opening <%s>: %w", field, err)
 				}
-				if _, err := bufferedWriter.WriteString(val); err != nil {
+				if _, err := bufferedWriter.WriteString(escaping.XMLText(val)); err != nil {
 					return rowCount, fmt.Errorf("error writing raw value for <%s>: %w", field, err)
 				}
 				if err := encoder.EncodeToken(xml.EndElement{Name: elem.Name}); err != nil {
@@ -106,6 +144,7 @@ func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOption
 		}
 
 		rowCount++
+		out.markRows(rowCount, bufferedWriter.Buffered())
 
 		if rowCount%10000 == 0 {
 			bufferedWriter.Flush()
@@ -118,6 +157,8 @@ func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOption
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
 
+	guard.report()
+
 	if err := encoder.EncodeToken(xml.EndElement{Name: startResults.Name}); err != nil {
 		return 0, fmt.Errorf("error ending </%s>: %w", options.XmlRootElement, err)
 	}
@@ -132,6 +173,109 @@ func (e *xmlExporter) Export(rows pgx.Rows, xmlPath string, options ExportOption
 	return rowCount, nil
 }
 
+// xmlColumnElements returns the element written for each column. Columns whose
+// names are not valid XML names are handled according to policy; the renamed
+// ones are reported once.
+func xmlColumnElements(keys []string, policy string) ([]xml.StartElement, error) {
+	elems := make([]xml.StartElement, len(keys))
+	var invalid []int
+	used := map[string]bool{}
+	for i, key := range keys {
+		if escaping.IsValidXMLName(key) {
+			elems[i] = xml.StartElement{Name: xml.Name{Local: key}}
+			used[key] = true
+		} else {
+			invalid = append(invalid, i)
+		}
+	}
+	if len(invalid) == 0 {
+		return elems, nil
+	}
+
+	if policy == XMLNameFail {
+		names := make([]string, len(invalid))
+		for j, i := range invalid {
+			names[j] = fmt.Sprintf("%q", keys[i])
+		}
+		return nil, fmt.Errorf("column names %s are not valid XML element names; rename them in the query or use --xml-name-policy %s or %s",
+			strings.Join(names, ", "), XMLNameSanitize, XMLNameAttr)
+	}
+
+	var mapping []string
+	for _, i := range invalid {
+		switch policy {
+		case XMLNameAttr:
+			elems[i] = xml.StartElement{
+				Name: xml.Name{Local: xmlColumnElement},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: keys[i]}},
+			}
+			mapping = append(mapping, fmt.Sprintf("%q -> <%s name=%q>", keys[i], xmlColumnElement, keys[i]))
+		default:
+			// Suffixes keep the elements of distinct columns apart, e.g. "a b" next to "a_b"
+			base := escaping.SanitizeXMLName(keys[i])
+			name := base
+			for n := 2; used[name]; n++ {
+				name = fmt.Sprintf("%s_%d", base, n)
+			}
+			used[name] = true
+			elems[i] = xml.StartElement{Name: xml.Name{Local: name}}
+			mapping = append(mapping, fmt.Sprintf("%q -> <%s>", keys[i], name))
+		}
+	}
+	logger.Warn("%d column name(s) are not valid XML element names: %s", len(invalid), strings.Join(mapping, ", "))
+	return elems, nil
+}
+
+// Validate checks that the root and row tags are well-formed XML element names.
+func (e *xmlExporter) Validate(options ExportOptions) error {
+	switch options.XmlNamePolicy {
+	case "", XMLNameSanitize, XMLNameAttr, XMLNameFail:
+	default:
+		return fmt.Errorf("invalid --xml-name-policy '%s'. Valid options are: %s, %s, %s",
+			options.XmlNamePolicy, XMLNameSanitize, XMLNameAttr, XMLNameFail)
+	}
+	if err := validateXMLTag("--xml-root-tag", options.XmlRootElement); err != nil {
+		return err
+	}
+	if err := validateXMLTag("--xml-row-tag", options.XmlRowElement); err != nil {
+		return err
+	}
+	if options.XmlRootElement == options.XmlRowElement {
+		return fmt.Errorf("--xml-root-tag and --xml-row-tag must be different (both are %q)", options.XmlRowElement)
+	}
+	return nil
+}
+
+func validateXMLTag(flag, tag string) error {
+	if strings.TrimSpace(tag) == "" {
+		return fmt.Errorf("%s cannot be empty", flag)
+	}
+	if !escaping.IsValidXMLName(tag) {
+		return fmt.Errorf("invalid %s %q: XML element names must start with a letter or underscore "+
+			"and may only contain letters, digits, '-', '_' and '.'", flag, tag)
+	}
+	return nil
+}
+
+// Info describes the format for help pages and generated documentation.
+func (e *xmlExporter) Info() FormatInfo {
+	return FormatInfo{
+		Title:       "XML",
+		Description: "A root element containing one element per row, with one child element per column.",
+		Extension:   ".xml",
+		Flags:       []string{"xml-root-tag", "xml-row-tag", "xml-name-policy"},
+		Options: []Option{
+			{Name: "root-tag", Flag: "xml-root-tag", Default: "results", Description: "Name of the root element"},
+			{Name: "row-tag", Flag: "xml-row-tag", Default: "row", Description: "Name of the row elements"},
+			{Name: "name-policy", Flag: "xml-name-policy", Default: XMLNameSanitize, Description: "Handling of column names that are not XML names (sanitize, attr, fail)"},
+		},
+		Notes: []string{
+			"Column names are used as element names. Invalid names such as count(*) are sanitized (count___), written as <column name=\"count(*)\"> with --xml-name-policy attr, or rejected with fail.",
+			"NULL values are written as empty elements.",
+		},
+	}
+}
+
 func init() {
 	MustRegisterExporter(FormatXML, func() Exporter { return &xmlExporter{} })
 }
@@ -3,13 +3,16 @@ package exporters
 import (
 	"context"
 	"encoding/xml"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/rowsource"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestExportXML(t *testing.T) {
@@ -700,3 +703,103 @@ func BenchmarkExportXML(b *testing.B) {
 		os.Remove(outputPath)
 	}
 }
+
+func TestXMLExporterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    string
+		row     string
+		policy  string
+		wantErr string
+	}{
+		{name: "defaults", root: "results", row: "row"},
+		{name: "custom tags", root: "users", row: "user-record"},
+		{name: "empty root", root: "", row: "row", wantErr: "--xml-root-tag cannot be empty"},
+		{name: "root starting with digit", root: "2024_results", row: "row", wantErr: "invalid --xml-root-tag"},
+		{name: "row with space", root: "results", row: "my row", wantErr: "invalid --xml-row-tag"},
+		{name: "row with namespace", root: "results", row: "ns:row", wantErr: "invalid --xml-row-tag"},
+		{name: "same tags", root: "item", row: "item", wantErr: "must be different"},
+		{name: "invalid name policy", root: "results", row: "row", policy: "drop", wantErr: "invalid --xml-name-policy"},
+	}
+
+	exporter := &xmlExporter{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := exporter.Validate(ExportOptions{Format: FormatXML, XmlRootElement: tt.root, XmlRowElement: tt.row, XmlNamePolicy: tt.policy})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, should contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteXMLColumnNamePolicies(t *testing.T) {
+	newRows := func() *rowsource.Rows {
+		rows, err := rowsource.New([]rowsource.Column{
+			{Name: "id", OID: pgtype.Int4OID},
+			{Name: "count(*)", OID: pgtype.Int8OID},
+			{Name: "2024_total", OID: pgtype.TextOID},
+			{Name: "a b", OID: pgtype.TextOID},
+			{Name: "a_b", OID: pgtype.TextOID},
+		}, [][]any{{int32(1), int64(3), "10", nil, "x"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rows
+	}
+	export := func(policy string) (string, error) {
+		path := filepath.Join(t.TempDir(), "out.xml")
+		options := ExportOptions{Format: FormatXML, Compression: "none", XmlRootElement: "results", XmlRowElement: "row", XmlNamePolicy: policy}
+		if _, err := (&xmlExporter{}).Export(newRows(), path, options); err != nil {
+			if _, statErr := os.Stat(path); statErr == nil {
+				t.Errorf("%s: output written despite the error", policy)
+			}
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The output must be well-formed whatever the column names
+		d := xml.NewDecoder(strings.NewReader(string(data)))
+		for {
+			if _, err := d.Token(); err != nil {
+				if err != io.EOF {
+					t.Errorf("%s: output is not well-formed: %v", policy, err)
+				}
+				break
+			}
+		}
+		return string(data), nil
+	}
+
+	out, err := export(XMLNameSanitize)
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	for _, want := range []string{"<id>1</id>", "<count___>3</count___>", "<_2024_total>10</_2024_total>", "<a_b_2></a_b_2>", "<a_b>x</a_b>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("sanitize: output missing %s:\n%s", want, out)
+		}
+	}
+
+	out, err = export(XMLNameAttr)
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	for _, want := range []string{"<id>1</id>", `<column name="count(*)">3</column>`, `<column name="a b"></column>`, "<a_b>x</a_b>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("attr: output missing %s:\n%s", want, out)
+		}
+	}
+
+	if _, err := export(XMLNameFail); err == nil || !strings.Contains(err.Error(), `"count(*)", "2024_total", "a b"`) {
+		t.Errorf("fail: Export() error = %v", err)
+	}
+}
@@ -13,17 +13,23 @@ import (
 
 type yamlExporter struct{}
 
-func (e *yamlExporter) Export(rows pgx.Rows, yamlPath string, options ExportOptions) (int, error) {
+func (e *yamlExporter) Export(rows pgx.Rows, yamlPath string, options ExportOptions) (rowCount int, err error) {
 	start := time.Now()
 	logger.Debug("Preparing YAML export (compression=%s)", options.Compression)
 
-	writeCloser, err := createOutputWriter(yamlPath, options, FormatYAML)
+	rows, err = checkColumns(rows, options)
 	if err != nil {
 		return 0, err
 	}
-	defer writeCloser.Close()
 
-	w := bufio.NewWriter(writeCloser)
+	out, err := createOutputWriter(yamlPath, options, FormatYAML)
+	if err != nil {
+		return 0, err
+	}
+	// The YAML document is emitted in one piece, so a write failure leaves no complete rows
+	defer func() { rowCount, err = out.finish(rowCount, err) }()
+
+	w := bufio.NewWriter(out)
 	defer w.Flush()
 
 	enc := yaml.NewEncoder(w)
@@ -46,17 +52,30 @@ func (e *yamlExporter) Export(rows pgx.Rows, yamlPath string, options ExportOpti
 
 	rowEncoder := encoders.NewOrderedYamlEncoder(options.TimeFormat, options.TimeZone)
 
-	rowCount := 0
+	rowNum := 0
+	guard := newRowSizeGuard(options)
 
 	for rows.Next() {
+		rowNum++
+
 		values, err := rows.Values()
 		if err != nil {
-			return rowCount, fmt.Errorf("error reading row %d: %w", rowCount+1, err)
+			return rowCount, fmt.Errorf("error reading row %d: %w", rowNum, err)
 		}
 
 		rowNode, err := rowEncoder.EncodeRow(keys, dataTypes, values)
 		if err != nil {
-			return rowCount, fmt.Errorf("error encoding YAML row %d: %w", rowCount+1, err)
+			return rowCount, fmt.Errorf("error encoding YAML row %d: %w", rowNum, err)
+		}
+
+		if guard.enabled() {
+			ok, err := guard.allow(rowNum, yamlNodeSize(rowNode))
+			if err != nil {
+				return rowCount, err
+			}
+			if !ok {
+				continue
+			}
 		}
 
 		// Add to sequence
@@ -72,6 +91,8 @@ func (e *yamlExporter) Export(rows pgx.Rows, yamlPath string, options ExportOpti
 		return rowCount, fmt.Errorf("error iterating rows: %w", err)
 	}
 
+	guard.report()
+
 	// Encode final YAML sequence
 	if err := enc.Encode(rootSeq); err != nil {
 		return rowCount, fmt.Errorf("error writing YAML: %w", err)
@@ -83,6 +104,23 @@ func (e *yamlExporter) Export(rows pgx.Rows, yamlPath string, options ExportOpti
 	return rowCount, nil
 }
 
+// Validate accepts any options: YAML export has no format-specific settings.
+func (e *yamlExporter) Validate(options ExportOptions) error {
+	return nil
+}
+
+// Info describes the format for help pages and generated documentation.
+func (e *yamlExporter) Info() FormatInfo {
+	return FormatInfo{
+		Title:       "YAML",
+		Description: "A sequence of mappings whose keys follow the column order of the query.",
+		Extension:   ".yaml",
+		Notes: []string{
+			"NULL values are written as null.",
+		},
+	}
+}
+
 func init() {
 	MustRegisterExporter(FormatYAML, func() Exporter { return &yamlExporter{} })
 }
@@ -1,26 +1,50 @@
 package formatters
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/fbz-tec/pgxport/core/escaping"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-var timeFormatReplacer = strings.NewReplacer(
-	"yyyy", "2006",
-	"yy", "06",
-	"MM", "01",
-	"dd", "02",
-	"HH", "15",
-	"mm", "04",
-	"ss", "05",
-	"SSS", "000", // Milliseconds
-	"S", "0", // Deciseconds
-)
+// TimeToken describes a pattern token accepted by --time-format.
+type TimeToken struct {
+	Token       string
+	Layout      string // Go reference layout the token maps to
+	Description string
+	Example     string
+}
+
+// TimeTokens lists the supported --time-format tokens. Longer tokens come
+// first so that "yyyy" is matched before "yy" and "SSS" before "S".
+var TimeTokens = []TimeToken{
+	{Token: "yyyy", Layout: "2006", Description: "4-digit year", Example: "2025"},
+	{Token: "yy", Layout: "06", Description: "2-digit year", Example: "25"},
+	{Token: "MM", Layout: "01", Description: "Month (01-12)", Example: "03"},
+	{Token: "dd", Layout: "02", Description: "Day (01-31)", Example: "15"},
+	{Token: "HH", Layout: "15", Description: "Hour 24h (00-23)", Example: "14"},
+	{Token: "mm", Layout: "04", Description: "Minute (00-59)", Example: "30"},
+	{Token: "ss", Layout: "05", Description: "Second (00-59)", Example: "45"},
+	{Token: "SSS", Layout: "000", Description: "Milliseconds (3 digits)", Example: "123"},
+	{Token: "SS", Layout: "00", Description: "Centiseconds (2 digits)", Example: "12"},
+	{Token: "S", Layout: "0", Description: "Deciseconds (1 digit)", Example: "1"},
+}
+
+var timeFormatReplacer = newTimeFormatReplacer()
+
+func newTimeFormatReplacer() *strings.Replacer {
+	pairs := make([]string, 0, 2*len(TimeTokens))
+	for _, tok := range TimeTokens {
+		pairs = append(pairs, tok.Token, tok.Layout)
+	}
+	return strings.NewReplacer(pairs...)
+}
 
 // formatValue is kept for backward compatibility (not used in new code)
 func formatValue(v interface{}, layout string, loc *time.Location) interface{} {
@@ -208,109 +232,164 @@ func FormatYAMLValue(val interface{}, valueType uint32, userTimefmt string, time
 
 // formatSQLValue formats a value for SQL export
 func FormatSQLValue(val interface{}, valueType uint32) string {
+	return string(AppendSQLValue(nil, val, valueType))
+}
+
+// AppendSQLValue appends the SQL literal for val to dst and returns the extended buffer.
+// It avoids fmt for common types since it runs once per exported value.
+func AppendSQLValue(dst []byte, val interface{}, valueType uint32) []byte {
 	if val == nil {
-		return "NULL"
+		return append(dst, "NULL"...)
 	}
 
 	switch valueType {
 	case pgtype.DateOID:
 		if t, ok := val.(time.Time); ok {
-			return fmt.Sprintf("'%s'::date", t.Format("2006-01-02"))
+			dst = append(dst, '\'')
+			dst = t.AppendFormat(dst, "2006-01-02")
+			return append(dst, "'::date"...)
 		}
 
 	case pgtype.TimestampOID:
 		if t, ok := val.(time.Time); ok {
-			return fmt.Sprintf("'%s'::timestamp", t.Format("2006-01-02 15:04:05.000"))
+			dst = append(dst, '\'')
+			dst = t.AppendFormat(dst, "2006-01-02 15:04:05.000")
+			return append(dst, "'::timestamp"...)
 		}
 
 	case pgtype.TimestamptzOID:
 		if t, ok := val.(time.Time); ok {
-			return fmt.Sprintf("'%s'::timestamptz", t.Format("2006-01-02 15:04:05.000-07"))
+			dst = append(dst, '\'')
+			dst = t.AppendFormat(dst, "2006-01-02 15:04:05.000-07")
+			return append(dst, "'::timestamptz"...)
 		}
 
 	case pgtype.UUIDOID:
 		if uuid, ok := val.([16]byte); ok {
-			return fmt.Sprintf("'%x-%x-%x-%x-%x'::uuid", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+			dst = append(dst, '\'')
+			dst = appendUUID(dst, uuid)
+			return append(dst, "'::uuid"...)
 		}
 
 	case pgtype.ByteaOID:
 		if bytes, ok := val.([]byte); ok {
-			escaped := strings.ReplaceAll(string(bytes), "'", "''")
-			return fmt.Sprintf("'%s'::bytea", escaped)
+			dst = escaping.AppendSQLBytea(dst, bytes)
+			return append(dst, "::bytea"...)
 		}
 
 	case pgtype.BoolOID:
 		if b, ok := val.(bool); ok {
-			if b {
-				return "true"
-			}
-			return "false"
+			return strconv.AppendBool(dst, b)
 		}
 
 	case pgtype.NumericOID:
 		if num, ok := val.(pgtype.Numeric); ok {
 			if !num.Valid {
-				return "NULL"
+				return append(dst, "NULL"...)
 			}
 			f, err := num.Float64Value()
 			if err != nil {
-				return "NULL"
+				return append(dst, "NULL"...)
 			}
-			return fmt.Sprintf("%.15g", f.Float64)
+			return strconv.AppendFloat(dst, f.Float64, 'g', 15, 64)
 		}
 
 	case pgtype.IntervalOID:
 		if interval, ok := val.(pgtype.Interval); ok {
 			if !interval.Valid {
-				return "NULL"
+				return append(dst, "NULL"...)
 			}
 			strVal, err := interval.Value()
 			if err != nil {
-				return "NULL"
+				return append(dst, "NULL"...)
 			}
-			return fmt.Sprintf("'%v'::interval", strVal)
+			dst = append(dst, '\'')
+			dst = fmt.Append(dst, strVal)
+			return append(dst, "'::interval"...)
 		}
 
 	case pgtype.JSONBOID:
 		jsonStr, err := json.Marshal(val)
 		if err != nil {
-			return "'{}'::jsonb"
+			return append(dst, "'{}'::jsonb"...)
 		}
-		return fmt.Sprintf("'%s'::jsonb", string(jsonStr))
+		dst = escaping.AppendSQLString(dst, string(jsonStr))
+		return append(dst, "::jsonb"...)
 
 	case pgtype.JSONOID:
 		jsonStr, err := json.Marshal(val)
 		if err != nil {
-			return "'{}'::json"
+			return append(dst, "'{}'::json"...)
 		}
-		return fmt.Sprintf("'%s'::json", string(jsonStr))
+		dst = escaping.AppendSQLString(dst, string(jsonStr))
+		return append(dst, "::json"...)
 	}
 
 	// Generic SQL value formatting
 	switch v := val.(type) {
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-		return fmt.Sprintf("%d", val)
+	case string:
+		return escaping.AppendSQLString(dst, v)
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(dst, v, 10)
+	case uint:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(dst, v, 10)
 
-	case float32, float64:
-		return fmt.Sprintf("%.15g", val)
+	case float32:
+		return strconv.AppendFloat(dst, float64(v), 'g', 15, 32)
+	case float64:
+		return strconv.AppendFloat(dst, v, 'g', 15, 64)
 
 	case []interface{}:
 		if len(v) == 0 {
-			return "'{}'"
+			return append(dst, "'{}'"...)
 		}
-		elems := make([]string, len(v))
+		var elems []byte
+		elems = append(elems, '{')
 		for i, elem := range v {
-			elems[i] = fmt.Sprintf("%v", elem)
+			if i > 0 {
+				elems = append(elems, ',')
+			}
+			elems = fmt.Append(elems, elem)
 		}
-		return fmt.Sprintf("'{%s}'", strings.Join(elems, ","))
+		elems = append(elems, '}')
+		return escaping.AppendSQLString(dst, string(elems))
 
 	default:
-		str := fmt.Sprintf("%v", val)
-		escaped := strings.ReplaceAll(str, "'", "''")
-		return fmt.Sprintf("'%s'", escaped)
+		return escaping.AppendSQLString(dst, fmt.Sprint(val))
 	}
 }
 
+// appendUUID appends the canonical 8-4-4-4-12 representation of uuid.
+func appendUUID(dst []byte, uuid [16]byte) []byte {
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:16])
+	return append(dst, buf[:]...)
+}
+
 // formatXLSXValue formats a PostgreSQL value for Excel
 func FormatXLSXValue(value interface{}, oid uint32, timeFormat, timeZone string) interface{} {
 
@@ -0,0 +1,124 @@
+package rowsource
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// BatchFunc transforms a batch of rows, for transformations that are cheaper on
+// many rows at once, such as calls to a remote service. It returns the rows to
+// pass on. The rows it is given are copies that it may modify.
+type BatchFunc func(rows [][]any) ([][]any, error)
+
+// Batched is a pgx.Rows reading another one size rows at a time through a BatchFunc.
+type Batched struct {
+	src     pgx.Rows
+	fields  []pgconn.FieldDescription
+	size    int
+	fn      BatchFunc
+	batch   [][]any
+	current []any
+	err     error
+}
+
+var _ pgx.Rows = (*Batched)(nil)
+
+// TransformBatches returns the rows of src as transformed by fn, size rows at a
+// time. fields describe the values returned by fn; nil keeps the columns of src.
+func TransformBatches(src pgx.Rows, fields []pgconn.FieldDescription, size int, fn BatchFunc) *Batched {
+	if fields == nil {
+		fields = src.FieldDescriptions()
+	}
+	return &Batched{src: src, fields: fields, size: max(size, 1), fn: fn}
+}
+
+func (b *Batched) Close() {
+	b.src.Close()
+}
+
+// Err returns the error of the source, or the first error returned by the BatchFunc.
+func (b *Batched) Err() error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.src.Err()
+}
+
+func (b *Batched) CommandTag() pgconn.CommandTag {
+	return b.src.CommandTag()
+}
+
+func (b *Batched) FieldDescriptions() []pgconn.FieldDescription {
+	return b.fields
+}
+
+func (b *Batched) Next() bool {
+	b.current = nil
+	for len(b.batch) == 0 {
+		if b.err != nil || !b.fill() {
+			return false
+		}
+	}
+	b.current, b.batch = b.batch[0], b.batch[1:]
+	if len(b.current) != len(b.fields) {
+		b.fail(fmt.Errorf("transformed row has %d values, expected %d", len(b.current), len(b.fields)))
+		return false
+	}
+	return true
+}
+
+// fill reads and transforms the next batch. It returns false at the end of src.
+func (b *Batched) fill() bool {
+	rows := make([][]any, 0, b.size)
+	for len(rows) < b.size && b.src.Next() {
+		values, err := b.src.Values()
+		if err != nil {
+			b.fail(err)
+			return false
+		}
+		rows = append(rows, slices.Clone(values))
+	}
+	if len(rows) == 0 {
+		return false
+	}
+	out, err := b.fn(rows)
+	if err != nil {
+		b.fail(err)
+		return false
+	}
+	b.batch = out
+	return true
+}
+
+func (b *Batched) fail(err error) {
+	b.err = err
+	b.current, b.batch = nil, nil
+	b.src.Close()
+}
+
+// Scan copies the current row values into dest, as Rows.Scan does.
+func (b *Batched) Scan(dest ...any) error {
+	values, err := b.Values()
+	if err != nil {
+		return err
+	}
+	return scanValues(b.fields, values, dest)
+}
+
+func (b *Batched) Values() ([]any, error) {
+	if b.current == nil {
+		return nil, fmt.Errorf("no current row")
+	}
+	return b.current, nil
+}
+
+func (b *Batched) RawValues() [][]byte {
+	return nil
+}
+
+func (b *Batched) Conn() *pgx.Conn {
+	return b.src.Conn()
+}
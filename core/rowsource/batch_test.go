@@ -0,0 +1,58 @@
+package rowsource
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransformBatches(t *testing.T) {
+	var sizes []int
+	rows := TransformBatches(transformSource(t), nil, 2, func(batch [][]any) ([][]any, error) {
+		sizes = append(sizes, len(batch))
+		var out [][]any
+		for _, values := range batch {
+			if values[1] != nil {
+				values[1] = "<redacted>"
+				out = append(out, values)
+			}
+		}
+		return out, nil
+	})
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			t.Fatalf("Scan() error: %v", err)
+		}
+		if email != "<redacted>" {
+			t.Errorf("email = %q", email)
+		}
+		ids = append(ids, id)
+	}
+	if rows.Err() != nil || len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("ids = %v, err = %v", ids, rows.Err())
+	}
+	if len(sizes) != 2 || sizes[0] != 2 || sizes[1] != 1 {
+		t.Errorf("batch sizes = %v, want [2 1]", sizes)
+	}
+}
+
+func TestTransformBatchesErrors(t *testing.T) {
+	boom := errors.New("service unavailable")
+	rows := TransformBatches(transformSource(t), nil, 10, func([][]any) ([][]any, error) { return nil, boom })
+	if rows.Next() {
+		t.Error("Next() should fail")
+	}
+	if !errors.Is(rows.Err(), boom) {
+		t.Errorf("Err() = %v, want %v", rows.Err(), boom)
+	}
+
+	rows = TransformBatches(transformSource(t), nil, 10, func(batch [][]any) ([][]any, error) {
+		return [][]any{{int64(1)}}, nil
+	})
+	if rows.Next() || rows.Err() == nil {
+		t.Errorf("a row with missing values should fail, err = %v", rows.Err())
+	}
+}
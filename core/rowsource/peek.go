@@ -0,0 +1,97 @@
+package rowsource
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Peeked is a pgx.Rows returning rows read ahead of time, then the rest of its source.
+type Peeked struct {
+	src     pgx.Rows
+	head    [][]any
+	current []any
+	err     error
+}
+
+var _ pgx.Rows = (*Peeked)(nil)
+
+// Peek reads up to n rows of src ahead, so that they can be inspected before the
+// rows are consumed. It returns these rows, which must not be modified, and rows
+// returning them again followed by the rest of src.
+func Peek(src pgx.Rows, n int) (*Peeked, [][]any, error) {
+	head := make([][]any, 0, n)
+	for len(head) < n && src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			src.Close()
+			return nil, nil, err
+		}
+		head = append(head, slices.Clone(values))
+	}
+	return &Peeked{src: src, head: head}, head, nil
+}
+
+func (p *Peeked) Close() {
+	p.src.Close()
+}
+
+func (p *Peeked) Err() error {
+	if p.err != nil {
+		return p.err
+	}
+	return p.src.Err()
+}
+
+func (p *Peeked) CommandTag() pgconn.CommandTag {
+	return p.src.CommandTag()
+}
+
+func (p *Peeked) FieldDescriptions() []pgconn.FieldDescription {
+	return p.src.FieldDescriptions()
+}
+
+func (p *Peeked) Next() bool {
+	p.current = nil
+	if len(p.head) > 0 {
+		p.current, p.head = p.head[0], p.head[1:]
+		return true
+	}
+	if !p.src.Next() {
+		return false
+	}
+	values, err := p.src.Values()
+	if err != nil {
+		p.err = err
+		p.src.Close()
+		return false
+	}
+	p.current = values
+	return true
+}
+
+// Scan copies the current row values into dest, as Rows.Scan does.
+func (p *Peeked) Scan(dest ...any) error {
+	values, err := p.Values()
+	if err != nil {
+		return err
+	}
+	return scanValues(p.FieldDescriptions(), values, dest)
+}
+
+func (p *Peeked) Values() ([]any, error) {
+	if p.current == nil {
+		return nil, fmt.Errorf("no current row")
+	}
+	return p.current, nil
+}
+
+func (p *Peeked) RawValues() [][]byte {
+	return nil
+}
+
+func (p *Peeked) Conn() *pgx.Conn {
+	return p.src.Conn()
+}
@@ -0,0 +1,28 @@
+package rowsource
+
+import "testing"
+
+func TestPeek(t *testing.T) {
+	for _, n := range []int{0, 2, 10} {
+		rows, head, err := Peek(transformSource(t), n)
+		if err != nil {
+			t.Fatalf("Peek(%d) error: %v", n, err)
+		}
+		if want := min(n, 3); len(head) != want {
+			t.Errorf("Peek(%d) read %d rows ahead, want %d", n, len(head), want)
+		}
+
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			var email *string
+			if err := rows.Scan(&id, &email); err != nil {
+				t.Fatalf("Scan() error: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		if rows.Err() != nil || len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+			t.Errorf("Peek(%d): ids = %v, err = %v", n, ids, rows.Err())
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package rowsource
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Column describes a result column of an in-memory row source.
+type Column struct {
+	Name string
+	OID  uint32 // PostgreSQL type OID (see pgtype constants), drives value formatting
+}
+
+// Rows is an in-memory pgx.Rows implementation.
+// It lets any Exporter be driven from cached or computed data without a live database.
+type Rows struct {
+	fields []pgconn.FieldDescription
+	data   [][]any
+	pos    int
+	closed bool
+}
+
+var _ pgx.Rows = (*Rows)(nil)
+
+// New creates a row source returning data with the given column layout.
+// Every row must contain exactly one value per column.
+func New(columns []Column, data [][]any) (*Rows, error) {
+	fields := make([]pgconn.FieldDescription, len(columns))
+	for i, c := range columns {
+		fields[i] = pgconn.FieldDescription{Name: c.Name, DataTypeOID: c.OID}
+	}
+	return FromFieldDescriptions(fields, data)
+}
+
+// FromFieldDescriptions creates a row source reusing field descriptions,
+// typically taken from another pgx.Rows.
+func FromFieldDescriptions(fields []pgconn.FieldDescription, data [][]any) (*Rows, error) {
+	for i, row := range data {
+		if len(row) != len(fields) {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i+1, len(row), len(fields))
+		}
+	}
+	return &Rows{fields: fields, data: data}, nil
+}
+
+// Collect reads all remaining rows of src into memory and closes it.
+// The returned Rows can be exported any number of times using Reset.
+func Collect(src pgx.Rows) (*Rows, error) {
+	defer src.Close()
+
+	fields := append([]pgconn.FieldDescription(nil), src.FieldDescriptions()...)
+	var data [][]any
+	for src.Next() {
+		values, err := src.Values()
+		if err != nil {
+			return nil, fmt.Errorf("error reading row %d: %w", len(data)+1, err)
+		}
+		data = append(data, values)
+	}
+	if err := src.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return &Rows{fields: fields, data: data}, nil
+}
+
+// Reset rewinds the row source so it can be iterated again.
+func (r *Rows) Reset() {
+	r.pos = 0
+	r.closed = false
+}
+
+// Len returns the total number of rows held by the source.
+func (r *Rows) Len() int {
+	return len(r.data)
+}
+
+func (r *Rows) Close() {
+	r.closed = true
+}
+
+func (r *Rows) Err() error {
+	return nil
+}
+
+func (r *Rows) CommandTag() pgconn.CommandTag {
+	return pgconn.NewCommandTag(fmt.Sprintf("SELECT %d", r.pos))
+}
+
+func (r *Rows) FieldDescriptions() []pgconn.FieldDescription {
+	return r.fields
+}
+
+func (r *Rows) Next() bool {
+	if r.closed || r.pos >= len(r.data) {
+		r.closed = true
+		return false
+	}
+	r.pos++
+	return true
+}
+
+// Scan copies the current row values into dest.
+// Values are assigned directly when their types match, or converted when
+// the Go types are convertible. A NULL value sets the destination to its zero value.
+func (r *Rows) Scan(dest ...any) error {
+	values, err := r.Values()
+	if err != nil {
+		return err
+	}
+	return scanValues(r.fields, values, dest)
+}
+
+// scanValues assigns the values of a row described by fields to dest.
+func scanValues(fields []pgconn.FieldDescription, values []any, dest []any) error {
+	if len(dest) != len(values) {
+		return fmt.Errorf("number of field descriptions must equal number of destinations, got %d and %d", len(values), len(dest))
+	}
+
+	for i, d := range dest {
+		if d == nil {
+			continue
+		}
+		if err := assign(d, values[i]); err != nil {
+			return fmt.Errorf("can't scan into dest[%d] (%s): %w", i, fields[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rows) Values() ([]any, error) {
+	if r.pos == 0 || r.pos > len(r.data) {
+		return nil, fmt.Errorf("no current row")
+	}
+	return r.data[r.pos-1], nil
+}
+
+func (r *Rows) RawValues() [][]byte {
+	return nil
+}
+
+func (r *Rows) Conn() *pgx.Conn {
+	return nil
+}
+
+func assign(dest any, value any) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("destination must be a non-nil pointer, got %T", dest)
+	}
+	target := dv.Elem()
+
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(target.Type()):
+		target.Set(v)
+	case target.Kind() == reflect.Pointer && v.Type().AssignableTo(target.Type().Elem()):
+		p := reflect.New(target.Type().Elem())
+		p.Elem().Set(v)
+		target.Set(p)
+	case v.Type().ConvertibleTo(target.Type()) && v.Kind() != reflect.String && target.Kind() != reflect.String:
+		target.Set(v.Convert(target.Type()))
+	default:
+		return fmt.Errorf("cannot assign %T to %s", value, target.Type())
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package rowsource
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestNew(t *testing.T) {
+	columns := []Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "name", OID: pgtype.TextOID},
+	}
+
+	rows, err := New(columns, [][]any{
+		{int32(1), "Alice"},
+		{int32(2), nil},
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	fields := rows.FieldDescriptions()
+	if len(fields) != 2 || fields[0].Name != "id" || fields[1].DataTypeOID != pgtype.TextOID {
+		t.Errorf("unexpected field descriptions: %+v", fields)
+	}
+
+	count := 0
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			t.Fatalf("Values() error: %v", err)
+		}
+		if len(values) != 2 {
+			t.Errorf("expected 2 values, got %d", len(values))
+		}
+		count++
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 rows, got %d", count)
+	}
+	if rows.Next() {
+		t.Error("Next() should return false after the last row")
+	}
+	if got := rows.CommandTag().RowsAffected(); got != 2 {
+		t.Errorf("CommandTag().RowsAffected() = %d, want 2", got)
+	}
+}
+
+func TestNewRowLengthMismatch(t *testing.T) {
+	_, err := New([]Column{{Name: "id", OID: pgtype.Int4OID}}, [][]any{{1, 2}})
+	if err == nil {
+		t.Error("New() expected error for row length mismatch, got nil")
+	}
+}
+
+func TestValuesWithoutNext(t *testing.T) {
+	rows, _ := New([]Column{{Name: "id", OID: pgtype.Int4OID}}, [][]any{{1}})
+	if _, err := rows.Values(); err == nil {
+		t.Error("Values() expected error before Next(), got nil")
+	}
+}
+
+func TestScan(t *testing.T) {
+	rows, _ := New(
+		[]Column{{Name: "id", OID: pgtype.Int4OID}, {Name: "name", OID: pgtype.TextOID}, {Name: "note", OID: pgtype.TextOID}},
+		[][]any{{int32(7), "Bob", nil}},
+	)
+	rows.Next()
+
+	var id int64
+	var name *string
+	var note string
+	if err := rows.Scan(&id, &name, &note); err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if id != 7 || name == nil || *name != "Bob" || note != "" {
+		t.Errorf("Scan() got id=%d name=%v note=%q", id, name, note)
+	}
+
+	var wrong bool
+	if err := rows.Scan(&wrong, nil, nil); err == nil {
+		t.Error("Scan() expected error for incompatible destination, got nil")
+	}
+}
+
+func TestCollectAndReset(t *testing.T) {
+	src, _ := New([]Column{{Name: "n", OID: pgtype.Int8OID}}, [][]any{{int64(1)}, {int64(2)}, {int64(3)}})
+
+	collected, err := Collect(src)
+	if err != nil {
+		t.Fatalf("Collect() error: %v", err)
+	}
+	if collected.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", collected.Len())
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		count := 0
+		for collected.Next() {
+			count++
+		}
+		if count != 3 {
+			t.Errorf("pass %d: expected 3 rows, got %d", pass, count)
+		}
+		collected.Reset()
+	}
+}
+
+type failingRows struct {
+	*Rows
+}
+
+func (f failingRows) Err() error { return errors.New("connection lost") }
+
+func TestCollectPropagatesError(t *testing.T) {
+	src, _ := New([]Column{{Name: "n", OID: pgtype.Int8OID}}, nil)
+	if _, err := Collect(failingRows{src}); err == nil {
+		t.Error("Collect() expected error from source, got nil")
+	}
+}
@@ -0,0 +1,140 @@
+package rowsource
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Stream is a pgx.Rows fed by concurrent producers, such as several connections
+// reading the shards of one table. Rows are returned in arrival order.
+//
+// Producers call SetFields, Send and Fail; whoever starts them calls CloseSend once
+// they have all returned. The consumer uses the pgx.Rows methods and must Close the
+// stream so that blocked producers give up.
+type Stream struct {
+	rows  chan []any
+	done  chan struct{}
+	ready chan struct{}
+
+	readyOnce sync.Once
+	closeOnce sync.Once
+	fields    []pgconn.FieldDescription
+
+	mu  sync.Mutex
+	err error
+
+	current []any
+	count   int
+	closed  bool
+}
+
+var _ pgx.Rows = (*Stream)(nil)
+
+// NewStream creates a stream buffering up to buffer rows between producers and the consumer.
+func NewStream(buffer int) *Stream {
+	return &Stream{
+		rows:  make(chan []any, buffer),
+		done:  make(chan struct{}),
+		ready: make(chan struct{}),
+	}
+}
+
+// SetFields publishes the column layout. Only the first call has an effect; later
+// producers are expected to return the same columns.
+func (s *Stream) SetFields(fields []pgconn.FieldDescription) {
+	s.readyOnce.Do(func() {
+		s.fields = append([]pgconn.FieldDescription(nil), fields...)
+		close(s.ready)
+	})
+}
+
+// Send queues a row. It returns false once the consumer has closed the stream.
+func (s *Stream) Send(values []any) bool {
+	select {
+	case s.rows <- values:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Fail records a producer error. Next then returns false and Err reports the
+// first error recorded.
+func (s *Stream) Fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.readyOnce.Do(func() { close(s.ready) })
+}
+
+// CloseSend signals that every producer has returned.
+func (s *Stream) CloseSend() {
+	s.readyOnce.Do(func() { close(s.ready) })
+	close(s.rows)
+}
+
+// FieldDescriptions blocks until a producer has published the column layout.
+func (s *Stream) FieldDescriptions() []pgconn.FieldDescription {
+	<-s.ready
+	return s.fields
+}
+
+func (s *Stream) Next() bool {
+	if s.closed {
+		return false
+	}
+	values, ok := <-s.rows
+	if !ok || s.Err() != nil {
+		s.Close()
+		return false
+	}
+	s.current = values
+	s.count++
+	return true
+}
+
+func (s *Stream) Values() ([]any, error) {
+	if s.current == nil {
+		return nil, fmt.Errorf("no current row")
+	}
+	return s.current, nil
+}
+
+// Scan copies the current row values into dest, like Rows.Scan.
+func (s *Stream) Scan(dest ...any) error {
+	values, err := s.Values()
+	if err != nil {
+		return err
+	}
+	return scanValues(s.fields, values, dest)
+}
+
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the consumer side; pending and future Send calls return false.
+func (s *Stream) Close() {
+	s.closed = true
+	s.current = nil
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func (s *Stream) CommandTag() pgconn.CommandTag {
+	return pgconn.NewCommandTag(fmt.Sprintf("SELECT %d", s.count))
+}
+
+func (s *Stream) RawValues() [][]byte {
+	return nil
+}
+
+func (s *Stream) Conn() *pgx.Conn {
+	return nil
+}
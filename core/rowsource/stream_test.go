@@ -0,0 +1,99 @@
+package rowsource
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestStreamMergesProducers(t *testing.T) {
+	stream := NewStream(4)
+	fields := []pgconn.FieldDescription{{Name: "id", DataTypeOID: pgtype.Int8OID}}
+
+	var wg sync.WaitGroup
+	for p := 0; p < 3; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			stream.SetFields(fields)
+			for i := 0; i < 100; i++ {
+				stream.Send([]any{int64(p*1000 + i)})
+			}
+		}(p)
+	}
+	go func() {
+		wg.Wait()
+		stream.CloseSend()
+	}()
+
+	if got := stream.FieldDescriptions(); len(got) != 1 || got[0].Name != "id" {
+		t.Fatalf("FieldDescriptions() = %+v", got)
+	}
+
+	seen := map[int64]bool{}
+	for stream.Next() {
+		var id int64
+		if err := stream.Scan(&id); err != nil {
+			t.Fatalf("Scan() error: %v", err)
+		}
+		seen[id] = true
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(seen) != 300 {
+		t.Errorf("expected 300 distinct rows, got %d", len(seen))
+	}
+	if tag := stream.CommandTag().String(); tag != "SELECT 300" {
+		t.Errorf("CommandTag() = %q", tag)
+	}
+}
+
+func TestStreamProducerFailure(t *testing.T) {
+	stream := NewStream(1)
+	failure := errors.New("worker node unreachable")
+
+	go func() {
+		stream.Fail(failure)
+		stream.CloseSend()
+	}()
+
+	if fields := stream.FieldDescriptions(); fields != nil {
+		t.Errorf("FieldDescriptions() = %+v, want nil", fields)
+	}
+	if stream.Next() {
+		t.Error("Next() should return false after a failure")
+	}
+	if !errors.Is(stream.Err(), failure) {
+		t.Errorf("Err() = %v, want %v", stream.Err(), failure)
+	}
+}
+
+func TestStreamCloseUnblocksProducers(t *testing.T) {
+	stream := NewStream(0)
+	stream.SetFields([]pgconn.FieldDescription{{Name: "id"}})
+
+	sent := make(chan bool)
+	go func() {
+		ok := true
+		for ok {
+			ok = stream.Send([]any{1})
+		}
+		sent <- ok
+	}()
+
+	if !stream.Next() {
+		t.Fatal("Next() should return the first row")
+	}
+	stream.Close()
+
+	if <-sent {
+		t.Error("Send() should return false after Close")
+	}
+	if stream.Next() {
+		t.Error("Next() should return false after Close")
+	}
+}
@@ -0,0 +1,105 @@
+package rowsource
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RowFunc transforms one row. It returns the values to pass on, or nil to drop
+// the row. The values of src must not be modified in place.
+type RowFunc func(values []any) ([]any, error)
+
+// Transformed is a pgx.Rows reading another one through a RowFunc.
+type Transformed struct {
+	src     pgx.Rows
+	fields  []pgconn.FieldDescription
+	fn      RowFunc
+	current []any
+	err     error
+}
+
+var _ pgx.Rows = (*Transformed)(nil)
+
+// Transform returns the rows of src as transformed by fn. fields describe the
+// values returned by fn; nil keeps the columns of src.
+func Transform(src pgx.Rows, fields []pgconn.FieldDescription, fn RowFunc) *Transformed {
+	if fields == nil {
+		fields = src.FieldDescriptions()
+	}
+	return &Transformed{src: src, fields: fields, fn: fn}
+}
+
+func (t *Transformed) Close() {
+	t.src.Close()
+}
+
+// Err returns the error of the source, or the first error returned by the RowFunc.
+func (t *Transformed) Err() error {
+	if t.err != nil {
+		return t.err
+	}
+	return t.src.Err()
+}
+
+func (t *Transformed) CommandTag() pgconn.CommandTag {
+	return t.src.CommandTag()
+}
+
+func (t *Transformed) FieldDescriptions() []pgconn.FieldDescription {
+	return t.fields
+}
+
+func (t *Transformed) Next() bool {
+	t.current = nil
+	if t.err != nil {
+		return false
+	}
+	for t.src.Next() {
+		values, err := t.src.Values()
+		if err == nil {
+			values, err = t.fn(values)
+		}
+		if err != nil {
+			t.err = err
+			t.src.Close()
+			return false
+		}
+		if values == nil {
+			continue
+		}
+		if len(values) != len(t.fields) {
+			t.err = fmt.Errorf("transformed row has %d values, expected %d", len(values), len(t.fields))
+			t.src.Close()
+			return false
+		}
+		t.current = values
+		return true
+	}
+	return false
+}
+
+// Scan copies the current row values into dest, as Rows.Scan does.
+func (t *Transformed) Scan(dest ...any) error {
+	values, err := t.Values()
+	if err != nil {
+		return err
+	}
+	return scanValues(t.fields, values, dest)
+}
+
+func (t *Transformed) Values() ([]any, error) {
+	if t.current == nil {
+		return nil, fmt.Errorf("no current row")
+	}
+	return t.current, nil
+}
+
+func (t *Transformed) RawValues() [][]byte {
+	return nil
+}
+
+func (t *Transformed) Conn() *pgx.Conn {
+	return t.src.Conn()
+}
@@ -0,0 +1,76 @@
+package rowsource
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func transformSource(t *testing.T) *Rows {
+	t.Helper()
+	src, err := New([]Column{{Name: "id", OID: pgtype.Int8OID}, {Name: "email", OID: pgtype.TextOID}}, [][]any{
+		{int64(1), "ann@example.com"},
+		{int64(2), nil},
+		{int64(3), "bob@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func TestTransform(t *testing.T) {
+	// Drop rows without email and keep the domain only
+	fields := []pgconn.FieldDescription{{Name: "id", DataTypeOID: pgtype.Int8OID}, {Name: "domain", DataTypeOID: pgtype.TextOID}}
+	rows := Transform(transformSource(t), fields, func(values []any) ([]any, error) {
+		email, ok := values[1].(string)
+		if !ok {
+			return nil, nil
+		}
+		_, domain, _ := strings.Cut(email, "@")
+		return []any{values[0], domain}, nil
+	})
+
+	if got := rows.FieldDescriptions()[1].Name; got != "domain" {
+		t.Errorf("column name = %q, want domain", got)
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var domain string
+		if err := rows.Scan(&id, &domain); err != nil {
+			t.Fatalf("Scan() error: %v", err)
+		}
+		if domain != "example.com" {
+			t.Errorf("domain = %q", domain)
+		}
+		ids = append(ids, id)
+	}
+	if rows.Err() != nil || len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("ids = %v, err = %v", ids, rows.Err())
+	}
+	if _, err := rows.Values(); err == nil {
+		t.Error("Values() after the last row should fail")
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	failure := errors.New("lookup failed")
+	rows := Transform(transformSource(t), nil, func(values []any) ([]any, error) {
+		return nil, failure
+	})
+	if rows.Next() || !errors.Is(rows.Err(), failure) {
+		t.Errorf("Err() = %v, want %v", rows.Err(), failure)
+	}
+
+	rows = Transform(transformSource(t), nil, func(values []any) ([]any, error) {
+		return values[:1], nil
+	})
+	if rows.Next() || rows.Err() == nil || !strings.Contains(rows.Err().Error(), "has 1 values, expected 2") {
+		t.Errorf("Err() = %v, want a column count error", rows.Err())
+	}
+}
@@ -0,0 +1,57 @@
+package tokenization
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Middleware returns the middleware replacing the values of columns with their
+// tokens. Rows are read BatchSize at a time so that their values are tokenized
+// together. Values are turned into text, formatted as in CSV output, first.
+func (c *Client) Middleware(columns []string) exporters.Middleware {
+	return exporters.WrapRows(func(rows pgx.Rows, options exporters.ExportOptions) (pgx.Rows, error) {
+		fields := slices.Clone(rows.FieldDescriptions())
+		cols := make([]int, len(columns))
+		oids := make([]uint32, len(columns))
+		for i, name := range columns {
+			col := slices.IndexFunc(fields, func(f pgconn.FieldDescription) bool { return f.Name == name })
+			if col < 0 {
+				return nil, fmt.Errorf("cannot tokenize column %q: no such column in the result", name)
+			}
+			cols[i], oids[i] = col, fields[col].DataTypeOID
+			fields[col].DataTypeOID = pgtype.TextOID
+		}
+
+		return rowsource.TransformBatches(rows, fields, c.BatchSize, func(batch [][]any) ([][]any, error) {
+			for i, col := range cols {
+				var texts []string
+				var positions []int
+				for r, values := range batch {
+					if values[col] != nil {
+						texts = append(texts, formatters.FormatCSVValue(values[col], oids[i], options.TimeFormat, options.TimeZone))
+						positions = append(positions, r)
+					}
+				}
+				if len(texts) == 0 {
+					continue
+				}
+				tokens, err := c.Tokenize(context.Background(), columns[i], texts)
+				if err != nil {
+					return nil, err
+				}
+				for j, r := range positions {
+					batch[r][col] = tokens[j]
+				}
+			}
+			return batch, nil
+		}), nil
+	})
+}
@@ -0,0 +1,227 @@
+// Package tokenization replaces the values of selected result columns with tokens
+// issued by an external tokenization (vault) HTTP service, so that sensitive data
+// such as card numbers never reaches the export file.
+//
+// Values are sent in batches as a JSON POST request:
+//
+//	{"column": "card_number", "values": ["4111111111111111", ...]}
+//
+// and the service answers with one token per value, in the same order:
+//
+//	{"tokens": ["tok_8f3a...", ...]}
+//
+// Tokens are cached for the duration of the export, so each distinct value of a
+// column is only sent once while it stays among the Client.CacheSize most recently
+// used. NULL values stay NULL.
+package tokenization
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/version"
+)
+
+// Environment variables of the service settings
+const (
+	EnvURL    = "PGXPORT_TOKENIZE_URL"
+	EnvAPIKey = "PGXPORT_TOKENIZE_API_KEY"
+)
+
+// DefaultBatchSize is the number of rows read before their values are tokenized.
+const DefaultBatchSize = 500
+
+// DefaultCacheSize is the number of tokens kept in memory.
+const DefaultCacheSize = 100_000
+
+// Client requests tokens from a tokenization service and caches them.
+type Client struct {
+	URL       string
+	APIKey    string
+	BatchSize int
+	CacheSize int // tokens kept, the least recently used are dropped first
+	HTTP      *http.Client
+
+	mu       sync.Mutex
+	cache    map[cacheKey]*list.Element
+	recent   list.List // of cacheEntry, most recently used first
+	requests int
+}
+
+type cacheKey struct {
+	column, value string
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	token string
+}
+
+type tokenizeRequest struct {
+	Column string   `json:"column"`
+	Values []string `json:"values"`
+}
+
+type tokenizeResponse struct {
+	Tokens []string `json:"tokens"`
+}
+
+// NewClient returns a client for the service at endpoint. The values and the API
+// key are only sent over https, or over http to a service on the loopback interface.
+func NewClient(endpoint, apiKey string) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid tokenization service URL %q", endpoint)
+	}
+	if u.Scheme == "http" && !isLoopback(u.Hostname()) {
+		return nil, fmt.Errorf("tokenization service URL %q must use https", u.Redacted())
+	}
+	return &Client{
+		URL:       u.String(),
+		APIKey:    apiKey,
+		BatchSize: DefaultBatchSize,
+		CacheSize: DefaultCacheSize,
+		HTTP:      &http.Client{Timeout: 30 * time.Second},
+		cache:     map[cacheKey]*list.Element{},
+	}, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Requests returns the number of requests sent to the service so far.
+func (c *Client) Requests() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests
+}
+
+// Tokenize returns the token of each value of column. Values already tokenized
+// are taken from the cache; the others are sent in requests of at most BatchSize
+// distinct values. The lock is only held around the cache: concurrent calls do
+// not wait for each other's requests.
+func (c *Client) Tokenize(ctx context.Context, column string, values []string) ([]string, error) {
+	// The tokens of this call are kept apart: the cache may drop them before the end
+	known := make(map[string]string, len(values))
+	var missing []string
+	c.mu.Lock()
+	for _, v := range values {
+		if _, ok := known[v]; ok {
+			continue
+		}
+		token, ok := c.cached(cacheKey{column, v})
+		known[v] = token
+		if !ok {
+			missing = append(missing, v)
+		}
+	}
+	c.mu.Unlock()
+
+	size := max(c.BatchSize, 1)
+	for start := 0; start < len(missing); start += size {
+		batch := missing[start:min(start+size, len(missing))]
+		tokens, err := c.request(ctx, column, batch)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		for i, v := range batch {
+			known[v] = tokens[i]
+			c.remember(cacheKey{column, v}, tokens[i])
+		}
+		c.mu.Unlock()
+	}
+
+	tokens := make([]string, len(values))
+	for i, v := range values {
+		tokens[i] = known[v]
+	}
+	return tokens, nil
+}
+
+// cached returns the token of key from the cache. c.mu must be held.
+func (c *Client) cached(key cacheKey) (string, bool) {
+	e, ok := c.cache[key]
+	if !ok {
+		return "", false
+	}
+	c.recent.MoveToFront(e)
+	return e.Value.(cacheEntry).token, true
+}
+
+// remember adds the token of key to the cache, dropping the least recently used
+// tokens beyond CacheSize. c.mu must be held.
+func (c *Client) remember(key cacheKey, token string) {
+	if c.CacheSize < 1 {
+		return
+	}
+	// A concurrent call may have requested the same value
+	if e, ok := c.cache[key]; ok {
+		c.recent.MoveToFront(e)
+		return
+	}
+	c.cache[key] = c.recent.PushFront(cacheEntry{key, token})
+	for c.recent.Len() > c.CacheSize {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.cache, oldest.Value.(cacheEntry).key)
+	}
+}
+
+func (c *Client) request(ctx context.Context, column string, values []string) ([]string, error) {
+	body, err := json.Marshal(tokenizeRequest{Column: column, Values: values})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tokenization service URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pgxport/"+version.AppVersion)
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	c.mu.Lock()
+	c.requests++
+	c.mu.Unlock()
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling tokenization service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		// The body may echo the values sent, so it is left out of the error
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		return nil, fmt.Errorf("tokenization service returned %s for column %q", resp.Status, column)
+	}
+	var out tokenizeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid tokenization service response: %w", err)
+	}
+	if len(out.Tokens) != len(values) {
+		return nil, fmt.Errorf("tokenization service returned %d tokens for %d values of column %q", len(out.Tokens), len(values), column)
+	}
+	for i, token := range out.Tokens {
+		if token == "" {
+			return nil, fmt.Errorf("tokenization service returned an empty token for value %d of column %q", i+1, column)
+		}
+	}
+	return out.Tokens, nil
+}
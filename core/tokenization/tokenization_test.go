@@ -0,0 +1,244 @@
+package tokenization
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// vault is a fake tokenization service issuing tok_<column>_<value> tokens.
+type vault struct {
+	mu       sync.Mutex
+	requests []tokenizeRequest
+}
+
+func (v *vault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer s3cret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req tokenizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	v.mu.Lock()
+	v.requests = append(v.requests, req)
+	v.mu.Unlock()
+
+	resp := tokenizeResponse{}
+	for _, value := range req.Values {
+		resp.Tokens = append(resp.Tokens, fmt.Sprintf("tok_%s_%s", req.Column, value))
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(srv.URL+"/tokenize", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNewClient(t *testing.T) {
+	for _, endpoint := range []string{"", "vault.internal/tokenize", "ftp://vault.internal", "http://vault.internal/tokenize", "http://10.0.0.7/tokenize"} {
+		if _, err := NewClient(endpoint, ""); err == nil {
+			t.Errorf("NewClient(%q) should fail", endpoint)
+		}
+	}
+	for _, endpoint := range []string{"https://vault.internal/tokenize", "http://localhost:8200/tokenize", "http://127.0.0.1:8200/tokenize", "http://[::1]:8200/tokenize"} {
+		if _, err := NewClient(endpoint, ""); err != nil {
+			t.Errorf("NewClient(%q) error: %v", endpoint, err)
+		}
+	}
+}
+
+func TestTokenizeBatchesAndCaches(t *testing.T) {
+	v := &vault{}
+	c := newTestClient(t, v)
+	c.BatchSize = 2
+
+	tokens, err := c.Tokenize(t.Context(), "pan", []string{"4111", "5500", "4111", "3782"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"tok_pan_4111", "tok_pan_5500", "tok_pan_4111", "tok_pan_3782"}
+	if strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Errorf("tokens = %v, want %v", tokens, want)
+	}
+	// Three distinct values in batches of two
+	if len(v.requests) != 2 || len(v.requests[0].Values) != 2 || len(v.requests[1].Values) != 1 {
+		t.Errorf("requests = %+v", v.requests)
+	}
+
+	// Cached values are not sent again, and the cache is kept per column
+	if _, err := c.Tokenize(t.Context(), "pan", []string{"5500", "4111"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Tokenize(t.Context(), "cvv", []string{"4111"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Requests() != 3 || v.requests[2].Column != "cvv" {
+		t.Errorf("requests = %+v", v.requests)
+	}
+}
+
+func TestTokenizeCacheSize(t *testing.T) {
+	v := &vault{}
+	c := newTestClient(t, v)
+	c.CacheSize = 2
+
+	// More distinct values than the cache holds still get their own token
+	tokens, err := c.Tokenize(t.Context(), "pan", []string{"4111", "5500", "3782", "4111"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tokens, ",") != "tok_pan_4111,tok_pan_5500,tok_pan_3782,tok_pan_4111" {
+		t.Errorf("tokens = %v", tokens)
+	}
+
+	// 3782 was used last and stays cached; 4111 was dropped
+	if _, err := c.Tokenize(t.Context(), "pan", []string{"3782"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Requests() != 1 {
+		t.Errorf("requests = %d, want 1 (3782 cached)", c.Requests())
+	}
+	if _, err := c.Tokenize(t.Context(), "pan", []string{"4111"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Requests() != 2 || len(c.cache) != 2 || c.recent.Len() != 2 {
+		t.Errorf("requests = %d, cache = %d entries, want 2 and 2", c.Requests(), len(c.cache))
+	}
+}
+
+func TestTokenizeConcurrent(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req tokenizeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Column == "slow" {
+			close(started)
+			<-release
+		}
+		json.NewEncoder(w).Encode(tokenizeResponse{Tokens: []string{"tok_" + req.Values[0]}})
+	}))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Tokenize(t.Context(), "slow", []string{"4111"})
+		done <- err
+	}()
+	<-started
+
+	// A request in flight must not hold up the other columns
+	fast := make(chan struct{})
+	go func() {
+		defer close(fast)
+		tokens, err := c.Tokenize(t.Context(), "pan", []string{"5500"})
+		if err != nil || tokens[0] != "tok_5500" {
+			t.Errorf("Tokenize() = %v, %v", tokens, err)
+		}
+	}()
+	select {
+	case <-fast:
+	case <-time.After(5 * time.Second):
+		t.Error("Tokenize() waited for the request of another call")
+	}
+	close(release)
+	<-fast
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if c.Requests() != 2 {
+		t.Errorf("requests = %d, want 2", c.Requests())
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{"status", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "card 4111 rejected", http.StatusForbidden)
+		}, `tokenization service returned 403 Forbidden for column "pan"`},
+		{"count", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"tokens": ["a"]}`)
+		}, "returned 1 tokens for 2 values"},
+		{"empty token", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"tokens": ["a", ""]}`)
+		}, "empty token for value 2"},
+		{"invalid json", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html>`)
+		}, "invalid tokenization service response"},
+	}
+	for _, tt := range tests {
+		c := newTestClient(t, tt.handler)
+		_, err := c.Tokenize(t.Context(), "pan", []string{"4111", "5500"})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+		// Values must never leak into error messages
+		if err != nil && strings.Contains(err.Error(), "4111") {
+			t.Errorf("%s: error leaks a value: %v", tt.name, err)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	v := &vault{}
+	c := newTestClient(t, v)
+	c.BatchSize = 2
+
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "pan", OID: pgtype.TextOID},
+		{Name: "amount", OID: pgtype.Int4OID},
+	}, [][]any{
+		{int32(1), "4111", int32(10)},
+		{int32(2), nil, int32(20)},
+		{int32(3), "4111", int32(30)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, _ := exporters.GetExporter(exporters.FormatCSV)
+	path := filepath.Join(t.TempDir(), "out.csv")
+	options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', Compression: "none"}
+	n, err := exporters.Chain(csv, c.Middleware([]string{"pan"})).Export(rows, path, options)
+	if err != nil || n != 3 {
+		t.Fatalf("Export() = %d, %v", n, err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "id,pan,amount\n1,tok_pan_4111,10\n2,,20\n3,tok_pan_4111,30\n"
+	if string(data) != want {
+		t.Errorf("output =\n%s\nwant\n%s", data, want)
+	}
+	// The second batch only holds a cached value
+	if len(v.requests) != 1 {
+		t.Errorf("requests = %+v, want one", v.requests)
+	}
+
+	rows.Reset()
+	_, err = exporters.Chain(csv, c.Middleware([]string{"cvv"})).Export(rows, path, options)
+	if err == nil || !strings.Contains(err.Error(), `cannot tokenize column "cvv"`) {
+		t.Errorf("Export() error = %v, want a missing column error", err)
+	}
+}
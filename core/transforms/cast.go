@@ -0,0 +1,354 @@
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Cast converts the values of a column to another type after they are fetched,
+// following the PostgreSQL casts: numbers are rounded, out of range values fail.
+type Cast struct {
+	Column string `yaml:"column"`
+	Type   string `yaml:"type"` // e.g. text, int, bigint, numeric(12,2), date
+}
+
+// castType is a parsed Cast.Type.
+type castType struct {
+	name      string // canonical PostgreSQL name
+	oid       uint32
+	precision int // numeric(p,s); 0 when unset
+	scale     int
+}
+
+func (c castType) String() string {
+	if c.precision > 0 {
+		return fmt.Sprintf("numeric(%d,%d)", c.precision, c.scale)
+	}
+	return c.name
+}
+
+// castTypes maps the accepted type names to their canonical name and OID.
+var castTypes = map[string]castType{
+	"text":             {name: "text", oid: pgtype.TextOID},
+	"varchar":          {name: "text", oid: pgtype.TextOID},
+	"smallint":         {name: "smallint", oid: pgtype.Int2OID},
+	"int2":             {name: "smallint", oid: pgtype.Int2OID},
+	"int":              {name: "integer", oid: pgtype.Int4OID},
+	"integer":          {name: "integer", oid: pgtype.Int4OID},
+	"int4":             {name: "integer", oid: pgtype.Int4OID},
+	"bigint":           {name: "bigint", oid: pgtype.Int8OID},
+	"int8":             {name: "bigint", oid: pgtype.Int8OID},
+	"numeric":          {name: "numeric", oid: pgtype.NumericOID},
+	"decimal":          {name: "numeric", oid: pgtype.NumericOID},
+	"real":             {name: "real", oid: pgtype.Float4OID},
+	"float4":           {name: "real", oid: pgtype.Float4OID},
+	"double precision": {name: "double precision", oid: pgtype.Float8OID},
+	"double":           {name: "double precision", oid: pgtype.Float8OID},
+	"float8":           {name: "double precision", oid: pgtype.Float8OID},
+	"float":            {name: "double precision", oid: pgtype.Float8OID},
+	"boolean":          {name: "boolean", oid: pgtype.BoolOID},
+	"bool":             {name: "boolean", oid: pgtype.BoolOID},
+	"date":             {name: "date", oid: pgtype.DateOID},
+}
+
+// numericType matches numeric(p) and numeric(p,s).
+var numericType = regexp.MustCompile(`^(?:numeric|decimal)\s*\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)$`)
+
+// CastTypeNames lists the type names accepted by Cast, for error messages and help.
+const CastTypeNames = "text, smallint, int, bigint, numeric, numeric(p,s), real, double, boolean, date"
+
+func parseCastType(s string) (castType, error) {
+	name := strings.ToLower(strings.Join(strings.Fields(s), " "))
+	if t, ok := castTypes[name]; ok {
+		return t, nil
+	}
+	m := numericType.FindStringSubmatch(name)
+	if m == nil {
+		return castType{}, fmt.Errorf("unknown type %q. Valid types are: %s", s, CastTypeNames)
+	}
+	precision, _ := strconv.Atoi(m[1])
+	scale := 0
+	if m[2] != "" {
+		scale, _ = strconv.Atoi(m[2])
+	}
+	if precision < 1 || precision > 1000 {
+		return castType{}, fmt.Errorf("invalid type %q: numeric precision must be between 1 and 1000", s)
+	}
+	if scale > precision {
+		return castType{}, fmt.Errorf("invalid type %q: numeric scale cannot exceed the precision", s)
+	}
+	return castType{name: "numeric", oid: pgtype.NumericOID, precision: precision, scale: scale}, nil
+}
+
+// ParseCast parses a column=type setting, as given to --cast.
+func ParseCast(s string) (Step, error) {
+	column, typ, ok := strings.Cut(s, "=")
+	c := &Cast{Column: strings.TrimSpace(column), Type: strings.TrimSpace(typ)}
+	if !ok || c.Column == "" || c.Type == "" {
+		return Step{}, fmt.Errorf("invalid cast %q: expected column=type, e.g. amount=numeric(12,2)", s)
+	}
+	if _, err := parseCastType(c.Type); err != nil {
+		return Step{}, fmt.Errorf("invalid cast %q: %w", s, err)
+	}
+	return Step{Cast: c}, nil
+}
+
+func (c *Cast) prepare(fields []pgconn.FieldDescription, text textFunc, timeZone string) ([]pgconn.FieldDescription, rowsource.RowFunc, error) {
+	col, err := column(fields, c.Column)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err := parseCastType(c.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, loc := formatters.UserTimeZoneFormat("", timeZone)
+
+	from := fields[col].DataTypeOID
+	out := asText(fields, col)
+	out[col].DataTypeOID = target.oid
+	out[col].TypeModifier = -1
+	if target.precision > 0 {
+		out[col].TypeModifier = int32(target.precision<<16|target.scale) + 4
+	}
+	return out, func(values []any) ([]any, error) {
+		if values[col] == nil {
+			return values, nil
+		}
+		v, err := castValue(values[col], from, target, func(v any) string { return text(v, col) }, loc)
+		if err != nil {
+			return nil, fmt.Errorf("cannot cast column %q to %s: %w", c.Column, target, err)
+		}
+		out := slices.Clone(values)
+		out[col] = v
+		return out, nil
+	}, nil
+}
+
+// castValue converts a non-NULL value of type from to target.
+func castValue(v any, from uint32, target castType, text func(any) string, loc *time.Location) (any, error) {
+	switch target.oid {
+	case pgtype.TextOID:
+		return text(v), nil
+	case pgtype.Int2OID:
+		n, err := toInt64(v)
+		if err == nil && (n < math.MinInt16 || n > math.MaxInt16) {
+			err = fmt.Errorf("value %d is out of range", n)
+		}
+		return int16(n), err
+	case pgtype.Int4OID:
+		n, err := toInt64(v)
+		if err == nil && (n < math.MinInt32 || n > math.MaxInt32) {
+			err = fmt.Errorf("value %d is out of range", n)
+		}
+		return int32(n), err
+	case pgtype.Int8OID:
+		return toInt64(v)
+	case pgtype.NumericOID:
+		n, err := toNumeric(v)
+		if err != nil {
+			return nil, err
+		}
+		if target.precision > 0 {
+			return roundNumeric(n, target.precision, target.scale)
+		}
+		return n, nil
+	case pgtype.Float4OID:
+		f, err := toFloat64(v)
+		if err == nil && !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+			err = fmt.Errorf("value %g is out of range", f)
+		}
+		return float32(f), err
+	case pgtype.Float8OID:
+		return toFloat64(v)
+	case pgtype.BoolOID:
+		return toBool(v)
+	case pgtype.DateOID:
+		if from != pgtype.TimestamptzOID {
+			loc = nil
+		}
+		return toDate(v, loc)
+	}
+	return nil, fmt.Errorf("unsupported type")
+}
+
+func toInt64(v any) (int64, error) {
+	switch n := v.(type) {
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	case float32:
+		return floatToInt64(float64(n))
+	case float64:
+		return floatToInt64(n)
+	case pgtype.Numeric:
+		r, err := roundNumeric(n, 0, 0)
+		if err != nil {
+			return 0, err
+		}
+		if !r.Int.IsInt64() {
+			return 0, fmt.Errorf("value %s is out of range", r.Int)
+		}
+		return r.Int.Int64(), nil
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer %q", n)
+		}
+		return i, nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to an integer", v)
+}
+
+// floatToInt64 rounds to the nearest integer, ties to even, as PostgreSQL does.
+func floatToInt64(f float64) (int64, error) {
+	r := math.RoundToEven(f)
+	if math.IsNaN(r) || r < math.MinInt64 || r >= math.MaxInt64 {
+		return 0, fmt.Errorf("value %g is out of range", f)
+	}
+	return int64(r), nil
+}
+
+func toNumeric(v any) (pgtype.Numeric, error) {
+	var n pgtype.Numeric
+	var err error
+	switch x := v.(type) {
+	case pgtype.Numeric:
+		return x, nil
+	case int16, int32, int64, int:
+		i, _ := toInt64(x)
+		return pgtype.Numeric{Int: big.NewInt(i), Valid: true}, nil
+	case float32:
+		err = n.Scan(strconv.FormatFloat(float64(x), 'f', -1, 32))
+	case float64:
+		err = n.Scan(strconv.FormatFloat(x, 'f', -1, 64))
+	case string:
+		if err = n.Scan(strings.TrimSpace(x)); err != nil {
+			err = fmt.Errorf("invalid number %q", x)
+		}
+	default:
+		err = fmt.Errorf("cannot convert %T to numeric", v)
+	}
+	return n, err
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundNumeric rounds n to scale digits, half away from zero, and checks that it
+// fits numeric(precision, scale). A zero precision only rounds.
+func roundNumeric(n pgtype.Numeric, precision, scale int) (pgtype.Numeric, error) {
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return pgtype.Numeric{}, fmt.Errorf("value is not a finite number")
+	}
+	i := new(big.Int)
+	if n.Int != nil {
+		i.Set(n.Int)
+	}
+	switch shift := int(n.Exp) + scale; {
+	case shift > 0:
+		i.Mul(i, pow10(shift))
+	case shift < 0:
+		divisor := pow10(-shift)
+		q, r := new(big.Int).QuoRem(i, divisor, new(big.Int))
+		// Round half away from zero: |2r| >= divisor
+		if r.Abs(r).Lsh(r, 1).Cmp(divisor) >= 0 {
+			if i.Sign() < 0 {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+		i = q
+	}
+	if precision > 0 && i.Sign() != 0 && len(new(big.Int).Abs(i).String()) > precision {
+		return pgtype.Numeric{}, fmt.Errorf("numeric field overflow: a numeric(%d,%d) value must be below 10^%d",
+			precision, scale, precision-scale)
+	}
+	return pgtype.Numeric{Int: i, Exp: int32(-scale), Valid: true}, nil
+}
+
+func toFloat64(v any) (float64, error) {
+	switch x := v.(type) {
+	case float32:
+		return float64(x), nil
+	case float64:
+		return x, nil
+	case int16, int32, int64, int:
+		i, _ := toInt64(x)
+		return float64(i), nil
+	case pgtype.Numeric:
+		f, err := x.Float64Value()
+		if err != nil {
+			return 0, err
+		}
+		return f.Float64, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", x)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to a floating-point number", v)
+}
+
+func toBool(v any) (bool, error) {
+	switch x := v.(type) {
+	case bool:
+		return x, nil
+	case int16, int32, int64, int:
+		i, _ := toInt64(x)
+		return i != 0, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(x)) {
+		case "t", "true", "y", "yes", "on", "1":
+			return true, nil
+		case "f", "false", "n", "no", "off", "0":
+			return false, nil
+		}
+		return false, fmt.Errorf("invalid boolean %q", x)
+	}
+	return false, fmt.Errorf("cannot convert %T to boolean", v)
+}
+
+// toDate keeps the date of a timestamp, seen in loc unless it is nil, or parses a
+// yyyy-mm-dd text.
+func toDate(v any, loc *time.Location) (time.Time, error) {
+	switch x := v.(type) {
+	case time.Time:
+		if loc != nil {
+			x = x.In(loc)
+		}
+		return time.Date(x.Year(), x.Month(), x.Day(), 0, 0, 0, 0, time.UTC), nil
+	case string:
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(x))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q: expected yyyy-mm-dd", x)
+		}
+		return d, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to date", v)
+}
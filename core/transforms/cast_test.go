@@ -0,0 +1,130 @@
+package transforms
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func numeric(i int64, exp int32) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(i), Exp: exp, Valid: true}
+}
+
+func TestCastValue(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	late := time.Date(2024, 3, 15, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value any
+		from  uint32
+		typ   string
+		want  any
+		err   string
+	}{
+		{name: "bigint to int", value: int64(42), from: pgtype.Int8OID, typ: "int", want: int32(42)},
+		{name: "bigint out of int range", value: int64(3_000_000_000), from: pgtype.Int8OID, typ: "int", err: "value 3000000000 is out of range"},
+		{name: "int to smallint", value: int32(-7), from: pgtype.Int4OID, typ: "smallint", want: int16(-7)},
+		{name: "numeric to int rounds half away", value: numeric(-25, -1), from: pgtype.NumericOID, typ: "integer", want: int32(-3)},
+		{name: "float to bigint rounds half even", value: 2.5, from: pgtype.Float8OID, typ: "bigint", want: int64(2)},
+		{name: "text to int", value: " 17 ", from: pgtype.TextOID, typ: "int4", want: int32(17)},
+		{name: "invalid text to int", value: "1.5", from: pgtype.TextOID, typ: "int", err: `invalid integer "1.5"`},
+		{name: "trim scale", value: numeric(123456, -4), from: pgtype.NumericOID, typ: "numeric(12,2)", want: numeric(1235, -2)},
+		{name: "pad scale", value: int32(12), from: pgtype.Int4OID, typ: "numeric(5, 2)", want: numeric(1200, -2)},
+		{name: "float to numeric", value: 19.999, from: pgtype.Float8OID, typ: "decimal(6,2)", want: numeric(2000, -2)},
+		{name: "text to numeric", value: "-0.005", from: pgtype.TextOID, typ: "numeric(3,2)", want: numeric(-1, -2)},
+		{name: "numeric overflow", value: numeric(100000, -2), from: pgtype.NumericOID, typ: "numeric(4,2)", err: "numeric field overflow"},
+		{name: "numeric NaN", value: pgtype.Numeric{NaN: true, Valid: true}, from: pgtype.NumericOID, typ: "numeric(4,2)", err: "not a finite number"},
+		{name: "numeric without typmod", value: int64(5), from: pgtype.Int8OID, typ: "numeric", want: numeric(5, 0)},
+		{name: "numeric to double", value: numeric(125, -2), from: pgtype.NumericOID, typ: "double precision", want: 1.25},
+		{name: "double to real", value: 1.5, from: pgtype.Float8OID, typ: "real", want: float32(1.5)},
+		{name: "real out of range", value: 1e300, from: pgtype.Float8OID, typ: "float4", err: "out of range"},
+		{name: "text to boolean", value: "Yes", from: pgtype.TextOID, typ: "bool", want: true},
+		{name: "int to boolean", value: int32(0), from: pgtype.Int4OID, typ: "boolean", want: false},
+		{name: "timestamp to date", value: late, from: pgtype.TimestampOID, typ: "date", want: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "timestamptz to date in time zone", value: late, from: pgtype.TimestamptzOID, typ: "date", want: time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{name: "text to date", value: "2024-02-29", from: pgtype.TextOID, typ: "date", want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "uuid to int", value: [16]byte{}, from: pgtype.UUIDOID, typ: "int", err: "cannot convert [16]uint8 to an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := parseCastType(tt.typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := castValue(tt.value, tt.from, target, nil, paris)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("castValue() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("castValue() error: %v", err)
+			}
+			if n, ok := got.(pgtype.Numeric); ok {
+				want := tt.want.(pgtype.Numeric)
+				if n.Int.Cmp(want.Int) != 0 || n.Exp != want.Exp {
+					t.Errorf("castValue() = %s e%d, want %s e%d", n.Int, n.Exp, want.Int, want.Exp)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("castValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCast(t *testing.T) {
+	step, err := ParseCast(" amount = Numeric(12, 2) ")
+	if err != nil || step.Cast.Column != "amount" || step.Cast.Type != "Numeric(12, 2)" {
+		t.Errorf("ParseCast() = %+v, %v", step.Cast, err)
+	}
+
+	for spec, want := range map[string]string{
+		"amount":                 "expected column=type",
+		"=int":                   "expected column=type",
+		"amount=money":           `unknown type "money"`,
+		"amount=numeric(2,3)":    "scale cannot exceed the precision",
+		"amount=numeric(0)":      "precision must be between 1 and 1000",
+		"amount=numeric(12,2,1)": "unknown type",
+	} {
+		if _, err := ParseCast(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseCast(%q) error = %v, want %q", spec, err, want)
+		}
+	}
+}
+
+func TestCastStep(t *testing.T) {
+	steps := parseSteps(t, `
+- cast: {column: id, type: "numeric(4,1)"}
+- cast: {column: card, type: bigint}
+`)
+	out, n, err := export(t, steps)
+	if err != nil || n != 3 {
+		t.Fatalf("export() = %d, %v", n, err)
+	}
+	want := "id,first,last,card,country\n" +
+		"1,Ann,Lee,4111111111111111,FR\n" +
+		"2,Bob,Ray,5500000000000004,DE\n" +
+		"3,Eve,,,XX\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+
+	_, _, err = export(t, parseSteps(t, `[{cast: {column: first, type: int}}]`))
+	if err == nil || !strings.Contains(err.Error(), `cannot cast column "first" to integer: invalid integer "Ann"`) {
+		t.Errorf("export() error = %v", err)
+	}
+
+	rows, _ := rowsource.New([]rowsource.Column{{Name: "id", OID: pgtype.Int4OID}}, nil)
+	if _, _, err := (&Cast{Column: "nope", Type: "int"}).prepare(rows.FieldDescriptions(), nil, ""); err == nil {
+		t.Error("prepare() should fail for a missing column")
+	}
+}
@@ -0,0 +1,394 @@
+// Package transforms builds exporter middlewares from a declarative list of steps,
+// as written under "transforms:" in an options document:
+//
+//	transforms:
+//	  - mask: {column: card_number, keep_last: 4}
+//	  - rename: {cust_id: customer_id}
+//	  - filter: {column: status, equals: active}
+//	  - derive: {column: full_name, template: "{first_name} {last_name}"}
+//	  - lookup: {column: country, into: country_name, file: countries.csv}
+//	  - cast: {column: amount, type: "numeric(12,2)"}
+//
+// Steps are applied in order, each one seeing the columns produced by the previous ones.
+package transforms
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/formatters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Step is one entry of a transforms list. Exactly one of its fields is set.
+type Step struct {
+	Mask   *Mask             `yaml:"mask"`
+	Rename map[string]string `yaml:"rename"`
+	Filter *Filter           `yaml:"filter"`
+	Derive *Derive           `yaml:"derive"`
+	Lookup *Lookup           `yaml:"lookup"`
+	Cast   *Cast             `yaml:"cast"`
+}
+
+// Mask hides the values of a column, optionally keeping their last characters.
+type Mask struct {
+	Column   string `yaml:"column"`
+	KeepLast int    `yaml:"keep_last"`
+	Char     string `yaml:"char"` // "*" by default
+}
+
+// Filter keeps the rows whose column meets one condition. Values are compared as
+// text, as they are written to CSV.
+type Filter struct {
+	Column    string   `yaml:"column"`
+	Equals    *string  `yaml:"equals"`
+	NotEquals *string  `yaml:"not_equals"`
+	In        []string `yaml:"in"`
+	IsNull    *bool    `yaml:"is_null"` // true keeps NULL values only, false non-NULL ones
+}
+
+// Derive adds a text column built from a template referring to other columns as {name}.
+type Derive struct {
+	Column   string `yaml:"column"`
+	Template string `yaml:"template"`
+}
+
+// Lookup replaces the values of a column by the matching values of a table, given
+// inline or as a CSV file of key,value lines. With Into, the result goes to a new
+// column instead. Keys without a match get Default, or NULL when it is unset.
+type Lookup struct {
+	Column  string            `yaml:"column"`
+	Into    string            `yaml:"into"`
+	Values  map[string]string `yaml:"values"`
+	File    string            `yaml:"file"`
+	Default *string           `yaml:"default"`
+}
+
+// placeholder matches the {column} references of a Derive template.
+var placeholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// kind returns the name of the step, or an error unless exactly one field is set.
+func (s Step) kind() (string, error) {
+	var kinds []string
+	if s.Mask != nil {
+		kinds = append(kinds, "mask")
+	}
+	if s.Rename != nil {
+		kinds = append(kinds, "rename")
+	}
+	if s.Filter != nil {
+		kinds = append(kinds, "filter")
+	}
+	if s.Derive != nil {
+		kinds = append(kinds, "derive")
+	}
+	if s.Lookup != nil {
+		kinds = append(kinds, "lookup")
+	}
+	if s.Cast != nil {
+		kinds = append(kinds, "cast")
+	}
+	if len(kinds) != 1 {
+		return "", fmt.Errorf("expected exactly one of mask, rename, filter, derive, lookup or cast, got %d", len(kinds))
+	}
+	return kinds[0], nil
+}
+
+// Compile checks steps, loads the lookup files and returns the middlewares
+// applying them, in order.
+func Compile(steps []Step) ([]exporters.Middleware, error) {
+	middlewares := make([]exporters.Middleware, 0, len(steps))
+	for i, step := range steps {
+		kind, err := step.kind()
+		if err == nil {
+			err = step.check()
+		}
+		if err == nil && step.Lookup != nil && step.Lookup.File != "" {
+			// The middleware owns the loaded table; steps is left as given so it can be compiled again
+			lookup := *step.Lookup
+			lookup.Values, err = lookup.load()
+			step.Lookup = &lookup
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transform %d: %w", i+1, err)
+		}
+		middlewares = append(middlewares, middleware(i+1, kind, step))
+	}
+	return middlewares, nil
+}
+
+// check validates the settings of a step that do not depend on the result columns.
+func (s Step) check() error {
+	switch {
+	case s.Mask != nil:
+		if s.Mask.Column == "" {
+			return fmt.Errorf("mask: column is required")
+		}
+		if s.Mask.KeepLast < 0 {
+			return fmt.Errorf("mask: keep_last cannot be negative")
+		}
+		if utf8.RuneCountInString(s.Mask.Char) > 1 {
+			return fmt.Errorf("mask: char must be a single character")
+		}
+	case s.Rename != nil:
+		if len(s.Rename) == 0 {
+			return fmt.Errorf("rename: no column to rename")
+		}
+		for from, to := range s.Rename {
+			if strings.TrimSpace(to) == "" {
+				return fmt.Errorf("rename: new name of %q is empty", from)
+			}
+		}
+	case s.Filter != nil:
+		conditions := 0
+		for _, set := range []bool{s.Filter.Equals != nil, s.Filter.NotEquals != nil, s.Filter.In != nil, s.Filter.IsNull != nil} {
+			if set {
+				conditions++
+			}
+		}
+		if s.Filter.Column == "" {
+			return fmt.Errorf("filter: column is required")
+		}
+		if conditions != 1 {
+			return fmt.Errorf("filter: expected exactly one of equals, not_equals, in or is_null")
+		}
+	case s.Derive != nil:
+		if s.Derive.Column == "" || s.Derive.Template == "" {
+			return fmt.Errorf("derive: column and template are required")
+		}
+	case s.Lookup != nil:
+		if s.Lookup.Column == "" {
+			return fmt.Errorf("lookup: column is required")
+		}
+		if (s.Lookup.File == "") == (s.Lookup.Values == nil) {
+			return fmt.Errorf("lookup: expected exactly one of values or file")
+		}
+	case s.Cast != nil:
+		if s.Cast.Column == "" || s.Cast.Type == "" {
+			return fmt.Errorf("cast: column and type are required")
+		}
+		if _, err := parseCastType(s.Cast.Type); err != nil {
+			return fmt.Errorf("cast: %w", err)
+		}
+	}
+	return nil
+}
+
+// load reads the key,value lines of the lookup file.
+func (l *Lookup) load() (map[string]string, error) {
+	f, err := os.Open(l.File)
+	if err != nil {
+		return nil, fmt.Errorf("lookup: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	values := map[string]string{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lookup: %s: %w", l.File, err)
+		}
+		values[record[0]] = record[1]
+	}
+}
+
+// middleware returns the middleware applying step, the n-th of the list.
+func middleware(n int, kind string, step Step) exporters.Middleware {
+	return exporters.WrapRows(func(rows pgx.Rows, options exporters.ExportOptions) (pgx.Rows, error) {
+		fields := rows.FieldDescriptions()
+		text := func(v any, col int) string {
+			return formatters.FormatCSVValue(v, fields[col].DataTypeOID, options.TimeFormat, options.TimeZone)
+		}
+
+		var out []pgconn.FieldDescription
+		var fn rowsource.RowFunc
+		var err error
+		switch kind {
+		case "mask":
+			out, fn, err = step.Mask.prepare(fields, text)
+		case "rename":
+			out, err = rename(fields, step.Rename)
+			fn = func(values []any) ([]any, error) { return values, nil }
+		case "filter":
+			fn, err = step.Filter.prepare(fields, text)
+		case "derive":
+			out, fn, err = step.Derive.prepare(fields, text)
+		case "lookup":
+			out, fn, err = step.Lookup.prepare(fields, text)
+		case "cast":
+			out, fn, err = step.Cast.prepare(fields, text, options.TimeZone)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transform %d (%s): %w", n, kind, err)
+		}
+		return rowsource.Transform(rows, out, fn), nil
+	})
+}
+
+// textFunc returns the text of the value of column col.
+type textFunc func(v any, col int) string
+
+func column(fields []pgconn.FieldDescription, name string) (int, error) {
+	for i, f := range fields {
+		if f.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no column %q in the result", name)
+}
+
+// asText returns fields with column col turned into text.
+func asText(fields []pgconn.FieldDescription, col int) []pgconn.FieldDescription {
+	out := slices.Clone(fields)
+	out[col].DataTypeOID = pgtype.TextOID
+	return out
+}
+
+// withColumn returns fields with a text column appended.
+func withColumn(fields []pgconn.FieldDescription, name string) ([]pgconn.FieldDescription, error) {
+	if _, err := column(fields, name); err == nil {
+		return nil, fmt.Errorf("column %q already exists", name)
+	}
+	return append(slices.Clone(fields), pgconn.FieldDescription{Name: name, DataTypeOID: pgtype.TextOID}), nil
+}
+
+func (m *Mask) prepare(fields []pgconn.FieldDescription, text textFunc) ([]pgconn.FieldDescription, rowsource.RowFunc, error) {
+	col, err := column(fields, m.Column)
+	if err != nil {
+		return nil, nil, err
+	}
+	char := m.Char
+	if char == "" {
+		char = "*"
+	}
+	return asText(fields, col), func(values []any) ([]any, error) {
+		if values[col] == nil {
+			return values, nil
+		}
+		runes := []rune(text(values[col], col))
+		keep := min(m.KeepLast, len(runes))
+		out := slices.Clone(values)
+		out[col] = strings.Repeat(char, len(runes)-keep) + string(runes[len(runes)-keep:])
+		return out, nil
+	}, nil
+}
+
+func rename(fields []pgconn.FieldDescription, names map[string]string) ([]pgconn.FieldDescription, error) {
+	out := slices.Clone(fields)
+	for from, to := range names {
+		col, err := column(fields, from)
+		if err != nil {
+			return nil, err
+		}
+		out[col].Name = to
+	}
+	return out, nil
+}
+
+func (f *Filter) prepare(fields []pgconn.FieldDescription, text textFunc) (rowsource.RowFunc, error) {
+	col, err := column(fields, f.Column)
+	if err != nil {
+		return nil, err
+	}
+	keep := func(v any) bool {
+		switch {
+		case f.IsNull != nil:
+			return (v == nil) == *f.IsNull
+		case v == nil:
+			return false // NULL is neither equal nor different, as in SQL
+		case f.Equals != nil:
+			return text(v, col) == *f.Equals
+		case f.NotEquals != nil:
+			return text(v, col) != *f.NotEquals
+		default:
+			return slices.Contains(f.In, text(v, col))
+		}
+	}
+	return func(values []any) ([]any, error) {
+		if !keep(values[col]) {
+			return nil, nil
+		}
+		return values, nil
+	}, nil
+}
+
+func (d *Derive) prepare(fields []pgconn.FieldDescription, text textFunc) ([]pgconn.FieldDescription, rowsource.RowFunc, error) {
+	// Literal parts and referenced columns alternate: parts[i] comes before cols[i]
+	var parts []string
+	var cols []int
+	last := 0
+	for _, m := range placeholder.FindAllStringSubmatchIndex(d.Template, -1) {
+		col, err := column(fields, d.Template[m[2]:m[3]])
+		if err != nil {
+			return nil, nil, err
+		}
+		parts = append(parts, d.Template[last:m[0]])
+		cols = append(cols, col)
+		last = m[1]
+	}
+	tail := d.Template[last:]
+
+	out, err := withColumn(fields, d.Column)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, func(values []any) ([]any, error) {
+		var b strings.Builder
+		for i, col := range cols {
+			b.WriteString(parts[i])
+			if values[col] != nil {
+				b.WriteString(text(values[col], col))
+			}
+		}
+		b.WriteString(tail)
+		return append(slices.Clone(values), b.String()), nil
+	}, nil
+}
+
+func (l *Lookup) prepare(fields []pgconn.FieldDescription, text textFunc) ([]pgconn.FieldDescription, rowsource.RowFunc, error) {
+	col, err := column(fields, l.Column)
+	if err != nil {
+		return nil, nil, err
+	}
+	lookup := func(v any) any {
+		if v != nil {
+			if found, ok := l.Values[text(v, col)]; ok {
+				return found
+			}
+		}
+		if l.Default != nil {
+			return *l.Default
+		}
+		return nil
+	}
+
+	if l.Into == "" {
+		return asText(fields, col), func(values []any) ([]any, error) {
+			out := slices.Clone(values)
+			out[col] = lookup(values[col])
+			return out, nil
+		}, nil
+	}
+	out, err := withColumn(fields, l.Into)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, func(values []any) ([]any, error) {
+		return append(slices.Clone(values), lookup(values[col])), nil
+	}, nil
+}
@@ -0,0 +1,129 @@
+package transforms
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fbz-tec/pgxport/core/exporters"
+	"github.com/fbz-tec/pgxport/core/rowsource"
+	"github.com/jackc/pgx/v5/pgtype"
+	"gopkg.in/yaml.v3"
+)
+
+func customerRows(t *testing.T) *rowsource.Rows {
+	t.Helper()
+	rows, err := rowsource.New([]rowsource.Column{
+		{Name: "id", OID: pgtype.Int4OID},
+		{Name: "first", OID: pgtype.TextOID},
+		{Name: "last", OID: pgtype.TextOID},
+		{Name: "card", OID: pgtype.TextOID},
+		{Name: "country", OID: pgtype.TextOID},
+	}, [][]any{
+		{int32(1), "Ann", "Lee", "4111111111111111", "FR"},
+		{int32(2), "Bob", "Ray", "5500000000000004", "DE"},
+		{int32(3), "Eve", nil, nil, "XX"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func parseSteps(t *testing.T, doc string) []Step {
+	t.Helper()
+	var steps []Step
+	if err := yaml.Unmarshal([]byte(doc), &steps); err != nil {
+		t.Fatal(err)
+	}
+	return steps
+}
+
+func export(t *testing.T, steps []Step) (string, int, error) {
+	t.Helper()
+	middlewares, err := Compile(steps)
+	if err != nil {
+		t.Fatalf("Compile() error: %v", err)
+	}
+	csv, _ := exporters.GetExporter(exporters.FormatCSV)
+	path := filepath.Join(t.TempDir(), "out.csv")
+	options := exporters.ExportOptions{Format: exporters.FormatCSV, Delimiter: ',', Compression: "none", TimeZone: "UTC"}
+	n, err := exporters.Chain(csv, middlewares...).Export(customerRows(t), path, options)
+	data, _ := os.ReadFile(path)
+	return string(data), n, err
+}
+
+func TestTransformsInOrder(t *testing.T) {
+	countries := filepath.Join(t.TempDir(), "countries.csv")
+	if err := os.WriteFile(countries, []byte("FR,France\nDE,Germany\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, n, err := export(t, parseSteps(t, `
+- mask: {column: card, keep_last: 4}
+- rename: {first: first_name}
+- filter: {column: id, not_equals: "2"}
+- derive: {column: name, template: "{first_name} {last}"}
+- lookup: {column: country, into: country_name, file: `+countries+`, default: unknown}
+`))
+	if err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("exported %d rows, want 2", n)
+	}
+	want := "id,first_name,last,card,country,name,country_name\n" +
+		"1,Ann,Lee,************1111,FR,Ann Lee,France\n" +
+		"3,Eve,,,XX,Eve ,unknown\n"
+	if out != want {
+		t.Errorf("output =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestFilterConditions(t *testing.T) {
+	for doc, want := range map[string]int{
+		`[{filter: {column: country, equals: FR}}]`:      1,
+		`[{filter: {column: country, in: [FR, DE]}}]`:    2,
+		`[{filter: {column: last, is_null: true}}]`:      1,
+		`[{filter: {column: last, is_null: false}}]`:     2,
+		`[{filter: {column: last, not_equals: Lee}}]`:    1, // NULL matches no comparison
+		`[{lookup: {column: country, values: {FR: F}}}]`: 3,
+	} {
+		if _, n, err := export(t, parseSteps(t, doc)); err != nil || n != want {
+			t.Errorf("%s: exported %d rows (error %v), want %d", doc, n, err, want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for doc, want := range map[string]string{
+		`[{mask: {column: card}, rename: {a: b}}]`: "transform 1: expected exactly one",
+		`[{}]`:                                            "got 0",
+		`[{mask: {column: card, char: "##"}}]`:            "single character",
+		`[{filter: {column: id}}]`:                        "exactly one of equals",
+		`[{derive: {column: x}}]`:                         "column and template are required",
+		`[{lookup: {column: x}}]`:                         "exactly one of values or file",
+		`[{lookup: {column: x, file: /nonexistent.csv}}]`: "nonexistent.csv",
+		`[{rename: {a: b}}, {rename: {c: " "}}]`:          "transform 2: rename",
+	} {
+		if _, err := Compile(parseSteps(t, doc)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Compile(%s) error = %v, should mention %q", doc, err, want)
+		}
+	}
+}
+
+func TestMissingColumn(t *testing.T) {
+	_, _, err := export(t, parseSteps(t, `
+- rename: {card: card_number}
+- mask: {column: card}
+`))
+	if err == nil || !strings.Contains(err.Error(), `transform 2 (mask): no column "card"`) {
+		t.Errorf("Export() error = %v", err)
+	}
+
+	_, _, err = export(t, parseSteps(t, `[{derive: {column: id, template: "{first}"}}]`))
+	if err == nil || !strings.Contains(err.Error(), `column "id" already exists`) {
+		t.Errorf("Export() error = %v", err)
+	}
+}
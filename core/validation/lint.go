@@ -0,0 +1,343 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rules reported by LintQuery
+const (
+	RuleUnusedCTE     = "unused-cte"
+	RuleSelectStar    = "select-star"
+	RuleLimitNoOrder  = "limit-without-order-by"
+	RuleCartesianJoin = "cartesian-join"
+)
+
+// LintWarning is a likely mistake found in a query.
+type LintWarning struct {
+	Rule    string
+	Line    int // 1-based line of the query where the problem starts
+	Message string
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("line %d: %s [%s]", w.Line, w.Message, w.Rule)
+}
+
+// Token kinds of the lint tokenizer
+const (
+	tokWord   = iota // keyword or unquoted identifier
+	tokIdent         // quoted identifier
+	tokString        // string literal, including dollar-quoted strings
+	tokOther         // number, operator or punctuation
+)
+
+type sqlToken struct {
+	kind int
+	text string // words are upper-cased, quoted identifiers unquoted
+	line int
+}
+
+var dollarTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// tokenizeSQL splits query into tokens, dropping comments. It knows enough of the
+// PostgreSQL lexical rules to not mistake the content of strings, quoted
+// identifiers and comments for SQL.
+func tokenizeSQL(query string) []sqlToken {
+	var tokens []sqlToken
+	line := 1
+	for i := 0; i < len(query); {
+		rest := query[i:]
+		c := rest[0]
+		end := 1
+		switch {
+		case c == '\n' || c == ' ' || c == '\t' || c == '\r':
+		case strings.HasPrefix(rest, "--"):
+			end = strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			end = commentEnd(rest)
+		case c == '\'' || c == '"':
+			end = quoteEnd(rest, c)
+			if c == '\'' {
+				tokens = append(tokens, sqlToken{kind: tokString, line: line})
+			} else {
+				inner := strings.TrimSuffix(rest[1:end], `"`)
+				tokens = append(tokens, sqlToken{kind: tokIdent, text: strings.ReplaceAll(inner, `""`, `"`), line: line})
+			}
+		case c == '$' && dollarTag.MatchString(rest):
+			tag := dollarTag.FindString(rest)
+			end = len(rest)
+			if close := strings.Index(rest[len(tag):], tag); close >= 0 {
+				end = len(tag) + close + len(tag)
+			}
+			tokens = append(tokens, sqlToken{kind: tokString, line: line})
+		case isWordStart(c):
+			for end < len(rest) && isWordChar(rest[end]) {
+				end++
+			}
+			tokens = append(tokens, sqlToken{kind: tokWord, text: strings.ToUpper(rest[:end]), line: line})
+		case c >= '0' && c <= '9':
+			for end < len(rest) && (isWordChar(rest[end]) || rest[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{kind: tokOther, text: rest[:end], line: line})
+		default:
+			if strings.HasPrefix(rest, "::") {
+				end = 2
+			}
+			tokens = append(tokens, sqlToken{kind: tokOther, text: rest[:end], line: line})
+		}
+		line += strings.Count(rest[:end], "\n")
+		i += end
+	}
+	return tokens
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || c >= '0' && c <= '9' || c == '$'
+}
+
+// quoteEnd returns the length of the quoted text at the start of s, doubled
+// quotes standing for the quote itself.
+func quoteEnd(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// commentEnd returns the length of the block comment at the start of s. Block
+// comments nest in PostgreSQL.
+func commentEnd(s string) int {
+	depth := 0
+	for i := 0; i+1 < len(s); i++ {
+		switch s[i : i+2] {
+		case "/*":
+			depth++
+			i++
+		case "*/":
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// selectScope tracks the clauses of the SELECT statement being read at one
+// parenthesis depth.
+type selectScope struct {
+	selecting bool // a SELECT was seen at this depth
+	exists    bool // the parentheses follow EXISTS, where SELECT * is idiomatic
+	inFrom    bool
+	orderBy   bool
+	where     bool
+	commaJoin int // line of the first comma-separated FROM item, 0 if none
+}
+
+// LintQuery reports likely mistakes in query: CTEs that are never used, SELECT *,
+// LIMIT without ORDER BY and comma joins without a WHERE clause. It works on the
+// tokens of the query and does not need a database connection.
+func LintQuery(query string) []LintWarning {
+	tokens := tokenizeSQL(query)
+	var warnings []LintWarning
+	warn := func(rule string, line int, format string, args ...any) {
+		warnings = append(warnings, LintWarning{Rule: rule, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	warnings = append(warnings, unusedCTEs(tokens)...)
+
+	scopes := []*selectScope{{}}
+	finish := func(s *selectScope) {
+		if s.selecting && s.commaJoin > 0 && !s.where {
+			warn(RuleCartesianJoin, s.commaJoin, "tables listed with commas in FROM without a WHERE clause form a cartesian product; join them with JOIN ... ON (or CROSS JOIN if intended)")
+		}
+	}
+
+	for i, t := range tokens {
+		scope := scopes[len(scopes)-1]
+		prev, next := tokenAt(tokens, i-1), tokenAt(tokens, i+1)
+
+		if t.kind == tokOther {
+			switch t.text {
+			case "(":
+				scopes = append(scopes, &selectScope{exists: prev.kind == tokWord && prev.text == "EXISTS"})
+			case ")":
+				if len(scopes) > 1 {
+					finish(scope)
+					scopes = scopes[:len(scopes)-1]
+				}
+			case ";":
+				finish(scope)
+				scopes = []*selectScope{{}}
+			case ",":
+				if scope.inFrom && scope.commaJoin == 0 && !(next.kind == tokWord && next.text == "LATERAL") {
+					scope.commaJoin = t.line
+				}
+			case "*":
+				afterList := prev.kind == tokWord && (prev.text == "SELECT" || prev.text == "DISTINCT" || prev.text == "ALL") ||
+					prev.kind == tokOther && (prev.text == "," || prev.text == ".")
+				if scope.selecting && !scope.inFrom && !scope.exists && afterList {
+					warn(RuleSelectStar, t.line, "SELECT * makes the exported columns depend on the table definition; list the columns")
+				}
+			}
+			continue
+		}
+		if t.kind != tokWord {
+			continue
+		}
+
+		switch t.text {
+		case "SELECT":
+			finish(scope)
+			*scope = selectScope{selecting: true, exists: scope.exists}
+		case "FROM":
+			if scope.selecting && prev.text != "DISTINCT" {
+				scope.inFrom = true
+			}
+		case "WHERE":
+			scope.inFrom, scope.where = false, true
+		case "GROUP", "HAVING", "WINDOW", "OFFSET", "UNION", "INTERSECT", "EXCEPT":
+			scope.inFrom = false
+		case "ORDER":
+			if next.text == "BY" {
+				scope.inFrom, scope.orderBy = false, true
+			}
+		case "LIMIT", "FETCH":
+			scope.inFrom = false
+			if t.text == "LIMIT" && next.text == "ALL" || t.text == "FETCH" && next.text != "FIRST" && next.text != "NEXT" {
+				continue
+			}
+			if scope.selecting && !scope.orderBy {
+				warn(RuleLimitNoOrder, t.line, "%s without ORDER BY returns an arbitrary set of rows that may change between runs", t.text)
+			}
+		}
+	}
+	for _, s := range scopes {
+		finish(s)
+	}
+	return warnings
+}
+
+func tokenAt(tokens []sqlToken, i int) sqlToken {
+	if i < 0 || i >= len(tokens) {
+		return sqlToken{kind: -1}
+	}
+	return tokens[i]
+}
+
+// unusedCTEs reports the CTEs of a leading WITH clause that are not referenced
+// outside their own definition.
+func unusedCTEs(tokens []sqlToken) []LintWarning {
+	if len(tokens) == 0 || tokens[0].kind != tokWord || tokens[0].text != "WITH" {
+		return nil
+	}
+
+	type cte struct {
+		name       string
+		line       int
+		start, end int    // tokens of the definition, name included
+		statement  string // INSERT, UPDATE or DELETE for a data-modifying CTE
+	}
+	var ctes []cte
+	i := 1
+	if tokenAt(tokens, i).text == "RECURSIVE" {
+		i++
+	}
+	for {
+		nameTok := tokenAt(tokens, i)
+		if nameTok.kind != tokWord && nameTok.kind != tokIdent {
+			break
+		}
+		c := cte{name: cteName(nameTok), line: nameTok.line, start: i}
+		i++
+		if tokenAt(tokens, i).text == "(" { // column list
+			i = closingParen(tokens, i) + 1
+		}
+		if tokenAt(tokens, i).text != "AS" {
+			break
+		}
+		i++
+		for tokenAt(tokens, i).text == "NOT" || tokenAt(tokens, i).text == "MATERIALIZED" {
+			i++
+		}
+		if tokenAt(tokens, i).text != "(" {
+			break
+		}
+		switch first := tokenAt(tokens, i+1); first.text {
+		case "INSERT", "UPDATE", "DELETE":
+			if first.kind == tokWord {
+				c.statement = first.text
+			}
+		}
+		i = closingParen(tokens, i)
+		c.end = i
+		ctes = append(ctes, c)
+		if tokenAt(tokens, i+1).text != "," {
+			break
+		}
+		i += 2
+	}
+
+	var warnings []LintWarning
+	for _, c := range ctes {
+		used := false
+		for j, t := range tokens {
+			if (j < c.start || j > c.end) && (t.kind == tokWord || t.kind == tokIdent) && cteName(t) == c.name {
+				used = true
+				break
+			}
+		}
+		if !used {
+			// Unlike queries, data-modifying statements run whether they are referenced or not
+			message := fmt.Sprintf("CTE %s is never used and can be removed", c.name)
+			if c.statement != "" {
+				message = fmt.Sprintf("CTE %s is never used, but its %s still runs on the server", c.name, c.statement)
+			}
+			warnings = append(warnings, LintWarning{Rule: RuleUnusedCTE, Line: c.line, Message: message})
+		}
+	}
+	return warnings
+}
+
+// cteName returns the name a token refers to: unquoted names are folded to lower case.
+func cteName(t sqlToken) string {
+	if t.kind == tokWord {
+		return strings.ToLower(t.text)
+	}
+	return t.text
+}
+
+// closingParen returns the index of the parenthesis closing the one at open.
+func closingParen(tokens []sqlToken, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch {
+		case tokens[i].kind != tokOther:
+		case tokens[i].text == "(":
+			depth++
+		case tokens[i].text == ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
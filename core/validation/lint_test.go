@@ -0,0 +1,79 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func lintRules(query string) []string {
+	var rules []string
+	for _, w := range LintQuery(query) {
+		rules = append(rules, w.Rule)
+	}
+	return rules
+}
+
+func TestLintQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"clean", "SELECT id, name FROM users WHERE active ORDER BY id LIMIT 10", nil},
+		{"select star", "SELECT * FROM users WHERE id = 1", []string{RuleSelectStar}},
+		{"qualified star", "SELECT u.*, o.id FROM users u JOIN orders o ON o.user_id = u.id", []string{RuleSelectStar}},
+		{"count star", "SELECT count(*), a * b FROM t", nil},
+		{"select star in exists", "SELECT id FROM users u WHERE EXISTS (SELECT * FROM orders o WHERE o.user_id = u.id)", nil},
+		{"limit without order", "SELECT id FROM users LIMIT 100", []string{RuleLimitNoOrder}},
+		{"fetch first without order", "SELECT id FROM users FETCH FIRST 5 ROWS ONLY", []string{RuleLimitNoOrder}},
+		{"limit all", "SELECT id FROM users LIMIT ALL", nil},
+		{"order by in window only", "SELECT id, row_number() OVER (ORDER BY id) FROM users LIMIT 5", []string{RuleLimitNoOrder}},
+		{"limit in ordered subquery", "SELECT id FROM (SELECT id FROM users ORDER BY created_at DESC LIMIT 5) s", nil},
+		{"comma join without where", "SELECT u.id, o.id FROM users u, orders o", []string{RuleCartesianJoin}},
+		{"comma join with where", "SELECT u.id, o.id FROM users u, orders o WHERE o.user_id = u.id", nil},
+		{"lateral", "SELECT u.id, t.tag FROM users u, LATERAL unnest(u.tags) t(tag)", nil},
+		{"cross join", "SELECT a.x, b.y FROM a CROSS JOIN b", nil},
+		{"function arguments", "SELECT id FROM generate_series(1, 10) id WHERE id IS DISTINCT FROM 3", nil},
+		{"unused cte", "WITH active AS (SELECT id FROM users WHERE active), old AS (SELECT id FROM users) SELECT id FROM active", []string{RuleUnusedCTE}},
+		{"quoted cte", `WITH "Active" AS (SELECT id FROM users) SELECT id FROM "Active"`, nil},
+		{"case folded cte", "WITH Active AS (SELECT id FROM users) SELECT id FROM ACTIVE", nil},
+		{"recursive cte used only by itself", "WITH RECURSIVE tree(id) AS (SELECT 1 UNION ALL SELECT id + 1 FROM tree WHERE id < 5) SELECT 1 AS x", []string{RuleUnusedCTE}},
+		{"materialized cte", "WITH t AS MATERIALIZED (SELECT id FROM users) SELECT id FROM t", nil},
+		{"strings and comments", "SELECT 'SELECT * FROM a, b LIMIT 1' AS q, $$LIMIT 1$$ AS r -- SELECT *\n/* FROM a, b */ FROM users WHERE id = 1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lintRules(tt.query)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("LintQuery() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintQueryLines(t *testing.T) {
+	warnings := LintQuery("WITH unused AS (SELECT 1)\nSELECT id\nFROM users\nLIMIT 5")
+	if len(warnings) != 2 {
+		t.Fatalf("LintQuery() = %v, want 2 warnings", warnings)
+	}
+	if warnings[0].Line != 1 || warnings[1].Line != 4 {
+		t.Errorf("lines = %d, %d, want 1, 4", warnings[0].Line, warnings[1].Line)
+	}
+	if got := warnings[1].String(); !strings.HasPrefix(got, "line 4: LIMIT without ORDER BY") || !strings.HasSuffix(got, "["+RuleLimitNoOrder+"]") {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestLintUnusedCTEMessages(t *testing.T) {
+	warnings := LintQuery("WITH old AS (SELECT id FROM users), purged AS (DELETE FROM sessions RETURNING id) SELECT 1 AS x")
+	if len(warnings) != 2 {
+		t.Fatalf("LintQuery() = %v, want 2 warnings", warnings)
+	}
+	if want := "CTE old is never used and can be removed"; warnings[0].Message != want {
+		t.Errorf("message = %q, want %q", warnings[0].Message, want)
+	}
+	if want := "CTE purged is never used, but its DELETE still runs on the server"; warnings[1].Message != want {
+		t.Errorf("message = %q, want %q", warnings[1].Message, want)
+	}
+}
@@ -0,0 +1,71 @@
+package bytesize
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	KB int64 = 1 << (10 * (iota + 1))
+	MB
+	GB
+	TB
+)
+
+var units = []struct {
+	suffix string
+	factor int64
+}{
+	// Longest suffixes first so "MB" is not matched as "B"
+	{"KIB", KB}, {"MIB", MB}, {"GIB", GB}, {"TIB", TB},
+	{"KB", KB}, {"MB", MB}, {"GB", GB}, {"TB", TB},
+	{"K", KB}, {"M", MB}, {"G", GB}, {"T", TB},
+	{"B", 1},
+}
+
+// Parse converts a human-readable size such as "512KB", "16MB" or "1.5GB" to bytes.
+// Units are binary (1KB = 1024 bytes); a plain number is interpreted as bytes.
+func Parse(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	if value == "" {
+		return 0, fmt.Errorf("size cannot be empty")
+	}
+
+	factor := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			factor = u.factor
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid size %q (use a number with an optional unit, e.g. 512KB, 16MB, 1GB)", s)
+	}
+
+	bytes := n * float64(factor)
+	if bytes > float64(1<<62) {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(bytes), nil
+}
+
+// Format returns a human-readable representation of n bytes.
+func Format(n int64) string {
+	switch {
+	case n >= TB:
+		return fmt.Sprintf("%.1fTB", float64(n)/float64(TB))
+	case n >= GB:
+		return fmt.Sprintf("%.1fGB", float64(n)/float64(GB))
+	case n >= MB:
+		return fmt.Sprintf("%.1fMB", float64(n)/float64(MB))
+	case n >= KB:
+		return fmt.Sprintf("%.1fKB", float64(n)/float64(KB))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
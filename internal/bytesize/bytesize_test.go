@@ -0,0 +1,59 @@
+package bytesize
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{input: "0", expected: 0},
+		{input: "1024", expected: 1024},
+		{input: "512B", expected: 512},
+		{input: "1KB", expected: 1024},
+		{input: "16mb", expected: 16 * MB},
+		{input: " 1 GB ", expected: GB},
+		{input: "1.5GB", expected: GB + GB/2},
+		{input: "2MiB", expected: 2 * MB},
+		{input: "4k", expected: 4 * KB},
+		{input: "", wantErr: true},
+		{input: "MB", wantErr: true},
+		{input: "-1MB", wantErr: true},
+		{input: "ten", wantErr: true},
+		{input: "NaN", wantErr: true},
+		{input: "NaNMB", wantErr: true},
+		{input: "Inf", wantErr: true},
+		{input: "+infinityKB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{512, "512B"},
+		{2048, "2.0KB"},
+		{16 * MB, "16.0MB"},
+		{GB + GB/2, "1.5GB"},
+	}
+
+	for _, tt := range tests {
+		if got := Format(tt.input); got != tt.expected {
+			t.Errorf("Format(%d) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package clock provides the wall-clock time and the run IDs recorded in export
+// artifacts (manifests, sidecars, dated directories, lineage events). Both can be
+// fixed with environment variables so that CI pipelines produce reproducible
+// files, and replaced in tests. Durations and deadlines are measured with the
+// monotonic time.Now instead: a fixed clock never advances.
+package clock
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Environment variables selecting the deterministic modes
+const (
+	EnvFixedTime = "PGXPORT_FIXED_TIME" // RFC 3339 time returned by Now, e.g. 2024-03-07T15:04:05Z
+	EnvRunID     = "PGXPORT_RUN_ID"     // seed of the run IDs returned by NewRunID
+)
+
+// Clock tells the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the clock of the machine.
+type System struct{}
+
+func (System) Now() time.Time { return time.Now() }
+
+// Fixed always returns the same time.
+type Fixed time.Time
+
+func (f Fixed) Now() time.Time { return time.Time(f) }
+
+// RunIDs generates run IDs, which are UUIDs as required by OpenLineage.
+type RunIDs interface {
+	NewRunID() string
+}
+
+// RandomIDs generates random (version 4) UUIDs.
+type RandomIDs struct{}
+
+func (RandomIDs) NewRunID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return formatUUID(b, 4)
+}
+
+// SeededIDs generates the same sequence of (version 5, name-based) UUIDs for the same seed.
+type SeededIDs struct {
+	Seed string
+
+	mu sync.Mutex
+	n  int
+}
+
+func (s *SeededIDs) NewRunID() string {
+	s.mu.Lock()
+	s.n++
+	n := s.n
+	s.mu.Unlock()
+
+	sum := sha1.Sum([]byte(s.Seed + "/" + strconv.Itoa(n)))
+	var b [16]byte
+	copy(b[:], sum[:16])
+	return formatUUID(b, 5)
+}
+
+func formatUUID(b [16]byte, version byte) string {
+	b[6] = b[6]&0x0f | version<<4
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// The clock and run IDs in use. Tests replace them, see Set.
+var (
+	current Clock  = System{}
+	ids     RunIDs = RandomIDs{}
+)
+
+// Now returns the current time of the clock in use.
+func Now() time.Time {
+	return current.Now()
+}
+
+// NewRunID returns a new run ID.
+func NewRunID() string {
+	return ids.NewRunID()
+}
+
+// Set replaces the clock and run IDs in use and returns a function restoring them.
+func Set(c Clock, r RunIDs) (restore func()) {
+	savedClock, savedIDs := current, ids
+	current, ids = c, r
+	return func() { current, ids = savedClock, savedIDs }
+}
+
+// FromEnv switches to the deterministic modes requested by $PGXPORT_FIXED_TIME and
+// $PGXPORT_RUN_ID.
+func FromEnv() error {
+	if value := os.Getenv(EnvFixedTime); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: use an RFC 3339 time such as 2024-03-07T15:04:05Z", EnvFixedTime, value)
+		}
+		current = Fixed(t)
+	}
+	if seed := os.Getenv(EnvRunID); seed != "" {
+		ids = &SeededIDs{Seed: seed}
+	}
+	return nil
+}
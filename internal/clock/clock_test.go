@@ -0,0 +1,59 @@
+package clock
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[45][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestSeededIDs(t *testing.T) {
+	a, b := &SeededIDs{Seed: "ci-1234"}, &SeededIDs{Seed: "ci-1234"}
+	first, second := a.NewRunID(), a.NewRunID()
+	if !uuidPattern.MatchString(first) || first[14] != '5' {
+		t.Errorf("NewRunID() = %q, not a version 5 UUID", first)
+	}
+	if first == second {
+		t.Error("NewRunID() returned the same ID twice")
+	}
+	if got := b.NewRunID(); got != first {
+		t.Errorf("same seed gave %q, then %q", first, got)
+	}
+	if other := (&SeededIDs{Seed: "ci-1235"}).NewRunID(); other == first {
+		t.Error("different seeds gave the same ID")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	defer Set(System{}, RandomIDs{})()
+
+	t.Setenv(EnvFixedTime, "2024-03-07T15:04:05Z")
+	t.Setenv(EnvRunID, "ci-1234")
+	if err := FromEnv(); err != nil {
+		t.Fatalf("FromEnv() error: %v", err)
+	}
+	if got, want := Now(), time.Date(2024, 3, 7, 15, 4, 5, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+	if got, want := NewRunID(), (&SeededIDs{Seed: "ci-1234"}).NewRunID(); got != want {
+		t.Errorf("NewRunID() = %q, want %q", got, want)
+	}
+
+	t.Setenv(EnvFixedTime, "yesterday")
+	if err := FromEnv(); err == nil {
+		t.Error("FromEnv() should reject an invalid time")
+	}
+}
+
+func TestSet(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	restore := Set(Fixed(fixed), &SeededIDs{Seed: "test"})
+	if !Now().Equal(fixed) {
+		t.Errorf("Now() = %v, want %v", Now(), fixed)
+	}
+	restore()
+	if Now().Equal(fixed) || !uuidPattern.MatchString(NewRunID()) {
+		t.Error("Set() restore did not bring back the system clock")
+	}
+}
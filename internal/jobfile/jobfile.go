@@ -0,0 +1,143 @@
+// Package jobfile reads pipelines of exports: named jobs, each an options
+// document, that may depend on other jobs.
+package jobfile
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DependsOnKey lists the jobs a job runs after.
+const DependsOnKey = "depends_on"
+
+// Job is an export of the jobfile.
+type Job struct {
+	Name      string
+	DependsOn []string
+	Options   map[string]any // the options document of the export, keyed by flag name
+}
+
+// Parse reads a jobfile:
+//
+//	jobs:
+//	  customers:
+//	    sql: SELECT * FROM customers
+//	    output: customers.csv
+//	  orders:
+//	    depends_on: [customers]
+//	    sqlfile: orders.sql
+//	    output: orders.csv
+//
+// Jobs are returned in execution order: every job comes after the jobs it depends
+// on, and independent jobs are ordered by name.
+func Parse(data []byte) ([]Job, error) {
+	var doc struct {
+		Jobs map[string]map[string]any `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid jobfile: %w", err)
+	}
+	if len(doc.Jobs) == 0 {
+		return nil, errors.New("invalid jobfile: no jobs defined")
+	}
+
+	jobs := make(map[string]Job, len(doc.Jobs))
+	for name, options := range doc.Jobs {
+		job := Job{Name: name, Options: map[string]any{}}
+		for key, value := range options {
+			if key != DependsOnKey {
+				job.Options[key] = value
+				continue
+			}
+			deps, err := dependencies(value)
+			if err != nil {
+				return nil, fmt.Errorf("job %s: %w", name, err)
+			}
+			job.DependsOn = deps
+		}
+		jobs[name] = job
+	}
+	return order(jobs)
+}
+
+// dependencies accepts a job name or a list of job names.
+func dependencies(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		deps := make([]string, len(v))
+		for i, item := range v {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must list job names", DependsOnKey)
+			}
+			deps[i] = name
+		}
+		return deps, nil
+	}
+	return nil, fmt.Errorf("%s must list job names", DependsOnKey)
+}
+
+// order sorts jobs so that each one follows its dependencies.
+func order(jobs map[string]Job) ([]Job, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var sorted []Job
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			cycle := slices.Concat(path[slices.Index(path, name):], []string{name})
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+		state[name] = visiting
+		job := jobs[name]
+		for _, dep := range job.DependsOn {
+			if _, ok := jobs[dep]; !ok {
+				return fmt.Errorf("job %s depends on unknown job %q", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		sorted = append(sorted, job)
+		return nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(jobs)) {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// Job statuses recorded in the state file
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped" // not run because a dependency did not succeed
+)
+
+// Result is the outcome of the last run of a job.
+type Result struct {
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Finished time.Time `json:"finished"`
+}
+
+// Results holds the last result of each job of a jobfile, so that failed jobs can be re-run.
+type Results map[string]Result
@@ -0,0 +1,59 @@
+package jobfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	jobs, err := Parse([]byte(`
+jobs:
+  report:
+    depends_on: [orders, customers]
+    sql: SELECT 1
+  orders:
+    depends_on: customers
+    sql: SELECT 2
+    output: orders.csv
+  customers:
+    sql: SELECT 3
+  audit:
+    sql: SELECT 4
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	var names []string
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	if got, want := strings.Join(names, ","), "audit,customers,orders,report"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+	orders := jobs[2]
+	if len(orders.DependsOn) != 1 || orders.DependsOn[0] != "customers" {
+		t.Errorf("orders depends on %v", orders.DependsOn)
+	}
+	if _, ok := orders.Options[DependsOnKey]; ok || orders.Options["output"] != "orders.csv" {
+		t.Errorf("orders options = %v", orders.Options)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{"jobs: {}", "no jobs defined"},
+		{"jobs: {a: {depends_on: [b]}}", `job a depends on unknown job "b"`},
+		{"jobs: {a: {depends_on: [b]}, b: {depends_on: [c]}, c: {depends_on: [b]}}", "dependency cycle: b -> c -> b"},
+		{"jobs: {a: {depends_on: {b: true}}}", "job a: depends_on must list job names"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%s) error = %v, want %q", tt.doc, err, tt.want)
+		}
+	}
+}
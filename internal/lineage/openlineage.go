@@ -0,0 +1,169 @@
+// Package lineage emits OpenLineage run events so that exports appear in lineage
+// graphs of catalogs such as Marquez or DataHub.
+package lineage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/clock"
+	"github.com/fbz-tec/pgxport/internal/version"
+)
+
+// Environment variables read when the corresponding flags are not set
+const (
+	EnvURL       = "OPENLINEAGE_URL"
+	EnvAPIKey    = "OPENLINEAGE_API_KEY"
+	EnvNamespace = "OPENLINEAGE_NAMESPACE"
+)
+
+// DefaultPath is appended to URLs without a path, as done by the OpenLineage HTTP transport.
+const DefaultPath = "/api/v1/lineage"
+
+const (
+	producer      = "https://github.com/fbz-tec/pgxport"
+	schemaURL     = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent"
+	sqlFacetURL   = "https://openlineage.io/spec/facets/1-0-1/SQLJobFacet.json#/$defs/SQLJobFacet"
+	errorFacetURL = "https://openlineage.io/spec/facets/1-0-1/ErrorMessageRunFacet.json#/$defs/ErrorMessageRunFacet"
+	statsFacetURL = "https://openlineage.io/spec/facets/1-0-2/OutputStatisticsOutputDatasetFacet.json#/$defs/OutputStatisticsOutputDatasetFacet"
+)
+
+// Run event types
+const (
+	EventStart    = "START"
+	EventComplete = "COMPLETE"
+	EventFail     = "FAIL"
+)
+
+// RunEvent is an OpenLineage run state update.
+type RunEvent struct {
+	EventType string    `json:"eventType"`
+	EventTime time.Time `json:"eventTime"`
+	Run       Run       `json:"run"`
+	Job       Job       `json:"job"`
+	Inputs    []Dataset `json:"inputs"`
+	Outputs   []Dataset `json:"outputs"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+}
+
+type Run struct {
+	RunID  string         `json:"runId"`
+	Facets map[string]any `json:"facets,omitempty"`
+}
+
+type Job struct {
+	Namespace string         `json:"namespace"`
+	Name      string         `json:"name"`
+	Facets    map[string]any `json:"facets,omitempty"`
+}
+
+// Dataset follows the OpenLineage naming conventions, e.g. namespace
+// "postgres://db:5432" and name "app.public.orders" for a table.
+type Dataset struct {
+	Namespace    string         `json:"namespace"`
+	Name         string         `json:"name"`
+	OutputFacets map[string]any `json:"outputFacets,omitempty"`
+}
+
+// Client posts run events to an OpenLineage HTTP endpoint.
+type Client struct {
+	URL    string
+	APIKey string
+	HTTP   *http.Client
+}
+
+// NewClient returns a client for endpoint. A URL without a path gets DefaultPath.
+func NewClient(endpoint, apiKey string) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid OpenLineage URL %q", endpoint)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		u.Path = DefaultPath
+	}
+	return &Client{URL: u.String(), APIKey: apiKey, HTTP: &http.Client{Timeout: 5 * time.Second}}, nil
+}
+
+// Emit sends ev.
+func (c *Client) Emit(ctx context.Context, ev RunEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid OpenLineage URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pgxport/"+version.AppVersion)
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending OpenLineage event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OpenLineage endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// NewRunID returns a UUID as required for OpenLineage run IDs: a random one, or
+// one derived from $PGXPORT_RUN_ID in the deterministic mode of package clock.
+func NewRunID() string {
+	return clock.NewRunID()
+}
+
+// NewEvent returns an event of the given type for a run.
+func NewEvent(eventType, runID string, job Job, inputs, outputs []Dataset) RunEvent {
+	return RunEvent{
+		EventType: eventType,
+		EventTime: clock.Now().UTC(),
+		Run:       Run{RunID: runID},
+		Job:       job,
+		Inputs:    inputs,
+		Outputs:   outputs,
+		Producer:  producer,
+		SchemaURL: schemaURL,
+	}
+}
+
+// SQLFacet describes the query run by a job.
+func SQLFacet(query string) map[string]any {
+	return map[string]any{
+		"sql": map[string]any{"_producer": producer, "_schemaURL": sqlFacetURL, "query": query},
+	}
+}
+
+// ErrorFacet describes why a run failed.
+func ErrorFacet(err error) map[string]any {
+	return map[string]any{
+		"errorMessage": map[string]any{
+			"_producer":           producer,
+			"_schemaURL":          errorFacetURL,
+			"message":             err.Error(),
+			"programmingLanguage": "Go",
+		},
+	}
+}
+
+// OutputStatisticsFacet records the rows and bytes written to an output dataset.
+func OutputStatisticsFacet(rows int, size int64) map[string]any {
+	stats := map[string]any{"_producer": producer, "_schemaURL": statsFacetURL, "rowCount": rows}
+	if size >= 0 {
+		stats["size"] = size
+	}
+	return map[string]any{"outputStatistics": stats}
+}
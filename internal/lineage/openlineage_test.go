@@ -0,0 +1,121 @@
+package lineage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestNewClientDefaultPath(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		expected string
+	}{
+		{endpoint: "http://marquez:5000", expected: "http://marquez:5000/api/v1/lineage"},
+		{endpoint: "http://marquez:5000/", expected: "http://marquez:5000/api/v1/lineage"},
+		{endpoint: "https://lineage.example.com/events", expected: "https://lineage.example.com/events"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			c, err := NewClient(tt.endpoint, "")
+			if err != nil {
+				t.Fatalf("NewClient() error: %v", err)
+			}
+			if c.URL != tt.expected {
+				t.Errorf("URL = %q, want %q", c.URL, tt.expected)
+			}
+		})
+	}
+
+	for _, endpoint := range []string{"", "marquez:5000", "://nope"} {
+		if _, err := NewClient(endpoint, ""); err == nil {
+			t.Errorf("NewClient(%q) expected error", endpoint)
+		}
+	}
+}
+
+func TestEmit(t *testing.T) {
+	var got map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DefaultPath {
+			t.Errorf("path = %q, want %q", r.URL.Path, DefaultPath)
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, "s3cret")
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+
+	job := Job{Namespace: "pgxport", Name: "export.orders", Facets: SQLFacet("SELECT * FROM orders")}
+	inputs := []Dataset{{Namespace: "postgres://db:5432", Name: "app.public.orders"}}
+	outputs := []Dataset{{Namespace: "file", Name: "/tmp/orders.csv", OutputFacets: OutputStatisticsFacet(42, 1024)}}
+	ev := NewEvent(EventComplete, NewRunID(), job, inputs, outputs)
+
+	if err := c.Emit(context.Background(), ev); err != nil {
+		t.Fatalf("Emit() error: %v", err)
+	}
+
+	if auth != "Bearer s3cret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if got["eventType"] != EventComplete {
+		t.Errorf("eventType = %v", got["eventType"])
+	}
+	if got["job"].(map[string]any)["name"] != "export.orders" {
+		t.Errorf("job = %v", got["job"])
+	}
+	in := got["inputs"].([]any)[0].(map[string]any)
+	if in["name"] != "app.public.orders" || in["namespace"] != "postgres://db:5432" {
+		t.Errorf("inputs = %v", got["inputs"])
+	}
+	stats := got["outputs"].([]any)[0].(map[string]any)["outputFacets"].(map[string]any)["outputStatistics"].(map[string]any)
+	if stats["rowCount"] != float64(42) || stats["size"] != float64(1024) {
+		t.Errorf("outputStatistics = %v", stats)
+	}
+}
+
+func TestEmitRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Authorization header sent without API key")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c, _ := NewClient(srv.URL, "")
+	ev := NewEvent(EventFail, NewRunID(), Job{Namespace: "pgxport", Name: "x"}, nil, nil)
+	ev.Run.Facets = ErrorFacet(errors.New("boom"))
+	if err := c.Emit(context.Background(), ev); err == nil {
+		t.Error("Emit() expected error on 400 response")
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := NewRunID(), NewRunID()
+	if !uuid.MatchString(a) {
+		t.Errorf("NewRunID() = %q, not a version 4 UUID", a)
+	}
+	if a == b {
+		t.Error("NewRunID() returned the same ID twice")
+	}
+}
+
+func TestOutputStatisticsFacetUnknownSize(t *testing.T) {
+	stats := OutputStatisticsFacet(3, -1)["outputStatistics"].(map[string]any)
+	if _, ok := stats["size"]; ok {
+		t.Error("size must be omitted when unknown")
+	}
+}
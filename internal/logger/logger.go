@@ -20,6 +20,7 @@ type Logger interface {
 	SetOutput(out io.Writer)
 	SetVerbose(enabled bool)
 	SetQuiet(enabled bool)
+	SetRecorder(w io.Writer)
 	IsVerbose() bool
 	IsQuiet() bool
 }
@@ -28,6 +29,7 @@ type Logger interface {
 type ConsoleLogger struct {
 	output      io.Writer
 	errOut      io.Writer
+	recorder    io.Writer
 	verboseMode bool
 	quietMode   bool
 	mu          sync.Mutex
@@ -70,6 +72,12 @@ func IsQuiet() bool {
 	return GetLogger().IsQuiet()
 }
 
+// SetRecorder mirrors every message to w in plain text, including debug messages
+// hidden by the current verbosity. Pass nil to stop recording.
+func SetRecorder(w io.Writer) {
+	GetLogger().SetRecorder(w)
+}
+
 // Global helper functions for convenience
 func Info(format string, args ...any)    { GetLogger().Info(format, args...) }
 func Debug(format string, args ...any)   { GetLogger().Debug(format, args...) }
@@ -105,20 +113,36 @@ func (l *ConsoleLogger) IsQuiet() bool {
 	return l.quietMode
 }
 
+func (l *ConsoleLogger) SetRecorder(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.recorder = w
+}
+
 func (l *ConsoleLogger) timestamp() string {
 	return time.Now().Format("2006-01-02 15:04:05.000")
 }
 
-func (l *ConsoleLogger) log(out io.Writer, prefix, color, format string, args ...any) {
+// log writes a message to out when visible is set, and to the recorder in any case.
+func (l *ConsoleLogger) log(out io.Writer, visible bool, level, icon, color, format string, args ...any) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	msg := fmt.Sprintf(format, args...)
-	if isTTY {
-		fmt.Fprintf(out, "%s%s %s%s\n", color, prefix, msg, resetColor)
-	} else {
-		fmt.Fprintf(out, "%s %s\n", prefix, msg)
+	if !visible && l.recorder == nil {
+		return
 	}
 
+	ts := l.timestamp()
+	msg := fmt.Sprintf(format, args...)
+	if visible {
+		if isTTY {
+			fmt.Fprintf(out, "%s[%s] %s %s%s\n", color, ts, icon, msg, resetColor)
+		} else {
+			fmt.Fprintf(out, "[%s] %s %s\n", ts, level, msg)
+		}
+	}
+	if l.recorder != nil {
+		fmt.Fprintf(l.recorder, "[%s] %s %s\n", ts, level, msg)
+	}
 }
 
 const (
@@ -131,55 +155,21 @@ const (
 )
 
 func (l *ConsoleLogger) Info(format string, args ...any) {
-	if l.quietMode {
-		return
-	}
-	icon := "ℹ️"
-	if !isTTY {
-		icon = "INFO"
-	}
-	l.log(l.output, fmt.Sprintf("[%s] %s", l.timestamp(), icon), blueColor, format, args...)
+	l.log(l.output, !l.quietMode, "INFO", "ℹ️", blueColor, format, args...)
 }
 
 func (l *ConsoleLogger) Debug(format string, args ...any) {
-	if !l.verboseMode {
-		return
-	}
-
-	icon := "🔍"
-	if !isTTY {
-		icon = "DEBUG"
-	}
-
-	l.log(l.output, fmt.Sprintf("[%s] %s", l.timestamp(), icon), grayColor, format, args...)
+	l.log(l.output, l.verboseMode, "DEBUG", "🔍", grayColor, format, args...)
 }
 
 func (l *ConsoleLogger) Success(format string, args ...any) {
-	if l.quietMode {
-		return
-	}
-	icon := "✓"
-	if !isTTY {
-		icon = "SUCCESS"
-	}
-	l.log(l.output, fmt.Sprintf("[%s] %s", l.timestamp(), icon), greenColor, format, args...)
+	l.log(l.output, !l.quietMode, "SUCCESS", "✓", greenColor, format, args...)
 }
 
 func (l *ConsoleLogger) Warn(format string, args ...any) {
-	if l.quietMode {
-		return
-	}
-	icon := "⚠"
-	if !isTTY {
-		icon = "WARN"
-	}
-	l.log(l.output, fmt.Sprintf("[%s] %s", l.timestamp(), icon), yellowColor, format, args...)
+	l.log(l.output, !l.quietMode, "WARN", "⚠", yellowColor, format, args...)
 }
 
 func (l *ConsoleLogger) Error(format string, args ...any) {
-	icon := "✗"
-	if !isTTY {
-		icon = "ERROR"
-	}
-	l.log(l.errOut, fmt.Sprintf("[%s] %s", l.timestamp(), icon), redColor, format, args...)
+	l.log(l.errOut, true, "ERROR", "✗", redColor, format, args...)
 }
@@ -0,0 +1,77 @@
+// Package memguard stops work that makes the process use more memory than allowed,
+// so that it can fail with an explanation instead of being killed by the OOM killer.
+package memguard
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/bytesize"
+)
+
+// interval is how often heap usage is sampled.
+var interval = 50 * time.Millisecond
+
+// heapMetric counts the memory occupied by heap objects, live or not yet swept.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// LimitError is the cancellation cause of a context watched by Watch when the limit is exceeded.
+type LimitError struct {
+	Limit int64
+	Heap  int64 // heap usage when the limit was detected
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("memory limit of %s exceeded (heap in use: %s)", bytesize.Format(e.Limit), bytesize.Format(e.Heap))
+}
+
+// Watch returns a context that is canceled with a *LimitError once heap usage goes
+// beyond limit. The garbage collector is asked to keep the heap under limit first, so
+// the context is only canceled when live data really does not fit.
+// stop must be called to end the watch; it restores the previous GC memory limit.
+func Watch(parent context.Context, limit int64) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	previous := debug.SetMemoryLimit(limit)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		sample := []metrics.Sample{{Name: heapMetric}}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			metrics.Read(sample)
+			if sample[0].Value.Kind() != metrics.KindUint64 {
+				return
+			}
+			heap := sample[0].Value.Uint64()
+			if int64(heap) <= limit {
+				continue
+			}
+			// Collect once before giving up: the sampled heap may hold garbage
+			debug.FreeOSMemory()
+			metrics.Read(sample)
+			if heap = sample[0].Value.Uint64(); int64(heap) > limit {
+				cancel(&LimitError{Limit: limit, Heap: int64(heap)})
+				return
+			}
+		}
+	}()
+
+	return ctx, func() {
+		close(done)
+		cancel(nil)
+		debug.SetMemoryLimit(previous)
+	}
+}
@@ -0,0 +1,55 @@
+package memguard
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchCancelsWhenLimitExceeded(t *testing.T) {
+	ctx, stop := Watch(context.Background(), 16<<20)
+	defer stop()
+
+	// Keep growing live data until the guard steps in
+	var hold [][]byte
+	deadline := time.After(10 * time.Second)
+	for ctx.Err() == nil {
+		select {
+		case <-deadline:
+			t.Fatal("context was not canceled")
+		default:
+		}
+		if len(hold) < 64 {
+			hold = append(hold, make([]byte, 1<<20))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	runtime.KeepAlive(hold)
+
+	var limitErr *LimitError
+	if !errors.As(context.Cause(ctx), &limitErr) {
+		t.Fatalf("Cause = %v, want *LimitError", context.Cause(ctx))
+	}
+	if limitErr.Heap <= limitErr.Limit {
+		t.Errorf("Heap = %d, should exceed Limit = %d", limitErr.Heap, limitErr.Limit)
+	}
+	if !strings.Contains(limitErr.Error(), "memory limit of 16.0MB exceeded") {
+		t.Errorf("Error() = %q", limitErr.Error())
+	}
+}
+
+func TestWatchStop(t *testing.T) {
+	ctx, stop := Watch(context.Background(), 1<<40)
+	time.Sleep(2 * interval)
+	if ctx.Err() != nil {
+		t.Fatalf("context canceled under the limit: %v", context.Cause(ctx))
+	}
+	stop()
+
+	if context.Cause(ctx) != context.Canceled {
+		t.Errorf("Cause after stop = %v, want context.Canceled", context.Cause(ctx))
+	}
+}
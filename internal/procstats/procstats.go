@@ -0,0 +1,17 @@
+// Package procstats reads the resource usage of the current process.
+package procstats
+
+import "time"
+
+// Stats is the resource usage of the process since it started.
+type Stats struct {
+	UserCPU   time.Duration
+	SystemCPU time.Duration
+	PeakRSS   int64 // bytes
+	Available bool  // false on platforms where usage cannot be read
+}
+
+// CPU returns the total CPU time.
+func (s Stats) CPU() time.Duration {
+	return s.UserCPU + s.SystemCPU
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package procstats
+
+// Read returns the resource usage of the current process. It is not available on this platform.
+func Read() Stats {
+	return Stats{}
+}
@@ -0,0 +1,29 @@
+package procstats
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" || runtime.GOOS == "js" {
+		t.Skip("resource usage is not available on " + runtime.GOOS)
+	}
+
+	// Burn a little CPU and memory so the counters cannot be zero
+	buf := make([]byte, 8<<20)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+
+	s := Read()
+	if !s.Available {
+		t.Fatal("Read() reported usage as unavailable")
+	}
+	if s.PeakRSS < int64(len(buf)) {
+		t.Errorf("PeakRSS = %d, want at least %d", s.PeakRSS, len(buf))
+	}
+	if s.CPU() <= 0 {
+		t.Errorf("CPU() = %v, want > 0", s.CPU())
+	}
+}
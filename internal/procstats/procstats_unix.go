@@ -0,0 +1,30 @@
+//go:build unix
+
+package procstats
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// Read returns the resource usage of the current process.
+func Read() Stats {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return Stats{}
+	}
+
+	// ru_maxrss is in bytes on Apple platforms and in kilobytes elsewhere
+	peak := int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		peak *= 1024
+	}
+
+	return Stats{
+		UserCPU:   time.Duration(ru.Utime.Nano()),
+		SystemCPU: time.Duration(ru.Stime.Nano()),
+		PeakRSS:   peak,
+		Available: true,
+	}
+}
@@ -0,0 +1,34 @@
+// Package settings locates the directory where pgxport keeps its settings, such as
+// the telemetry choice, the --template definitions and the state of past runs.
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EnvDir overrides the settings directory.
+const EnvDir = "PGXPORT_CONFIG_DIR"
+
+// Dir returns the settings directory: $PGXPORT_CONFIG_DIR, or pgxport in the
+// user configuration directory.
+func Dir() (string, error) {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine user config directory: %w", err)
+	}
+	return filepath.Join(base, "pgxport"), nil
+}
+
+// Path returns the location of the named file of the settings directory.
+func Path(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,24 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvDir, dir)
+	if got, err := Path("state.json"); err != nil || got != filepath.Join(dir, "state.json") {
+		t.Errorf("Path() = %s, %v, want %s", got, err, filepath.Join(dir, "state.json"))
+	}
+
+	t.Setenv(EnvDir, "")
+	base, err := os.UserConfigDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	if got, err := Dir(); err != nil || got != filepath.Join(base, "pgxport") {
+		t.Errorf("Dir() = %s, %v, want %s", got, err, filepath.Join(base, "pgxport"))
+	}
+}
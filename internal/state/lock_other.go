@@ -0,0 +1,20 @@
+//go:build !unix
+
+package state
+
+import (
+	"os"
+	"sync"
+)
+
+// Without flock, accesses are only serialized within the process.
+var mu sync.Mutex
+
+func lockFile(*os.File, bool) error {
+	mu.Lock()
+	return nil
+}
+
+func unlockFile(*os.File) {
+	mu.Unlock()
+}
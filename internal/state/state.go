@@ -0,0 +1,192 @@
+// Package state keeps what pgxport remembers between runs, such as the chunks
+// already exported by --by-chunk or the outcome of jobfile jobs, in a single JSON
+// file. Every access locks the file, so concurrent exports can share it.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fbz-tec/pgxport/internal/settings"
+)
+
+// EnvFile overrides the location of the state file.
+const EnvFile = "PGXPORT_STATE_FILE"
+
+const fileName = "state.json"
+
+// Key prefixes of the state entries
+const (
+	PrefixChunks       = "chunks:"        // chunks already exported, by output path
+	PrefixFailedChunks = "failed-chunks:" // chunks whose export failed, by output path
+	PrefixJobs         = "jobs:"          // outcome of the jobs of a jobfile, by jobfile path
+)
+
+// Key returns the key of the entry of path under prefix. Paths are made absolute so
+// that runs from other directories find the same entry.
+func Key(prefix, path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return prefix + path
+}
+
+// Path returns the state file: $PGXPORT_STATE_FILE, or state.json next to the
+// other pgxport settings.
+func Path() (string, error) {
+	if path := os.Getenv(EnvFile); path != "" {
+		return path, nil
+	}
+	return settings.Path(fileName)
+}
+
+// Entries maps keys to their JSON values.
+type Entries map[string]json.RawMessage
+
+// Get decodes the entry of key into v and reports whether it exists.
+func Get(key string, v any) (bool, error) {
+	var found bool
+	err := read(func(entries Entries) error {
+		raw, ok := entries[key]
+		if !ok {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(raw, v)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error reading state %s: %w", key, err)
+	}
+	return found, nil
+}
+
+// Update reads the entry of key into v, calls fn and stores v again, holding the
+// lock throughout so that concurrent updates of the same entry are not lost.
+func Update(key string, v any, fn func() error) error {
+	return update(func(entries Entries) error {
+		if raw, ok := entries[key]; ok {
+			if err := json.Unmarshal(raw, v); err != nil {
+				return fmt.Errorf("invalid state %s: %w", key, err)
+			}
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		entries[key] = raw
+		return nil
+	})
+}
+
+// Delete removes the entries of keys.
+func Delete(keys ...string) error {
+	return update(func(entries Entries) error {
+		for _, key := range keys {
+			delete(entries, key)
+		}
+		return nil
+	})
+}
+
+// List returns the entries whose key starts with prefix, all of them for "".
+func List(prefix string) (Entries, error) {
+	matched := Entries{}
+	err := read(func(entries Entries) error {
+		for key, raw := range entries {
+			if strings.HasPrefix(key, prefix) {
+				matched[key] = raw
+			}
+		}
+		return nil
+	})
+	return matched, err
+}
+
+// SortedKeys returns the keys of entries in order.
+func (e Entries) SortedKeys() []string {
+	return slices.Sorted(maps.Keys(e))
+}
+
+func read(fn func(Entries) error) error {
+	return withLock(false, func(path string) error {
+		entries, err := load(path)
+		if err != nil {
+			return err
+		}
+		return fn(entries)
+	})
+}
+
+// update applies fn to the entries and writes them back. The new file is renamed
+// over the old one so that a crash never leaves a truncated state.
+func update(fn func(Entries) error) error {
+	return withLock(true, func(path string) error {
+		entries, err := load(path)
+		if err != nil {
+			return err
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("error writing state: %w", err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return fmt.Errorf("error writing state: %w", err)
+		}
+		return nil
+	})
+}
+
+func load(path string) (Entries, error) {
+	entries := Entries{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state: %w", err)
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// withLock runs fn with the state file locked, exclusively when writing.
+func withLock(exclusive bool, fn func(path string) error) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("error locking state: %w", err)
+	}
+	defer lock.Close()
+	if err := lockFile(lock, exclusive); err != nil {
+		return fmt.Errorf("error locking state: %w", err)
+	}
+	defer unlockFile(lock)
+
+	return fn(path)
+}
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEntries(t *testing.T) {
+	t.Setenv(EnvFile, filepath.Join(t.TempDir(), "state.json"))
+
+	var chunks []string
+	if found, err := Get("chunks:/out/a.csv", &chunks); err != nil || found {
+		t.Fatalf("Get() of a missing entry = %v, %v", found, err)
+	}
+
+	for _, key := range []string{"chunks:/out/a.csv", "chunks:/out/b.csv", "jobs:/etc/pipeline.yaml"} {
+		var values []string
+		err := Update(key, &values, func() error {
+			values = append(values, "x")
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Update(%s) error: %v", key, err)
+		}
+	}
+
+	chunkEntries, err := List(PrefixChunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(chunkEntries.SortedKeys(), ","); got != "chunks:/out/a.csv,chunks:/out/b.csv" {
+		t.Errorf("List(chunks:) = %s", got)
+	}
+
+	if err := Delete("chunks:/out/a.csv"); err != nil {
+		t.Fatal(err)
+	}
+	all, err := List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(all.SortedKeys(), ","); got != "chunks:/out/b.csv,jobs:/etc/pipeline.yaml" {
+		t.Errorf("entries after Delete = %s", got)
+	}
+}
+
+// Updates from concurrent writers are serialized by the lock, none is lost
+func TestUpdateConcurrent(t *testing.T) {
+	t.Setenv(EnvFile, filepath.Join(t.TempDir(), "state.json"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var count int
+			if err := Update("counter", &count, func() error { count++; return nil }); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var count int
+	if _, err := Get("counter", &count); err != nil || count != 20 {
+		t.Errorf("counter = %d (err=%v), want 20", count, err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	key := Key(PrefixChunks, "metrics.csv")
+	if !strings.HasPrefix(key, PrefixChunks) || !filepath.IsAbs(strings.TrimPrefix(key, PrefixChunks)) {
+		t.Errorf("Key() = %s, want an absolute path", key)
+	}
+}
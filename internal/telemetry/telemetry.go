@@ -0,0 +1,187 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fbz-tec/pgxport/internal/settings"
+	"github.com/fbz-tec/pgxport/internal/version"
+)
+
+// Endpoint receives usage events. It is empty by default and can be set at build time with
+// -ldflags "-X github.com/fbz-tec/pgxport/internal/telemetry.Endpoint=https://..."
+var Endpoint = ""
+
+// Environment variables controlling telemetry
+const (
+	EnvEndpoint   = "PGXPORT_TELEMETRY_ENDPOINT" // overrides Endpoint
+	EnvTelemetry  = "PGXPORT_TELEMETRY"          // "off", "0" or "false" disables telemetry
+	EnvDoNotTrack = "DO_NOT_TRACK"               // any non-empty value other than "0" disables telemetry
+	EnvConfigDir  = settings.EnvDir              // overrides the settings directory
+)
+
+const settingsFile = "telemetry.json"
+
+// Settings is the persisted telemetry choice. Telemetry is off until the user opts in.
+type Settings struct {
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Event is the only payload ever sent. It describes which features were used and
+// how the run ended; it never contains queries, data, file paths, hosts or credentials.
+type Event struct {
+	Version     string   `json:"version"`
+	OS          string   `json:"os"`
+	Arch        string   `json:"arch"`
+	Format      string   `json:"format,omitempty"`
+	Compression string   `json:"compression,omitempty"`
+	Flags       []string `json:"flags,omitempty"` // names of the flags that were set, never their values
+	Success     bool     `json:"success"`
+	ErrorClass  string   `json:"error_class,omitempty"`
+}
+
+// NewEvent returns an event pre-filled with build and platform information.
+func NewEvent() Event {
+	return Event{
+		Version: version.AppVersion,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+	}
+}
+
+// Status describes whether events are sent and why.
+type Status struct {
+	Enabled    bool
+	Reason     string
+	Endpoint   string
+	ConfigPath string
+}
+
+// ConfigPath returns the location of the telemetry settings file.
+func ConfigPath() (string, error) {
+	return settings.Path(settingsFile)
+}
+
+// Load reads the persisted settings. A missing file means telemetry was never enabled.
+func Load() (Settings, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return Settings{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Settings{}, nil
+	}
+	if err != nil {
+		return Settings{}, fmt.Errorf("error reading telemetry settings: %w", err)
+	}
+
+	var s Settings
+	if err := json.Unmarshal(content, &s); err != nil {
+		return Settings{}, fmt.Errorf("invalid telemetry settings in %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// SetEnabled records the user's choice.
+func SetEnabled(enabled bool) error {
+	path, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(Settings{Enabled: enabled, UpdatedAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing telemetry settings: %w", err)
+	}
+	return nil
+}
+
+// CurrentStatus combines the persisted choice with environment overrides.
+func CurrentStatus() Status {
+	st := Status{Endpoint: endpoint()}
+	st.ConfigPath, _ = ConfigPath()
+
+	if dnt := os.Getenv(EnvDoNotTrack); dnt != "" && dnt != "0" {
+		st.Reason = EnvDoNotTrack + " is set"
+		return st
+	}
+	switch strings.ToLower(os.Getenv(EnvTelemetry)) {
+	case "off", "0", "false":
+		st.Reason = EnvTelemetry + " disables telemetry"
+		return st
+	}
+
+	s, err := Load()
+	if err != nil {
+		st.Reason = err.Error()
+		return st
+	}
+	if !s.Enabled {
+		st.Reason = "not enabled; run 'pgxport telemetry on' to opt in"
+		return st
+	}
+	if st.Endpoint == "" {
+		st.Reason = "enabled, but this build has no collection endpoint"
+		return st
+	}
+
+	st.Enabled = true
+	st.Reason = "enabled since " + s.UpdatedAt.Format(time.RFC3339)
+	return st
+}
+
+func endpoint() string {
+	if e := os.Getenv(EnvEndpoint); e != "" {
+		return e
+	}
+	return Endpoint
+}
+
+// Send posts the event when telemetry is enabled and does nothing otherwise.
+func Send(ctx context.Context, ev Event) error {
+	st := CurrentStatus()
+	if !st.Enabled {
+		return nil
+	}
+
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, st.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid telemetry endpoint: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pgxport/"+version.AppVersion)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolate points the settings directory to a temporary location and clears overrides.
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+	t.Setenv(EnvTelemetry, "")
+	t.Setenv(EnvDoNotTrack, "")
+	t.Setenv(EnvEndpoint, "")
+	return dir
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	isolate(t)
+	t.Setenv(EnvEndpoint, "http://127.0.0.1:1")
+
+	st := CurrentStatus()
+	if st.Enabled {
+		t.Fatal("telemetry must be off until the user opts in")
+	}
+	if !strings.Contains(st.Reason, "not enabled") {
+		t.Errorf("Reason = %q", st.Reason)
+	}
+}
+
+func TestSetEnabledPersists(t *testing.T) {
+	dir := isolate(t)
+
+	if err := SetEnabled(true); err != nil {
+		t.Fatalf("SetEnabled(true) error: %v", err)
+	}
+	s, err := Load()
+	if err != nil || !s.Enabled {
+		t.Fatalf("Load() = %+v, %v; want enabled", s, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, settingsFile)); err != nil {
+		t.Errorf("settings file not written: %v", err)
+	}
+
+	if err := SetEnabled(false); err != nil {
+		t.Fatalf("SetEnabled(false) error: %v", err)
+	}
+	if s, _ := Load(); s.Enabled {
+		t.Error("Load() still enabled after SetEnabled(false)")
+	}
+}
+
+func TestCurrentStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		endpoint   string
+		env        map[string]string
+		wantOn     bool
+		wantReason string
+	}{
+		{name: "opted in", enabled: true, endpoint: "http://example.invalid", wantOn: true, wantReason: "enabled since"},
+		{name: "no endpoint", enabled: true, wantReason: "no collection endpoint"},
+		{name: "do not track", enabled: true, endpoint: "http://example.invalid", env: map[string]string{EnvDoNotTrack: "1"}, wantReason: EnvDoNotTrack},
+		{name: "do not track zero", enabled: true, endpoint: "http://example.invalid", env: map[string]string{EnvDoNotTrack: "0"}, wantOn: true},
+		{name: "env off", enabled: true, endpoint: "http://example.invalid", env: map[string]string{EnvTelemetry: "off"}, wantReason: EnvTelemetry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			t.Setenv(EnvEndpoint, tt.endpoint)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if err := SetEnabled(tt.enabled); err != nil {
+				t.Fatal(err)
+			}
+
+			st := CurrentStatus()
+			if st.Enabled != tt.wantOn {
+				t.Errorf("Enabled = %v, want %v (reason %q)", st.Enabled, tt.wantOn, st.Reason)
+			}
+			if !strings.Contains(st.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want it to contain %q", st.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	isolate(t)
+
+	var received []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		received = append(received, ev)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv(EnvEndpoint, server.URL)
+
+	ev := NewEvent()
+	ev.Format = "csv"
+	ev.Flags = []string{"format", "output", "sql"}
+	ev.Success = true
+
+	// Nothing is sent before opting in
+	if err := Send(context.Background(), ev); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("event sent without opt-in")
+	}
+
+	if err := SetEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := Send(context.Background(), ev); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if len(received) != 1 || received[0].Format != "csv" || len(received[0].Flags) != 3 {
+		t.Errorf("received = %+v", received)
+	}
+}
+
+func TestSendReportsServerErrors(t *testing.T) {
+	isolate(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	t.Setenv(EnvEndpoint, server.URL)
+
+	if err := SetEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := Send(context.Background(), NewEvent()); err == nil {
+		t.Error("Send() expected error for HTTP 500")
+	}
+}